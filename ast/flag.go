@@ -71,6 +71,8 @@ func (f *flagSetter) Leave(in Node) (Node, bool) {
 		x.SetFlag(FlagHasReference)
 	case *RowExpr:
 		f.row(x)
+	case *SetCollationExpr:
+		x.SetFlag(x.Expr.GetFlag())
 	case *SubqueryExpr:
 		x.SetFlag(FlagHasSubquery)
 	case *UnaryOperationExpr:
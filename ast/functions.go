@@ -53,6 +53,8 @@ const (
 	UnaryPlus  = "unaryplus"
 	UnaryMinus = "unaryminus"
 	In         = "in"
+	Between    = "between"
+	NotBetween = "not_between"
 	Like       = "like"
 	Case       = "case"
 	Regexp     = "regexp"
@@ -64,6 +66,7 @@ const (
 	GetVar     = "getvar"
 	Values     = "values"
 	BitCount   = "bit_count"
+	Collate    = "collate"
 
 	// common functions
 	Coalesce = "coalesce"
@@ -253,6 +256,8 @@ const (
 	Sleep           = "sleep"
 	UUID            = "uuid"
 	UUIDShort       = "uuid_short"
+	UUIDToBin       = "uuid_to_bin"
+	BinToUUID       = "bin_to_uuid"
 	// get_lock() and release_lock() is parsed but do nothing.
 	// It is used for preventing error in Ruby's activerecord migrations.
 	GetLock     = "get_lock"
@@ -277,6 +282,21 @@ const (
 	Uncompress               = "uncompress"
 	UncompressedLength       = "uncompressed_length"
 	ValidatePasswordStrength = "validate_password_strength"
+
+	// json functions
+	JSONType          = "json_type"
+	JSONSet           = "json_set"
+	JSONInsert        = "json_insert"
+	JSONReplace       = "json_replace"
+	JSONMemberOf      = "json_memberof"
+	JSONKeys          = "json_keys"
+	JSONLength        = "json_length"
+	JSONMergePatch    = "json_merge_patch"
+	JSONMergePreserve = "json_merge_preserve"
+	JSONDepth         = "json_depth"
+	JSONValid         = "json_valid"
+	JSONQuote         = "json_quote"
+	JSONPretty        = "json_pretty"
 )
 
 // FuncCallExpr is for function expression.
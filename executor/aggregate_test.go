@@ -84,6 +84,10 @@ func (s *testSuite) TestAggregation(c *C) {
 	result.Check(testkit.Rows())
 	result = tk.MustQuery("select c,d from t group by d")
 	result.Check(testkit.Rows("<nil> 1", "1 2", "1 3"))
+	result = tk.MustQuery("select any_value(c), d from t group by d")
+	result.Check(testkit.Rows("<nil> 1", "1 2", "1 3"))
+	result = tk.MustQuery("select count(*), any_value(d) from t where c = 3")
+	result.Check(testkit.Rows("1 2"))
 	result = tk.MustQuery("select - c, c as d from t group by c having null not between c and avg(distinct d) - d")
 	result.Check(testkit.Rows())
 	result = tk.MustQuery("select - c as c from t group by c having t.c > 5")
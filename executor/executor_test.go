@@ -611,6 +611,18 @@ func (s *testSuite) TestTablePKisHandleScan(c *C) {
 			"select * from t where a between 1 and 2",
 			testkit.Rows("1", "2"),
 		},
+		{
+			"select * from t where a + 1 between 2 and 3",
+			testkit.Rows("1", "2"),
+		},
+		{
+			"select * from t where a * 2 not between 2 and 4",
+			testkit.Rows("-100", "3"),
+		},
+		{
+			"select a, a - 1 between null and 1, a - 1 not between 1 and null from t",
+			testkit.Rows("-100 <nil> 1", "1 <nil> 1", "2 <nil> <nil>", "3 0 <nil>"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -924,6 +936,54 @@ func (s *testSuite) TestBuiltin(c *C) {
 	}
 	patternMatching(c, tk, "regexp", likeTests)
 
+	// for collate
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(10), c int)")
+	tk.MustExec("insert t values ('aBc', 1)")
+	result = tk.MustQuery("select strcmp(a, 'ABC'), strcmp(a collate utf8_general_ci, 'ABC'), strcmp('ABC' collate utf8_general_ci, a) from t")
+	result.Check(testkit.Rows("1 0 0"))
+	result = tk.MustQuery("select a collate utf8_general_ci from t where strcmp(a collate utf8_general_ci, 'abc') = 0")
+	result.Check(testkit.Rows("aBc"))
+	_, err := tk.Exec("select a collate latin1_bin from t")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("select c collate utf8_bin from t")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("select strcmp(a collate utf8_bin, a collate utf8_general_ci) from t")
+	c.Assert(err, NotNil)
+	tk.MustExec("insert t values ('abd', 2), ('ABD', 3), ('abb', 4)")
+	result = tk.MustQuery("select c from t where a = 'ABC'")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select c from t where a collate utf8_general_ci = 'ABC'")
+	result.Check(testkit.Rows("1"))
+	result = tk.MustQuery("select c from t where a collate utf8_general_ci < 'ABD' order by c")
+	result.Check(testkit.Rows("1", "4"))
+	result = tk.MustQuery("select c from t where 'abd' collate utf8_general_ci = a order by c")
+	result.Check(testkit.Rows("2", "3"))
+	result = tk.MustQuery("select c from t order by a, c")
+	result.Check(testkit.Rows("3", "1", "4", "2"))
+	result = tk.MustQuery("select c from t order by a collate utf8_general_ci, c")
+	result.Check(testkit.Rows("4", "1", "2", "3"))
+	result = tk.MustQuery("select c from t order by a collate utf8_general_ci desc, c limit 2")
+	result.Check(testkit.Rows("2", "3"))
+	_, err = tk.Exec("select c from t where a collate utf8_bin = a collate utf8_general_ci")
+	c.Assert(err, NotNil)
+	// A "_ci" column is compared binarily without COLLATE.
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a varchar(10) collate utf8_general_ci, c int)")
+	tk.MustExec("insert t values ('aBc', 1)")
+	result = tk.MustQuery("select c from t where a = 'ABC'")
+	result.Check(testkit.Rows())
+	// utf8 is a subset of utf8mb4, so the columns of them can be compared.
+	tk.MustExec("drop table if exists t1, t2")
+	tk.MustExec("create table t1 (a varchar(10) charset utf8 collate utf8_bin)")
+	tk.MustExec("create table t2 (a varchar(10) charset utf8mb4 collate utf8mb4_bin)")
+	tk.MustExec("insert t1 values ('a'), ('b')")
+	tk.MustExec("insert t2 values ('b'), ('c')")
+	result = tk.MustQuery("select t1.a from t1, t2 where t1.a = t2.a")
+	result.Check(testkit.Rows("b"))
+	result = tk.MustQuery("select a from t1 where a in (select a from t2)")
+	result.Check(testkit.Rows("b"))
+
 	// for found_rows
 	tk.MustExec("drop table if exists t")
 	tk.MustExec("create table t (a int)")
@@ -951,6 +1011,18 @@ func (s *testSuite) TestBuiltin(c *C) {
 	tk.MustQuery("select count(*) from t") // Test ProjectionExec
 	result = tk.MustQuery("select found_rows()")
 	result.Check(testkit.Rows("1"))
+
+	// for the unsigned results of the integer functions
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a bigint unsigned)")
+	tk.MustExec("insert t values (18446744073709551615)")
+	result = tk.MustQuery("select abs(a) from t")
+	result.Check(testkit.Rows("18446744073709551615"))
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t(a bigint unsigned, b bigint unsigned)")
+	tk.MustExec("insert t values (18446744073709551615, 18446744073709551614), (null, 18446744073709551614)")
+	result = tk.MustQuery("select ifnull(a, b) from t")
+	result.Check(testkit.Rows("18446744073709551615", "18446744073709551614"))
 }
 
 func (s *testSuite) TestToPBExpr(c *C) {
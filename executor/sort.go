@@ -101,7 +101,7 @@ func (e *SortExec) Next() (*Row, error) {
 				key: make([]types.Datum, len(e.ByItems)),
 			}
 			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data)
+				orderRow.key[i], err = expression.EvalSortKey(byItem.Expr, srcRow.Data)
 				if err != nil {
 					return nil, errors.Trace(err)
 				}
@@ -196,7 +196,7 @@ func (e *TopnExec) Next() (*Row, error) {
 				key: make([]types.Datum, len(e.ByItems)),
 			}
 			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data)
+				orderRow.key[i], err = expression.EvalSortKey(byItem.Expr, srcRow.Data)
 				if err != nil {
 					return nil, errors.Trace(err)
 				}
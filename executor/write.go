@@ -676,7 +676,6 @@ func (e *InsertExec) Next() (*Row, error) {
 
 // Close implements the Executor Close interface.
 func (e *InsertExec) Close() error {
-	e.ctx.GetSessionVars().CurrInsertValues = nil
 	if e.SelectExec != nil {
 		return e.SelectExec.Close()
 	}
@@ -932,8 +931,13 @@ func (e *InsertExec) onDuplicateUpdate(row []types.Datum, h int64, cols map[int]
 		return errors.Trace(err)
 	}
 
+	// The assignments are evaluated on the row being updated followed by the insert row, which is read by VALUES().
+	// The insert row starts after all the columns of the table, as the assignments are resolved by its schema.
 	// See http://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_values
-	e.ctx.GetSessionVars().CurrInsertValues = row
+	numCols := len(e.Table.Meta().Columns)
+	evalRow := make([]types.Datum, numCols+len(row))
+	copy(evalRow, data)
+	copy(evalRow[numCols:], row)
 	// evaluate assignment
 	newData := make([]types.Datum, len(data))
 	for i, c := range row {
@@ -942,7 +946,7 @@ func (e *InsertExec) onDuplicateUpdate(row []types.Datum, h int64, cols map[int]
 			newData[i] = c
 			continue
 		}
-		val, err1 := asgn.Expr.Eval(data)
+		val, err1 := asgn.Expr.Eval(evalRow)
 		if err1 != nil {
 			return errors.Trace(err1)
 		}
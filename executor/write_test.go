@@ -120,6 +120,17 @@ func (s *testSuite) TestInsert(c *C) {
 	r = tk.MustQuery("select * from insert_test where id = 1;")
 	rowStr = fmt.Sprintf("%v %v %v %v", "1", "1", "10", "6")
 	r.Check(testkit.Rows(rowStr))
+	tk.MustExec(`INSERT INTO insert_test (id, c3) VALUES (1, 2) ON DUPLICATE KEY UPDATE c3=values(c3)+1;`)
+	r = tk.MustQuery("select * from insert_test where id = 1;")
+	r.Check(testkit.Rows("1 1 10 3"))
+	// Every row reads its own values.
+	tk.MustExec(`INSERT INTO insert_test (id, c2, c3) VALUES (1, 3, 4), (100, 5, 6) ON DUPLICATE KEY UPDATE c2=values(c3), c3=values(c2)+c3;`)
+	r = tk.MustQuery("select * from insert_test where id in (1, 100);")
+	r.Check(testkit.Rows("1 1 4 6", "100 <nil> 5 6"))
+	// The column not in the insert list is read as its default value.
+	tk.MustExec(`INSERT INTO insert_test (id, c3) VALUES (1, 2) ON DUPLICATE KEY UPDATE c2=values(c2);`)
+	r = tk.MustQuery("select * from insert_test where id = 1;")
+	r.Check(testkit.Rows("1 1 <nil> 6"))
 
 	tk.MustExec("create table insert_err (id int, c1 varchar(8))")
 	_, err = tk.Exec("insert insert_err values (1, 'abcdabcdabcd')")
@@ -510,6 +521,57 @@ func (s *testSuite) TestMultipleTableUpdate(c *C) {
 	r.Check(testkit.Rows("10", "10"))
 }
 
+func (s *testSuite) TestDefaultAssignment(c *C) {
+	defer func() {
+		s.cleanEnv(c)
+		testleak.AfterTest(c)()
+	}()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int default 10, b varchar(10) default 'x', c int not null)")
+	tk.MustExec("insert into t set a = 1, b = 'y', c = 1")
+	tk.MustExec("insert into t set a = default(a), b = default, c = 2")
+	tk.MustQuery("select * from t").Check(testkit.Rows("1 y 1", "10 x 2"))
+
+	tk.MustExec("update t set a = default(a) + 1, b = default where c = 1")
+	tk.MustQuery("select * from t").Check(testkit.Rows("11 x 1", "10 x 2"))
+	tk.MustExec("update t set a = 5")
+	tk.MustExec("update t T0 set T0.a = default(T0.a) where T0.c = 2")
+	tk.MustQuery("select * from t").Check(testkit.Rows("5 x 1", "10 x 2"))
+	tk.MustExec("insert into t values (3, 'z', 3) on duplicate key update a = default(a)")
+
+	// A NOT NULL column without default value.
+	tk.MustExec("set sql_mode = 'STRICT_TRANS_TABLES'")
+	_, err := tk.Exec("update t set c = default(c)")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("update t set c = default")
+	c.Assert(err, NotNil)
+	tk.MustExec("set sql_mode = ''")
+	tk.MustExec("update t set c = default(c) where a = 5")
+	tk.MustQuery("select * from t").Check(testkit.Rows("5 x 0", "10 x 2", "3 z 3"))
+
+	_, err = tk.Exec("update t set a = default(d)")
+	c.Assert(err, NotNil)
+
+	// DEFAULT(col) of the inserted table in expressions.
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, a int default 10, b int default 20)")
+	tk.MustExec("insert into t set id = 1, a = default(b)")
+	tk.MustExec("insert into t set id = 2, a = default(a) + 1, b = default(a)")
+	tk.MustExec("insert into t values (3, default(b) * 2, 1)")
+	tk.MustQuery("select * from t").Check(testkit.Rows("1 20 20", "2 11 10", "3 40 1"))
+	tk.MustExec("insert into t values (1, 5, 5) on duplicate key update a = default(a)")
+	tk.MustExec("insert into t values (2, 5, 5) on duplicate key update a = default(b) + 1, b = values(a)")
+	tk.MustExec("insert into t set id = 3 on duplicate key update b = default(b)")
+	tk.MustExec("insert into t select 1, 3, 3 on duplicate key update b = default(a)")
+	tk.MustQuery("select * from t").Check(testkit.Rows("1 10 10", "2 21 5", "3 40 20"))
+	_, err = tk.Exec("insert into t set id = 4, a = default(d) + 1")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("insert into t values (1, 1, 1) on duplicate key update a = default(d) + 1")
+	c.Assert(err, NotNil)
+}
+
 func (s *testSuite) TestDelete(c *C) {
 	defer func() {
 		s.cleanEnv(c)
@@ -645,6 +645,39 @@ func (af *avgFunction) GetStreamResult() (d types.Datum) {
 	return
 }
 
+// BuildGroupConcatArgs validates the arguments of group_concat and composes them into one expression,
+// which evaluates the string to be appended for each row. The non-string arguments are wrapped in casts.
+// The separator is put in front of the arguments, so the result of group_concat is the concatenation of
+// the values of the rows with the leading separator removed, and a row is skipped when any argument is null.
+// The items of the order by clause are passed as their expressions because plan.ByItems can't be referred
+// here, they are only checked to be single columns.
+func BuildGroupConcatArgs(ctx context.Context, exprs []Expression, separator string, orderBy []Expression) (Expression, error) {
+	if len(exprs) == 0 {
+		return nil, errIncorrectParameterCount.GenByArgs(ast.AggFuncGroupConcat)
+	}
+	for _, item := range orderBy {
+		if GetRowLen(item) != 1 {
+			return nil, ErrOperandColumns.GenByArgs(1)
+		}
+	}
+	tp := types.NewFieldType(mysql.TypeString)
+	tp.Charset, tp.Collate = charset.CharsetUTF8, charset.CollationUTF8
+	sep := &Constant{Value: types.NewStringDatum(separator), RetType: tp}
+	args := make([]Expression, 0, len(exprs)+1)
+	args = append(args, sep)
+	for _, arg := range exprs {
+		if GetRowLen(arg) != 1 {
+			return nil, ErrOperandColumns.GenByArgs(1)
+		}
+		if !isStringType(arg.GetType().Tp) {
+			arg = NewCastFunc(tp, arg, ctx)
+		}
+		args = append(args, arg)
+	}
+	concatExpr, err := NewFunction(ctx, ast.Concat, tp, args...)
+	return concatExpr, errors.Trace(err)
+}
+
 type concatFunction struct {
 	aggFunction
 }
@@ -0,0 +1,64 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) TestBuildGroupConcatArgs(c *C) {
+	defer testleak.AfterTest(c)()
+	args := datumsToConstants(types.MakeDatums("a", 1, types.NewDecFromStringForTest("1.50"), 2.5))
+	expr, err := BuildGroupConcatArgs(s.ctx, args, "|", []Expression{args[1]})
+	c.Assert(err, IsNil)
+	concat, ok := expr.(*ScalarFunction)
+	c.Assert(ok, IsTrue)
+	c.Assert(concat.FuncName.L, Equals, ast.Concat)
+	// The separator comes first, the string argument is kept, the others are cast to string.
+	concatArgs := concat.GetArgs()
+	c.Assert(concatArgs[0].(*Constant).Value.GetString(), Equals, "|")
+	c.Assert(concatArgs[1], Equals, args[0])
+	for _, arg := range concatArgs[2:] {
+		c.Assert(arg.(*ScalarFunction).FuncName.L, Equals, ast.Cast)
+		c.Assert(isStringType(arg.GetType().Tp), IsTrue)
+	}
+	d, err := expr.Eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetString(), Equals, "|a11.502.5")
+
+	expr, err = BuildGroupConcatArgs(s.ctx, args[1:2], ",", nil)
+	c.Assert(err, IsNil)
+	d, err = expr.Eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetString(), Equals, ",1")
+
+	// The row is skipped if any argument is null.
+	expr, err = BuildGroupConcatArgs(s.ctx, append(datumsToConstants(types.MakeDatums(nil)), args...), ",", nil)
+	c.Assert(err, IsNil)
+	d, err = expr.Eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+
+	_, err = BuildGroupConcatArgs(s.ctx, nil, ",", nil)
+	c.Assert(terror.ErrorEqual(err, errIncorrectParameterCount), IsTrue)
+	row := newFunction(ast.RowFunc, args[0], args[1])
+	_, err = BuildGroupConcatArgs(s.ctx, []Expression{row}, ",", nil)
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+	_, err = BuildGroupConcatArgs(s.ctx, args, ",", []Expression{row})
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func newBenchCNFExprs() CNFExprs {
+	return CNFExprs{
+		newFunction(ast.EQ, newColumn("a"), newLonglong(1)),
+		newFunction(ast.GT, newColumn("b"), newLonglong(2)),
+		newFunction(ast.LT, newColumn("c"), newColumn("d")),
+	}
+}
+
+func BenchmarkCNFExprsClone(b *testing.B) {
+	cnf := newBenchCNFExprs()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cnf.Clone()
+	}
+}
+
+// BenchmarkCNFExprsCloneInto reuses the destination slice, so it saves one allocation per iteration
+// compared with BenchmarkCNFExprsClone.
+func BenchmarkCNFExprsCloneInto(b *testing.B) {
+	cnf := newBenchCNFExprs()
+	var dst CNFExprs
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = cnf.CloneInto(dst)
+	}
+}
+
+func BenchmarkInHashSet(b *testing.B) {
+	ctx := mock.NewContext()
+	args := make([]Expression, 0, 101)
+	args = append(args, &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)})
+	for i := 0; i < 100; i++ {
+		args = append(args, newLonglong(int64(i)))
+	}
+	f, err := funcs[ast.In].getFunction(args, ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+	row := types.MakeDatums(99)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.eval(row)
+	}
+}
+
+func benchmarkRegexp(b *testing.B, constPattern bool) {
+	ctx := mock.NewContext()
+	const pattern = `^\[(info|warn)\] .*region [0-9]+$`
+	strType := types.NewFieldType(mysql.TypeVarString)
+	args := []Expression{&Column{Index: 0, RetType: strType}, &Column{Index: 1, RetType: strType}}
+	if constPattern {
+		args[1] = &Constant{Value: types.NewStringDatum(pattern), RetType: strType}
+	}
+	f, err := funcs[ast.Regexp].getFunction(args, ctx)
+	if err != nil {
+		b.Fatal(err)
+	}
+	row := types.MakeDatums("[info] split region 42", pattern)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.eval(row)
+	}
+}
+
+func BenchmarkRegexpConstPattern(b *testing.B) {
+	benchmarkRegexp(b, true)
+}
+
+func BenchmarkRegexpColumnPattern(b *testing.B) {
+	benchmarkRegexp(b, false)
+}
+
+func newBenchHashCodeFunc() *ScalarFunction {
+	return newFunction(ast.AndAnd,
+		newFunction(ast.EQ, newColumn("a"), newFunction(ast.Plus, newColumn("b"), newLonglong(1))),
+		newFunction(ast.LT, newColumn("c"), newColumn("d"))).(*ScalarFunction)
+}
+
+// BenchmarkScalarFunctionHashCode calls HashCode repeatedly on the same function, which is computed only once.
+func BenchmarkScalarFunctionHashCode(b *testing.B) {
+	f := newBenchHashCodeFunc()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.HashCode()
+	}
+}
+
+// BenchmarkScalarFunctionHashCodeUncached resets the cache in every iteration, so the hashcode of the root
+// function is recomputed each time as it was before the hashcodes were cached.
+func BenchmarkScalarFunctionHashCodeUncached(b *testing.B) {
+	f := newBenchHashCodeFunc()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.resetHashCode()
+		f.HashCode()
+	}
+}
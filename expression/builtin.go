@@ -23,6 +23,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -100,6 +101,40 @@ func (b *baseBuiltinFunc) evalDecimal(row []types.Datum) (*types.MyDecimal, bool
 	return decVal, false, errors.Trace(err)
 }
 
+func (b *baseBuiltinFunc) evalTime(row []types.Datum) (types.Time, bool, error) {
+	val, err := b.self.eval(row)
+	if err != nil || val.IsNull() {
+		return types.Time{}, val.IsNull(), errors.Trace(err)
+	}
+	if val.Kind() == types.KindMysqlTime {
+		return val.GetMysqlTime(), false, nil
+	}
+	ft := types.NewFieldType(mysql.TypeDatetime)
+	ft.Decimal = types.MaxFsp
+	timeVal, err := val.ConvertTo(b.ctx.GetSessionVars().StmtCtx, ft)
+	if err != nil {
+		return types.Time{}, true, errors.Trace(err)
+	}
+	return timeVal.GetMysqlTime(), false, nil
+}
+
+func (b *baseBuiltinFunc) evalDuration(row []types.Datum) (types.Duration, bool, error) {
+	val, err := b.self.eval(row)
+	if err != nil || val.IsNull() {
+		return types.Duration{}, val.IsNull(), errors.Trace(err)
+	}
+	if val.Kind() == types.KindMysqlDuration {
+		return val.GetMysqlDuration(), false, nil
+	}
+	ft := types.NewFieldType(mysql.TypeDuration)
+	ft.Decimal = types.MaxFsp
+	durVal, err := val.ConvertTo(b.ctx.GetSessionVars().StmtCtx, ft)
+	if err != nil {
+		return types.Duration{}, true, errors.Trace(err)
+	}
+	return durVal.GetMysqlDuration(), false, nil
+}
+
 // equal only checks if both functions are non-deterministic and if these arguments are same.
 // Function name will be checked outside.
 func (b *baseBuiltinFunc) equal(fun builtinFunc) bool {
@@ -122,6 +157,13 @@ func (b *baseBuiltinFunc) getCtx() context.Context {
 	return b.ctx
 }
 
+// setSelf sets the builtinFunc which embeds this baseBuiltinFunc, the evaluation methods of
+// the base functions call the overridden methods through it.
+func (b *baseBuiltinFunc) setSelf(f builtinFunc) builtinFunc {
+	b.self = f
+	return f
+}
+
 // baseIntBuiltinFunc represents the functions which return int values.
 type baseIntBuiltinFunc struct {
 	baseBuiltinFunc
@@ -136,6 +178,17 @@ func (b *baseIntBuiltinFunc) eval(row []types.Datum) (d types.Datum, err error)
 	return
 }
 
+// evalUint is the eval of the int functions whose results are unsigned, it returns the result of evalInt
+// as an uint64 datum, so that the values larger than MaxInt64 aren't turned into negative numbers.
+func (b *baseIntBuiltinFunc) evalUint(row []types.Datum) (d types.Datum, err error) {
+	res, isNull, err := b.self.evalInt(row)
+	if err != nil || isNull {
+		return d, errors.Trace(err)
+	}
+	d.SetUint64(uint64(res))
+	return
+}
+
 // evalInt will always be overridden.
 func (b *baseIntBuiltinFunc) evalInt(row []types.Datum) (int64, bool, error) {
 	return b.self.evalInt(row)
@@ -305,6 +358,112 @@ func (b *baseStringBuiltinFunc) evalDecimal(row []types.Datum) (*types.MyDecimal
 	return res, false, errors.Trace(err)
 }
 
+// baseTimeBuiltinFunc represents the functions which return DATE/DATETIME/TIMESTAMP values.
+type baseTimeBuiltinFunc struct {
+	baseBuiltinFunc
+}
+
+func (b *baseTimeBuiltinFunc) eval(row []types.Datum) (d types.Datum, err error) {
+	val, isNull, err := b.self.evalTime(row)
+	if err != nil || isNull {
+		return d, errors.Trace(err)
+	}
+	d.SetMysqlTime(val)
+	return
+}
+
+// evalTime will always be overridden.
+func (b *baseTimeBuiltinFunc) evalTime(row []types.Datum) (types.Time, bool, error) {
+	return b.self.evalTime(row)
+}
+
+func (b *baseTimeBuiltinFunc) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.self.evalTime(row)
+	if err != nil || isNull {
+		return 0, isNull, errors.Trace(err)
+	}
+	res, err := val.ToNumber().ToInt()
+	return res, false, errors.Trace(err)
+}
+
+func (b *baseTimeBuiltinFunc) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.self.evalTime(row)
+	if err != nil || isNull {
+		return 0, isNull, errors.Trace(err)
+	}
+	res, err := val.ToNumber().ToFloat64()
+	return res, false, errors.Trace(err)
+}
+
+func (b *baseTimeBuiltinFunc) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	val, isNull, err := b.self.evalTime(row)
+	if err != nil || isNull {
+		return nil, isNull, errors.Trace(err)
+	}
+	return val.ToNumber(), false, nil
+}
+
+func (b *baseTimeBuiltinFunc) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := b.self.evalTime(row)
+	if err != nil || isNull {
+		return "", isNull, errors.Trace(err)
+	}
+	return val.String(), false, nil
+}
+
+// baseDurationBuiltinFunc represents the functions which return TIME values.
+type baseDurationBuiltinFunc struct {
+	baseBuiltinFunc
+}
+
+func (b *baseDurationBuiltinFunc) eval(row []types.Datum) (d types.Datum, err error) {
+	val, isNull, err := b.self.evalDuration(row)
+	if err != nil || isNull {
+		return d, errors.Trace(err)
+	}
+	d.SetMysqlDuration(val)
+	return
+}
+
+// evalDuration will always be overridden.
+func (b *baseDurationBuiltinFunc) evalDuration(row []types.Datum) (types.Duration, bool, error) {
+	return b.self.evalDuration(row)
+}
+
+func (b *baseDurationBuiltinFunc) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.self.evalDuration(row)
+	if err != nil || isNull {
+		return 0, isNull, errors.Trace(err)
+	}
+	res, err := val.ToNumber().ToInt()
+	return res, false, errors.Trace(err)
+}
+
+func (b *baseDurationBuiltinFunc) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.self.evalDuration(row)
+	if err != nil || isNull {
+		return 0, isNull, errors.Trace(err)
+	}
+	res, err := val.ToNumber().ToFloat64()
+	return res, false, errors.Trace(err)
+}
+
+func (b *baseDurationBuiltinFunc) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	val, isNull, err := b.self.evalDuration(row)
+	if err != nil || isNull {
+		return nil, isNull, errors.Trace(err)
+	}
+	return val.ToNumber(), false, nil
+}
+
+func (b *baseDurationBuiltinFunc) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := b.self.evalDuration(row)
+	if err != nil || isNull {
+		return "", isNull, errors.Trace(err)
+	}
+	return val.String(), false, nil
+}
+
 // builtinFunc stands for a particular function signature.
 type builtinFunc interface {
 	// eval does evaluation by the given row.
@@ -317,6 +476,10 @@ type builtinFunc interface {
 	evalString(row []types.Datum) (val string, isNull bool, err error)
 	// evalDecimal evaluates decimal representation of builtinFunc by given row.
 	evalDecimal(row []types.Datum) (val *types.MyDecimal, isNull bool, err error)
+	// evalTime evaluates DATE/DATETIME/TIMESTAMP representation of builtinFunc by given row.
+	evalTime(row []types.Datum) (val types.Time, isNull bool, err error)
+	// evalDuration evaluates duration representation of builtinFunc by given row.
+	evalDuration(row []types.Datum) (val types.Duration, isNull bool, err error)
 	// getArgs returns the arguments expressions.
 	getArgs() []Expression
 	// isDeterministic checks if a function is deterministic.
@@ -327,6 +490,8 @@ type builtinFunc interface {
 	equal(builtinFunc) bool
 	// getCtx returns this function's context.
 	getCtx() context.Context
+	// setSelf sets the builtinFunc which embeds the base function, and returns it.
+	setSelf(builtinFunc) builtinFunc
 }
 
 // baseFunctionClass will be contained in every struct that implement functionClass interface.
@@ -530,7 +695,7 @@ var funcs = map[string]functionClass{
 	// miscellaneous functions
 	ast.Sleep:           &sleepFunctionClass{baseFunctionClass{ast.Sleep, 1, 1}},
 	ast.AnyValue:        &anyValueFunctionClass{baseFunctionClass{ast.AnyValue, 1, 1}},
-	ast.DefaultFunc:     &defaultFunctionClass{baseFunctionClass{ast.DefaultFunc, 1, 1}},
+	ast.DefaultFunc:     &defaultFunctionClass{baseFunctionClass{ast.DefaultFunc, 1, 1}, nil},
 	ast.InetAton:        &inetAtonFunctionClass{baseFunctionClass{ast.InetAton, 1, 1}},
 	ast.InetNtoa:        &inetNtoaFunctionClass{baseFunctionClass{ast.InetNtoa, 1, 1}},
 	ast.Inet6Aton:       &inet6AtonFunctionClass{baseFunctionClass{ast.Inet6Aton, 1, 1}},
@@ -546,6 +711,8 @@ var funcs = map[string]functionClass{
 	ast.ReleaseAllLocks: &releaseAllLocksFunctionClass{baseFunctionClass{ast.ReleaseAllLocks, 0, 0}},
 	ast.UUID:            &uuidFunctionClass{baseFunctionClass{ast.UUID, 0, 0}},
 	ast.UUIDShort:       &uuidShortFunctionClass{baseFunctionClass{ast.UUIDShort, 0, 0}},
+	ast.UUIDToBin:       &uuidToBinFunctionClass{baseFunctionClass{ast.UUIDToBin, 1, 2}},
+	ast.BinToUUID:       &binToUUIDFunctionClass{baseFunctionClass{ast.BinToUUID, 1, 2}},
 
 	// get_lock() and release_lock() are parsed but do nothing.
 	// It is used for preventing error in Ruby's activerecord migrations.
@@ -561,6 +728,8 @@ var funcs = map[string]functionClass{
 	ast.LT:         &compareFunctionClass{baseFunctionClass{ast.LT, 2, 2}, opcode.LT},
 	ast.GT:         &compareFunctionClass{baseFunctionClass{ast.GT, 2, 2}, opcode.GT},
 	ast.NullEQ:     &compareFunctionClass{baseFunctionClass{ast.NullEQ, 2, 2}, opcode.NullEQ},
+	ast.Between:    &betweenFunctionClass{baseFunctionClass{ast.Between, 3, 3}, false},
+	ast.NotBetween: &betweenFunctionClass{baseFunctionClass{ast.NotBetween, 3, 3}, true},
 	ast.Plus:       &arithmeticFunctionClass{baseFunctionClass{ast.Plus, 2, 2}, opcode.Plus},
 	ast.Minus:      &arithmeticFunctionClass{baseFunctionClass{ast.Minus, 2, 2}, opcode.Minus},
 	ast.Mod:        &arithmeticFunctionClass{baseFunctionClass{ast.Mod, 2, 2}, opcode.Mod},
@@ -587,6 +756,7 @@ var funcs = map[string]functionClass{
 	ast.SetVar:     &setVarFunctionClass{baseFunctionClass{ast.SetVar, 2, 2}},
 	ast.GetVar:     &getVarFunctionClass{baseFunctionClass{ast.GetVar, 1, 1}},
 	ast.BitCount:   &bitCountFunctionClass{baseFunctionClass{ast.BitCount, 1, 1}},
+	ast.Collate:    &collateFunctionClass{baseFunctionClass{ast.Collate, 2, 2}},
 
 	// encryption and compression functions
 	ast.AesDecrypt:               &aesDecryptFunctionClass{baseFunctionClass{ast.AesDecrypt, 2, 3}},
@@ -607,4 +777,30 @@ var funcs = map[string]functionClass{
 	ast.Uncompress:               &uncompressFunctionClass{baseFunctionClass{ast.Uncompress, 1, 1}},
 	ast.UncompressedLength:       &uncompressedLengthFunctionClass{baseFunctionClass{ast.UncompressedLength, 1, 1}},
 	ast.ValidatePasswordStrength: &validatePasswordStrengthFunctionClass{baseFunctionClass{ast.ValidatePasswordStrength, 1, 1}},
+
+	// json functions
+	ast.JSONType:          &jsonTypeFunctionClass{baseFunctionClass{ast.JSONType, 1, 1}},
+	ast.JSONSet:           &jsonModifyFunctionClass{baseFunctionClass{ast.JSONSet, 3, -1}, jsonModifySet},
+	ast.JSONInsert:        &jsonModifyFunctionClass{baseFunctionClass{ast.JSONInsert, 3, -1}, jsonModifyInsert},
+	ast.JSONReplace:       &jsonModifyFunctionClass{baseFunctionClass{ast.JSONReplace, 3, -1}, jsonModifyReplace},
+	ast.JSONMemberOf:      &memberOfFunctionClass{baseFunctionClass{ast.JSONMemberOf, 2, 2}},
+	ast.JSONKeys:          &jsonKeysFunctionClass{baseFunctionClass{ast.JSONKeys, 1, 2}},
+	ast.JSONLength:        &jsonLengthFunctionClass{baseFunctionClass{ast.JSONLength, 1, 2}},
+	ast.JSONMergePatch:    &jsonMergeFunctionClass{baseFunctionClass{ast.JSONMergePatch, 1, -1}, true},
+	ast.JSONMergePreserve: &jsonMergeFunctionClass{baseFunctionClass{ast.JSONMergePreserve, 1, -1}, false},
+	ast.JSONDepth:         &jsonDepthFunctionClass{baseFunctionClass{ast.JSONDepth, 1, 1}},
+	ast.JSONValid:         &jsonValidFunctionClass{baseFunctionClass{ast.JSONValid, 1, 1}},
+	ast.JSONQuote:         &jsonQuoteFunctionClass{baseFunctionClass{ast.JSONQuote, 1, 1}},
+	ast.JSONPretty:        &jsonPrettyFunctionClass{baseFunctionClass{ast.JSONPretty, 1, 1}},
+}
+
+// jsonFuncs are the functions in funcs returning JSON texts, their results are taken as JSON values
+// rather than strings by the functions accepting JSON arguments, e.g. MEMBER OF.
+var jsonFuncs = map[string]struct{}{
+	ast.JSONSet:           {},
+	ast.JSONInsert:        {},
+	ast.JSONReplace:       {},
+	ast.JSONKeys:          {},
+	ast.JSONMergePatch:    {},
+	ast.JSONMergePreserve: {},
 }
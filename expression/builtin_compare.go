@@ -16,6 +16,7 @@ package expression
 import (
 	"math"
 	"sort"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -31,14 +32,23 @@ var (
 	_ functionClass = &leastFunctionClass{}
 	_ functionClass = &intervalFunctionClass{}
 	_ functionClass = &compareFunctionClass{}
+	_ functionClass = &betweenFunctionClass{}
 )
 
 var (
 	_ builtinFunc = &builtinCoalesceSig{}
 	_ builtinFunc = &builtinGreatestSig{}
+	_ builtinFunc = &builtinGreatestTimeSig{}
 	_ builtinFunc = &builtinLeastSig{}
+	_ builtinFunc = &builtinLeastTimeSig{}
 	_ builtinFunc = &builtinIntervalSig{}
 	_ builtinFunc = &builtinCompareSig{}
+	_ builtinFunc = &builtinBetweenSig{}
+	_ builtinFunc = &builtinBetweenIntSig{}
+	_ builtinFunc = &builtinBetweenRealSig{}
+	_ builtinFunc = &builtinBetweenDecimalSig{}
+	_ builtinFunc = &builtinBetweenStringSig{}
+	_ builtinFunc = &builtinBetweenTimeSig{}
 )
 
 type coalesceFunctionClass struct {
@@ -78,7 +88,112 @@ type greatestFunctionClass struct {
 }
 
 func (c *greatestFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinGreatestSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinGreatestSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	if err := checkCmpArgTypes(c.funcName, args); err != nil {
+		return &builtinGreatestSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	if tp, fsp, ok := getTimeCmpType(args); ok {
+		sig := &builtinGreatestTimeSig{newBaseBuiltinFunc(args, ctx), tp, fsp}
+		return sig.setSelf(sig), nil
+	}
+	return &builtinGreatestSig{newBaseBuiltinFunc(args, ctx)}, nil
+}
+
+// checkCmpArgTypes checks whether the arguments of GREATEST and LEAST can be compared with each other,
+// so that the incompatible ones are reported when the function is built rather than compared as garbage.
+// A JSON text can't be compared with a number or a time, neither can a geometry with anything but
+// a geometry. The NULL arguments are compatible with anything.
+func checkCmpArgTypes(funcName string, args []Expression) error {
+	var jsonArg, geoArg, numOrTimeArg, otherArg string
+	for _, arg := range args {
+		if IsNullConstant(arg) {
+			continue
+		}
+		ft := arg.GetType()
+		switch {
+		case isJSONFunction(arg):
+			jsonArg = "json"
+		case ft == nil:
+		case ft.Tp == mysql.TypeGeometry:
+			geoArg = types.TypeStr(ft.Tp)
+		case isNumericOrTimeType(ft.Tp):
+			numOrTimeArg = types.TypeStr(ft.Tp)
+			otherArg = numOrTimeArg
+		default:
+			otherArg = types.TypeStr(ft.Tp)
+		}
+	}
+	if jsonArg != "" && numOrTimeArg != "" {
+		return errInvalidOperation.Gen("Incompatible types %s and %s in the arguments of %s", jsonArg, numOrTimeArg, funcName)
+	}
+	if geoArg != "" && jsonArg != "" {
+		return errInvalidOperation.Gen("Incompatible types %s and %s in the arguments of %s", geoArg, jsonArg, funcName)
+	}
+	if geoArg != "" && otherArg != "" {
+		return errInvalidOperation.Gen("Incompatible types %s and %s in the arguments of %s", geoArg, otherArg, funcName)
+	}
+	return nil
+}
+
+// isNumericOrTimeType checks whether the values of tp are numbers or times.
+func isNumericOrTimeType(tp byte) bool {
+	switch tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong,
+		mysql.TypeFloat, mysql.TypeDouble, mysql.TypeDecimal, mysql.TypeNewDecimal, mysql.TypeYear, mysql.TypeBit,
+		mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		return true
+	}
+	return false
+}
+
+// getTimeCmpType returns the type and the fsp in which GREATEST and LEAST compare args as times, ok is false
+// if they are compared as datums. The fsp is the maximum of the temporal arguments.
+func getTimeCmpType(args []Expression) (tp byte, fsp int, ok bool) {
+	fts := make([]*types.FieldType, 0, len(args))
+	for _, arg := range args {
+		ft := arg.GetType()
+		if ft == nil {
+			return 0, 0, false
+		}
+		if isTemporalType(ft.Tp) && ft.Decimal > fsp {
+			fsp = ft.Decimal
+		}
+		fts = append(fts, ft)
+	}
+	tp, ok = types.MergeTimeCmpTypes(fts)
+	if tp == mysql.TypeDate {
+		fsp = types.DefaultFsp
+	}
+	return tp, fsp, ok
+}
+
+// evalTimeArgs evaluates args to times of type tp. It returns isNull if any argument is NULL. The strings which
+// aren't valid times are zero times with warnings.
+func evalTimeArgs(b baseBuiltinFunc, row []types.Datum, tp byte, fsp int) (times []types.Time, isNull bool, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil {
+		return nil, true, errors.Trace(err)
+	}
+	sc := b.ctx.GetSessionVars().StmtCtx
+	ft := types.NewFieldType(tp)
+	ft.Decimal = fsp
+	times = make([]types.Time, 0, len(args))
+	for _, arg := range args {
+		if arg.IsNull() {
+			return nil, true, nil
+		}
+		d, err := arg.ConvertTo(sc, ft)
+		if err != nil {
+			str, _ := arg.ToString()
+			sc.AppendWarning(errTruncatedWrongValue.GenByArgs(types.TypeStr(tp), str))
+			times = append(times, types.Time{Time: types.ZeroTime, Type: tp, Fsp: fsp})
+			continue
+		}
+		times = append(times, d.GetMysqlTime())
+	}
+	return times, false, nil
 }
 
 type builtinGreatestSig struct {
@@ -115,12 +230,47 @@ func (b *builtinGreatestSig) eval(row []types.Datum) (d types.Datum, err error)
 	return
 }
 
+type builtinGreatestTimeSig struct {
+	baseBuiltinFunc
+
+	tp  byte
+	fsp int
+}
+
+// eval evals a builtinGreatestTimeSig, the arguments are dates, datetimes, timestamps or strings, and they are
+// compared as times of the merged type.
+// See http://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#function_greatest
+func (b *builtinGreatestTimeSig) eval(row []types.Datum) (d types.Datum, err error) {
+	times, isNull, err := evalTimeArgs(b.baseBuiltinFunc, row, b.tp, b.fsp)
+	if isNull || err != nil {
+		return d, errors.Trace(err)
+	}
+	max := times[0]
+	for _, t := range times[1:] {
+		if t.Compare(max) > 0 {
+			max = t
+		}
+	}
+	d.SetMysqlTime(max)
+	return d, nil
+}
+
 type leastFunctionClass struct {
 	baseFunctionClass
 }
 
 func (c *leastFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinLeastSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinLeastSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	if err := checkCmpArgTypes(c.funcName, args); err != nil {
+		return &builtinLeastSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	if tp, fsp, ok := getTimeCmpType(args); ok {
+		sig := &builtinLeastTimeSig{newBaseBuiltinFunc(args, ctx), tp, fsp}
+		return sig.setSelf(sig), nil
+	}
+	return &builtinLeastSig{newBaseBuiltinFunc(args, ctx)}, nil
 }
 
 type builtinLeastSig struct {
@@ -157,6 +307,31 @@ func (b *builtinLeastSig) eval(row []types.Datum) (d types.Datum, err error) {
 	return
 }
 
+type builtinLeastTimeSig struct {
+	baseBuiltinFunc
+
+	tp  byte
+	fsp int
+}
+
+// eval evals a builtinLeastTimeSig, the arguments are dates, datetimes, timestamps or strings, and they are
+// compared as times of the merged type.
+// See http://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#function_least
+func (b *builtinLeastTimeSig) eval(row []types.Datum) (d types.Datum, err error) {
+	times, isNull, err := evalTimeArgs(b.baseBuiltinFunc, row, b.tp, b.fsp)
+	if isNull || err != nil {
+		return d, errors.Trace(err)
+	}
+	min := times[0]
+	for _, t := range times[1:] {
+		if t.Compare(min) < 0 {
+			min = t
+		}
+	}
+	d.SetMysqlTime(min)
+	return d, nil
+}
+
 type intervalFunctionClass struct {
 	baseFunctionClass
 }
@@ -212,13 +387,185 @@ type compareFunctionClass struct {
 }
 
 func (c *compareFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinCompareSig{newBaseBuiltinFunc(args, ctx), c.op}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinCompareSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx), op: c.op}
+	if err := c.verifyArgs(args); err != nil {
+		return sig, errors.Trace(err)
+	}
+	if lLen, rLen := GetRowLen(args[0]), GetRowLen(args[1]); lLen != rLen {
+		return sig, ErrOperandColumns.GenByArgs(lLen)
+	}
+	caseInsensitive, err := isExplicitCICollation(c.funcName, args[0], args[1])
+	sig.caseInsensitive = caseInsensitive
+	return sig, errors.Trace(err)
+}
+
+// cmpFuncNames maps the comparison operators and the names of the comparison functions to the function names.
+var cmpFuncNames = map[string]string{
+	"=":   ast.EQ,
+	"<=>": ast.NullEQ,
+	"!=":  ast.NE,
+	"<>":  ast.NE,
+	"<":   ast.LT,
+	"<=":  ast.LE,
+	">":   ast.GT,
+	">=":  ast.GE,
+
+	ast.EQ:     ast.EQ,
+	ast.NullEQ: ast.NullEQ,
+	ast.NE:     ast.NE,
+	ast.LT:     ast.LT,
+	ast.LE:     ast.LE,
+	ast.GT:     ast.GT,
+	ast.GE:     ast.GE,
+}
+
+// NewComparison builds the comparison 'lhs op rhs', op is an operator like "=" and "<>", or the name of a
+// comparison function like ast.EQ. The comparison is built by NewFunction, then the types of the arguments are
+// merged as BETWEEN does, and the arguments are compared in the merged type, e.g. an int column and a decimal
+// constant are compared as decimals. The rows are compared element-wise as NewFunction does, and the comparison
+// is folded if all the arguments are constants.
+func NewComparison(ctx context.Context, op string, lhs, rhs Expression) (Expression, error) {
+	funcName, ok := cmpFuncNames[strings.ToLower(strings.TrimSpace(op))]
+	if !ok {
+		return nil, errFunctionNotExists.GenByArgs(op)
+	}
+	f, err := NewFunction(ctx, funcName, types.NewFieldType(mysql.TypeTiny), lhs, rhs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sf, ok := f.(*ScalarFunction)
+	if !ok || sf.FuncName.L != funcName {
+		// The rows are expanded to the composition of the element-wise comparisons.
+		return FoldConstant(f), nil
+	}
+	cmp := sf.Function.(*builtinCompareSig)
+	base := baseIntBuiltinFunc{newBaseBuiltinFunc(sf.GetArgs(), ctx)}
+	var sig builtinFunc
+	switch getBetweenCmpType(base.args) {
+	case betweenCmpInt:
+		sig = &builtinCompareIntSig{base, cmp.op}
+	case betweenCmpReal:
+		sig = &builtinCompareRealSig{base, cmp.op}
+	case betweenCmpDecimal:
+		sig = &builtinCompareDecimalSig{base, cmp.op}
+	case betweenCmpString:
+		sig = &builtinCompareStringSig{base, cmp.op, cmp.caseInsensitive}
+	}
+	if sig != nil {
+		sf.Function = sig.setSelf(sig)
+	}
+	return FoldConstant(sf), nil
+}
+
+// EvalSortKey evaluates expr on row as a key of ORDER BY. A string key is lowered if it's sorted case insensitively
+// by the same rule as the comparison functions, so that the keys can be compared binarily.
+func EvalSortKey(expr Expression, row []types.Datum) (types.Datum, error) {
+	key, err := expr.Eval(row)
+	if err != nil {
+		return key, errors.Trace(err)
+	}
+	if k := key.Kind(); k != types.KindString && k != types.KindBytes {
+		return key, nil
+	}
+	caseInsensitive, err := isExplicitCICollation("order by", expr)
+	if err != nil {
+		return key, errors.Trace(err)
+	}
+	if caseInsensitive {
+		key.SetString(strings.ToLower(key.GetString()))
+	}
+	return key, nil
+}
+
+// rowCmpComposers maps the comparison functions which can be expanded element-wise on rows
+// to the logic operators used to compose the element-wise results.
+var rowCmpComposers = map[string]string{
+	ast.EQ:     ast.AndAnd,
+	ast.NullEQ: ast.AndAnd,
+	ast.NE:     ast.OrOr,
+	ast.In:     ast.OrOr,
+}
+
+// GetRowLen gets the number of elements of a row expression, it returns 1 for non-row expressions.
+func GetRowLen(e Expression) int {
+	if f, ok := e.(*ScalarFunction); ok && f.FuncName.L == ast.RowFunc {
+		return len(f.GetArgs())
+	}
+	if c, ok := e.(*Constant); ok && c.Value.Kind() == types.KindRow {
+		return len(c.Value.GetRow())
+	}
+	return 1
+}
+
+// getRowArg gets the idx-th element of a row expression.
+func getRowArg(e Expression, idx int) Expression {
+	if f, ok := e.(*ScalarFunction); ok {
+		return f.GetArgs()[idx]
+	}
+	c := e.(*Constant)
+	d := c.Value.GetRow()[idx]
+	tp := types.NewFieldType(mysql.TypeUnspecified)
+	types.DefaultTypeForValue(d.GetValue(), tp)
+	return &Constant{Value: d, RetType: tp}
+}
+
+// isRowComparison checks whether the function is a comparison which should be expanded on rows.
+func isRowComparison(funcName string, args []Expression) bool {
+	if _, ok := rowCmpComposers[funcName]; !ok || len(args) < 2 {
+		return false
+	}
+	if funcName == ast.In {
+		// A scalar in a list of rows is expanded too, so that the row length mismatch is reported.
+		for _, arg := range args {
+			if GetRowLen(arg) != 1 {
+				return true
+			}
+		}
+		return false
+	}
+	return len(args) == 2 && (GetRowLen(args[0]) != 1 || GetRowLen(args[1]) != 1)
+}
+
+// expandRowComparison expands a row comparison to the composition of element-wise comparisons, e.g.
+// (a0, a1) = (b0, b1) is expanded to (a0 = b0) and (a1 = b1),
+// (a0, a1) != (b0, b1) is expanded to (a0 != b0) or (a1 != b1),
+// (a0, a1) in ((b0, b1), (c0, c1)) is expanded to ((a0, a1) = (b0, b1)) or ((a0, a1) = (c0, c1)).
+// The three-valued logic of and/or makes the result null if any element comparison is null and
+// no element comparison decides the result definitively, which is the same as MySQL.
+func expandRowComparison(ctx context.Context, funcName string, retType *types.FieldType, args []Expression) (Expression, error) {
+	if funcName == ast.In {
+		eqs := make([]Expression, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			eq, err := NewFunction(ctx, ast.EQ, retType, args[0], arg)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			eqs = append(eqs, eq)
+		}
+		return composeConditionWithBinaryOp(ctx, eqs, ast.OrOr), nil
+	}
+	l, r := args[0], args[1]
+	lLen, rLen := GetRowLen(l), GetRowLen(r)
+	if lLen != rLen {
+		return nil, ErrOperandColumns.GenByArgs(lLen)
+	}
+	cmps := make([]Expression, 0, lLen)
+	for i := 0; i < lLen; i++ {
+		cmp, err := NewFunction(ctx, funcName, retType, getRowArg(l, i), getRowArg(r, i))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		cmps = append(cmps, cmp)
+	}
+	return composeConditionWithBinaryOp(ctx, cmps, rowCmpComposers[funcName]), nil
 }
 
 type builtinCompareSig struct {
 	baseBuiltinFunc
 
 	op opcode.Op
+	// caseInsensitive is true if the strings are compared under a "_ci" collation set by COLLATE.
+	caseInsensitive bool
 }
 
 func (s *builtinCompareSig) eval(row []types.Datum) (d types.Datum, err error) {
@@ -246,6 +593,11 @@ func (s *builtinCompareSig) eval(row []types.Datum) (d types.Datum, err error) {
 		}
 		return
 	}
+	if s.caseInsensitive && (a.Kind() == types.KindString || a.Kind() == types.KindBytes) &&
+		(b.Kind() == types.KindString || b.Kind() == types.KindBytes) {
+		a.SetString(strings.ToLower(a.GetString()))
+		b.SetString(strings.ToLower(b.GetString()))
+	}
 
 	n, err := a.CompareDatum(sc, b)
 	if err != nil {
@@ -307,6 +659,8 @@ type builtinCompareStringSig struct {
 	baseIntBuiltinFunc
 
 	op opcode.Op
+	// caseInsensitive is true if the strings are compared under a "_ci" collation set by COLLATE.
+	caseInsensitive bool
 }
 
 func (s *builtinCompareStringSig) evalInt(row []types.Datum) (int64, bool, error) {
@@ -328,6 +682,9 @@ func (s *builtinCompareStringSig) evalInt(row []types.Datum) (int64, bool, error
 		}
 		return zeroI64, true, nil
 	}
+	if s.caseInsensitive {
+		arg0, arg1 = strings.ToLower(arg0), strings.ToLower(arg1)
+	}
 	ret := resOfCmp(types.CompareString(arg0, arg1), s.op)
 	if ret == -1 {
 		return zeroI64, false, errInvalidOperation.Gen("invalid op %v in comparison operation", s.op)
@@ -428,30 +785,29 @@ func (s *builtinCompareIntSig) evalInt(row []types.Datum) (int64, bool, error) {
 		return zeroI64, true, nil
 	}
 	isUnsigned0, isUnsigned1 := mysql.HasUnsignedFlag(s.args[0].GetType().Flag), mysql.HasUnsignedFlag(s.args[1].GetType().Flag)
-	var res int
+	ret := resOfCmp(compareIntWithUnsigned(arg0, isUnsigned0, arg1, isUnsigned1), s.op)
+	if ret == -1 {
+		return zeroI64, false, errInvalidOperation.Gen("invalid op %v in comparison operation", s.op)
+	}
+	return ret, false, nil
+}
+
+// compareIntWithUnsigned compares two integers, isUnsigned0 and isUnsigned1 tell whether they should be
+// regarded as uint64.
+func compareIntWithUnsigned(arg0 int64, isUnsigned0 bool, arg1 int64, isUnsigned1 bool) int {
 	switch {
 	case isUnsigned0 && isUnsigned1:
-		res = types.CompareUint64(uint64(arg0), uint64(arg1))
+		return types.CompareUint64(uint64(arg0), uint64(arg1))
 	case isUnsigned0 && !isUnsigned1:
 		if arg1 < 0 || arg0 > math.MaxInt64 {
-			res = 1
-		} else {
-			res = types.CompareInt64(arg0, arg1)
+			return 1
 		}
 	case !isUnsigned0 && isUnsigned1:
 		if arg0 < 0 || arg1 > math.MaxInt64 {
-			res = -1
-		} else {
-			res = types.CompareInt64(arg0, arg1)
+			return -1
 		}
-	case !isUnsigned0 && !isUnsigned1:
-		res = types.CompareInt64(arg0, arg1)
-	}
-	ret := resOfCmp(res, s.op)
-	if ret == -1 {
-		return zeroI64, false, errInvalidOperation.Gen("invalid op %v in comparison operation", s.op)
 	}
-	return ret, false, nil
+	return types.CompareInt64(arg0, arg1)
 }
 
 // builtinCompareRowSig compares two rows.
@@ -507,3 +863,316 @@ func (s *builtinCompareRowSig) evalInt(row []types.Datum) (int64, bool, error) {
 	}
 	return ret, false, nil
 }
+
+type betweenFunctionClass struct {
+	baseFunctionClass
+
+	not bool
+}
+
+// betweenCmpType is the type used to compare the arguments of BETWEEN.
+type betweenCmpType int
+
+const (
+	betweenCmpDatum betweenCmpType = iota
+	betweenCmpInt
+	betweenCmpReal
+	betweenCmpDecimal
+	betweenCmpString
+	betweenCmpTime
+)
+
+func isTemporalType(tp byte) bool {
+	return tp == mysql.TypeDatetime || tp == mysql.TypeDate || tp == mysql.TypeTimestamp
+}
+
+func isStringType(tp byte) bool {
+	switch tp {
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString,
+		mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob:
+		return true
+	}
+	return false
+}
+
+// getBetweenCmpType merges the types of the BETWEEN arguments to the type used to compare them. The NULL
+// arguments are ignored. The arguments whose types can't be merged are compared as datums.
+func getBetweenCmpType(args []Expression) betweenCmpType {
+	var hasInt, hasReal, hasDecimal, hasString, hasTemporal bool
+	for _, arg := range args {
+		if IsNullConstant(arg) {
+			continue
+		}
+		tp := arg.GetType().Tp
+		switch {
+		case isTemporalType(tp):
+			hasTemporal = true
+		case isStringType(tp):
+			hasString = true
+		case tp == mysql.TypeNull || tp == mysql.TypeUnspecified:
+			return betweenCmpDatum
+		default:
+			switch arg.GetType().ToClass() {
+			case types.ClassInt:
+				hasInt = true
+			case types.ClassReal:
+				hasReal = true
+			case types.ClassDecimal:
+				hasDecimal = true
+			default:
+				return betweenCmpDatum
+			}
+		}
+	}
+	hasNumber := hasInt || hasReal || hasDecimal
+	switch {
+	case hasTemporal:
+		if isTemporalType(args[0].GetType().Tp) && !hasNumber {
+			return betweenCmpTime
+		}
+		return betweenCmpDatum
+	case hasString && hasNumber, hasReal:
+		return betweenCmpReal
+	case hasString:
+		return betweenCmpString
+	case hasDecimal:
+		return betweenCmpDecimal
+	case hasInt:
+		return betweenCmpInt
+	}
+	return betweenCmpDatum
+}
+
+func (c *betweenFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	base := baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinBetweenSig{base, c.not}, errors.Trace(err)
+	}
+	var sig builtinFunc
+	switch getBetweenCmpType(args) {
+	case betweenCmpInt:
+		sig = &builtinBetweenIntSig{base, c.not}
+	case betweenCmpReal:
+		sig = &builtinBetweenRealSig{base, c.not}
+	case betweenCmpDecimal:
+		sig = &builtinBetweenDecimalSig{base, c.not}
+	case betweenCmpString:
+		sig = &builtinBetweenStringSig{base, c.not}
+	case betweenCmpTime:
+		sig = &builtinBetweenTimeSig{base, c.not}
+	default:
+		sig = &builtinBetweenSig{base, c.not}
+	}
+	return sig.setSelf(sig), nil
+}
+
+// betweenResult composes "a >= lo AND a <= hi" by the three-valued logic, cmpLo and cmpHi are the results of
+// comparing a to the bounds, which are meaningless if the bound is NULL. not negates the result for NOT BETWEEN.
+func betweenResult(cmpLo int, loIsNull bool, cmpHi int, hiIsNull bool, not bool) (int64, bool) {
+	if (!loIsNull && cmpLo < 0) || (!hiIsNull && cmpHi > 0) {
+		if not {
+			return 1, false
+		}
+		return 0, false
+	}
+	if loIsNull || hiIsNull {
+		return 0, true
+	}
+	if not {
+		return 0, false
+	}
+	return 1, false
+}
+
+// builtinBetweenSig compares the arguments as datums.
+type builtinBetweenSig struct {
+	baseIntBuiltinFunc
+
+	not bool
+}
+
+// evalInt evals a builtinBetweenSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_between
+func (b *builtinBetweenSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	a, err := b.args[0].Eval(row)
+	if a.IsNull() || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	var cmps [2]int
+	var isNulls [2]bool
+	for i, arg := range b.args[1:] {
+		bound, err := arg.Eval(row)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		x, y, err := types.CoerceDatum(sc, a, bound)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if isNulls[i] = y.IsNull(); isNulls[i] {
+			continue
+		}
+		if cmps[i], err = x.CompareDatum(sc, y); err != nil {
+			return 0, true, errors.Trace(err)
+		}
+	}
+	res, isNull := betweenResult(cmps[0], isNulls[0], cmps[1], isNulls[1], b.not)
+	return res, isNull, nil
+}
+
+type builtinBetweenIntSig struct {
+	baseIntBuiltinFunc
+
+	not bool
+}
+
+// evalInt evals a builtinBetweenIntSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_between
+func (b *builtinBetweenIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	a, isNull, err := b.args[0].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	isUnsigned := mysql.HasUnsignedFlag(b.args[0].GetType().Flag)
+	var cmps [2]int
+	var isNulls [2]bool
+	for i, arg := range b.args[1:] {
+		bound, isNull, err := arg.EvalInt(row, sc)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if isNulls[i] = isNull; !isNull {
+			cmps[i] = compareIntWithUnsigned(a, isUnsigned, bound, mysql.HasUnsignedFlag(arg.GetType().Flag))
+		}
+	}
+	res, isNull := betweenResult(cmps[0], isNulls[0], cmps[1], isNulls[1], b.not)
+	return res, isNull, nil
+}
+
+type builtinBetweenRealSig struct {
+	baseIntBuiltinFunc
+
+	not bool
+}
+
+// evalInt evals a builtinBetweenRealSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_between
+func (b *builtinBetweenRealSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	a, isNull, err := b.args[0].EvalReal(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	var cmps [2]int
+	var isNulls [2]bool
+	for i, arg := range b.args[1:] {
+		bound, isNull, err := arg.EvalReal(row, sc)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if isNulls[i] = isNull; !isNull {
+			cmps[i] = types.CompareFloat64(a, bound)
+		}
+	}
+	res, isNull := betweenResult(cmps[0], isNulls[0], cmps[1], isNulls[1], b.not)
+	return res, isNull, nil
+}
+
+type builtinBetweenDecimalSig struct {
+	baseIntBuiltinFunc
+
+	not bool
+}
+
+// evalInt evals a builtinBetweenDecimalSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_between
+func (b *builtinBetweenDecimalSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	a, isNull, err := b.args[0].EvalDecimal(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	var cmps [2]int
+	var isNulls [2]bool
+	for i, arg := range b.args[1:] {
+		bound, isNull, err := arg.EvalDecimal(row, sc)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if isNulls[i] = isNull; !isNull {
+			cmps[i] = a.Compare(bound)
+		}
+	}
+	res, isNull := betweenResult(cmps[0], isNulls[0], cmps[1], isNulls[1], b.not)
+	return res, isNull, nil
+}
+
+type builtinBetweenStringSig struct {
+	baseIntBuiltinFunc
+
+	not bool
+}
+
+// evalInt evals a builtinBetweenStringSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_between
+func (b *builtinBetweenStringSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	a, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	var cmps [2]int
+	var isNulls [2]bool
+	for i, arg := range b.args[1:] {
+		bound, isNull, err := arg.EvalString(row, sc)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if isNulls[i] = isNull; !isNull {
+			cmps[i] = types.CompareString(a, bound)
+		}
+	}
+	res, isNull := betweenResult(cmps[0], isNulls[0], cmps[1], isNulls[1], b.not)
+	return res, isNull, nil
+}
+
+// builtinBetweenTimeSig compares the arguments as datetime, the first argument is a datetime, date or
+// timestamp, and the bounds are temporal values or strings.
+type builtinBetweenTimeSig struct {
+	baseIntBuiltinFunc
+
+	not bool
+}
+
+// evalInt evals a builtinBetweenTimeSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_between
+func (b *builtinBetweenTimeSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	a, err := b.args[0].Eval(row)
+	if a.IsNull() || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	tp := types.NewFieldType(mysql.TypeDatetime)
+	tp.Decimal = types.MaxFsp
+	var cmps [2]int
+	var isNulls [2]bool
+	for i, arg := range b.args[1:] {
+		bound, err := arg.Eval(row)
+		if err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if isNulls[i] = bound.IsNull(); isNulls[i] {
+			continue
+		}
+		if bound.Kind() != types.KindMysqlTime {
+			if bound, err = bound.ConvertTo(sc, tp); err != nil {
+				return 0, true, errors.Trace(err)
+			}
+		}
+		cmps[i] = a.GetMysqlTime().Compare(bound.GetMysqlTime())
+	}
+	res, isNull := betweenResult(cmps[0], isNulls[0], cmps[1], isNulls[1], b.not)
+	return res, isNull, nil
+}
@@ -16,6 +16,8 @@ package expression
 import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -28,8 +30,16 @@ var (
 
 var (
 	_ builtinFunc = &builtinCaseWhenSig{}
+	_ builtinFunc = &builtinCaseWhenIntSig{}
+	_ builtinFunc = &builtinCaseWhenRealSig{}
+	_ builtinFunc = &builtinCaseWhenDecimalSig{}
+	_ builtinFunc = &builtinCaseWhenStringSig{}
 	_ builtinFunc = &builtinIfSig{}
 	_ builtinFunc = &builtinIfNullSig{}
+	_ builtinFunc = &builtinIfNullIntSig{}
+	_ builtinFunc = &builtinIfNullRealSig{}
+	_ builtinFunc = &builtinIfNullDecimalSig{}
+	_ builtinFunc = &builtinIfNullStringSig{}
 	_ builtinFunc = &builtinNullIfSig{}
 )
 
@@ -38,42 +48,175 @@ type caseWhenFunctionClass struct {
 }
 
 func (c *caseWhenFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinCaseWhenSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinCaseWhenSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	tc, ok := getCaseWhenRetClass(args)
+	if !ok {
+		return &builtinCaseWhenSig{bf}, nil
+	}
+	var sig builtinFunc
+	switch tc {
+	case types.ClassInt:
+		sig = &builtinCaseWhenIntSig{baseIntBuiltinFunc{bf}}
+	case types.ClassReal:
+		sig = &builtinCaseWhenRealSig{baseRealBuiltinFunc{bf}}
+	case types.ClassDecimal:
+		sig = &builtinCaseWhenDecimalSig{baseDecimalBuiltinFunc{bf}}
+	default:
+		sig = &builtinCaseWhenStringSig{baseStringBuiltinFunc{bf}}
+	}
+	return sig.setSelf(sig), nil
 }
 
-type builtinCaseWhenSig struct {
-	baseBuiltinFunc
+// getCaseWhenRetClass unifies the type classes of the THEN and ELSE branches of a CASE function,
+// string beats real, real beats decimal and decimal beats int. It returns false when some branch
+// has a type which can't be handled by the typed signatures, e.g. datetime, or all the branches are null.
+func getCaseWhenRetClass(args []Expression) (tc types.TypeClass, ok bool) {
+	l := len(args)
+	branches := make([]Expression, 0, l/2+1)
+	for i := 1; i < l; i += 2 {
+		branches = append(branches, args[i])
+	}
+	if l%2 == 1 {
+		branches = append(branches, args[l-1])
+	}
+	rank := map[types.TypeClass]int{types.ClassInt: 1, types.ClassDecimal: 2, types.ClassReal: 3, types.ClassString: 4}
+	maxRank := 0
+	for _, branch := range branches {
+		ft := branch.GetType()
+		if ft == nil {
+			return tc, false
+		}
+		var branchClass types.TypeClass
+		switch ft.Tp {
+		case mysql.TypeNull:
+			continue
+		case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+			if mysql.HasUnsignedFlag(ft.Flag) {
+				return tc, false
+			}
+			branchClass = types.ClassInt
+		case mysql.TypeFloat, mysql.TypeDouble:
+			branchClass = types.ClassReal
+		case mysql.TypeNewDecimal:
+			branchClass = types.ClassDecimal
+		case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString,
+			mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeBlob, mysql.TypeLongBlob:
+			branchClass = types.ClassString
+		default:
+			return tc, false
+		}
+		if rank[branchClass] > maxRank {
+			tc, maxRank = branchClass, rank[branchClass]
+		}
+	}
+	return tc, maxRank > 0
 }
 
-// eval evals a builtinCaseWhenSig.
-// See https://dev.mysql.com/doc/refman/5.7/en/case.html
-func (b *builtinCaseWhenSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	sc := b.ctx.GetSessionVars().StmtCtx
+// evalCaseWhenBranch evaluates the WHEN conditions in order and returns the index of the argument
+// of the selected branch, or -1 when no WHEN matches and there is no ELSE.
+func evalCaseWhenBranch(args []Expression, row []types.Datum, sc *variable.StatementContext) (int, error) {
 	l := len(args)
+	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
+	// else clause -> args[l-1]
+	// If case clause has else clause, l%2 == 1.
 	for i := 0; i < l-1; i += 2 {
-		if args[i].IsNull() {
+		cond, err := args[i].Eval(row)
+		if err != nil {
+			return -1, errors.Trace(err)
+		}
+		if cond.IsNull() {
 			continue
 		}
-		b, err1 := args[i].ToBool(sc)
-		if err1 != nil {
-			return d, errors.Trace(err1)
+		isTrue, err := cond.ToBool(sc)
+		if err != nil {
+			return -1, errors.Trace(err)
 		}
-		if b == 1 {
-			d = args[i+1]
-			return
+		if isTrue == 1 {
+			return i + 1, nil
 		}
 	}
-	// when clause(condition, result) -> args[i], args[i+1]; (i >= 0 && i+1 < l-1)
-	// else clause -> args[l-1]
-	// If case clause has else clause, l%2 == 1.
 	if l%2 == 1 {
-		d = args[l-1]
+		return l - 1, nil
 	}
-	return
+	return -1, nil
+}
+
+type builtinCaseWhenSig struct {
+	baseBuiltinFunc
+}
+
+// eval evals a builtinCaseWhenSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/case.html
+func (b *builtinCaseWhenSig) eval(row []types.Datum) (d types.Datum, err error) {
+	idx, err := evalCaseWhenBranch(b.args, row, b.ctx.GetSessionVars().StmtCtx)
+	if err != nil || idx < 0 {
+		return d, errors.Trace(err)
+	}
+	d, err = b.args[idx].Eval(row)
+	return d, errors.Trace(err)
+}
+
+type builtinCaseWhenIntSig struct {
+	baseIntBuiltinFunc
+}
+
+// evalInt evals a builtinCaseWhenIntSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/case.html
+func (b *builtinCaseWhenIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	idx, err := evalCaseWhenBranch(b.args, row, sc)
+	if err != nil || idx < 0 {
+		return 0, err == nil, errors.Trace(err)
+	}
+	return b.args[idx].EvalInt(row, sc)
+}
+
+type builtinCaseWhenRealSig struct {
+	baseRealBuiltinFunc
+}
+
+// evalReal evals a builtinCaseWhenRealSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/case.html
+func (b *builtinCaseWhenRealSig) evalReal(row []types.Datum) (float64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	idx, err := evalCaseWhenBranch(b.args, row, sc)
+	if err != nil || idx < 0 {
+		return 0, err == nil, errors.Trace(err)
+	}
+	return b.args[idx].EvalReal(row, sc)
+}
+
+type builtinCaseWhenDecimalSig struct {
+	baseDecimalBuiltinFunc
+}
+
+// evalDecimal evals a builtinCaseWhenDecimalSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/case.html
+func (b *builtinCaseWhenDecimalSig) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	idx, err := evalCaseWhenBranch(b.args, row, sc)
+	if err != nil || idx < 0 {
+		return nil, err == nil, errors.Trace(err)
+	}
+	return b.args[idx].EvalDecimal(row, sc)
+}
+
+type builtinCaseWhenStringSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals a builtinCaseWhenStringSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/case.html
+func (b *builtinCaseWhenStringSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	idx, err := evalCaseWhenBranch(b.args, row, sc)
+	if err != nil || idx < 0 {
+		return "", err == nil, errors.Trace(err)
+	}
+	return b.args[idx].EvalString(row, sc)
 }
 
 type ifFunctionClass struct {
@@ -124,11 +267,52 @@ type ifNullFunctionClass struct {
 }
 
 func (c *ifNullFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinIfNullSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinIfNullSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	if args[0].GetType() == nil || args[1].GetType() == nil {
+		return &builtinIfNullSig{baseBuiltinFunc: bf}, nil
+	}
+	tp := inferIfNullType(args)
+	var sig builtinFunc
+	switch tp.Tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		lhsUnsigned, rhsUnsigned := mysql.HasUnsignedFlag(args[0].GetType().Flag), mysql.HasUnsignedFlag(args[1].GetType().Flag)
+		if lhsUnsigned == rhsUnsigned || args[0].GetType().Tp == mysql.TypeNull || args[1].GetType().Tp == mysql.TypeNull {
+			sig = &builtinIfNullIntSig{baseIntBuiltinFunc{bf}, mysql.HasUnsignedFlag(tp.Flag)}
+		} else {
+			// A signed and an unsigned integer can't be held by BIGINT together.
+			sig = &builtinIfNullDecimalSig{baseDecimalBuiltinFunc: baseDecimalBuiltinFunc{bf}, frac: 0}
+		}
+	case mysql.TypeFloat, mysql.TypeDouble:
+		sig = &builtinIfNullRealSig{baseRealBuiltinFunc{bf}}
+	case mysql.TypeNewDecimal:
+		sig = &builtinIfNullDecimalSig{baseDecimalBuiltinFunc: baseDecimalBuiltinFunc{bf}, frac: tp.Decimal}
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString,
+		mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeBlob, mysql.TypeLongBlob:
+		sig = &builtinIfNullStringSig{baseStringBuiltinFunc{bf}}
+	default:
+		sig = &builtinIfNullSig{baseBuiltinFunc: bf, tp: tp}
+	}
+	return sig.setSelf(sig), nil
+}
+
+// inferIfNullType merges the field types of the two arguments of IFNULL, the length and the decimal are widened
+// to hold the values of both. The result is not null if either argument is provably not null.
+func inferIfNullType(args []Expression) *types.FieldType {
+	lhs, rhs := args[0].GetType(), args[1].GetType()
+	tp := types.MergeFieldTypes([]*types.FieldType{lhs, rhs})
+	if NotNull(args[0]) || NotNull(args[1]) {
+		tp.Flag |= mysql.NotNullFlag
+	}
+	return tp
 }
 
 type builtinIfNullSig struct {
 	baseBuiltinFunc
+
+	tp *types.FieldType
 }
 
 // eval evals a builtinIfNullSig.
@@ -143,11 +327,98 @@ func (b *builtinIfNullSig) eval(row []types.Datum) (types.Datum, error) {
 	v1 := args[0]
 	v2 := args[1]
 
-	if !v1.IsNull() {
-		return v1, nil
+	ret := v1
+	if v1.IsNull() {
+		ret = v2
+	}
+	// The temporal types are widened, e.g. ifnull(date, datetime) returns a datetime.
+	if ret.IsNull() || b.tp == nil || !isTemporalType(b.tp.Tp) {
+		return ret, nil
+	}
+	ret, err = ret.ConvertTo(b.ctx.GetSessionVars().StmtCtx, b.tp)
+	return ret, errors.Trace(err)
+}
+
+type builtinIfNullIntSig struct {
+	baseIntBuiltinFunc
+
+	// unsigned is true if the result is unsigned, i.e. neither argument is signed.
+	unsigned bool
+}
+
+// eval evals a builtinIfNullIntSig, an unsigned result is returned as an uint64 datum.
+func (b *builtinIfNullIntSig) eval(row []types.Datum) (types.Datum, error) {
+	if b.unsigned {
+		return b.evalUint(row)
 	}
+	return b.baseIntBuiltinFunc.eval(row)
+}
+
+// evalInt evals a builtinIfNullIntSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/control-flow-functions.html#function_ifnull
+func (b *builtinIfNullIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	arg0, isNull, err := b.args[0].EvalInt(row, sc)
+	if err != nil || !isNull {
+		return arg0, false, errors.Trace(err)
+	}
+	return b.args[1].EvalInt(row, sc)
+}
+
+type builtinIfNullRealSig struct {
+	baseRealBuiltinFunc
+}
+
+// evalReal evals a builtinIfNullRealSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/control-flow-functions.html#function_ifnull
+func (b *builtinIfNullRealSig) evalReal(row []types.Datum) (float64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	arg0, isNull, err := b.args[0].EvalReal(row, sc)
+	if err != nil || !isNull {
+		return arg0, false, errors.Trace(err)
+	}
+	return b.args[1].EvalReal(row, sc)
+}
+
+type builtinIfNullDecimalSig struct {
+	baseDecimalBuiltinFunc
 
-	return v2, nil
+	// frac is the scale of the merged type, the result is padded to it.
+	frac int
+}
+
+// evalDecimal evals a builtinIfNullDecimalSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/control-flow-functions.html#function_ifnull
+func (b *builtinIfNullDecimalSig) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	arg, isNull, err := b.args[0].EvalDecimal(row, sc)
+	if err == nil && isNull {
+		arg, isNull, err = b.args[1].EvalDecimal(row, sc)
+	}
+	if err != nil || isNull {
+		return nil, isNull, errors.Trace(err)
+	}
+	if _, frac := arg.PrecisionAndFrac(); frac < b.frac {
+		res := new(types.MyDecimal)
+		err = arg.Round(res, b.frac, types.ModeHalfEven)
+		return res, false, errors.Trace(err)
+	}
+	return arg, false, nil
+}
+
+type builtinIfNullStringSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals a builtinIfNullStringSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/control-flow-functions.html#function_ifnull
+func (b *builtinIfNullStringSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	arg0, isNull, err := b.args[0].EvalString(row, sc)
+	if err != nil || !isNull {
+		return arg0, false, errors.Trace(err)
+	}
+	return b.args[1].EvalString(row, sc)
 }
 
 type nullIfFunctionClass struct {
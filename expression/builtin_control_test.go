@@ -15,9 +15,12 @@ package expression
 
 import (
 	"errors"
+	"math"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
@@ -72,6 +75,82 @@ func (s *testEvaluatorSuite) TestIfNull(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestIfNullTypeMerging(c *C) {
+	defer testleak.AfterTest(c)()
+	newDecimal := func(str string, flen, frac int) *Constant {
+		d := types.NewDecFromStringForTest(str)
+		ft := types.NewFieldType(mysql.TypeNewDecimal)
+		ft.Flen, ft.Decimal = flen, frac
+		return &Constant{Value: types.NewDecimalDatum(d), RetType: ft}
+	}
+	nullDecimal := &Constant{Value: types.Datum{}, RetType: newDecimal("0", 3, 1).RetType}
+
+	// The narrow decimal is padded to the wider scale.
+	args := []Expression{newDecimal("1.5", 3, 1), newDecimal("123.4567", 10, 4)}
+	tp := inferIfNullType(args)
+	c.Assert(tp.Tp, Equals, mysql.TypeNewDecimal)
+	c.Assert(tp.Flen, Equals, 10)
+	c.Assert(tp.Decimal, Equals, 4)
+	c.Assert(mysql.HasNotNullFlag(tp.Flag), IsTrue)
+	f, err := funcs[ast.Ifnull].getFunction(args, s.ctx)
+	c.Assert(err, IsNil)
+	_, ok := f.(*builtinIfNullDecimalSig)
+	c.Assert(ok, IsTrue)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetMysqlDecimal().String(), Equals, "1.5000")
+
+	f, err = funcs[ast.Ifnull].getFunction([]Expression{nullDecimal, newDecimal("123.4567", 10, 4)}, s.ctx)
+	c.Assert(err, IsNil)
+	d, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetMysqlDecimal().String(), Equals, "123.4567")
+
+	// The result may be null unless one of the arguments is provably not null.
+	col := &Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	tp = inferIfNullType([]Expression{col, nullDecimal})
+	c.Assert(mysql.HasNotNullFlag(tp.Flag), IsFalse)
+	col.RetType.Flag |= mysql.NotNullFlag
+	tp = inferIfNullType([]Expression{nullDecimal, col})
+	c.Assert(mysql.HasNotNullFlag(tp.Flag), IsTrue)
+
+	// The temporal types are widened.
+	date := &Constant{Value: types.NewDatum(types.Time{
+		Time: types.FromDate(2017, 6, 1, 0, 0, 0, 0),
+		Type: mysql.TypeDate,
+	}), RetType: types.NewFieldType(mysql.TypeDate)}
+	datetime := &Constant{Value: types.Datum{}, RetType: types.NewFieldType(mysql.TypeDatetime)}
+	f, err = funcs[ast.Ifnull].getFunction([]Expression{date, datetime}, s.ctx)
+	c.Assert(err, IsNil)
+	d, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetMysqlTime().Type, Equals, mysql.TypeDatetime)
+	c.Assert(d.GetMysqlTime().String(), Equals, "2017-06-01 00:00:00")
+
+	// Two unsigned integers make an unsigned result.
+	for _, args := range [][]interface{}{
+		{uint64(math.MaxUint64), uint64(math.MaxUint64 - 1)},
+		{nil, uint64(math.MaxUint64)},
+	} {
+		f, err = funcs[ast.Ifnull].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		_, ok = f.(*builtinIfNullIntSig)
+		c.Assert(ok, IsTrue)
+		d, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(uint64(math.MaxUint64)))
+	}
+
+	args = datumsToConstants(types.MakeDatums(nil, "abc"))
+	f, err = funcs[ast.Ifnull].getFunction(args, s.ctx)
+	c.Assert(err, IsNil)
+	_, ok = f.(*builtinIfNullStringSig)
+	c.Assert(ok, IsTrue)
+	d, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetString(), Equals, "abc")
+}
+
 func (s *testEvaluatorSuite) TestNullIf(c *C) {
 	defer testleak.AfterTest(c)()
 	tbl := []struct {
@@ -94,3 +173,73 @@ func (s *testEvaluatorSuite) TestNullIf(c *C) {
 		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.Ret))
 	}
 }
+
+func (s *testEvaluatorSuite) TestCaseWhen(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.Case]
+	decimal := types.NewDecFromStringForTest("1.5")
+	tbl := []struct {
+		Args []interface{}
+		Ret  interface{}
+	}{
+		{[]interface{}{1, decimal, 2}, decimal},
+		{[]interface{}{0, decimal, 2}, types.NewDecFromInt(2)},
+		{[]interface{}{nil, 1, 1, "two"}, "two"},
+		{[]interface{}{1, 1, 1, "two"}, "1"},
+		{[]interface{}{0, 1.5, nil, 2, 3}, float64(3)},
+		{[]interface{}{0, 1, 0, 2}, nil},
+		{[]interface{}{0, 1}, nil},
+		{[]interface{}{"0", 1, "1", 2}, int64(2)},
+	}
+	for _, t := range tbl {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.Args...)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.Ret), Commentf("%v", t.Args))
+	}
+
+	// The result type is unified over all the THEN and ELSE branches.
+	f, err := fc.getFunction(datumsToConstants(types.MakeDatums(1, decimal, 2)), s.ctx)
+	c.Assert(err, IsNil)
+	_, ok := f.(*builtinCaseWhenDecimalSig)
+	c.Assert(ok, IsTrue)
+	f, err = fc.getFunction(datumsToConstants(types.MakeDatums(1, 1, "two")), s.ctx)
+	c.Assert(err, IsNil)
+	_, ok = f.(*builtinCaseWhenStringSig)
+	c.Assert(ok, IsTrue)
+
+	// Evaluation stops at the first true WHEN, so later branches are never evaluated.
+	// The failing branch counts its evaluations and returns an error whenever it's evaluated.
+	evals := 0
+	failing := func() Expression {
+		fail := &builtinFailingSig{newBaseBuiltinFunc(nil, s.ctx)}
+		counting := &builtinCountingSig{newBaseBuiltinFunc([]Expression{&ScalarFunction{
+			FuncName: model.NewCIStr("failing"),
+			RetType:  types.NewFieldType(mysql.TypeLonglong),
+			Function: fail.setSelf(fail),
+		}}, s.ctx), &evals}
+		return &ScalarFunction{FuncName: model.NewCIStr("counting"), RetType: types.NewFieldType(mysql.TypeLonglong), Function: counting.setSelf(counting)}
+	}
+	f, err = fc.getFunction([]Expression{One, newLonglong(1), failing(), failing(), failing()}, s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewDatum(1))
+	c.Assert(evals, Equals, 0)
+	// The failing branch does fail once it's taken.
+	f, err = fc.getFunction([]Expression{Zero, newLonglong(1), failing()}, s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(err, NotNil)
+	c.Assert(evals, Equals, 1)
+}
+
+// builtinFailingSig returns an error whenever it's evaluated.
+type builtinFailingSig struct {
+	baseBuiltinFunc
+}
+
+func (b *builtinFailingSig) eval(row []types.Datum) (types.Datum, error) {
+	return types.Datum{}, errors.New("must not be evaluated")
+}
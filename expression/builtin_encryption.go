@@ -319,33 +319,23 @@ type md5FunctionClass struct {
 }
 
 func (c *md5FunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinMD5Sig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinMD5Sig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinMD5Sig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinMD5Sig.
+// evalString evals a builtinMD5Sig.
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_md5
-func (b *builtinMD5Sig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	// This function takes one argument.
-	arg := args[0]
-	if arg.IsNull() {
-		return
-	}
-	bin, err := arg.ToBytes()
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinMD5Sig) evalString(row []types.Datum) (string, bool, error) {
+	arg, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-	sum := md5.Sum(bin)
-	hexStr := fmt.Sprintf("%x", sum)
-	d.SetString(hexStr)
-	return d, nil
+	sum := md5.Sum([]byte(arg))
+	return fmt.Sprintf("%x", sum), false, nil
 }
 
 type oldPasswordFunctionClass struct {
@@ -426,35 +416,24 @@ type sha1FunctionClass struct {
 }
 
 func (c *sha1FunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinSHA1Sig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinSHA1Sig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinSHA1Sig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinSHA1Sig.
+// evalString evals a builtinSHA1Sig.
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_sha1
 // The value is returned as a string of 40 hexadecimal digits, or NULL if the argument was NULL.
-func (b *builtinSHA1Sig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinSHA1Sig) evalString(row []types.Datum) (string, bool, error) {
+	arg, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-	// SHA/SHA1 function only accept 1 parameter
-	arg := args[0]
-	if arg.IsNull() {
-		return d, nil
-	}
-	bin, err := arg.ToBytes()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	hasher := sha1.New()
-	hasher.Write(bin)
-	data := fmt.Sprintf("%x", hasher.Sum(nil))
-	d.SetString(data)
-	return d, nil
+	sum := sha1.Sum([]byte(arg))
+	return fmt.Sprintf("%x", sum), false, nil
 }
 
 type sha2FunctionClass struct {
@@ -462,11 +441,12 @@ type sha2FunctionClass struct {
 }
 
 func (c *sha2FunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinSHA2Sig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinSHA2Sig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinSHA2Sig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
 // Supported hash length of SHA-2 family
@@ -478,28 +458,21 @@ const (
 	SHA512 int = 512
 )
 
-// eval evals a builtinSHA2Sig.
+// evalString evals a builtinSHA2Sig.
 // See https://dev.mysql.com/doc/refman/5.7/en/encryption-functions.html#function_sha2
-func (b *builtinSHA2Sig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	for _, arg := range args {
-		if arg.IsNull() {
-			return d, nil
-		}
-	}
+// The result is NULL if either argument is NULL or the hash length is not supported.
+func (b *builtinSHA2Sig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
 	// Meaning of each argument:
 	// args[0]: the cleartext string to be hashed
 	// args[1]: desired bit length of result
-	bin, err := args[0].ToBytes()
-	if err != nil {
-		return d, errors.Trace(err)
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-	hashLength, err := args[1].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
+	hashLength, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
 	var hasher hash.Hash
 	switch int(hashLength) {
@@ -511,13 +484,11 @@ func (b *builtinSHA2Sig) eval(row []types.Datum) (d types.Datum, err error) {
 		hasher = sha512.New384()
 	case SHA512:
 		hasher = sha512.New()
+	default:
+		return "", true, nil
 	}
-	if hasher != nil {
-		hasher.Write(bin)
-		data := fmt.Sprintf("%x", hasher.Sum(nil))
-		d.SetString(data)
-	}
-	return d, nil
+	hasher.Write([]byte(str))
+	return fmt.Sprintf("%x", hasher.Sum(nil)), false, nil
 }
 
 type uncompressFunctionClass struct {
@@ -51,6 +51,40 @@ func (s *testEvaluatorSuite) TestAESEncrypt(c *C) {
 	s.testNullInput(c, ast.AesDecrypt)
 }
 
+func (s *testEvaluatorSuite) TestDigestEvalString(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		funcName string
+		args     []interface{}
+		isNull   bool
+		digest   string
+	}{
+		{ast.MD5, []interface{}{"abc"}, false, "900150983cd24fb0d6963f7d28e17f72"},
+		{ast.MD5, []interface{}{nil}, true, ""},
+		{ast.SHA1, []interface{}{"abc"}, false, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{ast.SHA, []interface{}{"abc"}, false, "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{ast.SHA1, []interface{}{nil}, true, ""},
+		{ast.SHA2, []interface{}{"abc", 224}, false, "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7"},
+		{ast.SHA2, []interface{}{"abc", 256}, false, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{ast.SHA2, []interface{}{"abc", 0}, false, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{ast.SHA2, []interface{}{"abc", 384}, false, "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7"},
+		{ast.SHA2, []interface{}{"abc", 512}, false, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{ast.SHA2, []interface{}{"", 256}, false, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{ast.SHA2, []interface{}{"abc", 1}, true, ""},
+		{ast.SHA2, []interface{}{"abc", -256}, true, ""},
+		{ast.SHA2, []interface{}{nil, 256}, true, ""},
+		{ast.SHA2, []interface{}{"abc", nil}, true, ""},
+	}
+	for _, t := range tests {
+		f, err := funcs[t.funcName].getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil)
+		digest, isNull, err := f.evalString(nil)
+		c.Assert(err, IsNil)
+		c.Assert(isNull, Equals, t.isNull, Commentf("%s%v", t.funcName, t.args))
+		c.Assert(digest, Equals, t.digest, Commentf("%s%v", t.funcName, t.args))
+	}
+}
+
 func (s *testEvaluatorSuite) TestAESDecrypt(c *C) {
 	defer testleak.AfterTest(c)()
 	fc := funcs[ast.AesDecrypt]
@@ -18,9 +18,12 @@
 package expression
 
 import (
+	"strconv"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -60,24 +63,20 @@ type databaseFunctionClass struct {
 
 func (c *databaseFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
 	err := errors.Trace(c.verifyArgs(args))
-	bt := &builtinDatabaseSig{newBaseBuiltinFunc(args, ctx)}
+	bt := &builtinDatabaseSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
 	bt.deterministic = false
-	return bt, errors.Trace(err)
+	return bt.setSelf(bt), errors.Trace(err)
 }
 
 type builtinDatabaseSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinDatabaseSig.
+// evalString evals a builtinDatabaseSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html
-func (b *builtinDatabaseSig) eval(_ []types.Datum) (d types.Datum, err error) {
+func (b *builtinDatabaseSig) evalString(_ []types.Datum) (string, bool, error) {
 	currentDB := b.ctx.GetSessionVars().CurrentDB
-	if currentDB == "" {
-		return d, nil
-	}
-	d.SetString(currentDB)
-	return d, nil
+	return currentDB, currentDB == "", nil
 }
 
 type foundRowsFunctionClass struct {
@@ -142,25 +141,23 @@ type userFunctionClass struct {
 
 func (c *userFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
 	err := errors.Trace(c.verifyArgs(args))
-	bt := &builtinUserSig{newBaseBuiltinFunc(args, ctx)}
+	bt := &builtinUserSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
 	bt.deterministic = false
-	return bt, errors.Trace(err)
+	return bt.setSelf(bt), errors.Trace(err)
 }
 
 type builtinUserSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinUserSig.
+// evalString evals a builtinUserSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_user
-func (b *builtinUserSig) eval(_ []types.Datum) (d types.Datum, err error) {
+func (b *builtinUserSig) evalString(_ []types.Datum) (string, bool, error) {
 	data := b.ctx.GetSessionVars()
 	if data == nil {
-		return d, errors.Errorf("Missing session variable when evalue builtin")
+		return "", true, errors.Errorf("Missing session variable when evalue builtin")
 	}
-
-	d.SetString(data.User)
-	return d, nil
+	return data.User, false, nil
 }
 
 type connectionIDFunctionClass struct {
@@ -169,23 +166,28 @@ type connectionIDFunctionClass struct {
 
 func (c *connectionIDFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
 	err := errors.Trace(c.verifyArgs(args))
-	bt := &builtinConnectionIDSig{newBaseBuiltinFunc(args, ctx)}
+	bt := &builtinConnectionIDSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
 	bt.deterministic = false
-	return bt, errors.Trace(err)
+	return bt.setSelf(bt), errors.Trace(err)
 }
 
 type builtinConnectionIDSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-func (b *builtinConnectionIDSig) eval(_ []types.Datum) (d types.Datum, err error) {
+// evalInt evals a builtinConnectionIDSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_connection-id
+func (b *builtinConnectionIDSig) evalInt(_ []types.Datum) (int64, bool, error) {
 	data := b.ctx.GetSessionVars()
 	if data == nil {
-		return d, errors.Errorf("Missing session variable when evalue builtin")
+		return 0, true, errors.Errorf("Missing session variable when evalue builtin")
 	}
+	return int64(data.ConnectionID), false, nil
+}
 
-	d.SetUint64(data.ConnectionID)
-	return d, nil
+// eval evals a builtinConnectionIDSig, the connection ID is unsigned.
+func (b *builtinConnectionIDSig) eval(row []types.Datum) (types.Datum, error) {
+	return b.evalUint(row)
 }
 
 type lastInsertIDFunctionClass struct {
@@ -250,17 +252,46 @@ type benchmarkFunctionClass struct {
 }
 
 func (c *benchmarkFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinBenchmarkSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	err := errors.Trace(c.verifyArgs(args))
+	bt := &builtinBenchmarkSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	bt.deterministic = false
+	return bt.setSelf(bt), errors.Trace(err)
 }
 
+// benchmarkCheckInterval is the number of evaluations between two checks of the cancellation.
+const benchmarkCheckInterval = 1024
+
 type builtinBenchmarkSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinBenchmarkSig.
+// evalInt evals a builtinBenchmarkSig, it evaluates the second argument count times and returns 0,
+// or NULL if count is NULL or negative.
 // See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_benchmark
-func (b *builtinBenchmarkSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("BENCHMARK")
+func (b *builtinBenchmarkSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	count, isNull, err := b.args[0].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	if count < 0 {
+		sc.AppendWarning(errWrongValueForType.GenByArgs("count", strconv.FormatInt(count, 10), "benchmark"))
+		return 0, true, nil
+	}
+	done := b.ctx.GoCtx().Done()
+	for i := int64(0); i < count; i++ {
+		if i%benchmarkCheckInterval == 0 {
+			select {
+			case <-done:
+				return 0, true, errors.Trace(b.ctx.GoCtx().Err())
+			default:
+			}
+		}
+		if _, err = b.args[1].Eval(row); err != nil {
+			return 0, true, errors.Trace(err)
+		}
+	}
+	return 0, false, nil
 }
 
 type charsetFunctionClass struct {
@@ -268,17 +299,36 @@ type charsetFunctionClass struct {
 }
 
 func (c *charsetFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinCharsetSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinCharsetSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinCharsetSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinCharsetSig.
+// evalString evals CHARSET(str), the charset of the type of str. The argument isn't evaluated, so the function
+// is folded as a constant.
 // See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_charset
-func (b *builtinCharsetSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("CHARSET")
+func (b *builtinCharsetSig) evalString(row []types.Datum) (string, bool, error) {
+	chs, _ := charsetAndCollation(b.args[0].GetType())
+	return chs, false, nil
+}
+
+// charsetAndCollation returns the charset and the collation of ft, they are binary if ft isn't a string type.
+func charsetAndCollation(ft *types.FieldType) (string, string) {
+	if ft == nil || ft.Charset == "" || ft.Charset == charset.CharsetBin || ft.ToClass() != types.ClassString ||
+		isTemporalType(ft.Tp) || ft.Tp == mysql.TypeDuration {
+		return charset.CharsetBin, charset.CollationBin
+	}
+	if ft.Collate != "" {
+		return ft.Charset, ft.Collate
+	}
+	collation, err := charset.GetDefaultCollation(ft.Charset)
+	if err != nil {
+		return ft.Charset, charset.CollationBin
+	}
+	return ft.Charset, collation
 }
 
 type coercibilityFunctionClass struct {
@@ -304,17 +354,19 @@ type collationFunctionClass struct {
 }
 
 func (c *collationFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinCollationSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinCollationSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinCollationSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinCollationSig.
+// evalString evals COLLATION(str), the collation of the type of str. Like CHARSET, the argument isn't evaluated.
 // See https://dev.mysql.com/doc/refman/5.7/en/information-functions.html#function_collation
-func (b *builtinCollationSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("COLLATION")
+func (b *builtinCollationSig) evalString(row []types.Datum) (string, bool, error) {
+	_, collation := charsetAndCollation(b.args[0].GetType())
+	return collation, false, nil
 }
 
 type rowCountFunctionClass struct {
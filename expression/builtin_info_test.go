@@ -16,9 +16,13 @@ package expression
 import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -99,9 +103,85 @@ func (s *testEvaluatorSuite) TestConnectionID(c *C) {
 	c.Assert(err, IsNil)
 	d, err := f.eval(nil)
 	c.Assert(err, IsNil)
+	c.Assert(d.Kind(), Equals, types.KindUint64)
 	c.Assert(d.GetUint64(), Equals, uint64(1))
 }
 
+func (s *testEvaluatorSuite) TestSessionInfoFuncsNotFolded(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	sessionVars := ctx.GetSessionVars()
+	tests := []struct {
+		funcName string
+		tp       byte
+		before   interface{}
+		after    interface{}
+	}{
+		{ast.Database, mysql.TypeVarString, "test", "test2"},
+		{ast.User, mysql.TypeVarString, "root@localhost", "root@%"},
+		{ast.ConnectionID, mysql.TypeLonglong, uint64(1), uint64(2)},
+	}
+	for _, t := range tests {
+		sessionVars.CurrentDB = "test"
+		sessionVars.User = "root@localhost"
+		sessionVars.ConnectionID = 1
+		f, err := NewFunction(ctx, t.funcName, types.NewFieldType(t.tp))
+		c.Assert(err, IsNil)
+		f = FoldConstant(f)
+		_, ok := f.(*ScalarFunction)
+		c.Assert(ok, IsTrue, Commentf("%s is folded", t.funcName))
+		d, err := f.Eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.GetValue(), Equals, t.before)
+
+		// The function reads the session variables when it's evaluated.
+		sessionVars.CurrentDB = "test2"
+		sessionVars.User = "root@%"
+		sessionVars.ConnectionID = 2
+		d, err = f.Eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.GetValue(), Equals, t.after)
+	}
+}
+
+func (s *testEvaluatorSuite) TestCharsetAndCollation(c *C) {
+	defer testleak.AfterTest(c)()
+	utf8Type := types.NewFieldType(mysql.TypeVarString)
+	utf8Type.Charset, utf8Type.Collate = charset.CharsetUTF8, "utf8_general_ci"
+	binType := types.NewFieldType(mysql.TypeBlob)
+	binType.Charset, binType.Collate = charset.CharsetBin, charset.CollationBin
+	noCollateType := types.NewFieldType(mysql.TypeString)
+	noCollateType.Charset = charset.CharsetUTF8
+	intType := types.NewFieldType(mysql.TypeLonglong)
+	tests := []struct {
+		arg       Expression
+		charset   string
+		collation string
+	}{
+		{&Column{Index: 0, RetType: utf8Type}, "utf8", "utf8_general_ci"},
+		{&Column{Index: 0, RetType: binType}, "binary", "binary"},
+		{&Column{Index: 0, RetType: noCollateType}, "utf8", "utf8_bin"},
+		{newFunction(ast.Plus, &Column{Index: 0, RetType: intType}, newLonglong(1)), "binary", "binary"},
+		{&Column{Index: 0, RetType: types.NewFieldType(mysql.TypeDatetime)}, "binary", "binary"},
+	}
+	strType := types.NewFieldType(mysql.TypeVarString)
+	for _, t := range tests {
+		for funcName, expected := range map[string]string{ast.Charset: t.charset, ast.Collation: t.collation} {
+			f, err := NewFunction(s.ctx, funcName, strType, t.arg)
+			c.Assert(err, IsNil)
+			// The argument isn't evaluated, even if it's NULL.
+			d, err := f.Eval(types.MakeDatums(nil))
+			c.Assert(err, IsNil)
+			c.Assert(d, testutil.DatumEquals, types.NewStringDatum(expected), Commentf("%s(%s)", funcName, t.arg))
+			// The function is folded as a constant.
+			folded := FoldConstant(f)
+			con, ok := folded.(*Constant)
+			c.Assert(ok, IsTrue, Commentf("%s(%s)", funcName, t.arg))
+			c.Assert(con.Value, testutil.DatumEquals, types.NewStringDatum(expected))
+		}
+	}
+}
+
 func (s *testEvaluatorSuite) TestVersion(c *C) {
 	defer testleak.AfterTest(c)()
 	fc := funcs[ast.Version]
@@ -111,3 +191,59 @@ func (s *testEvaluatorSuite) TestVersion(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v.GetString(), Equals, mysql.ServerVersion)
 }
+
+func (s *testEvaluatorSuite) TestBenchmark(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	newCounting := func(count *int) Expression {
+		counting := &builtinCountingSig{newBaseBuiltinFunc([]Expression{newLonglong(2)}, ctx), count}
+		counting.deterministic = false
+		return &ScalarFunction{FuncName: model.NewCIStr("counting"), RetType: types.NewFieldType(mysql.TypeLonglong), Function: counting.setSelf(counting)}
+	}
+	tests := []struct {
+		count  interface{}
+		evals  int
+		result interface{}
+	}{
+		{0, 0, 0},
+		{1, 1, 0},
+		{3, 3, 0},
+		{benchmarkCheckInterval*2 + 1, benchmarkCheckInterval*2 + 1, 0},
+		{"5", 5, 0},
+		{nil, 0, nil},
+		{-1, 0, nil},
+	}
+	for _, t := range tests {
+		evals := 0
+		f, err := NewFunction(ctx, ast.Benchmark, types.NewFieldType(mysql.TypeLonglong),
+			datumsToConstants(types.MakeDatums(t.count))[0], newCounting(&evals))
+		c.Assert(err, IsNil)
+		// BENCHMARK is never folded.
+		f = FoldConstant(f)
+		_, ok := f.(*ScalarFunction)
+		c.Assert(ok, IsTrue)
+		d, err := f.Eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.result), Commentf("%v", t.count))
+		c.Assert(evals, Equals, t.evals, Commentf("%v", t.count))
+	}
+	// A negative count is warned.
+	warnings := ctx.GetSessionVars().StmtCtx.GetWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(terror.ErrorEqual(warnings[0], errWrongValueForType), IsTrue, Commentf("%v", warnings[0]))
+
+	// The arguments aren't folded either, so a constant inner expression is really evaluated count times.
+	f, err := NewFunction(ctx, ast.Benchmark, types.NewFieldType(mysql.TypeLonglong), newLonglong(3), newFunction(ast.Plus, One, One))
+	c.Assert(err, IsNil)
+	f = FoldConstant(f)
+	c.Assert(f.(*ScalarFunction).GetArgs()[1].(*ScalarFunction).FuncName.L, Equals, ast.Plus)
+
+	// The evaluation stops once the query is canceled.
+	evals := 0
+	f, err = NewFunction(ctx, ast.Benchmark, types.NewFieldType(mysql.TypeLonglong), newLonglong(10), newCounting(&evals))
+	c.Assert(err, IsNil)
+	ctx.Cancel()
+	_, err = f.Eval(nil)
+	c.Assert(err, NotNil)
+	c.Assert(evals, Equals, 0)
+}
@@ -0,0 +1,1042 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var (
+	_ functionClass = &jsonTypeFunctionClass{}
+	_ functionClass = &jsonModifyFunctionClass{}
+	_ functionClass = &memberOfFunctionClass{}
+	_ functionClass = &jsonKeysFunctionClass{}
+	_ functionClass = &jsonLengthFunctionClass{}
+	_ functionClass = &jsonMergeFunctionClass{}
+	_ functionClass = &jsonDepthFunctionClass{}
+	_ functionClass = &jsonValidFunctionClass{}
+	_ functionClass = &jsonQuoteFunctionClass{}
+	_ functionClass = &jsonPrettyFunctionClass{}
+)
+
+var (
+	_ builtinFunc = &builtinJSONTypeSig{}
+	_ builtinFunc = &builtinJSONModifySig{}
+	_ builtinFunc = &builtinJSONMemberOfSig{}
+	_ builtinFunc = &builtinJSONKeysSig{}
+	_ builtinFunc = &builtinJSONLengthSig{}
+	_ builtinFunc = &builtinJSONMergeSig{}
+	_ builtinFunc = &builtinJSONDepthSig{}
+	_ builtinFunc = &builtinJSONValidSig{}
+	_ builtinFunc = &builtinJSONQuoteSig{}
+	_ builtinFunc = &builtinJSONPrettySig{}
+)
+
+type jsonTypeFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonTypeFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONTypeSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	if tp := args[0].GetType().Tp; tp != mysql.TypeNull && !types.IsTypeChar(tp) && !types.IsTypeVarchar(tp) && !types.IsTypeBlob(tp) {
+		return sig.setSelf(sig), errInvalidOperation.Gen("Invalid data type for JSON data in argument 1 to function %s; a JSON string is required.", c.funcName)
+	}
+	return sig.setSelf(sig), nil
+}
+
+type builtinJSONTypeSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals a builtinJSONTypeSig.
+// A SQL NULL argument returns NULL, while a JSON null returns the string "NULL".
+// See https://dev.mysql.com/doc/refman/5.7/en/json-attribute-functions.html#function_json-type
+func (b *builtinJSONTypeSig) evalString(row []types.Datum) (string, bool, error) {
+	doc, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	tp, err := parseJSONType(doc)
+	if err != nil {
+		return "", true, errInvalidOperation.Gen("Invalid JSON text in argument 1 to function %s: %v", ast.JSONType, err)
+	}
+	return tp, false, nil
+}
+
+// parseJSON parses a JSON text, the numbers are decoded as json.Number so that they are kept as they are.
+func parseJSON(doc string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(doc))
+	decoder.UseNumber()
+	var val interface{}
+	if err := decoder.Decode(&val); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The text must contain exactly one value.
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("the document root must not be followed by other values")
+	}
+	return val, nil
+}
+
+// parseJSONType parses a JSON text and returns the type name of its top-level value.
+func parseJSONType(doc string) (string, error) {
+	val, err := parseJSON(doc)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	switch x := val.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		return "BOOLEAN", nil
+	case string:
+		return "STRING", nil
+	case []interface{}:
+		return "ARRAY", nil
+	case map[string]interface{}:
+		return "OBJECT", nil
+	case json.Number:
+		if _, err := strconv.ParseInt(string(x), 10, 64); err == nil {
+			return "INTEGER", nil
+		}
+		if _, err := strconv.ParseUint(string(x), 10, 64); err == nil {
+			return "UNSIGNED INTEGER", nil
+		}
+		return "DOUBLE", nil
+	}
+	return "", errors.Errorf("unknown JSON value %v", val)
+}
+
+// jsonModifyMode decides what JSON_SET, JSON_INSERT and JSON_REPLACE do with a path.
+type jsonModifyMode byte
+
+const (
+	// jsonModifySet replaces the existing values and adds the missing ones.
+	jsonModifySet jsonModifyMode = iota
+	// jsonModifyInsert only adds the missing values.
+	jsonModifyInsert
+	// jsonModifyReplace only replaces the existing values.
+	jsonModifyReplace
+)
+
+type jsonModifyFunctionClass struct {
+	baseFunctionClass
+
+	mode jsonModifyMode
+}
+
+func (c *jsonModifyFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONModifySig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}, c.mode, nil}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	// The document must be followed by (path, value) pairs.
+	if len(args)%2 == 0 {
+		return sig.setSelf(sig), errIncorrectParameterCount.GenByArgs(c.funcName)
+	}
+	// The constant paths are parsed only once.
+	sig.paths = make([]jsonPath, len(args))
+	for i := 1; i < len(args); i += 2 {
+		con, ok := args[i].(*Constant)
+		if !ok || con.Value.IsNull() {
+			continue
+		}
+		pathExpr, err := con.Value.ToString()
+		if err != nil {
+			return sig.setSelf(sig), errors.Trace(err)
+		}
+		if sig.paths[i], err = parseJSONPath(pathExpr); err != nil {
+			return sig.setSelf(sig), errors.Trace(err)
+		}
+	}
+	return sig.setSelf(sig), nil
+}
+
+type builtinJSONModifySig struct {
+	baseStringBuiltinFunc
+
+	mode jsonModifyMode
+	// paths[i] is the parsed path of args[i] if it's a constant.
+	paths []jsonPath
+}
+
+// evalString evals JSON_SET, JSON_INSERT or JSON_REPLACE. The (path, value) pairs are applied from left to right,
+// and the result is NULL if the document or any path is NULL.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-modification-functions.html
+func (b *builtinJSONModifySig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	doc, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	val, err := parseJSON(doc)
+	if err != nil {
+		return "", true, errInvalidOperation.Gen("Invalid JSON text in argument 1 to function %s: %v", b.funcName(), err)
+	}
+	for i := 1; i < len(b.args); i += 2 {
+		path := b.paths[i]
+		if path == nil {
+			pathExpr, isNull, err := b.args[i].EvalString(row, sc)
+			if isNull || err != nil {
+				return "", true, errors.Trace(err)
+			}
+			if path, err = parseJSONPath(pathExpr); err != nil {
+				return "", true, errors.Trace(err)
+			}
+		}
+		newVal, _, err := evalJSONValue(b.args[i+1], row, sc)
+		if err != nil {
+			return "", true, errors.Trace(err)
+		}
+		val = modifyJSON(val, path, newVal, b.mode)
+	}
+	var buf bytes.Buffer
+	writeJSON(&buf, val)
+	return buf.String(), false, nil
+}
+
+func (b *builtinJSONModifySig) funcName() string {
+	switch b.mode {
+	case jsonModifyInsert:
+		return ast.JSONInsert
+	case jsonModifyReplace:
+		return ast.JSONReplace
+	}
+	return ast.JSONSet
+}
+
+// evalJSONValue evaluates arg to a JSON value according to its type, a SQL NULL is a JSON null.
+func evalJSONValue(arg Expression, row []types.Datum, sc *variable.StatementContext) (interface{}, bool, error) {
+	tp := arg.GetType()
+	switch tp.ToClass() {
+	case types.ClassInt:
+		val, isNull, err := arg.EvalInt(row, sc)
+		if isNull || err != nil {
+			return nil, isNull, errors.Trace(err)
+		}
+		if mysql.HasUnsignedFlag(tp.Flag) {
+			return json.Number(strconv.FormatUint(uint64(val), 10)), false, nil
+		}
+		return json.Number(strconv.FormatInt(val, 10)), false, nil
+	case types.ClassReal:
+		val, isNull, err := arg.EvalReal(row, sc)
+		if isNull || err != nil {
+			return nil, isNull, errors.Trace(err)
+		}
+		return json.Number(strconv.FormatFloat(val, 'g', -1, 64)), false, nil
+	case types.ClassDecimal:
+		val, isNull, err := arg.EvalDecimal(row, sc)
+		if isNull || err != nil {
+			return nil, isNull, errors.Trace(err)
+		}
+		return json.Number(val.String()), false, nil
+	}
+	val, isNull, err := arg.EvalString(row, sc)
+	if isNull || err != nil {
+		return nil, isNull, errors.Trace(err)
+	}
+	return val, false, nil
+}
+
+// jsonPathLeg is a member or an array cell of a JSON path.
+type jsonPathLeg struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// jsonPath is a JSON path without the leading '$' scope, e.g. $.a[1] is [{key: "a"}, {index: 1, isIndex: true}].
+type jsonPath []jsonPathLeg
+
+// parseJSONPath parses a JSON path expression like '$.a."b c"[1]', wildcards are not supported.
+func parseJSONPath(pathExpr string) (jsonPath, error) {
+	invalidPath := func(pos int) error {
+		return errInvalidOperation.Gen("Invalid JSON path expression. The error is around character position %d.", pos)
+	}
+	s := strings.TrimSpace(pathExpr)
+	if len(s) == 0 || s[0] != '$' {
+		return nil, invalidPath(0)
+	}
+	path := jsonPath{}
+	offset := len(pathExpr) - len(strings.TrimLeft(pathExpr, " \t\n\r"))
+	i := 1
+	for i < len(s) {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		case '.':
+			i++
+			for i < len(s) && strings.IndexByte(" \t\n\r", s[i]) != -1 {
+				i++
+			}
+			if i == len(s) {
+				return nil, invalidPath(offset + i)
+			}
+			if s[i] == '*' {
+				return nil, errInvalidOperation.Gen("In this situation, path expressions may not contain the * and ** tokens.")
+			}
+			if s[i] == '"' {
+				end := scanJSONString(s, i)
+				var key string
+				if end == -1 || json.Unmarshal([]byte(s[i:end]), &key) != nil {
+					return nil, invalidPath(offset + i)
+				}
+				i = end
+				path = append(path, jsonPathLeg{key: key})
+				continue
+			}
+			start := i
+			for i < len(s) && isJSONPathKeyChar(rune(s[i]), i == start) {
+				i++
+			}
+			if i == start {
+				return nil, invalidPath(offset + i)
+			}
+			path = append(path, jsonPathLeg{key: s[start:i]})
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				return nil, invalidPath(offset + i)
+			}
+			idxStr := strings.TrimSpace(s[i+1 : i+end])
+			if idxStr == "*" {
+				return nil, errInvalidOperation.Gen("In this situation, path expressions may not contain the * and ** tokens.")
+			}
+			idx, err := strconv.ParseUint(idxStr, 10, 31)
+			if err != nil {
+				return nil, invalidPath(offset + i)
+			}
+			path = append(path, jsonPathLeg{index: int(idx), isIndex: true})
+			i += end + 1
+		case '*':
+			return nil, errInvalidOperation.Gen("In this situation, path expressions may not contain the * and ** tokens.")
+		default:
+			return nil, invalidPath(offset + i)
+		}
+	}
+	return path, nil
+}
+
+// scanJSONString returns the end offset of the quoted JSON string starting at s[start], or -1 if the
+// closing quote is missing. The escaped quotes are skipped, the string itself is validated by the caller.
+func scanJSONString(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isJSONPathKeyChar checks whether c can be a character of an unquoted key, which is an ECMAScript identifier.
+func isJSONPathKeyChar(c rune, first bool) bool {
+	if c == '_' || c == '$' || unicode.IsLetter(c) || c >= utf8.RuneSelf {
+		return true
+	}
+	return !first && unicode.IsDigit(c)
+}
+
+// lookupJSON returns the value at path of val, found is false if there is no such value.
+// As in modifyJSON, a non-array value is treated as an array of itself.
+func lookupJSON(val interface{}, path jsonPath) (result interface{}, found bool) {
+	for _, leg := range path {
+		if leg.isIndex {
+			arr, ok := val.([]interface{})
+			if !ok {
+				if leg.index != 0 {
+					return nil, false
+				}
+				continue
+			}
+			if leg.index >= len(arr) {
+				return nil, false
+			}
+			val = arr[leg.index]
+			continue
+		}
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if val, ok = obj[leg.key]; !ok {
+			return nil, false
+		}
+	}
+	return val, true
+}
+
+// modifyJSON applies newVal to the value at path of val and returns the modified value. A missing value
+// is added only if its parent exists, an object gets the new member and an array gets the new value
+// appended. A non-array value is treated as an array of itself, so $[0] is the value itself and $[1]
+// wraps the value into an array when the new value is added.
+func modifyJSON(val interface{}, path jsonPath, newVal interface{}, mode jsonModifyMode) interface{} {
+	if len(path) == 0 {
+		if mode == jsonModifyInsert {
+			return val
+		}
+		return newVal
+	}
+	leg, last := path[0], len(path) == 1
+	if leg.isIndex {
+		arr, ok := val.([]interface{})
+		if !ok {
+			if leg.index == 0 {
+				return modifyJSON(val, path[1:], newVal, mode)
+			}
+			if last && mode != jsonModifyReplace {
+				return []interface{}{val, newVal}
+			}
+			return val
+		}
+		if leg.index < len(arr) {
+			arr[leg.index] = modifyJSON(arr[leg.index], path[1:], newVal, mode)
+			return arr
+		}
+		if last && mode != jsonModifyReplace {
+			return append(arr, newVal)
+		}
+		return arr
+	}
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	if child, ok := obj[leg.key]; ok {
+		obj[leg.key] = modifyJSON(child, path[1:], newVal, mode)
+	} else if last && mode != jsonModifyReplace {
+		obj[leg.key] = newVal
+	}
+	return obj
+}
+
+// writeJSON writes val to buf in the format of MySQL, i.e. separated by ", " and ": ", and the keys of
+// an object are sorted by their lengths first.
+func writeJSON(buf *bytes.Buffer, val interface{}) {
+	switch x := val.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(x))
+	case json.Number:
+		buf.WriteString(string(x))
+	case string:
+		writeJSONString(buf, x)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range x {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeJSON(buf, elem)
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, key := range sortedJSONKeys(x) {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			writeJSONString(buf, key)
+			buf.WriteString(": ")
+			writeJSON(buf, x[key])
+		}
+		buf.WriteByte('}')
+	}
+}
+
+// sortedJSONKeys returns the keys of obj sorted by their lengths first, which is the order MySQL stores them in.
+func sortedJSONKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// writeJSONString writes s to buf as a JSON string literal escaped as MySQL does: the quotes, the backslashes
+// and the control characters are escaped, and the other characters are written as they are.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+type jsonMergeFunctionClass struct {
+	baseFunctionClass
+
+	// patch decides whether to merge as JSON_MERGE_PATCH or JSON_MERGE_PRESERVE.
+	patch bool
+}
+
+func (c *jsonMergeFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONMergeSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}, c.patch}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinJSONMergeSig struct {
+	baseStringBuiltinFunc
+
+	patch bool
+}
+
+// evalString evals JSON_MERGE_PATCH or JSON_MERGE_PRESERVE, the documents are merged from left to right and
+// a single document is returned as it is. The result is NULL if any document is NULL.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-modification-functions.html#function_json-merge-patch
+// and https://dev.mysql.com/doc/refman/5.7/en/json-modification-functions.html#function_json-merge-preserve
+func (b *builtinJSONMergeSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	var result interface{}
+	for i, arg := range b.args {
+		doc, isNull, err := arg.EvalString(row, sc)
+		if isNull || err != nil {
+			return "", true, errors.Trace(err)
+		}
+		val, err := parseJSON(doc)
+		if err != nil {
+			return "", true, errInvalidOperation.Gen("Invalid JSON text in argument %d to function %s: %v", i+1, b.funcName(), err)
+		}
+		switch {
+		case i == 0:
+			result = val
+		case b.patch:
+			result = mergePatchJSON(result, val)
+		default:
+			result = mergePreserveJSON(result, val)
+		}
+	}
+	var buf bytes.Buffer
+	writeJSON(&buf, result)
+	return buf.String(), false, nil
+}
+
+func (b *builtinJSONMergeSig) funcName() string {
+	if b.patch {
+		return ast.JSONMergePatch
+	}
+	return ast.JSONMergePreserve
+}
+
+// mergePatchJSON merges patch into target as RFC 7396, a non-object patch replaces the target, otherwise
+// its members are merged into the target recursively and a null member removes the key.
+func mergePatchJSON(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{}, len(patchObj))
+	}
+	for key, val := range patchObj {
+		if val == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatchJSON(targetObj[key], val)
+	}
+	return targetObj
+}
+
+// mergePreserveJSON merges b into a without losing any value. Two arrays are concatenated, two objects
+// are united and the values of the same key are merged recursively, otherwise the non-array values are
+// wrapped into arrays before being concatenated.
+func mergePreserveJSON(a, b interface{}) interface{} {
+	objA, okA := a.(map[string]interface{})
+	objB, okB := b.(map[string]interface{})
+	if okA && okB {
+		for key, val := range objB {
+			if old, ok := objA[key]; ok {
+				objA[key] = mergePreserveJSON(old, val)
+			} else {
+				objA[key] = val
+			}
+		}
+		return objA
+	}
+	arrA, ok := a.([]interface{})
+	if !ok {
+		arrA = []interface{}{a}
+	}
+	if arrB, ok := b.([]interface{}); ok {
+		return append(arrA, arrB...)
+	}
+	return append(arrA, b)
+}
+
+type memberOfFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *memberOfFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONMemberOfSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinJSONMemberOfSig struct {
+	baseIntBuiltinFunc
+}
+
+// evalInt evals a 'value MEMBER OF(json_array)', it returns 1 if value is an element of json_array, otherwise 0.
+// The value is converted to JSON by its type, unless it's the result of a JSON function which is a JSON text.
+// See https://dev.mysql.com/doc/refman/8.0/en/json-search-functions.html#operator_member-of
+func (b *builtinJSONMemberOfSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	var target interface{}
+	if isJSONFunction(b.args[0]) {
+		doc, isNull, err := b.args[0].EvalString(row, sc)
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if target, err = parseJSON(doc); err != nil {
+			return 0, true, errInvalidOperation.Gen("Invalid JSON text in argument 1 to function member of: %v", err)
+		}
+	} else {
+		val, isNull, err := evalJSONValue(b.args[0], row, sc)
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		target = val
+	}
+	doc, isNull, err := b.args[1].EvalString(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	val, err := parseJSON(doc)
+	if err != nil {
+		return 0, true, errInvalidOperation.Gen("Invalid JSON text in argument 2 to function member of: %v", err)
+	}
+	arr, ok := val.([]interface{})
+	if !ok {
+		return 0, true, errInvalidOperation.Gen("Invalid JSON value in argument 2 to function member of; a JSON array is required.")
+	}
+	for _, elem := range arr {
+		if jsonValueEqual(elem, target) {
+			return 1, false, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// isJSONFunction checks whether expr is a function returning a JSON text.
+func isJSONFunction(expr Expression) bool {
+	f, ok := expr.(*ScalarFunction)
+	if !ok {
+		return false
+	}
+	_, ok = jsonFuncs[f.FuncName.L]
+	return ok
+}
+
+// jsonValueEqual checks whether two JSON values are equal, the numbers are compared by their values,
+// so 1 equals 1.0, and the objects are equal if they have the same members regardless of the order.
+func jsonValueEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		var dx, dy types.MyDecimal
+		if dx.FromString([]byte(x)) == nil && dy.FromString([]byte(y)) == nil {
+			return dx.Compare(&dy) == 0
+		}
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		return errx == nil && erry == nil && fx == fy
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonValueEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, val := range x {
+			other, ok := y[key]
+			if !ok || !jsonValueEqual(val, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// parseConstJSONPath parses the path if it's a non-null constant, otherwise nil is returned and the path is
+// parsed for each row.
+func parseConstJSONPath(arg Expression) (jsonPath, error) {
+	con, ok := arg.(*Constant)
+	if !ok || con.Value.IsNull() {
+		return nil, nil
+	}
+	pathExpr, err := con.Value.ToString()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	path, err := parseJSONPath(pathExpr)
+	return path, errors.Trace(err)
+}
+
+// evalJSONTarget evaluates the document args[0] and returns its value at the optional path args[1]. It's NULL
+// if the document or the path is NULL, or there is no value at the path. path is the parsed args[1] if it's
+// a constant.
+func evalJSONTarget(funcName string, args []Expression, path jsonPath, row []types.Datum, sc *variable.StatementContext) (interface{}, bool, error) {
+	doc, isNull, err := args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return nil, true, errors.Trace(err)
+	}
+	val, err := parseJSON(doc)
+	if err != nil {
+		return nil, true, errInvalidOperation.Gen("Invalid JSON text in argument 1 to function %s: %v", funcName, err)
+	}
+	if len(args) == 1 {
+		return val, false, nil
+	}
+	if path == nil {
+		pathExpr, isNull, err := args[1].EvalString(row, sc)
+		if isNull || err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		if path, err = parseJSONPath(pathExpr); err != nil {
+			return nil, true, errors.Trace(err)
+		}
+	}
+	target, found := lookupJSON(val, path)
+	return target, !found, nil
+}
+
+type jsonKeysFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonKeysFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONKeysSig{baseStringBuiltinFunc: baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	if len(args) == 2 {
+		path, err := parseConstJSONPath(args[1])
+		if err != nil {
+			return sig.setSelf(sig), errors.Trace(err)
+		}
+		sig.path = path
+	}
+	return sig.setSelf(sig), nil
+}
+
+type builtinJSONKeysSig struct {
+	baseStringBuiltinFunc
+
+	// path is the parsed path if it's a constant.
+	path jsonPath
+}
+
+// evalString evals JSON_KEYS(doc[, path]), it returns the keys of the object at path as a JSON array.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-search-functions.html#function_json-keys
+func (b *builtinJSONKeysSig) evalString(row []types.Datum) (string, bool, error) {
+	target, isNull, err := evalJSONTarget(ast.JSONKeys, b.args, b.path, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	obj, ok := target.(map[string]interface{})
+	if !ok {
+		return "", true, errInvalidOperation.Gen("Invalid JSON value for function %s; a JSON object is required.", ast.JSONKeys)
+	}
+	keys := sortedJSONKeys(obj)
+	arr := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		arr = append(arr, key)
+	}
+	var buf bytes.Buffer
+	writeJSON(&buf, arr)
+	return buf.String(), false, nil
+}
+
+type jsonLengthFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonLengthFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONLengthSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	if len(args) == 2 {
+		path, err := parseConstJSONPath(args[1])
+		if err != nil {
+			return sig.setSelf(sig), errors.Trace(err)
+		}
+		sig.path = path
+	}
+	return sig.setSelf(sig), nil
+}
+
+type builtinJSONLengthSig struct {
+	baseIntBuiltinFunc
+
+	// path is the parsed path if it's a constant.
+	path jsonPath
+}
+
+// evalInt evals JSON_LENGTH(doc[, path]), it returns the number of the members of an object, the number of
+// the elements of an array, or 1 for a scalar.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-attribute-functions.html#function_json-length
+func (b *builtinJSONLengthSig) evalInt(row []types.Datum) (int64, bool, error) {
+	target, isNull, err := evalJSONTarget(ast.JSONLength, b.args, b.path, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	switch x := target.(type) {
+	case map[string]interface{}:
+		return int64(len(x)), false, nil
+	case []interface{}:
+		return int64(len(x)), false, nil
+	}
+	return 1, false, nil
+}
+
+type jsonDepthFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonDepthFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONDepthSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinJSONDepthSig struct {
+	baseIntBuiltinFunc
+}
+
+// evalInt evals JSON_DEPTH(doc), it returns the maximum depth of the document. A scalar, an empty array
+// and an empty object have depth 1, and a non-empty array or object is one deeper than its deepest value.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-attribute-functions.html#function_json-depth
+func (b *builtinJSONDepthSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := evalJSONTarget(ast.JSONDepth, b.args, nil, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	return jsonDepth(val), false, nil
+}
+
+func jsonDepth(val interface{}) int64 {
+	var maxDepth int64
+	switch x := val.(type) {
+	case map[string]interface{}:
+		for _, v := range x {
+			if depth := jsonDepth(v); depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+	case []interface{}:
+		for _, v := range x {
+			if depth := jsonDepth(v); depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+	}
+	return maxDepth + 1
+}
+
+type jsonValidFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonValidFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONValidSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinJSONValidSig struct {
+	baseIntBuiltinFunc
+}
+
+// evalInt evals JSON_VALID(val), it returns 1 if val is a well-formed JSON text, otherwise 0.
+// Unlike the other JSON functions, an invalid JSON text isn't an error.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-attribute-functions.html#function_json-valid
+func (b *builtinJSONValidSig) evalInt(row []types.Datum) (int64, bool, error) {
+	doc, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	if _, err = parseJSON(doc); err != nil {
+		return 0, false, nil
+	}
+	return 1, false, nil
+}
+
+type jsonQuoteFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonQuoteFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONQuoteSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinJSONQuoteSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals JSON_QUOTE(str), it wraps str with double quotes and escapes the quotes, the backslashes
+// and the control characters in it, so that the result is a JSON string literal.
+// See https://dev.mysql.com/doc/refman/5.7/en/json-creation-functions.html#function_json-quote
+func (b *builtinJSONQuoteSig) evalString(row []types.Datum) (string, bool, error) {
+	str, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	writeJSONString(&buf, str)
+	return buf.String(), false, nil
+}
+
+type jsonPrettyFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *jsonPrettyFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinJSONPrettySig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinJSONPrettySig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals JSON_PRETTY(doc), it formats the document as MySQL does: each member of an object and
+// each element of an array is on its own line indented by two spaces per level, and the empty ones are
+// written as {} and [].
+// See https://dev.mysql.com/doc/refman/5.7/en/json-utility-functions.html#function_json-pretty
+func (b *builtinJSONPrettySig) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := evalJSONTarget(ast.JSONPretty, b.args, nil, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	var buf bytes.Buffer
+	writePrettyJSON(&buf, val, 0)
+	return buf.String(), false, nil
+}
+
+// writePrettyJSON writes val to buf in the format of JSON_PRETTY, level is the nesting level of val.
+func writePrettyJSON(buf *bytes.Buffer, val interface{}, level int) {
+	switch x := val.(type) {
+	case []interface{}:
+		if len(x) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteByte('[')
+		for i, elem := range x {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writePrettyJSONIndent(buf, level+1)
+			writePrettyJSON(buf, elem, level+1)
+		}
+		writePrettyJSONIndent(buf, level)
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		if len(x) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		buf.WriteByte('{')
+		for i, key := range sortedJSONKeys(x) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writePrettyJSONIndent(buf, level+1)
+			writeJSONString(buf, key)
+			buf.WriteString(": ")
+			writePrettyJSON(buf, x[key], level+1)
+		}
+		writePrettyJSONIndent(buf, level)
+		buf.WriteByte('}')
+	default:
+		writeJSON(buf, val)
+	}
+}
+
+// writePrettyJSONIndent starts a new line indented for level.
+func writePrettyJSONIndent(buf *bytes.Buffer, level int) {
+	buf.WriteByte('\n')
+	buf.WriteString(strings.Repeat("  ", level))
+}
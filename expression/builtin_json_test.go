@@ -0,0 +1,393 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testEvaluatorSuite) TestJSONType(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.JSONType]
+	tbl := []struct {
+		Input    interface{}
+		Expected interface{}
+	}{
+		{`{"a": [1, 2]}`, "OBJECT"},
+		{` [1, "a"] `, "ARRAY"},
+		{`"abc"`, "STRING"},
+		{`-3`, "INTEGER"},
+		{`18446744073709551615`, "UNSIGNED INTEGER"},
+		{`3.14`, "DOUBLE"},
+		{`1e2`, "DOUBLE"},
+		{`true`, "BOOLEAN"},
+		// A JSON null is the string "NULL", but a SQL NULL is NULL.
+		{`null`, "NULL"},
+		{nil, nil},
+	}
+	dtbl := tblToDtbl(tbl)
+	for _, t := range dtbl {
+		f, err := fc.getFunction(datumsToConstants(t["Input"]), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, t["Expected"][0])
+	}
+
+	// Invalid JSON text.
+	for _, doc := range []string{``, `{"a": 1`, `[1] 2`, `nul`} {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(doc)), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%s", doc))
+	}
+
+	// Non-string arguments.
+	_, err := fc.getFunction(datumsToConstants(types.MakeDatums(1)), s.ctx)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestJSONModify(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		fn       string
+		args     []interface{}
+		expected interface{}
+	}{
+		{ast.JSONSet, []interface{}{`{"a": 1}`, "$.a", 2, "$.b", 3}, `{"a": 2, "b": 3}`},
+		{ast.JSONInsert, []interface{}{`{"a": 1}`, "$.a", 2, "$.b", 3}, `{"a": 1, "b": 3}`},
+		{ast.JSONReplace, []interface{}{`{"a": 1}`, "$.a", 2, "$.b", 3}, `{"a": 2}`},
+		// The pairs are applied from left to right.
+		{ast.JSONSet, []interface{}{`{}`, "$.a", "x", "$.a", "y"}, `{"a": "y"}`},
+		{ast.JSONInsert, []interface{}{`{}`, "$.a", "x", "$.a", "y"}, `{"a": "x"}`},
+		// Arrays.
+		{ast.JSONSet, []interface{}{`[1, [2, 3]]`, "$[1][0]", 4, "$[5]", 5}, `[1, [4, 3], 5]`},
+		{ast.JSONInsert, []interface{}{`[1, [2, 3]]`, "$[1][0]", 4, "$[1][2]", 5}, `[1, [2, 3, 5]]`},
+		{ast.JSONReplace, []interface{}{`[1, [2, 3]]`, "$[1][0]", 4, "$[1][2]", 5}, `[1, [4, 3]]`},
+		// A non-array value is treated as an array of itself.
+		{ast.JSONSet, []interface{}{`{"a": 1}`, "$[0].a", 2}, `{"a": 2}`},
+		{ast.JSONInsert, []interface{}{`{"a": 1}`, "$[1]", 2}, `[{"a": 1}, 2]`},
+		{ast.JSONReplace, []interface{}{`1`, "$[1]", 2}, `1`},
+		// A missing parent is ignored.
+		{ast.JSONSet, []interface{}{`{"a": 1}`, "$.b.c", 2, "$.a.c", 3, "$.a[1]", 4}, `{"a": [1, 4]}`},
+		// The whole document.
+		{ast.JSONSet, []interface{}{`{"a": 1}`, "$", 2}, `2`},
+		{ast.JSONInsert, []interface{}{`{"a": 1}`, "$", 2}, `{"a": 1}`},
+		// The values are converted to JSON by their types, and the keys are sorted by length.
+		{ast.JSONSet, []interface{}{`{"bb": true, "c": null}`, `$."a b"`, nil, "$.d", 1.5, "$.e", types.NewDecFromFloatForTest(2.25), "$.f", uint64(18446744073709551615)},
+			`{"c": null, "d": 1.5, "e": 2.25, "f": 18446744073709551615, "bb": true, "a b": null}`},
+		{ast.JSONSet, []interface{}{`{"a": 1.00}`, "$.b", "<\"x\">"}, `{"a": 1.00, "b": "<\"x\">"}`},
+		// A quoted key may contain escaped quotes and be followed by other legs.
+		{ast.JSONSet, []interface{}{`{"a\"b": {"c": 1}}`, `$."a\"b".c`, 2}, `{"a\"b": {"c": 2}}`},
+		{ast.JSONSet, []interface{}{` {"a" : {"b": [1]} } `, " $ . a . b [ 0 ] ", "中文"}, `{"a": {"b": ["中文"]}}`},
+		// NULL document or path.
+		{ast.JSONSet, []interface{}{nil, "$.a", 1}, nil},
+		{ast.JSONInsert, []interface{}{`{}`, nil, 1}, nil},
+	}
+	for _, t := range tbl {
+		f, err := funcs[t.fn].getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil, Commentf("%s%v", t.fn, t.args))
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%s%v", t.fn, t.args))
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expected), Commentf("%s%v", t.fn, t.args))
+	}
+
+	// The path is parsed for each row if it's not a constant.
+	col := &Column{RetType: types.NewFieldType(mysql.TypeVarString)}
+	f, err := funcs[ast.JSONSet].getFunction([]Expression{datumsToConstants(types.MakeDatums(`{"a": 1}`))[0], col, One}, s.ctx)
+	c.Assert(err, IsNil)
+	for path, expected := range map[string]string{"$.a": `{"a": 1}`, "$.b": `{"a": 1, "b": 1}`, "$[1]": `[{"a": 1}, 1]`} {
+		d, err := f.eval(types.MakeDatums(path))
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(expected))
+	}
+	_, err = f.eval(types.MakeDatums("$.*"))
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+
+	// An even number of arguments.
+	_, err = funcs[ast.JSONSet].getFunction(datumsToConstants(types.MakeDatums(`{}`, "$.a", 1, "$.b")), s.ctx)
+	c.Assert(terror.ErrorEqual(err, errIncorrectParameterCount), IsTrue)
+	// Invalid paths.
+	for _, path := range []string{"", "a", "$.", "$a", "$[a]", "$[-1]", "$[1", "$.*", "$**.a", "$[*]", `$."a`, `$."a\"`, `$."a\x"`} {
+		_, err = funcs[ast.JSONReplace].getFunction(datumsToConstants(types.MakeDatums(`{}`, path, 1)), s.ctx)
+		c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%s", path))
+	}
+	// Invalid document.
+	f, err = funcs[ast.JSONInsert].getFunction(datumsToConstants(types.MakeDatums(`{"a"}`, "$.a", 1)), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestJSONMemberOf(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.JSONMemberOf]
+	tbl := []struct {
+		args     []interface{}
+		expected interface{}
+	}{
+		{[]interface{}{1, `[1, 2, 3]`}, int64(1)},
+		{[]interface{}{4, `[1, 2, 3]`}, int64(0)},
+		// The numbers are compared by their values.
+		{[]interface{}{1.0, `[3, 1.00]`}, int64(1)},
+		{[]interface{}{types.NewDecFromFloatForTest(2.5), `[2.50]`}, int64(1)},
+		{[]interface{}{uint64(18446744073709551615), `[18446744073709551615]`}, int64(1)},
+		// A string is a JSON string, it's never parsed.
+		{[]interface{}{"a", `["a", "b"]`}, int64(1)},
+		{[]interface{}{"1", `[1]`}, int64(0)},
+		{[]interface{}{`{"a": 1}`, `[{"a": 1}]`}, int64(0)},
+		{[]interface{}{`{"a": 1}`, `["{\"a\": 1}"]`}, int64(1)},
+		// Only the elements are checked, the nested values aren't.
+		{[]interface{}{2, `[1, [2]]`}, int64(0)},
+		{[]interface{}{"a", `[{"a": "a"}]`}, int64(0)},
+		// NULL arguments.
+		{[]interface{}{nil, `[null]`}, nil},
+		{[]interface{}{1, nil}, nil},
+	}
+	for _, t := range tbl {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%v", t.args))
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expected), Commentf("%v", t.args))
+	}
+
+	// The result of a JSON function is a JSON text, so objects and arrays can be members.
+	obj := newFunction(ast.JSONSet, datumsToConstants(types.MakeDatums(`{"b": [2, 3]}`, "$.a", 1))...)
+	for doc, expected := range map[string]int64{
+		`[1, {"a": 1, "b": [2, 3]}]`:      1,
+		`[{"b": [2.0, 3], "a": 1.0}]`:     1,
+		`[{"a": 1, "b": [3, 2]}]`:         0,
+		`[{"a": 1, "b": [2, 3], "c": 4}]`: 0,
+		`[{"a": 1}, {"b": [2, 3]}]`:       0,
+	} {
+		f, err := fc.getFunction([]Expression{obj, datumsToConstants(types.MakeDatums(doc))[0]}, s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(expected), Commentf("%s", doc))
+	}
+
+	// The second argument must be a JSON array.
+	for _, doc := range []string{`1`, `{"a": 1}`, `[1`} {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(1, doc)), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%s", doc))
+	}
+}
+
+func (s *testEvaluatorSuite) TestJSONKeysAndLength(c *C) {
+	defer testleak.AfterTest(c)()
+	doc := `{"a": 1, "bb": {"c": [1, {"d": 2, "e": 3}], "f": "x"}, "g": [1, 2, 3]}`
+	tbl := []struct {
+		fn       string
+		args     []interface{}
+		expected interface{}
+	}{
+		{ast.JSONKeys, []interface{}{doc}, `["a", "g", "bb"]`},
+		{ast.JSONKeys, []interface{}{doc, "$.bb"}, `["c", "f"]`},
+		{ast.JSONKeys, []interface{}{doc, "$.bb.c[1]"}, `["d", "e"]`},
+		{ast.JSONKeys, []interface{}{`{}`}, `[]`},
+		{ast.JSONLength, []interface{}{doc}, int64(3)},
+		{ast.JSONLength, []interface{}{doc, "$"}, int64(3)},
+		{ast.JSONLength, []interface{}{doc, "$.bb"}, int64(2)},
+		{ast.JSONLength, []interface{}{doc, "$.g"}, int64(3)},
+		{ast.JSONLength, []interface{}{doc, "$.bb.c[1]"}, int64(2)},
+		// The length of a scalar is 1.
+		{ast.JSONLength, []interface{}{doc, "$.a"}, int64(1)},
+		{ast.JSONLength, []interface{}{doc, "$.bb.f"}, int64(1)},
+		{ast.JSONLength, []interface{}{`null`}, int64(1)},
+		{ast.JSONLength, []interface{}{`[]`}, int64(0)},
+		// A non-array value is treated as an array of itself.
+		{ast.JSONLength, []interface{}{doc, "$.bb[0].c"}, int64(2)},
+		// A missing value, a NULL document or a NULL path.
+		{ast.JSONKeys, []interface{}{doc, "$.x"}, nil},
+		{ast.JSONLength, []interface{}{doc, "$.g[3]"}, nil},
+		{ast.JSONLength, []interface{}{doc, "$.a.b"}, nil},
+		{ast.JSONKeys, []interface{}{nil}, nil},
+		{ast.JSONLength, []interface{}{nil, "$"}, nil},
+		{ast.JSONLength, []interface{}{doc, nil}, nil},
+	}
+	for _, t := range tbl {
+		f, err := funcs[t.fn].getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil, Commentf("%s%v", t.fn, t.args))
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%s%v", t.fn, t.args))
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expected), Commentf("%s%v", t.fn, t.args))
+	}
+
+	// The path is parsed for each row if it's not a constant.
+	col := &Column{RetType: types.NewFieldType(mysql.TypeVarString)}
+	f, err := funcs[ast.JSONLength].getFunction([]Expression{datumsToConstants(types.MakeDatums(doc))[0], col}, s.ctx)
+	c.Assert(err, IsNil)
+	for path, expected := range map[string]int64{"$": 3, "$.bb.c": 2, "$.bb.c[0]": 1} {
+		d, err := f.eval(types.MakeDatums(path))
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(expected))
+	}
+
+	// JSON_KEYS requires an object.
+	for _, args := range [][]interface{}{{`[1]`}, {`1`}, {doc, "$.g"}} {
+		f, err := funcs[ast.JSONKeys].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", args))
+	}
+	// Invalid documents and paths.
+	f, err = funcs[ast.JSONLength].getFunction(datumsToConstants(types.MakeDatums(`{"a"`)), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+	_, err = funcs[ast.JSONKeys].getFunction(datumsToConstants(types.MakeDatums(doc, "$.*")), s.ctx)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestJSONMerge(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		args     []interface{}
+		patch    interface{}
+		preserve interface{}
+	}{
+		// The later value wins for the same key, or both values are kept.
+		{[]interface{}{`{"a": 1, "b": 2}`, `{"a": 3, "c": 4}`}, `{"a": 3, "b": 2, "c": 4}`, `{"a": [1, 3], "b": 2, "c": 4}`},
+		{[]interface{}{`{"a": {"x": 1}}`, `{"a": {"y": 2}}`}, `{"a": {"x": 1, "y": 2}}`, `{"a": {"x": 1, "y": 2}}`},
+		{[]interface{}{`{"a": [1]}`, `{"a": 2}`}, `{"a": 2}`, `{"a": [1, 2]}`},
+		// A null member removes the key, or it's kept as a value.
+		{[]interface{}{`{"a": 1, "b": 2}`, `{"a": null}`}, `{"b": 2}`, `{"a": [1, null], "b": 2}`},
+		{[]interface{}{`{"a": {"b": 1}}`, `{"a": {"b": null, "c": null}}`}, `{"a": {}}`, `{"a": {"b": [1, null], "c": null}}`},
+		// Arrays and scalars.
+		{[]interface{}{`[1, 2]`, `[3]`}, `[3]`, `[1, 2, 3]`},
+		{[]interface{}{`[1, 2]`, `true`}, `true`, `[1, 2, true]`},
+		{[]interface{}{`1`, `[2]`}, `[2]`, `[1, 2]`},
+		{[]interface{}{`"a"`, `"b"`}, `"b"`, `["a", "b"]`},
+		{[]interface{}{`{"a": 1}`, `[2]`}, `[2]`, `[{"a": 1}, 2]`},
+		{[]interface{}{`[1]`, `{"a": 2}`}, `{"a": 2}`, `[1, {"a": 2}]`},
+		// The documents are merged from left to right.
+		{[]interface{}{`{"a": 1}`, `{"a": 2}`, `{"a": 3}`}, `{"a": 3}`, `{"a": [1, 2, 3]}`},
+		{[]interface{}{`{"a": 1}`, `2`, `{"b": 3}`}, `{"b": 3}`, `[{"a": 1}, 2, {"b": 3}]`},
+		// A single document is returned as it is.
+		{[]interface{}{`{"b": null, "a": [1]}`}, `{"a": [1], "b": null}`, `{"a": [1], "b": null}`},
+		// NULL documents.
+		{[]interface{}{nil, `{}`}, nil, nil},
+		{[]interface{}{`{}`, `[1]`, nil}, nil, nil},
+	}
+	for _, t := range tbl {
+		for fn, expected := range map[string]interface{}{ast.JSONMergePatch: t.patch, ast.JSONMergePreserve: t.preserve} {
+			f, err := funcs[fn].getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+			c.Assert(err, IsNil, Commentf("%s%v", fn, t.args))
+			d, err := f.eval(nil)
+			c.Assert(err, IsNil, Commentf("%s%v", fn, t.args))
+			c.Assert(d, testutil.DatumEquals, types.NewDatum(expected), Commentf("%s%v", fn, t.args))
+		}
+	}
+
+	// Invalid documents.
+	for _, fn := range []string{ast.JSONMergePatch, ast.JSONMergePreserve} {
+		for _, args := range [][]interface{}{{`{"a"`}, {`{}`, `[1`}, {`[1]`, `{}`, `x`}} {
+			f, err := funcs[fn].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+			c.Assert(err, IsNil)
+			_, err = f.eval(nil)
+			c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%s%v", fn, args))
+		}
+	}
+}
+
+func (s *testEvaluatorSuite) TestJSONDepthAndValid(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		fn       string
+		arg      interface{}
+		expected interface{}
+	}{
+		{ast.JSONDepth, `1`, int64(1)},
+		{ast.JSONDepth, `"a"`, int64(1)},
+		{ast.JSONDepth, `null`, int64(1)},
+		{ast.JSONDepth, `[]`, int64(1)},
+		{ast.JSONDepth, `{}`, int64(1)},
+		{ast.JSONDepth, `[10, 20]`, int64(2)},
+		{ast.JSONDepth, `[[], {}]`, int64(2)},
+		{ast.JSONDepth, `{"a": [1, {"b": [2]}], "c": 3}`, int64(5)},
+		{ast.JSONDepth, `[1, [2, [3, [4]]], {"a": 1}]`, int64(5)},
+		{ast.JSONDepth, nil, nil},
+		{ast.JSONValid, `{"a": [1, {"b": [2]}], "c": 3}`, int64(1)},
+		{ast.JSONValid, `"hello"`, int64(1)},
+		{ast.JSONValid, `null`, int64(1)},
+		{ast.JSONValid, `{"a"`, int64(0)},
+		{ast.JSONValid, `hello`, int64(0)},
+		{ast.JSONValid, `[1, 2] [3]`, int64(0)},
+		{ast.JSONValid, ``, int64(0)},
+		{ast.JSONValid, nil, nil},
+	}
+	for _, t := range tbl {
+		f, err := funcs[t.fn].getFunction(datumsToConstants(types.MakeDatums(t.arg)), s.ctx)
+		c.Assert(err, IsNil, Commentf("%s(%v)", t.fn, t.arg))
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%s(%v)", t.fn, t.arg))
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expected), Commentf("%s(%v)", t.fn, t.arg))
+	}
+
+	// JSON_DEPTH requires a valid document.
+	f, err := funcs[ast.JSONDepth].getFunction(datumsToConstants(types.MakeDatums(`[1, 2`)), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestJSONQuoteAndPretty(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		fn       string
+		arg      interface{}
+		expected interface{}
+	}{
+		{ast.JSONQuote, `abc`, `"abc"`},
+		{ast.JSONQuote, ``, `""`},
+		{ast.JSONQuote, `say "hi"`, `"say \"hi\""`},
+		{ast.JSONQuote, "a\\b", `"a\\b"`},
+		{ast.JSONQuote, "line1\nline2\r\ttab", `"line1\nline2\r\ttab"`},
+		{ast.JSONQuote, "\b\f\x01\x1f", `"\b\f\u0001\u001f"`},
+		{ast.JSONQuote, `[1, 2]`, `"[1, 2]"`},
+		{ast.JSONQuote, `中文`, `"中文"`},
+		{ast.JSONQuote, nil, nil},
+		{ast.JSONPretty, `1`, `1`},
+		{ast.JSONPretty, `"a\"b"`, `"a\"b"`},
+		{ast.JSONPretty, `[]`, `[]`},
+		{ast.JSONPretty, `{}`, `{}`},
+		{ast.JSONPretty, `[1, "a", null]`, "[\n  1,\n  \"a\",\n  null\n]"},
+		{ast.JSONPretty, `{"b": [1, {"c": true}], "a": {}, "dd": []}`,
+			"{\n  \"a\": {},\n  \"b\": [\n    1,\n    {\n      \"c\": true\n    }\n  ],\n  \"dd\": []\n}"},
+		{ast.JSONPretty, nil, nil},
+	}
+	for _, t := range tbl {
+		f, err := funcs[t.fn].getFunction(datumsToConstants(types.MakeDatums(t.arg)), s.ctx)
+		c.Assert(err, IsNil, Commentf("%s(%v)", t.fn, t.arg))
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%s(%v)", t.fn, t.arg))
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expected), Commentf("%s(%v)", t.fn, t.arg))
+	}
+
+	// JSON_PRETTY requires a valid document.
+	f, err := funcs[ast.JSONPretty].getFunction(datumsToConstants(types.MakeDatums(`{"a": 1`)), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue)
+}
@@ -15,9 +15,11 @@ package expression
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/stringutil"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -85,37 +87,80 @@ type regexpFunctionClass struct {
 }
 
 func (c *regexpFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinRegexpSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinRegexpSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	sig.caseInsensitive = isCICollation(args[0].GetType(), args[1].GetType())
+	// A constant pattern is compiled only once here instead of for every row.
+	if con, ok := args[1].(*Constant); ok && !con.IsNull() {
+		pattern, err := con.Value.ToString()
+		if err != nil {
+			sig.compileErr = errors.Trace(err)
+		} else {
+			sig.compiled, sig.compileErr = sig.compile(pattern)
+		}
+	}
+	return sig.setSelf(sig), nil
+}
+
+// isCICollation returns whether the strings should be compared case insensitively, that is
+// none of them is binary and some of them has a "_ci" collation.
+func isCICollation(fts ...*types.FieldType) bool {
+	ci := false
+	for _, ft := range fts {
+		if ft == nil {
+			continue
+		}
+		if ft.Collate == charset.CollationBin || ft.Charset == charset.CharsetBin {
+			return false
+		}
+		if strings.HasSuffix(strings.ToLower(ft.Collate), "_ci") {
+			ci = true
+		}
+	}
+	return ci
 }
 
 type builtinRegexpSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
+
+	caseInsensitive bool
+	// compiled and compileErr are the result of compiling a constant pattern.
+	compiled   *regexp.Regexp
+	compileErr error
 }
 
-// eval evals a builtinRegexpSig.
-// See https://dev.mysql.com/doc/refman/5.7/en/regexp.html#operator_regexp
-func (b *builtinRegexpSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinRegexpSig) compile(pattern string) (*regexp.Regexp, error) {
+	if b.caseInsensitive {
+		pattern = "(?i)" + pattern
 	}
-	// TODO: We don't need to compile pattern if it has been compiled or it is static.
-	if args[0].IsNull() || args[1].IsNull() {
-		return
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errRegexp.GenByArgs(err.Error())
 	}
+	return re, nil
+}
 
-	targetStr, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Errorf("non-string Expression in LIKE: %v (Value of type %T)", args[0], args[0])
+// evalInt evals a builtinRegexpSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/regexp.html#operator_regexp
+func (b *builtinRegexpSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	target, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	patternStr, err := args[1].ToString()
-	if err != nil {
-		return d, errors.Errorf("non-string Expression in LIKE: %v (Value of type %T)", args[1], args[1])
+	re, err := b.compiled, b.compileErr
+	if re == nil && err == nil {
+		var pattern string
+		pattern, isNull, err = b.args[1].EvalString(row, sc)
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		re, err = b.compile(pattern)
 	}
-	re, err := regexp.Compile(patternStr)
 	if err != nil {
-		return d, errors.Trace(err)
+		return 0, true, errors.Trace(err)
 	}
-	d.SetInt64(boolToInt64(re.MatchString(targetStr)))
-	return
+	return boolToInt64(re.MatchString(target)), false, nil
 }
@@ -27,7 +27,9 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -61,7 +63,10 @@ var (
 )
 
 var (
-	_ builtinFunc = &builtinAbsSig{}
+	_ builtinFunc = &builtinAbsIntSig{}
+	_ builtinFunc = &builtinAbsUIntSig{}
+	_ builtinFunc = &builtinAbsDecimalSig{}
+	_ builtinFunc = &builtinAbsRealSig{}
 	_ builtinFunc = &builtinCeilSig{}
 	_ builtinFunc = &builtinFloorSig{}
 	_ builtinFunc = &builtinLogSig{}
@@ -70,6 +75,7 @@ var (
 	_ builtinFunc = &builtinRandSig{}
 	_ builtinFunc = &builtinPowSig{}
 	_ builtinFunc = &builtinRoundSig{}
+	_ builtinFunc = &builtinRoundIntSig{}
 	_ builtinFunc = &builtinConvSig{}
 	_ builtinFunc = &builtinCRC32Sig{}
 	_ builtinFunc = &builtinSignSig{}
@@ -77,7 +83,8 @@ var (
 	_ builtinFunc = &builtinArithmeticSig{}
 	_ builtinFunc = &builtinAcosSig{}
 	_ builtinFunc = &builtinAsinSig{}
-	_ builtinFunc = &builtinAtanSig{}
+	_ builtinFunc = &builtinAtan1ArgSig{}
+	_ builtinFunc = &builtinAtan2ArgsSig{}
 	_ builtinFunc = &builtinCosSig{}
 	_ builtinFunc = &builtinCotSig{}
 	_ builtinFunc = &builtinDegreesSig{}
@@ -86,49 +93,104 @@ var (
 	_ builtinFunc = &builtinRadiansSig{}
 	_ builtinFunc = &builtinSinSig{}
 	_ builtinFunc = &builtinTanSig{}
-	_ builtinFunc = &builtinTruncateSig{}
+	_ builtinFunc = &builtinTruncateDecimalSig{}
+	_ builtinFunc = &builtinTruncateRealSig{}
+	_ builtinFunc = &builtinTruncateIntSig{}
 )
 
+// errDoubleOutOfRange returns the error of a math function whose argument is out of its domain.
+func errDoubleOutOfRange(funcName string, arg float64) error {
+	return types.ErrOverflow.GenByArgs("DOUBLE", fmt.Sprintf("%s(%s)", funcName, strconv.FormatFloat(arg, 'g', -1, 64)))
+}
+
 type absFunctionClass struct {
 	baseFunctionClass
 }
 
 func (c *absFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinAbsSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinAbsRealSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}, errors.Trace(err)
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	var sig builtinFunc
+	switch tp := args[0].GetType(); tp.ToClass() {
+	case types.ClassInt:
+		if mysql.HasUnsignedFlag(tp.Flag) {
+			sig = &builtinAbsUIntSig{baseIntBuiltinFunc{bf}}
+		} else {
+			sig = &builtinAbsIntSig{baseIntBuiltinFunc{bf}}
+		}
+	case types.ClassDecimal:
+		sig = &builtinAbsDecimalSig{baseDecimalBuiltinFunc{bf}}
+	default:
+		sig = &builtinAbsRealSig{baseRealBuiltinFunc{bf}}
+	}
+	return sig.setSelf(sig), nil
 }
 
-type builtinAbsSig struct {
-	baseBuiltinFunc
+type builtinAbsIntSig struct {
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinAbsSig.
+// evalInt evals ABS(X) when X is a signed integer, ABS(-9223372036854775808) is out of the range of BIGINT.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_abs
-func (b *builtinAbsSig) eval(row []types.Datum) (types.Datum, error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinAbsIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	d := args[0]
-	switch d.Kind() {
-	case types.KindNull:
-		return d, nil
-	case types.KindUint64:
-		return d, nil
-	case types.KindInt64:
-		iv := d.GetInt64()
-		if iv >= 0 {
-			d.SetInt64(iv)
-			return d, nil
-		}
-		d.SetInt64(-iv)
-		return d, nil
-	default:
-		// we will try to convert other types to float
-		// TODO: if time has no precision, it will be a integer
-		f, err := d.ToFloat64(b.ctx.GetSessionVars().StmtCtx)
-		d.SetFloat64(math.Abs(f))
-		return d, errors.Trace(err)
+	if val >= 0 {
+		return val, false, nil
+	}
+	if val == math.MinInt64 {
+		return 0, true, types.ErrOverflow.GenByArgs("BIGINT", fmt.Sprintf("abs(%d)", val))
+	}
+	return -val, false, nil
+}
+
+type builtinAbsUIntSig struct {
+	baseIntBuiltinFunc
+}
+
+// evalInt evals ABS(X) when X is an unsigned integer.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_abs
+func (b *builtinAbsUIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	return b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
+}
+
+// eval evals ABS(X) when X is an unsigned integer, the result is unsigned too.
+func (b *builtinAbsUIntSig) eval(row []types.Datum) (types.Datum, error) {
+	return b.evalUint(row)
+}
+
+type builtinAbsDecimalSig struct {
+	baseDecimalBuiltinFunc
+}
+
+// evalDecimal evals ABS(X) when X is a decimal, the precision is kept.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_abs
+func (b *builtinAbsDecimalSig) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	val, isNull, err := b.args[0].EvalDecimal(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return nil, true, errors.Trace(err)
+	}
+	result := new(types.MyDecimal)
+	val.Abs(result)
+	return result, false, nil
+}
+
+type builtinAbsRealSig struct {
+	baseRealBuiltinFunc
+}
+
+// evalReal evals ABS(X) when X is a float or any other type.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_abs
+func (b *builtinAbsRealSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
+	return math.Abs(val), false, nil
 }
 
 type ceilFunctionClass struct {
@@ -395,7 +457,91 @@ type roundFunctionClass struct {
 }
 
 func (c *roundFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinRoundSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinRoundSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(err)
+	}
+	if tp := args[0].GetType(); tp != nil && tp.ToClass() == types.ClassInt {
+		sig := &builtinRoundIntSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}, mysql.HasUnsignedFlag(tp.Flag)}
+		return sig.setSelf(sig), nil
+	}
+	return &builtinRoundSig{newBaseBuiltinFunc(args, ctx)}, nil
+}
+
+type builtinRoundIntSig struct {
+	baseIntBuiltinFunc
+
+	// unsigned means the argument is an unsigned integer, so is the result.
+	unsigned bool
+}
+
+// evalInt evals ROUND(X[, D]) when X is an integer, the result is an integer too. X is returned unchanged
+// unless D is negative, in which case X is rounded to the -D-th digit left of the decimal point.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_round
+func (b *builtinRoundIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	x, isNull, err := b.args[0].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	if len(b.args) == 1 {
+		return x, false, nil
+	}
+	frac, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	if frac >= 0 {
+		return x, false, nil
+	}
+
+	negative := !b.unsigned && x < 0
+	abs := uint64(x)
+	if negative {
+		abs = uint64(-x)
+	}
+	abs, ok := roundUint(abs, uint64(-frac), b.ctx.GetSessionVars().RoundHalfEven)
+	switch {
+	case !ok:
+	case b.unsigned:
+		return int64(abs), false, nil
+	case negative && abs <= -math.MinInt64:
+		return -int64(abs), false, nil
+	case !negative && abs <= math.MaxInt64:
+		return int64(abs), false, nil
+	}
+	if b.unsigned {
+		return 0, true, types.ErrOverflow.GenByArgs("BIGINT UNSIGNED", fmt.Sprintf("round(%d, %d)", uint64(x), frac))
+	}
+	return 0, true, types.ErrOverflow.GenByArgs("BIGINT", fmt.Sprintf("round(%d, %d)", x, frac))
+}
+
+// eval evals ROUND(X[, D]) when X is an integer, an unsigned result is returned as an uint64 datum.
+func (b *builtinRoundIntSig) eval(row []types.Datum) (types.Datum, error) {
+	if b.unsigned {
+		return b.evalUint(row)
+	}
+	return b.baseIntBuiltinFunc.eval(row)
+}
+
+// roundUint rounds x to the digits-th digit left of the decimal point. A tie is rounded up, or to the nearest
+// even digit if halfEven is true. ok is false if the result overflows uint64.
+func roundUint(x uint64, digits uint64, halfEven bool) (result uint64, ok bool) {
+	// 10^20 overflows uint64, so x is always rounded to 0 if digits is larger than 19.
+	if digits > 19 {
+		return 0, true
+	}
+	pow := uint64(1)
+	for i := uint64(0); i < digits; i++ {
+		pow *= 10
+	}
+	quo, rem := x/pow, x%pow
+	if half := pow / 2; rem > half || (rem == half && (!halfEven || quo%2 == 1)) {
+		quo++
+	}
+	if quo > math.MaxUint64/pow {
+		return 0, false
+	}
+	return quo * pow, true
 }
 
 type builtinRoundSig struct {
@@ -423,9 +569,15 @@ func (b *builtinRoundSig) eval(row []types.Datum) (d types.Datum, err error) {
 		frac = int(frac64)
 	}
 
+	// A tie is rounded away from zero by default, or to the nearest even digit if tidb_round_half_even is on.
+	halfEven := b.ctx.GetSessionVars().RoundHalfEven
 	if args[0].Kind() == types.KindMysqlDecimal {
+		mode := types.ModeHalfEven
+		if halfEven {
+			mode = types.ModeBankers
+		}
 		var dec types.MyDecimal
-		err = args[0].GetMysqlDecimal().Round(&dec, frac, types.ModeHalfEven)
+		err = args[0].GetMysqlDecimal().Round(&dec, frac, mode)
 		if err != nil {
 			return d, errors.Trace(err)
 		}
@@ -438,7 +590,12 @@ func (b *builtinRoundSig) eval(row []types.Datum) (d types.Datum, err error) {
 		return d, errors.Trace(err)
 	}
 
-	val := types.Round(x, frac)
+	var val float64
+	if halfEven {
+		val = types.RoundHalfEven(x, frac)
+	} else {
+		val = types.Round(x, frac)
+	}
 	switch args[0].Kind() {
 	case types.KindInt64:
 		d.SetInt64(int64(val))
@@ -458,44 +615,55 @@ type convFunctionClass struct {
 }
 
 func (c *convFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinConvSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinConvSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinConvSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinConvSig.
+// evalString evals CONV(N,from_base,to_base).
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_conv
-func (b *builtinConvSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinConvSig) evalString(row []types.Datum) (res string, isNull bool, err error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	n, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return res, isNull, errors.Trace(err)
 	}
-	var (
-		signed     bool
-		negative   bool
-		ignoreSign bool
-	)
-	for _, arg := range args {
-		if arg.IsNull() {
-			return d, nil
-		}
+	fromBase, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return res, isNull, errors.Trace(err)
 	}
-	n, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
+	toBase, isNull, err := b.args[2].EvalInt(row, sc)
+	if isNull || err != nil {
+		return res, isNull, errors.Trace(err)
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	fromBase, err := args[1].ToInt64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+
+	if !isValidBase(fromBase) || !isValidBase(toBase) {
+		return res, true, nil
 	}
-	toBase, err := args[2].ToInt64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	return convertBase(n, fromBase, toBase), false, nil
+}
+
+// isValidBase checks whether base, regardless of its sign, is a base supported by CONV.
+func isValidBase(base int64) bool {
+	if base < 0 {
+		base = -base
 	}
+	return base >= 2 && base <= 36
+}
 
+// convertBase converts the number n from fromBase to toBase like CONV does, the bases must be in
+// [2, 36] or [-36, -2]. A negative fromBase means n is signed, and a negative toBase means the
+// result is signed.
+func convertBase(n string, fromBase, toBase int64) string {
+	var (
+		signed     bool
+		negative   bool
+		ignoreSign bool
+		overflow   bool
+	)
 	if fromBase < 0 {
 		fromBase = -fromBase
 		signed = true
@@ -504,13 +672,9 @@ func (b *builtinConvSig) eval(row []types.Datum) (d types.Datum, err error) {
 		ignoreSign = true
 		toBase = -toBase
 	}
-	if fromBase > 36 || fromBase < 2 || toBase > 36 || toBase < 2 {
-		return d, nil
-	}
 	n = getValidPrefix(strings.TrimSpace(n), fromBase)
 	if len(n) == 0 {
-		d.SetString("0")
-		return d, nil
+		return "0"
 	}
 	if n[0] == '-' {
 		negative = true
@@ -519,9 +683,11 @@ func (b *builtinConvSig) eval(row []types.Datum) (d types.Datum, err error) {
 
 	val, err := strconv.ParseUint(n, int(fromBase), 64)
 	if err != nil {
-		return d, types.ErrOverflow.GenByArgs("BIGINT UNSINGED", n)
+		// The value is out of the range of 64-bit unsigned, MySQL uses the max value in this case.
+		// See https://github.com/mysql/mysql-server/blob/5.7/strings/ctype-simple.c#L598
+		val = math.MaxUint64
+		overflow = true
 	}
-	// See https://github.com/mysql/mysql-server/blob/5.7/strings/ctype-simple.c#L598
 	if signed {
 		if negative && val > -math.MinInt64 {
 			val = -math.MinInt64
@@ -530,7 +696,8 @@ func (b *builtinConvSig) eval(row []types.Datum) (d types.Datum, err error) {
 			val = math.MaxInt64
 		}
 	}
-	if negative {
+	// The max value is returned without its sign when an unsigned value overflows.
+	if negative && (signed || !overflow) {
 		val = -val
 	}
 	// See https://github.com/mysql/mysql-server/blob/5.7/strings/longlong2str.c#L58
@@ -547,8 +714,7 @@ func (b *builtinConvSig) eval(row []types.Datum) (d types.Datum, err error) {
 	if negative && ignoreSign {
 		s = "-" + s
 	}
-	d.SetString(strings.ToUpper(s))
-	return d, nil
+	return strings.ToUpper(s)
 }
 
 type crc32FunctionClass struct {
@@ -587,29 +753,64 @@ type signFunctionClass struct {
 }
 
 func (c *signFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinSignSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinSignSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}, tc: types.ClassReal}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	if tp := args[0].GetType(); tp != nil {
+		switch tp.ToClass() {
+		case types.ClassInt:
+			sig.tc, sig.unsigned = types.ClassInt, mysql.HasUnsignedFlag(tp.Flag)
+		case types.ClassDecimal:
+			sig.tc = types.ClassDecimal
+		}
+	}
+	return sig.setSelf(sig), nil
 }
 
 type builtinSignSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
+
+	// tc is the class in which the argument is evaluated, the strings are evaluated as reals.
+	tc types.TypeClass
+	// unsigned means the argument is an unsigned integer, which is never negative.
+	unsigned bool
 }
 
-// eval evals a builtinSignSig.
+// evalInt evals SIGN(X), it returns -1, 0 or 1 as X is negative, zero or positive.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_sign
-func (b *builtinSignSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinSignSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	switch b.tc {
+	case types.ClassInt:
+		val, isNull, err := b.args[0].EvalInt(row, sc)
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		if b.unsigned {
+			return boolToInt64(val != 0), false, nil
+		}
+		return signOf(val < 0, val > 0), false, nil
+	case types.ClassDecimal:
+		val, isNull, err := b.args[0].EvalDecimal(row, sc)
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		cmp := val.Compare(new(types.MyDecimal))
+		return signOf(cmp < 0, cmp > 0), false, nil
 	}
-	if args[0].IsNull() {
-		return d, nil
+	val, isNull, err := b.args[0].EvalReal(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	cmp, err := args[0].CompareDatum(b.ctx.GetSessionVars().StmtCtx, types.NewIntDatum(0))
-	d.SetInt64(int64(cmp))
-	if err != nil {
-		return d, errors.Trace(err)
+	return signOf(val < 0, val > 0), false, nil
+}
+
+func signOf(negative, positive bool) int64 {
+	if negative {
+		return -1
 	}
-	return d, nil
+	return boolToInt64(positive)
 }
 
 type sqrtFunctionClass struct {
@@ -713,34 +914,27 @@ type acosFunctionClass struct {
 }
 
 func (c *acosFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinAcosSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinAcosSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinAcosSig struct {
-	baseBuiltinFunc
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinAcosSig.
+// evalReal evals a builtinAcosSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_acos
-func (b *builtinAcosSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	if args[0].IsNull() {
-		return args[0], nil
-	}
+func (b *builtinAcosSig) evalReal(row []types.Datum) (float64, bool, error) {
 	sc := b.ctx.GetSessionVars().StmtCtx
-	f, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	val, isNull, err := b.args[0].EvalReal(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	if f < -1 || f > 1 {
-		return d, nil
+	if val < -1 || val > 1 {
+		sc.AppendWarning(errDoubleOutOfRange("acos", val))
+		return 0, true, nil
 	}
-
-	d.SetFloat64(math.Acos(f))
-	return
+	return math.Acos(val), false, nil
 }
 
 type asinFunctionClass struct {
@@ -748,34 +942,27 @@ type asinFunctionClass struct {
 }
 
 func (c *asinFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinAsinSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinAsinSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinAsinSig struct {
-	baseBuiltinFunc
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinAsinSig.
+// evalReal evals a builtinAsinSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_asin
-func (b *builtinAsinSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	if args[0].IsNull() {
-		return d, nil
-	}
+func (b *builtinAsinSig) evalReal(row []types.Datum) (float64, bool, error) {
 	sc := b.ctx.GetSessionVars().StmtCtx
-	f, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	val, isNull, err := b.args[0].EvalReal(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	if f < -1 || f > 1 {
-		return d, nil
+	if val < -1 || val > 1 {
+		sc.AppendWarning(errDoubleOutOfRange("asin", val))
+		return 0, true, nil
 	}
-
-	d.SetFloat64(math.Asin(f))
-	return
+	return math.Asin(val), false, nil
 }
 
 type atanFunctionClass struct {
@@ -783,41 +970,52 @@ type atanFunctionClass struct {
 }
 
 func (c *atanFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinAtanSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	bf := newBaseBuiltinFunc(args, ctx)
+	var sig builtinFunc
+	if len(args) == 2 {
+		sig = &builtinAtan2ArgsSig{baseRealBuiltinFunc{bf}}
+	} else {
+		sig = &builtinAtan1ArgSig{baseRealBuiltinFunc{bf}}
+	}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
-type builtinAtanSig struct {
-	baseBuiltinFunc
+type builtinAtan1ArgSig struct {
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinAtanSig.
+// evalReal evals ATAN(X).
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_atan
-func (b *builtinAtanSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	if args[0].IsNull() {
-		return d, nil
-	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	y, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinAtan1ArgSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
+	return math.Atan(val), false, nil
+}
 
-	if len(args) == 2 {
-		x, err := args[1].ToFloat64(sc)
-		if err != nil {
-			d.SetFloat64(math.Atan(y))
-		} else {
-			d.SetFloat64(math.Atan2(y, x))
+type builtinAtan2ArgsSig struct {
+	baseRealBuiltinFunc
+}
+
+// evalReal evals ATAN(Y, X) and ATAN2(Y, X), the signs of both arguments determine the quadrant of the result.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_atan2
+func (b *builtinAtan2ArgsSig) evalReal(row []types.Datum) (float64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	vals := make([]float64, 0, len(b.args))
+	for _, arg := range b.args {
+		val, isNull, err := arg.EvalReal(row, sc)
+		// A string argument is truncated to its numeric prefix with a warning, e.g. ATAN(0, 'aaa') is 0.
+		if err != nil && arg.GetType().ToClass() == types.ClassString && terror.ErrorEqual(err, types.ErrTruncated) {
+			sc.AppendWarning(err)
+			err = nil
 		}
-	} else {
-		d.SetFloat64(math.Atan(y))
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
+		}
+		vals = append(vals, val)
 	}
-
-	return d, nil
+	return math.Atan2(vals[0], vals[1]), false, nil
 }
 
 type cosFunctionClass struct {
@@ -858,37 +1056,32 @@ type cotFunctionClass struct {
 }
 
 func (c *cotFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinCotSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinCotSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinCotSig struct {
-	baseBuiltinFunc
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinCotSig.
+// evalReal evals a builtinCotSig.
+// COT(0) is a division by zero, it's an error in the strict sql mode, otherwise the result is NULL with a warning.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_cot
-func (b *builtinCotSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	if args[0].IsNull() {
-		return args[0], nil
-	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	degree, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinCotSig) evalReal(row []types.Datum) (float64, bool, error) {
+	sessVars := b.ctx.GetSessionVars()
+	val, isNull, err := b.args[0].EvalReal(row, sessVars.StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	sin := math.Sin(degree)
-	cos := math.Cos(degree)
-	degreeString, _ := args[0].ToString()
+	sin := math.Sin(val)
 	if sin == 0 {
-		return d, errors.New("Value is out of range of cot(" + degreeString + ")")
+		if sessVars.StrictSQLMode {
+			return 0, true, errors.Trace(errDoubleOutOfRange("cot", val))
+		}
+		sessVars.StmtCtx.AppendWarning(types.ErrDivByZero)
+		return 0, true, nil
 	}
-	// Set the result to be of type float64
-	d.SetFloat64(cos / sin)
-	return d, nil
+	return math.Cos(val) / sin, false, nil
 }
 
 type degreesFunctionClass struct {
@@ -896,34 +1089,22 @@ type degreesFunctionClass struct {
 }
 
 func (c *degreesFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinDegreesSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinDegreesSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinDegreesSig struct {
-	baseBuiltinFunc
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinDegreesSig.
+// evalReal evals a builtinDegreesSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_degrees
-func (b *builtinDegreesSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinDegreesSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	if args[0].IsNull() {
-		d.SetNull()
-		return d, nil
-	}
-
-	sc := b.ctx.GetSessionVars().StmtCtx
-	x, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-
-	d.SetFloat64(x * 180 / math.Pi)
-	return d, nil
+	return val * 180 / math.Pi, false, nil
 }
 
 type expFunctionClass struct {
@@ -984,30 +1165,22 @@ type radiansFunctionClass struct {
 }
 
 func (c *radiansFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinRadiansSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinRadiansSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinRadiansSig struct {
-	baseBuiltinFunc
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinRadiansSig.
+// evalReal evals a builtinRadiansSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_radians
-func (b *builtinRadiansSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinRadiansSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	arg := args[0]
-	if arg.IsNull() {
-		return d, nil
-	}
-	degree, err := arg.ToFloat64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	d.SetFloat64(degree * math.Pi / 180)
-	return d, nil
+	return val * math.Pi / 180, false, nil
 }
 
 type sinFunctionClass struct {
@@ -1047,31 +1220,22 @@ type tanFunctionClass struct {
 }
 
 func (c *tanFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinTanSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinTanSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinTanSig struct {
-	baseBuiltinFunc
+	baseRealBuiltinFunc
 }
 
-// eval evals a builtinTanSig.
+// evalReal evals a builtinTanSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_tan
-func (b *builtinTanSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinTanSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	if args[0].IsNull() {
-		return args[0], nil
-	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-	theta, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	// Set the result to be of type float64
-	d.SetFloat64(math.Tan(theta))
-	return d, nil
+	return math.Tan(val), false, nil
 }
 
 type truncateFunctionClass struct {
@@ -1079,63 +1243,105 @@ type truncateFunctionClass struct {
 }
 
 func (c *truncateFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinTruncateSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinTruncateRealSig{baseRealBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}, errors.Trace(err)
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	var sig builtinFunc
+	switch args[0].GetType().ToClass() {
+	case types.ClassInt:
+		sig = &builtinTruncateIntSig{baseIntBuiltinFunc{bf}, mysql.HasUnsignedFlag(args[0].GetType().Flag)}
+	case types.ClassDecimal:
+		sig = &builtinTruncateDecimalSig{baseDecimalBuiltinFunc{bf}}
+	default:
+		sig = &builtinTruncateRealSig{baseRealBuiltinFunc{bf}}
+	}
+	return sig.setSelf(sig), nil
 }
 
-type builtinTruncateSig struct {
-	baseBuiltinFunc
+type builtinTruncateDecimalSig struct {
+	baseDecimalBuiltinFunc
 }
 
-// eval evals a builtinTruncateSig.
+// evalDecimal evals a TRUNCATE(X,D) when X is a decimal.
 // See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_truncate
-func (b *builtinTruncateSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinTruncateDecimalSig) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	x, isNull, err := b.args[0].EvalDecimal(row, sc)
+	if isNull || err != nil {
+		return nil, true, errors.Trace(err)
 	}
-	if len(args) != 2 || args[0].IsNull() || args[1].IsNull() {
-		return
+	d, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return nil, true, errors.Trace(err)
 	}
-	sc := b.ctx.GetSessionVars().StmtCtx
-
-	// Get the fraction as Int.
-	frac64, err1 := args[1].ToInt64(sc)
-	if err1 != nil {
-		return d, errors.Trace(err1)
+	result := new(types.MyDecimal)
+	if err = x.Round(result, int(d), types.ModeTruncate); err != nil {
+		return nil, true, errors.Trace(err)
 	}
-	frac := int(frac64)
+	return result, false, nil
+}
 
-	// The number is a decimal, run decimal.Round(number, fraction, 9).
-	if args[0].Kind() == types.KindMysqlDecimal {
-		var dec types.MyDecimal
-		err = args[0].GetMysqlDecimal().Round(&dec, frac, types.ModeTruncate)
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		d.SetMysqlDecimal(&dec)
-		return d, nil
-	}
+type builtinTruncateRealSig struct {
+	baseRealBuiltinFunc
+}
 
-	// The number is a float, run math.Trunc.
-	x, err := args[0].ToFloat64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+// evalReal evals a TRUNCATE(X,D) when X is a float or a string.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_truncate
+func (b *builtinTruncateRealSig) evalReal(row []types.Datum) (float64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	x, isNull, err := b.args[0].EvalReal(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
+	d, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	return types.Truncate(x, int(d)), false, nil
+}
 
-	// Get the truncate.
-	val := types.Truncate(x, frac)
+type builtinTruncateIntSig struct {
+	baseIntBuiltinFunc
 
-	// Return result as Round does.
-	switch args[0].Kind() {
-	case types.KindInt64:
-		d.SetInt64(int64(val))
-	case types.KindUint64:
-		d.SetUint64(uint64(val))
-	default:
-		d.SetFloat64(val)
-		if frac > 0 {
-			d.SetFrac(frac)
-		}
+	// unsigned is true if X is an unsigned integer, the result is unsigned too.
+	unsigned bool
+}
+
+// eval evals a builtinTruncateIntSig, an unsigned result is returned as an uint64 datum.
+func (b *builtinTruncateIntSig) eval(row []types.Datum) (types.Datum, error) {
+	if b.unsigned {
+		return b.evalUint(row)
 	}
-	return d, nil
+	return b.baseIntBuiltinFunc.eval(row)
+}
+
+// evalInt evals a TRUNCATE(X,D) when X is an integer, only a negative D changes the result.
+// See https://dev.mysql.com/doc/refman/5.7/en/mathematical-functions.html#function_truncate
+func (b *builtinTruncateIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	x, isNull, err := b.args[0].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	d, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	if d >= 0 {
+		return x, false, nil
+	}
+	// 10^20 exceeds both MaxInt64 and MaxUint64, so all the digits are zeroed out.
+	if d <= -20 {
+		return 0, false, nil
+	}
+	shift := uint64(math.Pow10(int(-d)))
+	if b.unsigned {
+		ux := uint64(x)
+		return int64(ux - ux%shift), false, nil
+	}
+	if shift > math.MaxInt64 {
+		return 0, false, nil
+	}
+	return x - x%int64(shift), false, nil
 }
@@ -18,6 +18,8 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
@@ -32,6 +34,7 @@ func (s *testEvaluatorSuite) TestAbs(c *C) {
 		{nil, nil},
 		{int64(1), int64(1)},
 		{uint64(1), uint64(1)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
 		{int64(-1), int64(1)},
 		{float64(3.14), float64(3.14)},
 		{float64(-3.14), float64(3.14)},
@@ -47,6 +50,27 @@ func (s *testEvaluatorSuite) TestAbs(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(v, testutil.DatumEquals, t["Ret"][0])
 	}
+
+	// The decimals don't lose precision.
+	dec := types.NewDecFromStringForTest("-123456789012345678901234567890.123456789")
+	f, err := funcs[ast.Abs].getFunction(datumsToConstants(types.MakeDatums(dec)), s.ctx)
+	c.Assert(err, IsNil)
+	v, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.Kind(), Equals, types.KindMysqlDecimal)
+	c.Assert(v.GetMysqlDecimal().String(), Equals, "123456789012345678901234567890.123456789")
+	c.Assert(dec.String(), Equals, "-123456789012345678901234567890.123456789")
+
+	// ABS(-9223372036854775808) is out of the range of BIGINT.
+	f, err = funcs[ast.Abs].getFunction(datumsToConstants(types.MakeDatums(int64(math.MinInt64))), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, types.ErrOverflow), IsTrue)
+	f, err = funcs[ast.Abs].getFunction(datumsToConstants(types.MakeDatums(int64(math.MinInt64+1))), s.ctx)
+	c.Assert(err, IsNil)
+	v, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v, testutil.DatumEquals, types.NewIntDatum(math.MaxInt64))
 }
 
 func (s *testEvaluatorSuite) TestCeil(c *C) {
@@ -269,6 +293,89 @@ func (s *testEvaluatorSuite) TestRound(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestRoundHalfEven(c *C) {
+	defer testleak.AfterTest(c)()
+	newDec := types.NewDecFromStringForTest
+	tbl := []struct {
+		Arg      []interface{}
+		HalfUp   interface{}
+		HalfEven interface{}
+	}{
+		{[]interface{}{2.5}, 3, 2},
+		{[]interface{}{3.5}, 4, 4},
+		{[]interface{}{-2.5}, -3, -2},
+		{[]interface{}{2.51}, 3, 3},
+		{[]interface{}{1.25, 1}, 1.3, 1.2},
+		{[]interface{}{250, -2}, 300, 200},
+		{[]interface{}{350, -2}, 400, 400},
+		{[]interface{}{250.0, -2}, 300, 200},
+		{[]interface{}{newDec("2.5")}, newDec("3"), newDec("2")},
+		{[]interface{}{newDec("3.5")}, newDec("4"), newDec("4")},
+		{[]interface{}{newDec("-2.5")}, newDec("-3"), newDec("-2")},
+		{[]interface{}{newDec("1.25"), 1}, newDec("1.3"), newDec("1.2")},
+		{[]interface{}{newDec("1.251"), 1}, newDec("1.3"), newDec("1.3")},
+		{[]interface{}{newDec("250"), -2}, newDec("300"), newDec("200")},
+		{[]interface{}{newDec("350"), -2}, newDec("400"), newDec("400")},
+		{[]interface{}{newDec("-250"), -2}, newDec("-300"), newDec("-200")},
+	}
+
+	Dtbl := tblToDtbl(tbl)
+	sessVars := s.ctx.GetSessionVars()
+	defer func() {
+		sessVars.RoundHalfEven = false
+	}()
+	for _, t := range Dtbl {
+		fc := funcs[ast.Round]
+		f, err := fc.getFunction(datumsToConstants(t["Arg"]), s.ctx)
+		c.Assert(err, IsNil)
+		sessVars.RoundHalfEven = false
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, t["HalfUp"][0], Commentf("%v", t["Arg"]))
+		sessVars.RoundHalfEven = true
+		v, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, t["HalfEven"][0], Commentf("%v", t["Arg"]))
+	}
+}
+
+func (s *testEvaluatorSuite) TestRoundInt(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		Arg []interface{}
+		Ret interface{}
+	}{
+		{[]interface{}{123}, int64(123)},
+		{[]interface{}{-123}, int64(-123)},
+		{[]interface{}{123, 2}, int64(123)},
+		{[]interface{}{123, -1}, int64(120)},
+		{[]interface{}{125, -1}, int64(130)},
+		{[]interface{}{-125, -1}, int64(-130)},
+		{[]interface{}{-123, -2}, int64(-100)},
+		{[]interface{}{123, -3}, int64(0)},
+		{[]interface{}{int64(math.MinInt64), -30}, int64(0)},
+		{[]interface{}{uint64(18446744073709551610), -2}, uint64(18446744073709551600)},
+		{[]interface{}{123, nil}, nil},
+	}
+	for _, t := range tbl {
+		f, err := funcs[ast.Round].getFunction(datumsToConstants(types.MakeDatums(t.Arg...)), s.ctx)
+		c.Assert(err, IsNil)
+		_, ok := f.(*builtinRoundIntSig)
+		c.Assert(ok, IsTrue, Commentf("%v", t.Arg))
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%v", t.Arg))
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(t.Ret), Commentf("%v", t.Arg))
+	}
+
+	// The results overflow BIGINT and BIGINT UNSIGNED.
+	for _, x := range []interface{}{int64(math.MaxInt64), int64(math.MinInt64), uint64(math.MaxUint64)} {
+		f, err := funcs[ast.Round].getFunction(datumsToConstants(types.MakeDatums(x, -1)), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(terror.ErrorEqual(err, types.ErrOverflow), IsTrue, Commentf("%v", x))
+	}
+}
+
 func (s *testEvaluatorSuite) TestTruncate(c *C) {
 	defer testleak.AfterTest(c)()
 	newDec := types.NewDecFromStringForTest
@@ -295,6 +402,8 @@ func (s *testEvaluatorSuite) TestTruncate(c *C) {
 		{[]interface{}{newDec("23.298"), -100}, newDec("0")},
 		{[]interface{}{newDec("23.298"), 100}, newDec("23.298")},
 		{[]interface{}{nil, 2}, nil},
+		{[]interface{}{uint64(math.MaxUint64), 0}, uint64(math.MaxUint64)},
+		{[]interface{}{uint64(math.MaxUint64), -1}, uint64(18446744073709551610)},
 	}
 
 	Dtbl := tblToDtbl(tbl)
@@ -307,6 +416,64 @@ func (s *testEvaluatorSuite) TestTruncate(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(v, testutil.DatumEquals, t["Ret"][0])
 	}
+
+	intTbl := []struct {
+		x        int64
+		d        int64
+		unsigned bool
+		ret      int64
+	}{
+		{123, 2, false, 123},
+		{123, -1, false, 120},
+		{-123, -2, false, -100},
+		{123, -3, false, 0},
+		{math.MaxInt64, -18, false, 9000000000000000000},
+		{math.MaxInt64, -19, false, 0},
+		{-1, -19, true, -8446744073709551616}, // 18446744073709551615 -> 10000000000000000000
+		{123, -100, false, 0},
+	}
+	for _, t := range intTbl {
+		args := datumsToConstants(types.MakeDatums(t.x, t.d))
+		if t.unsigned {
+			args[0].GetType().Flag |= mysql.UnsignedFlag
+		}
+		f, err := funcs[ast.Truncate].getFunction(args, s.ctx)
+		c.Assert(err, IsNil)
+		_, ok := f.(*builtinTruncateIntSig)
+		c.Assert(ok, IsTrue)
+		v, isNull, err := f.evalInt(nil)
+		c.Assert(err, IsNil)
+		c.Assert(isNull, IsFalse)
+		c.Assert(v, Equals, t.ret, Commentf("%v", t))
+	}
+
+	// TRUNCATE drops the digits while ROUND rounds them.
+	for _, arg := range []interface{}{3.567, newDec("3.567")} {
+		argDatum := types.NewDatum(arg)
+		f, err := funcs[ast.Truncate].getFunction(datumsToConstants(types.MakeDatums(arg, 1)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v.Kind(), Equals, argDatum.Kind())
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(3.5))
+		f, err = funcs[ast.Round].getFunction(datumsToConstants(types.MakeDatums(arg, 1)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(3.6))
+	}
+
+	// The decimal result keeps the truncated scale.
+	f, err := funcs[ast.Truncate].getFunction(datumsToConstants(types.MakeDatums(newDec("3.567"), 2)), s.ctx)
+	c.Assert(err, IsNil)
+	v, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.GetMysqlDecimal().String(), Equals, "3.56")
+	f, err = funcs[ast.Truncate].getFunction(datumsToConstants(types.MakeDatums(newDec("123.567"), -2)), s.ctx)
+	c.Assert(err, IsNil)
+	v, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.GetMysqlDecimal().String(), Equals, "100")
 }
 
 func (s *testEvaluatorSuite) TestCRC32(c *C) {
@@ -351,6 +518,23 @@ func (s *testEvaluatorSuite) TestConv(c *C) {
 		{[]interface{}{"aa", 10, 2}, "0"},
 		{[]interface{}{" A", -10, 16}, "0"},
 		{[]interface{}{"a6a", 10, 8}, "0"},
+		{[]interface{}{"FF", 16, 2}, "11111111"},
+		{[]interface{}{"ff", 16, 10}, "255"},
+		{[]interface{}{255, 10, 16}, "FF"},
+		{[]interface{}{"-FF", -16, 10}, "18446744073709551361"},
+		{[]interface{}{"-FF", -16, -10}, "-255"},
+		{[]interface{}{"FF", 16, -10}, "255"},
+		{[]interface{}{"18446744073709551615", 10, -10}, "-1"},
+		{[]interface{}{"FFFFFFFFFFFFFFFFFF", 16, 10}, "18446744073709551615"},
+		{[]interface{}{"-FFFFFFFFFFFFFFFFFF", -16, 10}, "9223372036854775808"},
+		{[]interface{}{"-FFFFFFFFFFFFFFFFFF", 16, 10}, "18446744073709551615"},
+		{[]interface{}{"z", 36, 10}, "35"},
+		{[]interface{}{"35", 10, 36}, "Z"},
+		{[]interface{}{"12", 1, 10}, nil},
+		{[]interface{}{"12", 10, 37}, nil},
+		{[]interface{}{"12", -37, 10}, nil},
+		{[]interface{}{"12", nil, 10}, nil},
+		{[]interface{}{"12", 10, nil}, nil},
 	}
 
 	Dtbl := tblToDtbl(tbl)
@@ -395,10 +579,17 @@ func (s *testEvaluatorSuite) TestSign(c *C) {
 		{-0.4, -1, IsNil},
 		{"1", 1, IsNil},
 		{"-1", -1, IsNil},
-		{"1a", 1, NotNil},
-		{"-1a", -1, NotNil},
-		{"a", 0, NotNil},
+		{"1a", nil, NotNil},
+		{"-1a", nil, NotNil},
+		{"a", nil, NotNil},
 		{uint64(9223372036854775808), 1, IsNil},
+		{uint64(math.MaxUint64), 1, IsNil},
+		{uint64(0), 0, IsNil},
+		{int64(math.MinInt64), -1, IsNil},
+		{types.NewDecFromStringForTest("-0.000000000000000000000000001"), -1, IsNil},
+		{types.NewDecFromStringForTest("12345678901234567890.5"), 1, IsNil},
+		{types.NewDecFromStringForTest("0.000"), 0, IsNil},
+		{0.0, 0, IsNil},
 	} {
 		fc := funcs[ast.Sign]
 		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.num)), s.ctx)
@@ -626,9 +817,13 @@ func (s *testEvaluatorSuite) TestAtan(c *C) {
 		{[]interface{}{nil}, nil},
 		{[]interface{}{nil, nil}, nil},
 		{[]interface{}{int64(0), "aaa"}, float64(0)},
+		{[]interface{}{int64(1), nil}, nil},
 		{[]interface{}{int64(0)}, float64(0)},
 		{[]interface{}{"0", "1"}, float64(0)},
 		{[]interface{}{"0.0", "-2.0"}, float64(math.Pi)},
+		{[]interface{}{int64(1), int64(0)}, math.Pi / 2},
+		{[]interface{}{int64(-1), int64(-1)}, -math.Pi * 3 / 4},
+		{[]interface{}{int64(1), int64(-1)}, math.Pi * 3 / 4},
 	}
 
 	Dtbl := tblToDtbl(tbl)
@@ -640,7 +835,28 @@ func (s *testEvaluatorSuite) TestAtan(c *C) {
 		v, err := f.eval(nil)
 		c.Assert(err, IsNil)
 		c.Assert(v, DeepEquals, t["Ret"][0], Commentf("[%v] - arg:%v", idx, t["Arg"]))
+		if len(t["Arg"]) == 2 {
+			f, err = funcs[ast.Atan2].getFunction(datumsToConstants(t["Arg"]), s.ctx)
+			c.Assert(err, IsNil)
+			v, err = f.eval(nil)
+			c.Assert(err, IsNil)
+			c.Assert(v, DeepEquals, t["Ret"][0], Commentf("[%v] - arg:%v", idx, t["Arg"]))
+		}
 	}
+
+	_, err := funcs[ast.Atan2].getFunction(datumsToConstants(types.MakeDatums(1)), s.ctx)
+	c.Assert(err, NotNil)
+	// The string that isn't a number is truncated with a warning.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	sc.SetWarnings(nil)
+	f, err := funcs[ast.Atan2].getFunction(datumsToConstants(types.MakeDatums(1, "-1aaa")), s.ctx)
+	c.Assert(err, IsNil)
+	v, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v, DeepEquals, types.NewDatum(math.Pi*3/4))
+	warnings := sc.GetWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(terror.ErrorEqual(warnings[0], types.ErrTruncated), IsTrue)
 }
 
 func (s *testEvaluatorSuite) TestTan(c *C) {
@@ -693,3 +909,59 @@ func (s *testEvaluatorSuite) TestCot(c *C) {
 		c.Assert(v, testutil.DatumEquals, t["Ret"][0])
 	}
 }
+
+func (s *testEvaluatorSuite) TestTrigDomain(c *C) {
+	defer testleak.AfterTest(c)()
+	sessionVars := s.ctx.GetSessionVars()
+	sc := sessionVars.StmtCtx
+	tests := []struct {
+		funcName string
+		arg      interface{}
+		isNull   bool
+		ret      float64
+	}{
+		{ast.Asin, 1, false, math.Pi / 2},
+		{ast.Asin, -1, false, -math.Pi / 2},
+		{ast.Asin, 2, true, 0},
+		{ast.Asin, -1.0001, true, 0},
+		{ast.Acos, -1, false, math.Pi},
+		{ast.Acos, 1, false, 0},
+		{ast.Acos, 1.5, true, 0},
+		{ast.Acos, "-2", true, 0},
+	}
+	for _, t := range tests {
+		warnCnt := len(sc.GetWarnings())
+		f, err := funcs[t.funcName].getFunction(datumsToConstants(types.MakeDatums(t.arg)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v.IsNull(), Equals, t.isNull, Commentf("%s(%v)", t.funcName, t.arg))
+		if t.isNull {
+			warnings := sc.GetWarnings()
+			c.Assert(warnings, HasLen, warnCnt+1)
+			c.Assert(terror.ErrorEqual(warnings[warnCnt], types.ErrOverflow), IsTrue)
+		} else {
+			c.Assert(v.GetFloat64(), Equals, t.ret)
+		}
+	}
+
+	// COT(0) is an error in the strict sql mode.
+	f, err := funcs[ast.Cot].getFunction(datumsToConstants(types.MakeDatums(0)), s.ctx)
+	c.Assert(err, IsNil)
+	originStrict := sessionVars.StrictSQLMode
+	defer func() {
+		sessionVars.StrictSQLMode = originStrict
+	}()
+	sessionVars.StrictSQLMode = true
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, types.ErrOverflow), IsTrue)
+	// Otherwise it's NULL with a warning.
+	sessionVars.StrictSQLMode = false
+	warnCnt := len(sc.GetWarnings())
+	v, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.IsNull(), IsTrue)
+	warnings := sc.GetWarnings()
+	c.Assert(warnings, HasLen, warnCnt+1)
+	c.Assert(terror.ErrorEqual(warnings[warnCnt], types.ErrDivByZero), IsTrue)
+}
@@ -14,6 +14,7 @@ package expression
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"math"
 	"net"
 	"strings"
@@ -21,7 +22,8 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
-	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/twinj/uuid"
 )
@@ -47,6 +49,8 @@ var (
 	_ functionClass = &releaseAllLocksFunctionClass{}
 	_ functionClass = &uuidFunctionClass{}
 	_ functionClass = &uuidShortFunctionClass{}
+	_ functionClass = &uuidToBinFunctionClass{}
+	_ functionClass = &binToUUIDFunctionClass{}
 )
 
 var (
@@ -70,6 +74,8 @@ var (
 	_ builtinFunc = &builtinReleaseAllLocksSig{}
 	_ builtinFunc = &builtinUUIDSig{}
 	_ builtinFunc = &builtinUUIDShortSig{}
+	_ builtinFunc = &builtinUUIDToBinSig{}
+	_ builtinFunc = &builtinBinToUUIDSig{}
 )
 
 type sleepFunctionClass struct {
@@ -78,55 +84,53 @@ type sleepFunctionClass struct {
 
 func (c *sleepFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
 	err := errors.Trace(c.verifyArgs(args))
-	bt := &builtinSleepSig{newBaseBuiltinFunc(args, ctx)}
+	bt := &builtinSleepSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
 	bt.deterministic = false
-	return bt, errors.Trace(err)
+	return bt.setSelf(bt), errors.Trace(err)
 }
 
 type builtinSleepSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinSleepSig.
+// sleepStep is the longest time SLEEP waits before checking whether the query is canceled.
+const sleepStep = 10 * time.Millisecond
+
+// evalInt evals a builtinSleepSig.
+// It returns 0 when the whole duration has elapsed, or 1 if the query is canceled while sleeping.
 // See https://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_sleep
-func (b *builtinSleepSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
+func (b *builtinSleepSig) evalInt(row []types.Datum) (int64, bool, error) {
 	sessVars := b.ctx.GetSessionVars()
-	if args[0].IsNull() {
-		if sessVars.StrictSQLMode {
-			return d, errors.New("incorrect arguments to sleep")
-		}
-		d.SetInt64(0)
-		return
-	}
-	// processing argument is negative
-	zero := types.NewIntDatum(0)
-	sc := sessVars.StmtCtx
-	ret, err := args[0].CompareDatum(sc, zero)
+	val, isNull, err := b.args[0].EvalReal(row, sessVars.StmtCtx)
 	if err != nil {
-		return d, errors.Trace(err)
+		return 0, false, errors.Trace(err)
 	}
-	if ret == -1 {
+	if isNull || val < 0 {
 		if sessVars.StrictSQLMode {
-			return d, errors.New("incorrect arguments to sleep")
+			return 0, false, errors.New("incorrect arguments to sleep")
 		}
-		d.SetInt64(0)
-		return
+		return 0, false, nil
 	}
 
-	// TODO: consider it's interrupted using KILL QUERY from other session, or
-	// interrupted by time out.
-	sleepTime, err := args[0].ConvertTo(sc, types.NewFieldType(mysql.TypeDouble))
-	if err != nil {
-		return d, errors.Trace(err)
+	var done <-chan struct{}
+	if goCtx := b.ctx.GoCtx(); goCtx != nil {
+		done = goCtx.Done()
+	}
+	deadline := time.Now().Add(time.Duration(val * float64(time.Second.Nanoseconds())))
+	for {
+		remain := deadline.Sub(time.Now())
+		if remain <= 0 {
+			return 0, false, nil
+		}
+		if remain > sleepStep {
+			remain = sleepStep
+		}
+		select {
+		case <-done:
+			return 1, false, nil
+		case <-time.After(remain):
+		}
 	}
-	duration := time.Duration(sleepTime.GetFloat64() * float64(time.Second.Nanoseconds()))
-	time.Sleep(duration)
-	d.SetInt64(0)
-	return
 }
 
 type lockFunctionClass struct {
@@ -176,9 +180,12 @@ type anyValueFunctionClass struct {
 }
 
 func (c *anyValueFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinAnyValueSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinAnyValueSig{newBaseBuiltinFunc(args, ctx)}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
+// builtinAnyValueSig passes its argument through, it's deterministic as long as the argument is,
+// so ANY_VALUE of a constant is folded like the constant itself.
 type builtinAnyValueSig struct {
 	baseBuiltinFunc
 }
@@ -186,30 +193,62 @@ type builtinAnyValueSig struct {
 // eval evals a builtinAnyValueSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_any-value
 func (b *builtinAnyValueSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	d = args[0]
-	return d, nil
+	d, err = b.args[0].Eval(row)
+	return d, errors.Trace(err)
+}
+
+func (b *builtinAnyValueSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
+
+func (b *builtinAnyValueSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
+
+func (b *builtinAnyValueSig) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
+
+func (b *builtinAnyValueSig) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	val, isNull, err := b.args[0].EvalDecimal(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
 }
 
 type defaultFunctionClass struct {
 	baseFunctionClass
+
+	colInfo *model.ColumnInfo
 }
 
 func (c *defaultFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinDefaultSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	err := errors.Trace(c.verifyArgs(args))
+	bt := &builtinDefaultSig{newBaseBuiltinFunc(args, ctx), c.colInfo}
+	bt.deterministic = false
+	if err == nil && c.colInfo != nil {
+		if _, ok := args[0].(*Column); !ok {
+			err = errInvalidOperation.Gen("the argument of function default must be a column")
+		}
+	}
+	return bt, errors.Trace(err)
 }
 
 type builtinDefaultSig struct {
 	baseBuiltinFunc
+
+	colInfo *model.ColumnInfo
 }
 
 // eval evals a builtinDefaultSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_default
 func (b *builtinDefaultSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("DEFAULT")
+	if b.colInfo == nil || GetColDefaultValue == nil {
+		return d, errFunctionNotExists.GenByArgs("DEFAULT")
+	}
+	d, err = GetColDefaultValue(b.ctx, b.colInfo)
+	return d, errors.Trace(err)
 }
 
 type inetAtonFunctionClass struct {
@@ -674,3 +713,117 @@ type builtinUUIDShortSig struct {
 func (b *builtinUUIDShortSig) eval(row []types.Datum) (d types.Datum, err error) {
 	return d, errFunctionNotExists.GenByArgs("UUID_SHORT")
 }
+
+type uuidToBinFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *uuidToBinFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinUUIDToBinSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinUUIDToBinSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals UUID_TO_BIN(string_uuid[, swap_flag]).
+// See https://dev.mysql.com/doc/refman/8.0/en/miscellaneous-functions.html#function_uuid-to-bin
+func (b *builtinUUIDToBinSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	swap, err := evalUUIDSwapFlag(b.args, row, sc)
+	if err != nil {
+		return "", true, errors.Trace(err)
+	}
+	bin, ok := parseUUID(str)
+	if !ok {
+		return "", true, errWrongValueForType.GenByArgs("string", str, "uuid_to_bin")
+	}
+	if swap {
+		bin = swapUUIDTimeFields(bin, true)
+	}
+	return string(bin), false, nil
+}
+
+type binToUUIDFunctionClass struct {
+	baseFunctionClass
+}
+
+func (c *binToUUIDFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinBinToUUIDSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+type builtinBinToUUIDSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals BIN_TO_UUID(binary_uuid[, swap_flag]).
+// See https://dev.mysql.com/doc/refman/8.0/en/miscellaneous-functions.html#function_bin-to-uuid
+func (b *builtinBinToUUIDSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	swap, err := evalUUIDSwapFlag(b.args, row, sc)
+	if err != nil {
+		return "", true, errors.Trace(err)
+	}
+	if len(str) != uuidBinLen {
+		return "", true, errWrongValueForType.GenByArgs("string", str, "bin_to_uuid")
+	}
+	bin := []byte(str)
+	if swap {
+		bin = swapUUIDTimeFields(bin, false)
+	}
+	h := hex.EncodeToString(bin)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], false, nil
+}
+
+const uuidBinLen = 16
+
+// evalUUIDSwapFlag evaluates the optional swap_flag of UUID_TO_BIN and BIN_TO_UUID, NULL is regarded as 0.
+func evalUUIDSwapFlag(args []Expression, row []types.Datum, sc *variable.StatementContext) (bool, error) {
+	if len(args) < 2 {
+		return false, nil
+	}
+	flag, isNull, err := args[1].EvalInt(row, sc)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return !isNull && flag != 0, nil
+}
+
+// parseUUID parses a UUID in the canonical form "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee" to its 16 bytes.
+func parseUUID(str string) ([]byte, bool) {
+	if len(str) != 36 || str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+		return nil, false
+	}
+	bin, err := hex.DecodeString(str[0:8] + str[9:13] + str[14:18] + str[19:23] + str[24:])
+	if err != nil {
+		return nil, false
+	}
+	return bin, true
+}
+
+// swapUUIDTimeFields swaps the time-low(4 bytes) and time-high(2 bytes) fields of a binary UUID, so that
+// the UUIDs generated in order are stored in order. toBin tells whether bin is in the UUID layout, or in
+// the swapped layout which starts with time-high.
+func swapUUIDTimeFields(bin []byte, toBin bool) []byte {
+	swapped := make([]byte, 0, uuidBinLen)
+	if toBin {
+		swapped = append(swapped, bin[6:8]...)
+		swapped = append(swapped, bin[4:6]...)
+		swapped = append(swapped, bin[0:4]...)
+	} else {
+		swapped = append(swapped, bin[4:8]...)
+		swapped = append(swapped, bin[2:4]...)
+		swapped = append(swapped, bin[0:2]...)
+	}
+	return append(swapped, bin[8:]...)
+}
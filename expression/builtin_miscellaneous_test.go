@@ -13,11 +13,13 @@
 package expression
 
 import (
+	"encoding/hex"
 	"math"
 	"strings"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
@@ -113,6 +115,77 @@ func (s *testEvaluatorSuite) TestUUID(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestUUIDToBinAndBinToUUID(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		uuid string
+		swap interface{}
+		bin  string
+	}{
+		{"6ccd780c-baba-1026-9564-5b8c656024db", nil, "6ccd780cbaba102695645b8c656024db"},
+		{"6ccd780c-baba-1026-9564-5b8c656024db", 0, "6ccd780cbaba102695645b8c656024db"},
+		{"6ccd780c-baba-1026-9564-5b8c656024db", 1, "1026baba6ccd780c95645b8c656024db"},
+		{"00000000-0000-0000-0000-000000000000", 1, "00000000000000000000000000000000"},
+		{"ffffffff-eeee-dddd-cccc-bbbbbbbbbbbb", 1, "ddddeeeeffffffffccccbbbbbbbbbbbb"},
+	}
+	for _, t := range tbl {
+		args := []interface{}{t.uuid}
+		if t.swap != nil {
+			args = append(args, t.swap)
+		}
+		f, err := funcs[ast.UUIDToBin].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		bin, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(hex.EncodeToString(bin.GetBytes()), Equals, t.bin)
+
+		args[0] = bin.GetBytes()
+		f, err = funcs[ast.BinToUUID].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		uuid, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(uuid.GetString(), Equals, t.uuid)
+	}
+
+	// The generated UUIDs survive the round trip.
+	for _, swap := range []int{0, 1} {
+		gen, err := funcs[ast.UUID].getFunction(nil, s.ctx)
+		c.Assert(err, IsNil)
+		uuid, err := gen.eval(nil)
+		c.Assert(err, IsNil)
+		toBin, err := NewFunction(s.ctx, ast.UUIDToBin, types.NewFieldType(mysql.TypeVarString), datumsToConstants([]types.Datum{uuid})[0], newLonglong(int64(swap)))
+		c.Assert(err, IsNil)
+		toUUID, err := funcs[ast.BinToUUID].getFunction([]Expression{toBin, newLonglong(int64(swap))}, s.ctx)
+		c.Assert(err, IsNil)
+		r, err := toUUID.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r.GetString(), Equals, uuid.GetString())
+	}
+
+	// NULL arguments.
+	for _, fn := range []string{ast.UUIDToBin, ast.BinToUUID} {
+		f, err := funcs[fn].getFunction(datumsToConstants(types.MakeDatums(nil, 1)), s.ctx)
+		c.Assert(err, IsNil)
+		r, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r.IsNull(), IsTrue)
+	}
+
+	// Malformed arguments.
+	for _, str := range []string{"", "6ccd780c-baba-1026-9564-5b8c656024d", "6ccd780cbaba102695645b8c656024db", "6ccd780c-baba-1026-9564-5b8c656024dx", "{6ccd780c-baba-1026-9564-5b8c656024db}"} {
+		f, err := funcs[ast.UUIDToBin].getFunction(datumsToConstants(types.MakeDatums(str)), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(err, NotNil, Commentf("%s", str))
+	}
+	for _, bin := range []string{"", "0123456789abcde", "0123456789abcdefg"} {
+		f, err := funcs[ast.BinToUUID].getFunction(datumsToConstants(types.MakeDatums([]byte(bin))), s.ctx)
+		c.Assert(err, IsNil)
+		_, err = f.eval(nil)
+		c.Assert(err, NotNil, Commentf("%s", bin))
+	}
+}
+
 func (s *testEvaluatorSuite) TestAnyValue(c *C) {
 	defer testleak.AfterTest(c)()
 
@@ -134,6 +207,29 @@ func (s *testEvaluatorSuite) TestAnyValue(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.ret))
 	}
+
+	// ANY_VALUE of a constant is folded.
+	f := FoldConstant(newFunction(ast.AnyValue, newFunction(ast.Plus, One, One)))
+	con, ok := f.(*Constant)
+	c.Assert(ok, IsTrue)
+	c.Assert(con.Value, testutil.DatumEquals, types.NewDatum(2))
+
+	// ANY_VALUE of a column is evaluated as the column.
+	col := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	f, err := NewFunction(s.ctx, ast.AnyValue, col.GetType(), col)
+	c.Assert(err, IsNil)
+	c.Assert(f.GetType(), Equals, col.GetType())
+	sc := s.ctx.GetSessionVars().StmtCtx
+	intVal, isNull, err := f.EvalInt(types.MakeDatums(3), sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(intVal, Equals, int64(3))
+	_, isNull, err = f.EvalInt(types.MakeDatums(nil), sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsTrue)
+	strVal, _, err := f.EvalString(types.MakeDatums(3), sc)
+	c.Assert(err, IsNil)
+	c.Assert(strVal, Equals, "3")
 }
 
 func (s *testEvaluatorSuite) TestIsIPv6(c *C) {
@@ -17,6 +17,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -35,7 +36,9 @@ var (
 	_ builtinFunc = &builtinOrOrSig{}
 	_ builtinFunc = &builtinLogicXorSig{}
 	_ builtinFunc = &builtinBitOpSig{}
-	_ builtinFunc = &builtinIsTrueOpSig{}
+	_ builtinFunc = &builtinIsTrueOpIntSig{}
+	_ builtinFunc = &builtinIsTrueOpRealSig{}
+	_ builtinFunc = &builtinIsTrueOpDecimalSig{}
 	_ builtinFunc = &builtinUnaryOpSig{}
 	_ builtinFunc = &builtinIsNullSig{}
 )
@@ -145,39 +148,41 @@ type logicXorFunctionClass struct {
 }
 
 func (c *logicXorFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinLogicXorSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinLogicXorSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinLogicXorSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-func (b *builtinLogicXorSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	leftDatum := args[0]
-	righDatum := args[1]
-	if leftDatum.IsNull() || righDatum.IsNull() {
-		return
-	}
+// evalInt evals a builtinLogicXorSig, it returns 1 if exactly one of the arguments is true,
+// and NULL if any of them is NULL.
+// See https://dev.mysql.com/doc/refman/5.7/en/logical-operators.html#operator_xor
+func (b *builtinLogicXorSig) evalInt(row []types.Datum) (int64, bool, error) {
 	sc := b.ctx.GetSessionVars().StmtCtx
-	x, err := leftDatum.ToBool(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	x, isNull, err := evalArgToBool(b.args[0], row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	y, err := righDatum.ToBool(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	y, isNull, err := evalArgToBool(b.args[1], row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
 	if x == y {
-		d.SetInt64(zeroI64)
-	} else {
-		d.SetInt64(oneI64)
+		return 0, false, nil
 	}
-	return
+	return 1, false, nil
+}
+
+// evalArgToBool evaluates arg to 1 or 0 by its truth value.
+func evalArgToBool(arg Expression, row []types.Datum, sc *variable.StatementContext) (int64, bool, error) {
+	d, err := arg.Eval(row)
+	if d.IsNull() || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	x, err := d.ToBool(sc)
+	return x, false, errors.Trace(err)
 }
 
 type bitOpFunctionClass struct {
@@ -245,32 +250,80 @@ type isTrueOpFunctionClass struct {
 }
 
 func (c *isTrueOpFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinIsTrueOpSig{newBaseBuiltinFunc(args, ctx), c.op}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinIsTrueOpRealSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}, c.op}, errors.Trace(err)
+	}
+	bf := baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}
+	var sig builtinFunc
+	switch args[0].GetType().ToClass() {
+	case types.ClassInt:
+		sig = &builtinIsTrueOpIntSig{bf, c.op}
+	case types.ClassDecimal:
+		sig = &builtinIsTrueOpDecimalSig{bf, c.op}
+	default:
+		sig = &builtinIsTrueOpRealSig{bf, c.op}
+	}
+	return sig.setSelf(sig), nil
 }
 
-type builtinIsTrueOpSig struct {
-	baseBuiltinFunc
+// evalIsTrueOp returns the result of IS TRUE or IS FALSE, which is never null.
+func evalIsTrueOp(op opcode.Op, isNull, isZero bool) int64 {
+	if isNull {
+		return 0
+	}
+	if op == opcode.IsTruth {
+		return boolToInt64(!isZero)
+	}
+	return boolToInt64(isZero)
+}
+
+type builtinIsTrueOpIntSig struct {
+	baseIntBuiltinFunc
 
 	op opcode.Op
 }
 
-func (b *builtinIsTrueOpSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
+// evalInt evals IS TRUE or IS FALSE of an integer.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_is
+func (b *builtinIsTrueOpIntSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+		return 0, false, errors.Trace(err)
 	}
-	var boolVal bool
-	if !args[0].IsNull() {
-		iVal, err := args[0].ToBool(b.ctx.GetSessionVars().StmtCtx)
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		if (b.op == opcode.IsTruth && iVal == 1) || (b.op == opcode.IsFalsity && iVal == 0) {
-			boolVal = true
-		}
+	return evalIsTrueOp(b.op, isNull, val == 0), false, nil
+}
+
+type builtinIsTrueOpRealSig struct {
+	baseIntBuiltinFunc
+
+	op opcode.Op
+}
+
+// evalInt evals IS TRUE or IS FALSE of a real, the other types are evaluated as reals too.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_is
+func (b *builtinIsTrueOpRealSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return 0, false, errors.Trace(err)
 	}
-	d.SetInt64(boolToInt64(boolVal))
-	return
+	return evalIsTrueOp(b.op, isNull, val == 0), false, nil
+}
+
+type builtinIsTrueOpDecimalSig struct {
+	baseIntBuiltinFunc
+
+	op opcode.Op
+}
+
+// evalInt evals IS TRUE or IS FALSE of a decimal.
+// See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#operator_is
+func (b *builtinIsTrueOpDecimalSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.args[0].EvalDecimal(row, b.ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return 0, false, errors.Trace(err)
+	}
+	isZero := isNull || val.Compare(new(types.MyDecimal)) == 0
+	return evalIsTrueOp(b.op, isNull, isZero), false, nil
 }
 
 type unaryOpFunctionClass struct {
@@ -390,24 +443,21 @@ type isNullFunctionClass struct {
 }
 
 func (c *isNullFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinIsNullSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinIsNullSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinIsNullSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinIsNullSig.
+// evalInt evals ISNULL(expr), which is also the IS NULL operator. It returns 1 if expr is NULL, otherwise 0,
+// and it's never NULL itself.
 // See https://dev.mysql.com/doc/refman/5.7/en/comparison-operators.html#function_isnull
-func (b *builtinIsNullSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
+func (b *builtinIsNullSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, err := b.args[0].Eval(row)
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	if args[0].IsNull() {
-		d.SetInt64(1)
-	} else {
-		d.SetInt64(0)
+		return 0, false, errors.Trace(err)
 	}
-	return d, nil
+	return boolToInt64(val.IsNull()), false, nil
 }
@@ -14,6 +14,7 @@
 package expression
 
 import (
+	"math"
 	"strings"
 
 	"github.com/juju/errors"
@@ -21,6 +22,8 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -34,6 +37,7 @@ var (
 	_ functionClass = &releaseLockFunctionClass{}
 	_ functionClass = &valuesFunctionClass{}
 	_ functionClass = &bitCountFunctionClass{}
+	_ functionClass = &collateFunctionClass{}
 )
 
 var (
@@ -47,6 +51,7 @@ var (
 	_ builtinFunc = &builtinReleaseLockSig{}
 	_ builtinFunc = &builtinValuesSig{}
 	_ builtinFunc = &builtinBitCountSig{}
+	_ builtinFunc = &builtinCollateSig{}
 )
 
 type inFunctionClass struct {
@@ -54,16 +59,110 @@ type inFunctionClass struct {
 }
 
 func (c *inFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinInSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinInSig{baseBuiltinFunc: newBaseBuiltinFunc(args, ctx)}
+	if len(args) > 1 {
+		sig.buildHashSet()
+	}
+	return sig, errors.Trace(c.verifyArgs(args))
 }
 
 type builtinInSig struct {
 	baseBuiltinFunc
+
+	// hashSet is built when all the arguments except the first one are constants of the same comparison
+	// type, it's keyed by the normalised encoded datum and turns the evaluation into a single lookup.
+	hashSet map[string]struct{}
+	// setKind is the normalised datum kind of the non-null constants in hashSet.
+	setKind byte
+	// hasNull indicates whether there is a null constant in the list.
+	hasNull bool
+}
+
+// hashSetKey normalises d to the comparison type of its kind before encoding it, so that the datums which
+// compare equal, like int64 1 and uint64 1, or a string and bytes with the same content, share the same key.
+// The returned kind is types.KindNull if d can't be looked up in the hash set.
+func hashSetKey(d types.Datum) (key string, kind byte, err error) {
+	switch d.Kind() {
+	case types.KindInt64:
+		kind = types.KindInt64
+	case types.KindUint64:
+		// A uint64 larger than math.MaxInt64 is encoded as is, it never equals an int64.
+		kind = types.KindInt64
+		if v := d.GetUint64(); v <= math.MaxInt64 {
+			d = types.NewIntDatum(int64(v))
+		}
+	case types.KindString, types.KindBytes:
+		kind = types.KindBytes
+		d = types.NewBytesDatum(d.GetBytes())
+	default:
+		return "", types.KindNull, nil
+	}
+	b, err := codec.EncodeValue(nil, d)
+	if err != nil {
+		return "", types.KindNull, errors.Trace(err)
+	}
+	return string(b), kind, nil
+}
+
+// buildHashSet builds the hash set for the constant list, it leaves hashSet nil if the fast path
+// can't be used, i.e. some argument is not a constant or the constants have different comparison types.
+func (b *builtinInSig) buildHashSet() {
+	consts, ok := ExpressionsToConstants(b.args[1:])
+	if !ok {
+		return
+	}
+	hashSet := make(map[string]struct{}, len(consts))
+	setKind := types.KindNull
+	hasNull := false
+	for _, d := range ConstantsToDatums(consts) {
+		if d.IsNull() {
+			hasNull = true
+			continue
+		}
+		key, kind, err := hashSetKey(d)
+		if err != nil || kind == types.KindNull {
+			return
+		}
+		if setKind == types.KindNull {
+			setKind = kind
+		} else if kind != setKind {
+			return
+		}
+		hashSet[key] = struct{}{}
+	}
+	b.hashSet, b.setKind, b.hasNull = hashSet, setKind, hasNull
+}
+
+// evalByHashSet evaluates the in function with the hash set, ok is false if the hash set can't be used
+// because the comparison type of the first argument is different from the one of the constants.
+func (b *builtinInSig) evalByHashSet(arg0 types.Datum) (d types.Datum, ok bool, err error) {
+	key, kind, err := hashSetKey(arg0)
+	if err != nil {
+		return d, true, errors.Trace(err)
+	}
+	if kind != b.setKind {
+		return d, false, nil
+	}
+	if _, found := b.hashSet[key]; found {
+		d.SetInt64(1)
+	} else if !b.hasNull {
+		d.SetInt64(0)
+	}
+	return d, true, nil
 }
 
 // eval evals a builtinInSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/any-in-some-subqueries.html
 func (b *builtinInSig) eval(row []types.Datum) (d types.Datum, err error) {
+	if b.hashSet != nil {
+		arg0, err := b.args[0].Eval(row)
+		if err != nil || arg0.IsNull() {
+			return d, errors.Trace(err)
+		}
+		if d, ok, err := b.evalByHashSet(arg0); ok {
+			return d, errors.Trace(err)
+		}
+	}
 	args, err := b.evalArgs(row)
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
@@ -133,6 +232,18 @@ func (c *castFunctionClass) getFunction(args []Expression, ctx context.Context)
 	return &builtinCastSig{newBaseBuiltinFunc(args, ctx), c.tp}, errors.Trace(c.verifyArgs(args))
 }
 
+// castableTypes are the types builtinCastSig can convert to.
+// Parser has restricted this. TypeDouble is used during plan optimization.
+var castableTypes = map[byte]struct{}{
+	mysql.TypeString:     {},
+	mysql.TypeDuration:   {},
+	mysql.TypeDatetime:   {},
+	mysql.TypeDate:       {},
+	mysql.TypeLonglong:   {},
+	mysql.TypeNewDecimal: {},
+	mysql.TypeDouble:     {},
+}
+
 type builtinCastSig struct {
 	baseBuiltinFunc
 
@@ -147,18 +258,14 @@ func (b *builtinCastSig) eval(row []types.Datum) (d types.Datum, err error) {
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
-	switch b.tp.Tp {
-	// Parser has restricted this.
-	// TypeDouble is used during plan optimization.
-	case mysql.TypeString, mysql.TypeDuration, mysql.TypeDatetime,
-		mysql.TypeDate, mysql.TypeLonglong, mysql.TypeNewDecimal, mysql.TypeDouble:
-		d = args[0]
-		if d.IsNull() {
-			return
-		}
-		return d.ConvertTo(b.ctx.GetSessionVars().StmtCtx, b.tp)
+	if _, ok := castableTypes[b.tp.Tp]; !ok {
+		return d, errors.Errorf("unknown cast type - %v", b.tp)
+	}
+	d = args[0]
+	if d.IsNull() {
+		return
 	}
-	return d, errors.Errorf("unknown cast type - %v", b.tp)
+	return d.ConvertTo(b.ctx.GetSessionVars().StmtCtx, b.tp)
 }
 
 type setVarFunctionClass struct {
@@ -225,11 +332,12 @@ type valuesFunctionClass struct {
 	baseFunctionClass
 
 	offset int
+	tp     *types.FieldType
 }
 
 func (c *valuesFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
 	err := errors.Trace(c.verifyArgs(args))
-	bt := &builtinValuesSig{newBaseBuiltinFunc(args, ctx), c.offset}
+	bt := &builtinValuesSig{newBaseBuiltinFunc(args, ctx), c.offset, c.tp}
 	bt.deterministic = false
 	return bt, errors.Trace(err)
 }
@@ -238,18 +346,21 @@ type builtinValuesSig struct {
 	baseBuiltinFunc
 
 	offset int
+	tp     *types.FieldType
 }
 
-func (b *builtinValuesSig) eval(_ []types.Datum) (types.Datum, error) {
-	values := b.ctx.GetSessionVars().CurrInsertValues
-	if values == nil {
-		return types.Datum{}, errors.New("Session current insert values is nil")
+// eval evals a builtinValuesSig, it returns the value to be inserted at offset of the row, which the insert
+// executor appends to the row being updated. The result is NULL if the offset is out of the row.
+// See http://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_values
+func (b *builtinValuesSig) eval(row []types.Datum) (types.Datum, error) {
+	if b.offset < 0 || b.offset >= len(row) || row[b.offset].IsNull() {
+		return types.Datum{}, nil
 	}
-	row := values.([]types.Datum)
-	if len(row) > b.offset {
+	if b.tp == nil {
 		return row[b.offset], nil
 	}
-	return types.Datum{}, errors.Errorf("Session current insert values len %d and column's offset %v don't match", len(row), b.offset)
+	d, err := row[b.offset].ConvertTo(b.ctx.GetSessionVars().StmtCtx, b.tp)
+	return d, errors.Trace(err)
 }
 
 type bitCountFunctionClass struct {
@@ -294,3 +405,62 @@ func (b *builtinBitCountSig) eval(row []types.Datum) (d types.Datum, err error)
 	d.SetInt64(count)
 	return d, nil
 }
+
+type collateFunctionClass struct {
+	baseFunctionClass
+}
+
+// getFunction checks that the collation, which is the constant second argument, is valid for
+// the charset of the first argument.
+func (c *collateFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
+	sig := &builtinCollateSig{newBaseBuiltinFunc(args, ctx)}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	con, ok := args[1].(*Constant)
+	if !ok || con.Value.Kind() != types.KindString {
+		return sig.setSelf(sig), errInvalidOperation.Gen("The collation of COLLATE must be a constant string")
+	}
+	collation := strings.ToLower(con.Value.GetString())
+	chs := args[0].GetType().Charset
+	if !charset.ValidCharsetAndCollation(chs, collation) {
+		if chs == "" {
+			chs = charset.CharsetUTF8
+		}
+		return sig.setSelf(sig), errInvalidOperation.Gen("COLLATION '%s' is not valid for CHARACTER SET '%s'", collation, chs)
+	}
+	return sig.setSelf(sig), nil
+}
+
+// builtinCollateSig passes its first argument through, the collation only takes effect on the
+// return type of the function, which the comparisons on it use.
+type builtinCollateSig struct {
+	baseBuiltinFunc
+}
+
+// eval evals a builtinCollateSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/charset-collate.html
+func (b *builtinCollateSig) eval(row []types.Datum) (d types.Datum, err error) {
+	d, err = b.args[0].Eval(row)
+	return d, errors.Trace(err)
+}
+
+func (b *builtinCollateSig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
+
+func (b *builtinCollateSig) evalReal(row []types.Datum) (float64, bool, error) {
+	val, isNull, err := b.args[0].EvalReal(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
+
+func (b *builtinCollateSig) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
+
+func (b *builtinCollateSig) evalDecimal(row []types.Datum) (*types.MyDecimal, bool, error) {
+	val, isNull, err := b.args[0].EvalDecimal(row, b.ctx.GetSessionVars().StmtCtx)
+	return val, isNull, errors.Trace(err)
+}
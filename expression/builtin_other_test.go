@@ -14,12 +14,17 @@
 package expression
 
 import (
+	"math"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
-	"math"
 )
 
 var bitCountCases = []struct {
@@ -60,3 +65,297 @@ func (s *testEvaluatorSuite) TestBitCount(c *C) {
 		c.Assert(res, Equals, test.count)
 	}
 }
+
+func (s *testEvaluatorSuite) TestRowComparison(c *C) {
+	defer testleak.AfterTest(c)()
+	row := func(args ...interface{}) Expression {
+		f, err := NewFunction(s.ctx, ast.RowFunc, types.NewFieldType(mysql.TypeLonglong), datumsToConstants(types.MakeDatums(args...))...)
+		c.Assert(err, IsNil)
+		return f
+	}
+	tests := []struct {
+		funcName string
+		args     []Expression
+		expr     string
+		ret      interface{}
+	}{
+		{ast.EQ, []Expression{row(1, 2), row(1, 2)}, "and(eq(1, 1), eq(2, 2))", int64(1)},
+		{ast.EQ, []Expression{row(1, 2), row(1, 3)}, "and(eq(1, 1), eq(2, 3))", int64(0)},
+		{ast.EQ, []Expression{row(1, nil), row(1, 2)}, "and(eq(1, 1), eq(<nil>, 2))", nil},
+		{ast.EQ, []Expression{row(2, nil), row(1, 2)}, "and(eq(2, 1), eq(<nil>, 2))", int64(0)},
+		{ast.NullEQ, []Expression{row(1, nil), row(1, nil)}, "and(nulleq(1, 1), nulleq(<nil>, <nil>))", int64(1)},
+		{ast.NE, []Expression{row(1, nil), row(1, 2)}, "or(ne(1, 1), ne(<nil>, 2))", nil},
+		{ast.NE, []Expression{row(2, nil), row(1, 2)}, "or(ne(2, 1), ne(<nil>, 2))", int64(1)},
+		{ast.In, []Expression{row(1, 2), row(3, 4), row(1, 2)}, "or(and(eq(1, 3), eq(2, 4)), and(eq(1, 1), eq(2, 2)))", int64(1)},
+		{ast.In, []Expression{row(1, 2), row(3, 4), row(1, nil)}, "or(and(eq(1, 3), eq(2, 4)), and(eq(1, 1), eq(2, <nil>)))", nil},
+	}
+	for _, t := range tests {
+		f, err := NewFunction(s.ctx, t.funcName, types.NewFieldType(mysql.TypeTiny), t.args...)
+		c.Assert(err, IsNil)
+		c.Assert(f.String(), Equals, t.expr)
+		d, err := f.Eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.ret))
+	}
+
+	// The row length mismatches.
+	_, err := NewFunction(s.ctx, ast.EQ, types.NewFieldType(mysql.TypeTiny), row(1, 2), row(1, 2, 3))
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+	_, err = NewFunction(s.ctx, ast.LT, types.NewFieldType(mysql.TypeTiny), row(1, 2), One)
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+	_, err = NewFunction(s.ctx, ast.In, types.NewFieldType(mysql.TypeTiny), row(1, 2), row(1, 2), row(1, 2, 3))
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+	_, err = NewFunction(s.ctx, ast.In, types.NewFieldType(mysql.TypeTiny), One, One, row(1, 2))
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+	_, err = NewFunction(s.ctx, ast.In, types.NewFieldType(mysql.TypeTiny), One, row(1, 2), row(1, 2))
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), IsTrue)
+
+	c.Assert(row(1, 2).GetType().Tp, Equals, types.KindRow)
+	c.Assert(GetRowLen(row(1, 2)), Equals, 2)
+
+	// The elements of a row constant are typed by their own values.
+	rowCon := &Constant{Value: types.NewDatum([]types.Datum{types.NewIntDatum(1), types.NewStringDatum("a")}), RetType: types.NewFieldType(types.KindRow)}
+	c.Assert(getRowArg(rowCon, 0).GetType().Tp, Equals, mysql.TypeLonglong)
+	c.Assert(getRowArg(rowCon, 1).GetType().Tp, Equals, mysql.TypeVarString)
+}
+
+func (s *testEvaluatorSuite) TestInFunc(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.In]
+	tests := []struct {
+		args    []interface{}
+		hashSet bool
+		ret     interface{}
+	}{
+		{[]interface{}{1, 1, 2, 3}, true, int64(1)},
+		{[]interface{}{4, 1, 2, 3}, true, int64(0)},
+		{[]interface{}{4, 1, nil, 3}, true, nil},
+		{[]interface{}{1, 1, nil}, true, int64(1)},
+		{[]interface{}{nil, 1, 2}, true, nil},
+		{[]interface{}{uint64(1), uint64(1), uint64(2)}, true, int64(1)},
+		{[]interface{}{"a", "a", "b"}, true, int64(1)},
+		{[]interface{}{"A", "a", "b"}, true, int64(0)},
+		{[]interface{}{"c", "a", nil}, true, nil},
+		// The datums are normalised to the comparison type, so mixed kinds of it share the hash set.
+		{[]interface{}{1, uint64(1), uint64(2)}, true, int64(1)},
+		{[]interface{}{uint64(2), 1, uint64(2)}, true, int64(1)},
+		{[]interface{}{uint64(3), 1, uint64(2)}, true, int64(0)},
+		{[]interface{}{-1, uint64(math.MaxUint64), 1}, true, int64(0)},
+		{[]interface{}{uint64(math.MaxUint64), -1, uint64(math.MaxUint64)}, true, int64(1)},
+		{[]interface{}{[]byte("a"), "a", "b"}, true, int64(1)},
+		{[]interface{}{"b", []byte("a"), "b"}, true, int64(1)},
+		{[]interface{}{"c", []byte("a"), "b", nil}, true, nil},
+		// The kind of the first argument differs from the list, falls back to the comparison chain.
+		{[]interface{}{1.0, 1, 2}, true, int64(1)},
+		{[]interface{}{"1", 1, 2}, true, int64(1)},
+		{[]interface{}{3.0, 1, nil}, true, nil},
+		// Mixed-type lists use the comparison chain.
+		{[]interface{}{1, "1", 2}, false, int64(1)},
+		{[]interface{}{3, "1", 2, nil}, false, nil},
+		{[]interface{}{1.5, 1.5, 2.5}, false, int64(1)},
+		{[]interface{}{nil, nil}, true, nil},
+	}
+	for _, t := range tests {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil)
+		c.Assert(f.(*builtinInSig).hashSet != nil, Equals, t.hashSet, Commentf("%v", t.args))
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.ret), Commentf("%v", t.args))
+	}
+
+	// A column in the list disables the hash set.
+	col := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	f, err := fc.getFunction([]Expression{One, newLonglong(2), col}, s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(f.(*builtinInSig).hashSet, IsNil)
+	d, err := f.eval(types.MakeDatums(1))
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewDatum(int64(1)))
+
+	// The first argument is evaluated against every row.
+	f, err = fc.getFunction([]Expression{col, newLonglong(1), newLonglong(2)}, s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(f.(*builtinInSig).hashSet, NotNil)
+	for i, ret := range []interface{}{nil, int64(1), int64(1), int64(0)} {
+		var row []types.Datum
+		if i == 0 {
+			row = []types.Datum{{}}
+		} else {
+			row = types.MakeDatums(i)
+		}
+		d, err = f.eval(row)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(ret))
+	}
+}
+
+func (s *testEvaluatorSuite) TestCollate(c *C) {
+	defer testleak.AfterTest(c)()
+	newStrCol := func(chs, collation string) *Column {
+		ft := types.NewFieldType(mysql.TypeVarString)
+		ft.Charset, ft.Collate = chs, collation
+		return &Column{Index: 0, RetType: ft}
+	}
+	collate := func(arg Expression, collation string) (Expression, error) {
+		ft := *arg.GetType()
+		ft.Collate = collation
+		return NewFunction(s.ctx, ast.Collate, &ft, arg, datumsToConstants(types.MakeDatums(collation))[0])
+	}
+
+	// The value is passed through, only the collation is overridden.
+	col := newStrCol(charset.CharsetUTF8, "utf8_bin")
+	f, err := collate(col, "utf8_general_ci")
+	c.Assert(err, IsNil)
+	c.Assert(f.GetType().Collate, Equals, "utf8_general_ci")
+	c.Assert(f.GetType().Charset, Equals, charset.CharsetUTF8)
+	d, err := f.Eval(types.MakeDatums("aBc"))
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewDatum("aBc"))
+	str, isNull, err := f.EvalString(types.MakeDatums(nil), s.ctx.GetSessionVars().StmtCtx)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsTrue)
+	c.Assert(str, Equals, "")
+	_, err = collate(newStrCol(charset.CharsetUTF8MB4, "utf8mb4_general_ci"), "UTF8MB4_BIN")
+	c.Assert(err, IsNil)
+
+	// The collation must be valid for the charset of the argument.
+	for _, t := range []struct {
+		arg       Expression
+		collation string
+	}{
+		{newStrCol(charset.CharsetUTF8, "utf8_bin"), "latin1_bin"},
+		{newStrCol(charset.CharsetLatin1, "latin1_bin"), "utf8_bin"},
+		{newStrCol(charset.CharsetUTF8, "utf8_bin"), "unknown_collation"},
+		{newStrCol(charset.CharsetBin, charset.CollationBin), "utf8_bin"},
+	} {
+		_, err = collate(t.arg, t.collation)
+		c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+	}
+	_, err = NewFunction(s.ctx, ast.Collate, col.GetType(), col, newStrCol(charset.CharsetUTF8, "utf8_bin"))
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+
+	// The collation set by COLLATE beats the ones of the columns and the constants.
+	ciConst := datumsToConstants(types.MakeDatums("a"))[0]
+	ciConst.GetType().Charset, ciConst.GetType().Collate = charset.CharsetUTF8, "utf8_general_ci"
+	ciCol := newStrCol(charset.CharsetUTF8, "utf8_general_ci")
+	binCol, err := collate(ciCol, "utf8_bin")
+	c.Assert(err, IsNil)
+	ciCol2, err := collate(newStrCol(charset.CharsetUTF8, "utf8_bin"), "utf8_general_ci")
+	c.Assert(err, IsNil)
+	explicitBin, err := collate(ciConst, "utf8_bin")
+	c.Assert(err, IsNil)
+	// The comparisons are case insensitive only under the collation set by COLLATE, STRCMP, '=' and '<' always
+	// agree with each other.
+	collationTbl := []struct {
+		args   []Expression
+		expect int64
+	}{
+		{[]Expression{ciCol, ciConst}, -1},
+		{[]Expression{binCol, ciConst}, -1},
+		{[]Expression{ciCol, explicitBin}, -1},
+		{[]Expression{ciCol2, ciConst}, 0},
+		{[]Expression{col, ciConst}, -1},
+		{[]Expression{ciCol2, newStrCol(charset.CharsetUTF8, "utf8_bin")}, 0},
+	}
+	for _, t := range collationTbl {
+		f, err := funcs[ast.Strcmp].getFunction(t.args, s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(types.MakeDatums("A"))
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%v", t.args))
+
+		eq, lt := int64(0), int64(0)
+		if t.expect == 0 {
+			eq = 1
+		} else if t.expect < 0 {
+			lt = 1
+		}
+		f, err = funcs[ast.EQ].getFunction(t.args, s.ctx)
+		c.Assert(err, IsNil)
+		d, err = f.eval(types.MakeDatums("A"))
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(eq), Commentf("%v", t.args))
+		cmp, err := NewComparison(s.ctx, "<", t.args[0], t.args[1])
+		c.Assert(err, IsNil)
+		d, err = cmp.Eval(types.MakeDatums("A"))
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(lt), Commentf("%v", t.args))
+	}
+
+	// The sort keys follow the same rule, only the strings sorted under a "_ci" collation set by COLLATE are lowered.
+	for _, t := range []struct {
+		arg    Expression
+		expect interface{}
+	}{
+		{ciCol2, "a"},
+		{ciCol, "A"},
+		{binCol, "A"},
+		{col, "A"},
+	} {
+		key, err := EvalSortKey(t.arg, types.MakeDatums("A"))
+		c.Assert(err, IsNil)
+		c.Assert(key, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%v", t.arg))
+	}
+	key, err := EvalSortKey(ciCol2, types.MakeDatums(nil))
+	c.Assert(err, IsNil)
+	c.Assert(key.IsNull(), IsTrue)
+
+	// The collations of the same coercibility are merged if the charset of one is a superset of the other one.
+	for _, t := range []struct {
+		args   []Expression
+		expect string
+	}{
+		{[]Expression{newStrCol(charset.CharsetUTF8, "utf8_bin"), newStrCol(charset.CharsetUTF8MB4, "utf8mb4_general_ci")}, "utf8mb4_general_ci"},
+		{[]Expression{newStrCol(charset.CharsetUTF8MB4, "utf8mb4_bin"), newStrCol(charset.CharsetUTF8, "utf8_general_ci")}, "utf8mb4_bin"},
+		{[]Expression{newStrCol(charset.CharsetLatin1, "latin1_bin"), newStrCol(charset.CharsetUTF8, "utf8_bin")}, "utf8_bin"},
+	} {
+		collation, err := mergeCollation(ast.EQ, t.args...)
+		c.Assert(err, IsNil)
+		c.Assert(collation, Equals, t.expect)
+		_, err = NewComparison(s.ctx, "=", t.args[0], t.args[1])
+		c.Assert(err, IsNil)
+	}
+	_, err = mergeCollation(ast.EQ, newStrCol(charset.CharsetUTF8, "utf8_bin"), newStrCol(charset.CharsetUTF8, "utf8_general_ci"))
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+
+	// Explicit collations can't be mixed.
+	_, err = funcs[ast.Strcmp].getFunction([]Expression{binCol, ciCol2}, s.ctx)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+	c.Assert(err.Error(), Matches, ".*Illegal mix of collations.*EXPLICIT.*")
+	_, err = funcs[ast.LT].getFunction([]Expression{binCol, ciCol2}, s.ctx)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+	_, err = NewComparison(s.ctx, "=", binCol, ciCol2)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+}
+
+func (s *testEvaluatorSuite) TestValues(c *C) {
+	defer testleak.AfterTest(c)()
+	// 'insert into t values (1, 5) on duplicate key update c = values(c) + 1', the executor evaluates the
+	// assignment on the row of the table being updated followed by the insert row, so values(c) reads offset 2+1.
+	values := NewValuesFunc(3, types.NewFieldType(mysql.TypeLonglong), s.ctx)
+	assignment := newFunction(ast.Plus, values, One)
+	d, err := assignment.Eval(types.MakeDatums(1, 100, 1, 5))
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewIntDatum(6))
+	d, err = assignment.Eval(types.MakeDatums(2, 100, 2, nil))
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+
+	// The offset out of the row results in NULL, e.g. VALUES() isn't in ON DUPLICATE KEY UPDATE.
+	d, err = values.Eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+	d, err = values.Eval(types.MakeDatums(1, 100))
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+
+	// The value is converted to the return type.
+	row := types.MakeDatums("12", 1.5)
+	d, err = NewValuesFunc(0, types.NewFieldType(mysql.TypeLonglong), s.ctx).Eval(row)
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewIntDatum(12))
+	d, err = NewValuesFunc(1, types.NewFieldType(mysql.TypeVarchar), s.ctx).Eval(row)
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewStringDatum("1.5"))
+}
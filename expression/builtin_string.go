@@ -25,6 +25,7 @@ import (
 	"math"
 	"strconv"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/juju/errors"
@@ -33,6 +34,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/hack"
 	"github.com/pingcap/tidb/util/stringutil"
@@ -101,7 +103,8 @@ var (
 	_ builtinFunc = &builtinSubstringSig{}
 	_ builtinFunc = &builtinSubstringIndexSig{}
 	_ builtinFunc = &builtinLocateSig{}
-	_ builtinFunc = &builtinHexSig{}
+	_ builtinFunc = &builtinHexStrArgSig{}
+	_ builtinFunc = &builtinHexIntArgSig{}
 	_ builtinFunc = &builtinUnHexSig{}
 	_ builtinFunc = &builtinTrimSig{}
 	_ builtinFunc = &builtinLTrimSig{}
@@ -112,12 +115,14 @@ var (
 	_ builtinFunc = &builtinCharLengthSig{}
 	_ builtinFunc = &builtinFindInSetSig{}
 	_ builtinFunc = &builtinMakeSetSig{}
-	_ builtinFunc = &builtinOctSig{}
+	_ builtinFunc = &builtinOctIntSig{}
+	_ builtinFunc = &builtinOctStringSig{}
 	_ builtinFunc = &builtinOrdSig{}
 	_ builtinFunc = &builtinQuoteSig{}
 	_ builtinFunc = &builtinBinSig{}
 	_ builtinFunc = &builtinEltSig{}
 	_ builtinFunc = &builtinExportSetSig{}
+	_ builtinFunc = &builtinFormatWithLocaleSig{}
 	_ builtinFunc = &builtinFormatSig{}
 	_ builtinFunc = &builtinFromBase64Sig{}
 	_ builtinFunc = &builtinToBase64Sig{}
@@ -132,31 +137,36 @@ type lengthFunctionClass struct {
 }
 
 func (c *lengthFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinLengthSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinLengthSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinLengthSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinLengthSig.
-// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html
-func (b *builtinLengthSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+// evalInt evals LENGTH(str), the length is counted in bytes.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_length
+func (b *builtinLengthSig) evalInt(row []types.Datum) (int64, bool, error) {
+	str, isNull, err := evalStringByValue(b.args[0], row)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	switch args[0].Kind() {
-	case types.KindNull:
-		return d, nil
-	default:
-		s, err := args[0].ToString()
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		d.SetInt64(int64(len(s)))
-		return d, nil
+	return int64(len(str)), false, nil
+}
+
+// evalStringByValue evaluates expr to a string converted from its value. Unlike EvalString, the value isn't taken
+// as a string by the type of expr, because a datetime or a duration is in string class but isn't stored as a string.
+func evalStringByValue(expr Expression, row []types.Datum) (string, bool, error) {
+	val, err := expr.Eval(row)
+	if val.IsNull() || err != nil {
+		return "", true, errors.Trace(err)
 	}
+	str, err := val.ToString()
+	if err != nil {
+		return "", true, errors.Trace(err)
+	}
+	return str, false, nil
 }
 
 type asciiFunctionClass struct {
@@ -164,35 +174,25 @@ type asciiFunctionClass struct {
 }
 
 func (c *asciiFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinASCIISig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinASCIISig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinASCIISig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinASCIISig.
+// evalInt evals a builtinASCIISig, it returns the numeric value of the leftmost byte of the string.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_ascii
-func (b *builtinASCIISig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinASCIISig) evalInt(row []types.Datum) (int64, bool, error) {
+	val, isNull, err := evalStringByValue(b.args[0], row)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	switch args[0].Kind() {
-	case types.KindNull:
-		return d, nil
-	default:
-		s, err := args[0].ToString()
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		if len(s) == 0 {
-			d.SetInt64(0)
-			return d, nil
-		}
-		d.SetInt64(int64(s[0]))
-		return d, nil
+	if len(val) == 0 {
+		return 0, false, nil
 	}
+	return int64(val[0]), false, nil
 }
 
 type concatFunctionClass struct {
@@ -235,43 +235,48 @@ type concatWSFunctionClass struct {
 }
 
 func (c *concatWSFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinConcatWSSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinConcatWSSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinConcatWSSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinConcatWSSig.
+// evalString evals a builtinConcatWSSig.
+// The NULL arguments after the separator are skipped, the result is NULL only if the separator is NULL.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_concat-ws
-func (b *builtinConcatWSSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
+func (b *builtinConcatWSSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	sep, isNull, err := evalStringByValue(b.args[0], row)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	maxAllowedPacket, err := getMaxAllowedPacket(b.ctx)
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+		return "", true, errors.Trace(err)
 	}
-	var sep string
-	s := make([]string, 0, len(args))
-	for i, a := range args {
-		if a.IsNull() {
-			if i == 0 {
-				return d, nil
-			}
-			continue
-		}
-		ss, err := a.ToString()
+	strs := make([]string, 0, len(b.args)-1)
+	length := 0
+	for _, arg := range b.args[1:] {
+		str, isNull, err := evalStringByValue(arg, row)
 		if err != nil {
-			return d, errors.Trace(err)
+			return "", true, errors.Trace(err)
 		}
-
-		if i == 0 {
-			sep = ss
+		if isNull {
 			continue
 		}
-		s = append(s, ss)
+		if len(strs) > 0 {
+			length += len(sep)
+		}
+		length += len(str)
+		if uint64(length) > maxAllowedPacket {
+			sc.AppendWarning(errWarnAllowedPacketOverflowed.GenByArgs("concat_ws", maxAllowedPacket))
+			return "", true, nil
+		}
+		strs = append(strs, str)
 	}
-
-	d.SetString(strings.Join(s, sep))
-	return d, nil
+	return strings.Join(strs, sep), false, nil
 }
 
 type leftFunctionClass struct {
@@ -553,34 +558,121 @@ type strcmpFunctionClass struct {
 }
 
 func (c *strcmpFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinStrcmpSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinStrcmpSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	caseInsensitive, err := isExplicitCICollation(ast.Strcmp, args[0], args[1])
+	sig.caseInsensitive = caseInsensitive
+	return sig.setSelf(sig), errors.Trace(err)
 }
 
-type builtinStrcmpSig struct {
-	baseBuiltinFunc
+const (
+	coercibilityExplicit  = "EXPLICIT"
+	coercibilityImplicit  = "IMPLICIT"
+	coercibilityCoercible = "COERCIBLE"
+)
+
+// coercibilityLevels are the levels of the coercibilities, the collation of the lower level wins.
+var coercibilityLevels = map[string]int{
+	coercibilityExplicit:  0,
+	coercibilityImplicit:  1,
+	coercibilityCoercible: 2,
 }
 
-// eval evals a builtinStrcmpSig.
-// See https://dev.mysql.com/doc/refman/5.7/en/string-comparison-functions.html
-func (b *builtinStrcmpSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	if args[0].IsNull() || args[1].IsNull() {
-		return d, nil
+// unicodeCharsetLevels are the levels of the unicode charsets. A string can be converted to a charset of a higher
+// level without loss, e.g. utf8 is a subset of utf8mb4. The other charsets are of level 0.
+var unicodeCharsetLevels = map[string]int{
+	charset.CharsetUTF8:    1,
+	charset.CharsetUTF8MB4: 2,
+}
+
+// mergeCollation derives the collation used to compare the arguments of a function. A binary
+// argument makes the comparison binary, the collation set by COLLATE beats the one of a column, which
+// beats the one of a constant.
+// It returns an error if two arguments of the same coercibility have different collations, unless the charset of
+// one collation is a superset of the other one, e.g. utf8_bin and utf8mb4_bin are merged to utf8mb4_bin.
+// See https://dev.mysql.com/doc/refman/5.7/en/charset-collation-coercibility.html
+func mergeCollation(funcName string, args ...Expression) (string, error) {
+	collation, _, err := deriveCollation(funcName, args...)
+	return collation, errors.Trace(err)
+}
+
+// deriveCollation is like mergeCollation, and it also returns the coercibility of the derived collation.
+func deriveCollation(funcName string, args ...Expression) (collation, coercibility string, err error) {
+	for _, arg := range args {
+		ft := arg.GetType()
+		if ft == nil {
+			continue
+		}
+		if ft.Collate == charset.CollationBin || ft.Charset == charset.CharsetBin {
+			return charset.CollationBin, coercibilityImplicit, nil
+		}
+		if ft.Collate == "" {
+			continue
+		}
+		argCoercibility := coercibilityCoercible
+		switch x := arg.(type) {
+		case *Column, *CorrelatedColumn:
+			argCoercibility = coercibilityImplicit
+		case *ScalarFunction:
+			if x.FuncName.L == ast.Collate {
+				argCoercibility = coercibilityExplicit
+			}
+		}
+		switch {
+		case collation == "" || coercibilityLevels[argCoercibility] < coercibilityLevels[coercibility]:
+			collation, coercibility = ft.Collate, argCoercibility
+		case coercibility == argCoercibility && !strings.EqualFold(collation, ft.Collate):
+			level, argLevel := unicodeCharsetLevels[collationCharset(collation)], unicodeCharsetLevels[collationCharset(ft.Collate)]
+			if level == argLevel {
+				return "", "", errInvalidOperation.Gen("Illegal mix of collations (%s,%s) and (%s,%s) for operation '%s'",
+					collation, coercibility, ft.Collate, argCoercibility, funcName)
+			}
+			if argLevel > level {
+				collation = ft.Collate
+			}
+		}
 	}
-	left, err := args[0].ToString()
+	return strings.ToLower(collation), coercibility, nil
+}
+
+// isExplicitCICollation returns whether the arguments are compared case insensitively, that is they're compared
+// under a "_ci" collation set by COLLATE. The other comparisons stay binary.
+// All the functions comparing strings, like the comparison operators, STRCMP and LOCATE, decide the case
+// sensitivity with it, so they always agree with each other.
+// It returns an error if the arguments have an illegal mix of collations.
+func isExplicitCICollation(funcName string, args ...Expression) (bool, error) {
+	collation, coercibility, err := deriveCollation(funcName, args...)
 	if err != nil {
-		return d, errors.Trace(err)
+		return false, errors.Trace(err)
 	}
-	right, err := args[1].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
+	return coercibility == coercibilityExplicit && strings.HasSuffix(collation, "_ci"), nil
+}
+
+// collationCharset returns the charset of a collation, which is the prefix of the collation name.
+func collationCharset(collation string) string {
+	return strings.ToLower(strings.SplitN(collation, "_", 2)[0])
+}
+
+type builtinStrcmpSig struct {
+	baseIntBuiltinFunc
+
+	caseInsensitive bool
+}
+
+// evalInt evals a builtinStrcmpSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-comparison-functions.html#function_strcmp
+func (b *builtinStrcmpSig) evalInt(row []types.Datum) (int64, bool, error) {
+	strs, isNull, err := EvalArgsString(b.args, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	res := types.CompareString(left, right)
-	d.SetInt64(int64(res))
-	return d, nil
+	left, right := strs[0], strs[1]
+	if b.caseInsensitive {
+		left, right = strings.ToLower(left), strings.ToLower(right)
+	}
+	return int64(types.CompareString(left, right)), false, nil
 }
 
 type replaceFunctionClass struct {
@@ -683,64 +775,63 @@ type substringFunctionClass struct {
 }
 
 func (c *substringFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinSubstringSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinSubstringSig{baseStringBuiltinFunc: baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	sig.binary = args[0].GetType().Charset == charset.CharsetBin
+	return sig.setSelf(sig), nil
 }
 
 type builtinSubstringSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
+
+	// binary indicates that the positions are counted in bytes rather than characters.
+	binary bool
 }
 
-func (b *builtinSubstringSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	// The meaning of the elements of args.
-	// arg[0] -> StrExpr
-	// arg[1] -> Pos
-	// arg[2] -> Len (Optional)
-	str, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Errorf("Substring invalid args, need string but get %T", args[0].GetValue())
+// evalString evals SUBSTRING(str,pos) and SUBSTRING(str,pos,len), MID and SUBSTR are the synonyms.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_substring
+func (b *builtinSubstringSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-
-	if args[1].Kind() != types.KindInt64 {
-		return d, errors.Errorf("Substring invalid pos args, need int but get %T", args[1].GetValue())
+	pos, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	pos := args[1].GetInt64()
-
-	length, hasLen := int64(-1), false
-	if len(args) == 3 {
-		if args[2].Kind() != types.KindInt64 {
-			return d, errors.Errorf("Substring invalid pos args, need int but get %T", args[2].GetValue())
+	length, hasLen := int64(0), len(b.args) == 3
+	if hasLen {
+		length, isNull, err = b.args[2].EvalInt(row, sc)
+		if isNull || err != nil {
+			return "", true, errors.Trace(err)
 		}
-		length, hasLen = args[2].GetInt64(), true
 	}
-	// The forms without a len argument return a substring from string str starting at position pos.
-	// The forms with a len argument return a substring len characters long from string str, starting at position pos.
-	// The forms that use FROM are standard SQL syntax. It is also possible to use a negative value for pos.
-	// In this case, the beginning of the substring is pos characters from the end of the string, rather than the beginning.
-	// A negative value may be used for pos in any of the forms of this function.
+	var runes []rune
+	n := int64(len(str))
+	if !b.binary {
+		runes = []rune(str)
+		n = int64(len(runes))
+	}
+	// A negative pos means the substring begins pos characters from the end of the string.
 	if pos < 0 {
-		pos = int64(len(str)) + pos
+		pos += n
 	} else {
 		pos--
 	}
-	if pos > int64(len(str)) || pos < int64(0) {
-		pos = int64(len(str))
+	if pos < 0 || pos >= n || (hasLen && length <= 0) {
+		return "", false, nil
 	}
-	if hasLen {
-		if end := pos + length; end < pos {
-			d.SetString("")
-		} else if end > int64(len(str)) {
-			d.SetString(str[pos:])
-		} else {
-			d.SetString(str[pos:end])
-		}
-	} else {
-		d.SetString(str[pos:])
+	end := n
+	if hasLen && length < n-pos {
+		end = pos + length
 	}
-	return d, nil
+	if b.binary {
+		return str[pos:end], false, nil
+	}
+	return string(runes[pos:end]), false, nil
 }
 
 type substringIndexFunctionClass struct {
@@ -748,48 +839,36 @@ type substringIndexFunctionClass struct {
 }
 
 func (c *substringIndexFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinSubstringIndexSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinSubstringIndexSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinSubstringIndexSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinSubstringIndexSig.
+// evalString evals a builtinSubstringIndexSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_substring-index
-func (b *builtinSubstringIndexSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	// The meaning of the elements of args.
-	// args[0] -> StrExpr
-	// args[1] -> Delim
-	// args[2] -> Count
-	str, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Errorf("Substring_Index invalid args, need string but get %T", args[0].GetValue())
+func (b *builtinSubstringIndexSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-
-	delim, err := args[1].ToString()
-	if err != nil {
-		return d, errors.Errorf("Substring_Index invalid delim, need string but get %T", args[1].GetValue())
+	delim, isNull, err := b.args[1].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-	if len(delim) == 0 {
-		d.SetString("")
-		return d, nil
+	count, isNull, err := b.args[2].EvalInt(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-
-	c, err := args[2].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
+	if len(delim) == 0 || count == 0 {
+		return "", false, nil
 	}
-	count := int(c)
+	// The delimiter is matched as a whole, so a multi-character delimiter won't be split.
 	strs := strings.Split(str, delim)
-	var (
-		start = 0
-		end   = len(strs)
-	)
+	start, end := int64(0), int64(len(strs))
 	if count > 0 {
 		// If count is positive, everything to the left of the final delimiter (counting from the left) is returned.
 		if count < end {
@@ -797,14 +876,13 @@ func (b *builtinSubstringIndexSig) eval(row []types.Datum) (d types.Datum, err e
 		}
 	} else {
 		// If count is negative, everything to the right of the final delimiter (counting from the right) is returned.
+		// Note that -count overflows when count is math.MinInt64, then the whole string is returned.
 		count = -count
-		if count < end {
+		if count > 0 && count < end {
 			start = end - count
 		}
 	}
-	substrs := strs[start:end]
-	d.SetString(strings.Join(substrs, delim))
-	return d, nil
+	return strings.Join(strs[start:end], delim), false, nil
 }
 
 type locateFunctionClass struct {
@@ -812,82 +890,74 @@ type locateFunctionClass struct {
 }
 
 func (c *locateFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinLocateSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinLocateSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	collation, err := mergeCollation(c.funcName, args[0], args[1])
+	if err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	sig.binary = collation == charset.CollationBin
+	sig.caseInsensitive, err = isExplicitCICollation(c.funcName, args[0], args[1])
+	return sig.setSelf(sig), errors.Trace(err)
 }
 
 type builtinLocateSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
+
+	// binary indicates that the positions are counted in bytes, otherwise they're counted in characters.
+	binary bool
+	// caseInsensitive indicates that the search is case insensitive.
+	caseInsensitive bool
 }
 
-// eval evals a builtinLocateSig.
+// evalInt evals a builtinLocateSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_locate
-func (b *builtinLocateSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	// The meaning of the elements of args.
-	// args[0] -> SubStr
-	// args[1] -> Str
-	// args[2] -> Pos
-
-	// eval str
-	if args[1].IsNull() {
-		return d, nil
-	}
-	str, err := args[1].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	// eval substr
-	if args[0].IsNull() {
-		return d, nil
-	}
-	subStr, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	// eval pos
-	pos := int64(0)
-	if len(args) == 3 {
-		p, err := args[2].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-		if err != nil {
-			return d, errors.Trace(err)
+func (b *builtinLocateSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	subStr, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	str, isNull, err := b.args[1].EvalString(row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	pos := int64(1)
+	if len(b.args) == 3 {
+		pos, isNull, err = b.args[2].EvalInt(row, sc)
+		if isNull || err != nil {
+			return 0, true, errors.Trace(err)
 		}
-		pos = p - 1
 	}
-	var ret, subStrLen, sentinel int64
-	caseSensitive := false
-	if args[0].Kind() == types.KindBytes || args[1].Kind() == types.KindBytes {
-		caseSensitive = true
-		subStrLen = int64(len(subStr))
-		sentinel = int64(len(str)) - subStrLen
-	} else {
-		subStrLen = int64(len([]rune(subStr)))
-		sentinel = int64(len([]rune(strings.ToLower(str)))) - subStrLen
+	if pos < 1 {
+		return 0, false, nil
 	}
-
-	if pos < 0 || pos > sentinel {
-		d.SetInt64(0)
-		return d, nil
-	} else if subStrLen == 0 {
-		d.SetInt64(pos + 1)
-		return d, nil
-	} else if caseSensitive {
-		slice := str[pos:]
-		idx := strings.Index(slice, subStr)
-		if idx != -1 {
-			ret = pos + int64(idx) + 1
+	if b.binary {
+		if pos-1 > int64(len(str)-len(subStr)) {
+			return 0, false, nil
 		}
-	} else {
-		slice := string([]rune(strings.ToLower(str))[pos:])
-		idx := strings.Index(slice, strings.ToLower(subStr))
-		if idx != -1 {
-			ret = pos + int64(utf8.RuneCountInString(slice[:idx])) + 1
+		idx := strings.Index(str[pos-1:], subStr)
+		if idx == -1 {
+			return 0, false, nil
 		}
+		return pos + int64(idx), false, nil
 	}
-	d.SetInt64(ret)
-	return d, nil
+	if b.caseInsensitive {
+		// unicode.ToLower maps a rune to a rune, so the character positions are kept.
+		str, subStr = strings.Map(unicode.ToLower, str), strings.Map(unicode.ToLower, subStr)
+	}
+	runes := []rune(str)
+	if pos-1 > int64(len(runes)-utf8.RuneCountInString(subStr)) {
+		return 0, false, nil
+	}
+	slice := string(runes[pos-1:])
+	idx := strings.Index(slice, subStr)
+	if idx == -1 {
+		return 0, false, nil
+	}
+	return pos + int64(utf8.RuneCountInString(slice[:idx])), false, nil
 }
 
 const spaceChars = "\n\t\r "
@@ -897,38 +967,45 @@ type hexFunctionClass struct {
 }
 
 func (c *hexFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinHexSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinHexStrArgSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}, errors.Trace(err)
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	var sig builtinFunc
+	if args[0].GetType().ToClass() == types.ClassString {
+		sig = &builtinHexStrArgSig{baseStringBuiltinFunc{bf}}
+	} else {
+		sig = &builtinHexIntArgSig{baseStringBuiltinFunc{bf}}
+	}
+	return sig.setSelf(sig), nil
 }
 
-type builtinHexSig struct {
-	baseBuiltinFunc
+type builtinHexStrArgSig struct {
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinHexSig.
+// evalString evals HEX(str), each byte of str is converted to two hexadecimal digits.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_hex
-func (b *builtinHexSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinHexStrArgSig) evalString(row []types.Datum) (string, bool, error) {
+	str, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	switch args[0].Kind() {
-	case types.KindNull:
-		return d, nil
-	case types.KindString:
-		x, err := args[0].ToString()
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		d.SetString(strings.ToUpper(hex.EncodeToString(hack.Slice(x))))
-		return d, nil
-	case types.KindInt64, types.KindUint64, types.KindMysqlHex, types.KindFloat32, types.KindFloat64, types.KindMysqlDecimal:
-		x, _ := args[0].Cast(b.ctx.GetSessionVars().StmtCtx, types.NewFieldType(mysql.TypeLonglong))
-		h := fmt.Sprintf("%x", uint64(x.GetInt64()))
-		d.SetString(strings.ToUpper(h))
-		return d, nil
-	default:
-		return d, errors.Errorf("Hex invalid args, need int or string but get %T", args[0].GetValue())
+	return strings.ToUpper(hex.EncodeToString(hack.Slice(str))), false, nil
+}
+
+type builtinHexIntArgSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals HEX(N), N is rounded to an integer and converted as a 64-bit unsigned integer.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_hex
+func (b *builtinHexIntArgSig) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
+	return strings.ToUpper(strconv.FormatUint(uint64(val), 16)), false, nil
 }
 
 type unhexFunctionClass struct {
@@ -936,48 +1013,26 @@ type unhexFunctionClass struct {
 }
 
 func (c *unhexFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinUnHexSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinUnHexSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinUnHexSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinUnHexSig.
+// evalString evals UNHEX(str), the result is NULL if str has an odd length or non-hexadecimal digits.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_unhex
-func (b *builtinUnHexSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinUnHexSig) evalString(row []types.Datum) (string, bool, error) {
+	str, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	switch args[0].Kind() {
-	case types.KindNull:
-		return d, nil
-	case types.KindString:
-		x, err := args[0].ToString()
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		bytes, err := hex.DecodeString(x)
-		if err != nil {
-			return d, nil
-		}
-		d.SetString(string(bytes))
-		return d, nil
-	case types.KindInt64, types.KindUint64, types.KindMysqlHex, types.KindFloat32, types.KindFloat64, types.KindMysqlDecimal:
-		x, _ := args[0].Cast(b.ctx.GetSessionVars().StmtCtx, types.NewFieldType(mysql.TypeString))
-		if x.IsNull() {
-			return d, nil
-		}
-		bytes, err := hex.DecodeString(x.GetString())
-		if err != nil {
-			return d, nil
-		}
-		d.SetString(string(bytes))
-		return d, nil
-	default:
-		return d, errors.Errorf("Unhex invalid args, need int or string but get %T", args[0].GetValue())
+	bs, err := hex.DecodeString(str)
+	if err != nil {
+		return "", true, nil
 	}
+	return string(bs), false, nil
 }
 
 type trimFunctionClass struct {
@@ -1132,50 +1187,18 @@ type rpadFunctionClass struct {
 }
 
 func (c *rpadFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinRpadSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinRpadSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinRpadSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinRpadSig.
+// evalString evals a builtinRpadSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_rpad
-func (b *builtinRpadSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	// RPAD(str,len,padstr)
-	// args[0] string, args[1] int, args[2] string
-	str, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	length, err := args[1].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	l := int(length)
-
-	padStr, err := args[2].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-
-	if l < 0 || (len(str) < l && padStr == "") {
-		d.SetNull()
-		return d, nil
-	}
-
-	tailLen := l - len(str)
-	if tailLen > 0 {
-		repeatCount := tailLen/len(padStr) + 1
-		str = str + strings.Repeat(padStr, repeatCount)
-	}
-	d.SetString(str[:l])
-
-	return d, nil
+func (b *builtinRpadSig) evalString(row []types.Datum) (string, bool, error) {
+	return evalPad(b.args, row, b.ctx.GetSessionVars().StmtCtx, false)
 }
 
 type bitLengthFunctionClass struct {
@@ -1319,32 +1342,32 @@ type charLengthFunctionClass struct {
 }
 
 func (c *charLengthFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinCharLengthSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinCharLengthSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	sig.binary = isBinaryStr(args[0].GetType())
+	return sig.setSelf(sig), nil
 }
 
 type builtinCharLengthSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
+
+	// binary indicates that the length is counted in bytes rather than characters.
+	binary bool
 }
 
-// eval evals a builtinCharLengthSig.
+// evalInt evals CHAR_LENGTH(str), the length is counted in characters, and a binary string counts its bytes.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_char-length
-func (b *builtinCharLengthSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinCharLengthSig) evalInt(row []types.Datum) (int64, bool, error) {
+	str, isNull, err := evalStringByValue(b.args[0], row)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	switch args[0].Kind() {
-	case types.KindNull:
-		return d, nil
-	default:
-		s, err := args[0].ToString()
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		r := []rune(s)
-		d.SetInt64(int64(len(r)))
-		return d, nil
+	if b.binary {
+		return int64(len(str)), false, nil
 	}
+	return int64(utf8.RuneCountInString(str)), false, nil
 }
 
 type findInSetFunctionClass struct {
@@ -1352,48 +1375,42 @@ type findInSetFunctionClass struct {
 }
 
 func (c *findInSetFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinFindInSetSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinFindInSetSig{baseIntBuiltinFunc: baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	caseInsensitive, err := isExplicitCICollation(c.funcName, args[0], args[1])
+	sig.caseInsensitive = caseInsensitive
+	return sig.setSelf(sig), errors.Trace(err)
 }
 
 type builtinFindInSetSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
+
+	caseInsensitive bool
 }
 
-// eval evals a builtinFindInSetSig.
+// evalInt evals a builtinFindInSetSig, it returns the 1-based position of args[0] in the comma-separated
+// list args[1], or 0 if it isn't in the list. The elements of the list aren't trimmed, and a string
+// containing a comma is never found.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_find-in-set
 // TODO: This function can be optimized by using bit arithmetic when the first argument is
 // a constant string and the second is a column of type SET.
-func (b *builtinFindInSetSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinFindInSetSig) evalInt(row []types.Datum) (int64, bool, error) {
+	strs, isNull, err := EvalArgsString(b.args, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	// args[0] -> Str
-	// args[1] -> StrList
-	if args[0].IsNull() || args[1].IsNull() {
-		return
-	}
-
-	str, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	strlst, err := args[1].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-
-	d.SetInt64(0)
-	if len(strlst) == 0 {
-		return
+	str, strlst := strs[0], strs[1]
+	if len(strlst) == 0 || strings.Contains(str, ",") {
+		return 0, false, nil
 	}
 	for i, s := range strings.Split(strlst, ",") {
-		if s == str {
-			d.SetInt64(int64(i + 1))
-			return
+		if s == str || (b.caseInsensitive && strings.EqualFold(s, str)) {
+			return int64(i + 1), false, nil
 		}
 	}
-	return
+	return 0, false, nil
 }
 
 type fieldFunctionClass struct {
@@ -1536,60 +1553,45 @@ type octFunctionClass struct {
 }
 
 func (c *octFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinOctSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return &builtinOctStringSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}, errors.Trace(err)
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	var sig builtinFunc
+	if args[0].GetType().ToClass() == types.ClassInt {
+		sig = &builtinOctIntSig{baseStringBuiltinFunc{bf}}
+	} else {
+		sig = &builtinOctStringSig{baseStringBuiltinFunc{bf}}
+	}
+	return sig.setSelf(sig), nil
 }
 
-type builtinOctSig struct {
-	baseBuiltinFunc
+type builtinOctIntSig struct {
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinOctSig.
+// evalString evals OCT(N) of an integer N, a negative N is converted as a 64-bit unsigned integer.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_oct
-func (b *builtinOctSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	var (
-		negative bool
-		overflow bool
-	)
-	arg := args[0]
-	if arg.IsNull() {
-		return d, nil
-	}
-	n, err := arg.ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	n = getValidPrefix(strings.TrimSpace(n), 10)
-	if len(n) == 0 {
-		d.SetString("0")
-		return d, nil
-	}
-	if n[0] == '-' {
-		negative = true
-		n = n[1:]
-	}
-	val, err := strconv.ParseUint(n, 10, 64)
-	if err != nil {
-		if numError, ok := err.(*strconv.NumError); ok {
-			if numError.Err == strconv.ErrRange {
-				overflow = true
-			} else {
-				return d, errors.Trace(err)
-			}
-		} else {
-			return d, errors.Trace(err)
-		}
+func (b *builtinOctIntSig) evalString(row []types.Datum) (string, bool, error) {
+	val, isNull, err := b.args[0].EvalInt(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
+	return strconv.FormatUint(uint64(val), 8), false, nil
+}
 
-	if negative && !overflow {
-		val = -val
+type builtinOctStringSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals OCT(N), which is the same as CONV(N, 10, 8).
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_oct
+func (b *builtinOctStringSig) evalString(row []types.Datum) (string, bool, error) {
+	n, isNull, err := b.args[0].EvalString(row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	str := strconv.FormatUint(val, 8)
-	d.SetString(str)
-	return d, nil
+	return convertBase(n, 10, 8), false, nil
 }
 
 type ordFunctionClass struct {
@@ -1844,50 +1846,117 @@ type formatFunctionClass struct {
 }
 
 func (c *formatFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinFormatSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if len(args) == 3 {
+		sig := &builtinFormatWithLocaleSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+		return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+	}
+	sig := &builtinFormatSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
+}
+
+// formatMaxDecimals limits the maximum number of decimal digits for result of
+// function `format`, this value is same as `FORMAT_MAX_DECIMALS` in MySQL source code.
+const formatMaxDecimals int64 = 30
+
+// evalNumDecArgsForFormat evaluates first 2 arguments, i.e, x and d, for function `format`.
+// The number x is rounded half up to d decimal places, and d is clamped to [0, formatMaxDecimals].
+func evalNumDecArgsForFormat(f builtinFunc, row []types.Datum) (string, string, bool, error) {
+	args := f.getArgs()
+	sc := f.getCtx().GetSessionVars().StmtCtx
+	var xDec *types.MyDecimal
+	if args[0].GetType().ToClass() == types.ClassString {
+		xStr, isNull, err := args[0].EvalString(row, sc)
+		if isNull || err != nil {
+			return "", "", isNull, errors.Trace(err)
+		}
+		xDec = parseDecimalPrefix(xStr)
+	} else {
+		var isNull bool
+		var err error
+		xDec, isNull, err = args[0].EvalDecimal(row, sc)
+		if isNull || err != nil {
+			return "", "", isNull, errors.Trace(err)
+		}
+	}
+	var d int64
+	if args[1].GetType().ToClass() == types.ClassString {
+		dStr, isNull, err := args[1].EvalString(row, sc)
+		if isNull || err != nil {
+			return "", "", isNull, errors.Trace(err)
+		}
+		d, err = parseDecimalPrefix(dStr).ToInt()
+		if err != nil && !terror.ErrorEqual(err, types.ErrTruncated) {
+			return "", "", false, errors.Trace(err)
+		}
+	} else {
+		var isNull bool
+		var err error
+		d, isNull, err = args[1].EvalInt(row, sc)
+		if isNull || err != nil {
+			return "", "", isNull, errors.Trace(err)
+		}
+	}
+	if d < 0 {
+		d = 0
+	} else if d > formatMaxDecimals {
+		d = formatMaxDecimals
+	}
+	if err := xDec.Round(xDec, int(d), types.ModeHalfEven); err != nil {
+		return "", "", false, errors.Trace(err)
+	}
+	return string(xDec.ToString()), strconv.FormatInt(d, 10), false, nil
 }
 
-type builtinFormatSig struct {
-	baseBuiltinFunc
+// parseDecimalPrefix parses the longest valid decimal prefix of s, an invalid number is treated as 0.
+func parseDecimalPrefix(s string) *types.MyDecimal {
+	dec := new(types.MyDecimal)
+	if err := dec.FromString([]byte(s)); err != nil {
+		dec = new(types.MyDecimal)
+	}
+	return dec
 }
 
-// eval evals a builtinFormatSig.
-// See https://dev.mysql.com/doc/refman/5.6/en/string-functions.html#function_format
-func (b *builtinFormatSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	if args[0].IsNull() {
-		d.SetNull()
-		return
+type builtinFormatWithLocaleSig struct {
+	baseStringBuiltinFunc
+}
+
+// evalString evals FORMAT(X,D,locale).
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_format
+func (b *builtinFormatWithLocaleSig) evalString(row []types.Datum) (string, bool, error) {
+	x, d, isNull, err := evalNumDecArgsForFormat(b, row)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-	arg0, err := args[0].ToString()
+	sc := b.ctx.GetSessionVars().StmtCtx
+	locale, isNull, err := b.args[2].EvalString(row, sc)
 	if err != nil {
-		return d, errors.Trace(err)
+		return "", false, errors.Trace(err)
+	}
+	if isNull {
+		sc.AppendWarning(errUnknownLocale.GenByArgs("NULL"))
+		locale = "en_US"
 	}
-	arg1, err := args[1].ToString()
+	formatString, err := mysql.GetLocaleFormatFunction(locale)(x, d)
 	if err != nil {
-		return d, errors.Trace(err)
+		sc.AppendWarning(errUnknownLocale.GenByArgs(locale))
+		formatString, err = mysql.GetLocaleFormatFunction("en_US")(x, d)
 	}
-	var arg2 string
+	return formatString, false, errors.Trace(err)
+}
 
-	if len(args) == 2 {
-		arg2 = "en_US"
-	} else if len(args) == 3 {
-		arg2, err = args[2].ToString()
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-	}
+type builtinFormatSig struct {
+	baseStringBuiltinFunc
+}
 
-	formatString, err := mysql.GetLocaleFormatFunction(arg2)(arg0, arg1)
-	if err != nil {
-		return d, errors.Trace(err)
+// evalString evals FORMAT(X,D).
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_format
+func (b *builtinFormatSig) evalString(row []types.Datum) (string, bool, error) {
+	x, d, isNull, err := evalNumDecArgsForFormat(b, row)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-
-	d.SetString(formatString)
-	return d, nil
+	formatString, err := mysql.GetLocaleFormatFunction("en_US")(x, d)
+	return formatString, false, errors.Trace(err)
 }
 
 type fromBase64FunctionClass struct {
@@ -1895,34 +1964,35 @@ type fromBase64FunctionClass struct {
 }
 
 func (c *fromBase64FunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinFromBase64Sig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinFromBase64Sig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinFromBase64Sig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinFromBase64Sig.
-// See https://dev.mysql.com/doc/refman/5.6/en/string-functions.html#function_from-base64
-func (b *builtinFromBase64Sig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	str, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	str = strings.Replace(str, "\t", "", -1)
-	str = strings.Replace(str, " ", "", -1)
+// evalString evals a builtinFromBase64Sig.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_from-base64
+func (b *builtinFromBase64Sig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
+	}
+	str = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f', '\v':
+			return -1
+		}
+		return r
+	}, str)
 	result, err := base64.StdEncoding.DecodeString(str)
 	if err != nil {
-		return d, errors.Trace(err)
+		// An invalid base64 string results in null.
+		return "", true, nil
 	}
-	// Set the result to be of type []byte
-	d.SetBytes(result)
-	return d, nil
-
+	return string(result), false, nil
 }
 
 type toBase64FunctionClass struct {
@@ -1930,36 +2000,49 @@ type toBase64FunctionClass struct {
 }
 
 func (c *toBase64FunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinToBase64Sig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinToBase64Sig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinToBase64Sig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinToBase64Sig.
+// base64LineLen is the max length of a line in the output of TO_BASE64.
+const base64LineLen = 76
+
+// base64EncodedLen returns the length of the result of TO_BASE64 for a string of n bytes,
+// including the newlines added after every base64LineLen characters.
+func base64EncodedLen(n int) int {
+	encLen := base64.StdEncoding.EncodedLen(n)
+	if encLen == 0 {
+		return 0
+	}
+	return encLen + (encLen-1)/base64LineLen
+}
+
+// evalString evals a builtinToBase64Sig.
 // See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_to-base64
-func (b *builtinToBase64Sig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinToBase64Sig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", isNull, errors.Trace(err)
 	}
-	str, err := args[0].ToString()
+	maxAllowedPacket, err := getMaxAllowedPacket(b.ctx)
 	if err != nil {
-		return d, errors.Trace(err)
+		return "", false, errors.Trace(err)
 	}
-	//encode
-	strBytes := []byte(str)
-	result := base64.StdEncoding.EncodeToString(strBytes)
-	//A newline is added after each 76 characters of encoded output to divide long output into multiple lines.
-	count := len(result)
-	if count > 76 {
-		resultArr := splitToSubN(result, 76)
-		result = strings.Join(resultArr, "\n")
+	if uint64(base64EncodedLen(len(str))) > maxAllowedPacket {
+		sc.AppendWarning(errWarnAllowedPacketOverflowed.GenByArgs("to_base64", maxAllowedPacket))
+		return "", true, nil
 	}
-	// Set the result to be of type string
-	d.SetString(result)
-	return d, nil
+	result := base64.StdEncoding.EncodeToString([]byte(str))
+	// A newline is added after each 76 characters of encoded output to divide long output into multiple lines.
+	if len(result) > base64LineLen {
+		result = strings.Join(splitToSubN(result, base64LineLen), "\n")
+	}
+	return result, false, nil
 }
 
 // splitToSubN splits a string every n runes into a string[]
@@ -1978,61 +2061,71 @@ type insertFuncFunctionClass struct {
 }
 
 func (c *insertFuncFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinInsertFuncSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinInsertFuncSig{baseStringBuiltinFunc: baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	if err := c.verifyArgs(args); err != nil {
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	sig.binary = isBinaryStr(args[0].GetType())
+	return sig.setSelf(sig), nil
 }
 
 type builtinInsertFuncSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
+
+	// binary indicates that the positions and the length are counted in bytes rather than characters.
+	binary bool
 }
 
-// eval evals a builtinInsertFuncSig.
-// See https://dev.mysql.com/doc/refman/5.6/en/string-functions.html#function_insert
-func (b *builtinInsertFuncSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+// evalString evals INSERT(str,pos,len,newstr).
+// str is returned unchanged if pos is out of range, and the rest of str is replaced if len is out of range.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_insert
+func (b *builtinInsertFuncSig) evalString(row []types.Datum) (string, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	str, isNull, err := b.args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-
-	// Returns NULL if any argument is NULL.
-	if args[0].IsNull() || args[1].IsNull() || args[2].IsNull() || args[3].IsNull() {
-		return
+	pos, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-
-	str0, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
+	length, isNull, err := b.args[2].EvalInt(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	str := []rune(str0)
-	strLen := len(str)
-
-	posInt64, err := args[1].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
+	newStr, isNull, err := b.args[3].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	pos := int(posInt64)
 
-	lenInt64, err := args[2].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
+	var runes []rune
+	n := int64(len(str))
+	if !b.binary {
+		runes = []rune(str)
+		n = int64(len(runes))
 	}
-	length := int(lenInt64)
-
-	newstr, err := args[3].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
+	if pos < 1 || pos > n {
+		return str, false, nil
 	}
-
-	var s string
-	if pos < 1 || pos > strLen {
-		s = str0
-	} else if length > strLen-pos+1 || length < 0 {
-		s = string(str[0:pos-1]) + newstr
+	if length < 0 || length > n-pos+1 {
+		length = n - pos + 1
+	}
+	var head, tail string
+	if b.binary {
+		head, tail = str[:pos-1], str[pos-1+length:]
 	} else {
-		s = string(str[0:pos-1]) + newstr + string(str[pos+length-1:])
+		head, tail = string(runes[:pos-1]), string(runes[pos-1+length:])
 	}
 
-	d.SetString(s)
-	return d, nil
+	maxAllowedPacket, err := getMaxAllowedPacket(b.ctx)
+	if err != nil {
+		return "", true, errors.Trace(err)
+	}
+	if uint64(len(head)+len(newStr)+len(tail)) > maxAllowedPacket {
+		sc.AppendWarning(errWarnAllowedPacketOverflowed.GenByArgs("insert", maxAllowedPacket))
+		return "", true, nil
+	}
+	return head + newStr + tail, false, nil
 }
 
 type instrFunctionClass struct {
@@ -2116,47 +2209,73 @@ type lpadFunctionClass struct {
 }
 
 func (c *lpadFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinLpadSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinLpadSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinLpadSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinLpadSig.
-// See https://dev.mysql.com/doc/refman/5.6/en/string-functions.html#function_lpad
-func (b *builtinLpadSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+// evalString evals a builtinLpadSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/string-functions.html#function_lpad
+func (b *builtinLpadSig) evalString(row []types.Datum) (string, bool, error) {
+	return evalPad(b.args, row, b.ctx.GetSessionVars().StmtCtx, true)
+}
+
+// evalPad evaluates LPAD(str,len,padstr) and RPAD(str,len,padstr). The length is counted in
+// characters, unless str or padstr is a binary string, in which case it is counted in bytes.
+// The result is null if len is null or negative, or if padding is needed but padstr is empty.
+func evalPad(args []Expression, row []types.Datum, sc *variable.StatementContext, left bool) (string, bool, error) {
+	str, isNull, err := args[0].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	// LPAD(str,len,padstr)
-	// args[0] string, args[1] int, args[2] string
-	str, err := args[0].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
+	length, isNull, err := args[1].EvalInt(row, sc)
+	if isNull || err != nil || length < 0 {
+		return "", true, errors.Trace(err)
 	}
-	length, err := args[1].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-	if err != nil {
-		return d, errors.Trace(err)
+	padStr, isNull, err := args[2].EvalString(row, sc)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
 	}
-	l := int(length)
 
-	padStr, err := args[2].ToString()
-	if err != nil {
-		return d, errors.Trace(err)
+	if isBinaryStr(args[0].GetType()) || isBinaryStr(args[2].GetType()) {
+		l := int(length)
+		if l <= len(str) {
+			return str[:l], false, nil
+		}
+		if padStr == "" {
+			return "", true, nil
+		}
+		tailLen := l - len(str)
+		pad := strings.Repeat(padStr, tailLen/len(padStr)+1)[:tailLen]
+		if left {
+			return pad + str, false, nil
+		}
+		return str + pad, false, nil
 	}
 
-	if l < 0 || (len(str) < l && padStr == "") {
-		return d, nil
+	runes, padRunes := []rune(str), []rune(padStr)
+	l := int(length)
+	if l <= len(runes) {
+		return string(runes[:l]), false, nil
 	}
-
-	tailLen := l - len(str)
-	if tailLen > 0 {
-		repeatCount := tailLen/len(padStr) + 1
-		str = strings.Repeat(padStr, repeatCount)[:tailLen] + str
+	if len(padRunes) == 0 {
+		return "", true, nil
 	}
-	d.SetString(str[:l])
+	tailLen := l - len(runes)
+	pad := string([]rune(strings.Repeat(padStr, tailLen/len(padRunes)+1))[:tailLen])
+	if left {
+		return pad + str, false, nil
+	}
+	return str + pad, false, nil
+}
 
-	return d, nil
+// isBinaryStr returns whether a string type uses the binary charset, so that its length is counted in bytes.
+func isBinaryStr(tp *types.FieldType) bool {
+	if tp == nil || tp.Charset != charset.CharsetBin {
+		return false
+	}
+	return types.IsTypeBlob(tp.Tp) || types.IsTypeChar(tp.Tp) || types.IsTypeVarchar(tp.Tp)
 }
@@ -14,6 +14,7 @@
 package expression
 
 import (
+	"math"
 	"strings"
 	"time"
 
@@ -21,6 +22,10 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -94,6 +99,41 @@ func (s *testEvaluatorSuite) TestASCII(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testEvaluatorSuite) TestASCIIAndOrd(c *C) {
+	defer testleak.AfterTest(c)()
+	// ASCII returns the first byte while ORD returns the first character for multi-byte strings.
+	f, err := funcs[ast.ASCII].getFunction(datumsToConstants(types.MakeDatums("é")), s.ctx)
+	c.Assert(err, IsNil)
+	v, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.GetInt64(), Equals, int64(0xc3))
+
+	f, err = funcs[ast.Ord].getFunction(datumsToConstants(types.MakeDatums("é")), s.ctx)
+	c.Assert(err, IsNil)
+	v, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.GetInt64(), Equals, int64(0xc3a9))
+
+	// Numbers and times are converted to strings first.
+	for _, t := range []struct {
+		input    interface{}
+		expected int64
+	}{
+		{-1, '-'},
+		{0.5, '0'},
+		{types.NewDecFromFloatForTest(7.2), '7'},
+		{uint64(9), '9'},
+		{types.Time{Time: types.FromDate(2017, 1, 1, 0, 0, 0, 0), Type: mysql.TypeDatetime}, '2'},
+		{types.Duration{Duration: time.Hour}, '0'},
+	} {
+		f, err = funcs[ast.ASCII].getFunction(datumsToConstants(types.MakeDatums(t.input)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v.GetInt64(), Equals, t.expected)
+	}
+}
+
 func (s *testEvaluatorSuite) TestConcat(c *C) {
 	defer testleak.AfterTest(c)()
 	args := []interface{}{nil}
@@ -149,6 +189,26 @@ func (s *testEvaluatorSuite) TestConcatWS(c *C) {
 	c.Assert(err, IsNil)
 	v, err = f.eval(nil)
 	c.Assert(err, NotNil)
+
+	tests := []struct {
+		args   []interface{}
+		isNull bool
+		res    string
+	}{
+		{[]interface{}{",", "a", nil, "c"}, false, "a,c"},
+		{[]interface{}{nil, "a"}, true, ""},
+		{[]interface{}{",", nil, nil}, false, ""},
+		{[]interface{}{",", nil, "a"}, false, "a"},
+		{[]interface{}{"", "a", 1, 2.5}, false, "a12.5"},
+	}
+	for _, t := range tests {
+		f, err = fc.getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+		c.Assert(err, IsNil)
+		res, isNull, err := f.evalString(nil)
+		c.Assert(err, IsNil)
+		c.Assert(isNull, Equals, t.isNull)
+		c.Assert(res, Equals, t.res)
+	}
 }
 
 func (s *testEvaluatorSuite) TestLeft(c *C) {
@@ -329,6 +389,50 @@ func (s *testEvaluatorSuite) TestStrcmp(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(d, testutil.DatumEquals, t["Expect"][0])
 	}
+
+	newStrCol := func(collation string) *Column {
+		ft := types.NewFieldType(mysql.TypeVarString)
+		ft.Charset, ft.Collate = charset.CharsetUTF8, collation
+		return &Column{Index: 0, RetType: ft}
+	}
+	newStrConst := func(str, collation string) *Constant {
+		ft := types.NewFieldType(mysql.TypeVarString)
+		ft.Charset, ft.Collate = charset.CharsetUTF8, collation
+		return &Constant{Value: types.NewStringDatum(str), RetType: ft}
+	}
+	collate := func(arg Expression, collation string) Expression {
+		ft := *arg.GetType()
+		ft.Collate = collation
+		f, err := NewFunction(s.ctx, ast.Collate, &ft, arg, datumsToConstants(types.MakeDatums(collation))[0])
+		c.Assert(err, IsNil)
+		return f
+	}
+	collationTbl := []struct {
+		args   []Expression
+		row    []types.Datum
+		expect int64
+	}{
+		{[]Expression{newStrConst("A", "utf8_bin"), newStrConst("a", "utf8_bin")}, nil, -1},
+		// The strings are compared case insensitively only under a "_ci" collation set by COLLATE, as '=' does.
+		{[]Expression{newStrConst("A", "utf8_general_ci"), newStrConst("a", "utf8_general_ci")}, nil, -1},
+		{[]Expression{collate(newStrConst("A", "utf8_bin"), "utf8_general_ci"), newStrConst("a", "utf8_bin")}, nil, 0},
+		{[]Expression{collate(newStrConst("B", "utf8_bin"), "utf8_general_ci"), newStrConst("a", "utf8_bin")}, nil, 1},
+		{[]Expression{newStrCol("utf8_general_ci"), newStrConst("a", "utf8_bin")}, types.MakeDatums("A"), -1},
+		{[]Expression{collate(newStrCol("utf8_bin"), "utf8_general_ci"), newStrConst("a", "utf8_bin")}, types.MakeDatums("A"), 0},
+		{[]Expression{collate(newStrCol("utf8_general_ci"), "utf8_bin"), newStrConst("a", "utf8_general_ci")}, types.MakeDatums("A"), -1},
+	}
+	for _, t := range collationTbl {
+		f, err := funcs[ast.Strcmp].getFunction(t.args, s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(t.row)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%v", t.args))
+	}
+
+	// Columns with different collations can't be compared.
+	_, err := funcs[ast.Strcmp].getFunction([]Expression{newStrCol("utf8_bin"), newStrCol("utf8_general_ci")}, s.ctx)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
+	c.Assert(err.Error(), Matches, ".*Illegal mix of collations.*")
 }
 
 func (s *testEvaluatorSuite) TestReplace(c *C) {
@@ -412,26 +516,48 @@ func (s *testEvaluatorSuite) TestSubstring(c *C) {
 		c.Assert(r1.Kind(), Equals, types.KindString)
 		c.Assert(r.GetString(), Equals, r1.GetString())
 	}
-	errTbl := []struct {
+	// The positions and lengths are converted to integers, the positions of a non-binary string are
+	// counted in characters.
+	convTbl := []struct {
+		fn     string
 		str    interface{}
 		pos    interface{}
 		len    interface{}
-		result string
+		result interface{}
 	}{
-		{"foobarbar", "4", -1, "barbar"},
-		{"Quadratically", 5, "6", "ratica"},
-	}
-	for _, v := range errTbl {
-		fc := funcs[ast.Substring]
+		{ast.Substring, "foobarbar", "4", -1, "barbar"},
+		{ast.Substring, "Quadratically", 5, "6", "ratica"},
+		{ast.Substr, "foobar", -3, -1, "bar"},
+		{ast.Substr, "foobar", 2, 3, "oob"},
+		{ast.Substr, "foobar", 0, -1, ""},
+		{ast.Substr, "foobar", 0, 3, ""},
+		{ast.Substr, "foobar", 7, -1, ""},
+		{ast.Substr, "foobar", -7, -1, ""},
+		{ast.Substr, "foobar", 2.5, -1, "obar"},
+		{ast.Mid, "foobar", -3, 2, "ba"},
+		{ast.Mid, "你好世界", 2, 2, "好世"},
+		{ast.Substring, "你好世界", -1, -1, "界"},
+		{ast.Substring, "a你b好", -3, 2, "你b"},
+		{ast.Substring, []byte("你好世界"), 4, 3, "好"},
+		{ast.Substring, []byte("你好世界"), -3, -1, "界"},
+		{ast.Substring, nil, 1, -1, nil},
+		{ast.Substring, "foobar", nil, -1, nil},
+		{ast.Substring, "foobar", 1, nil, nil},
+	}
+	for _, v := range convTbl {
 		datums := types.MakeDatums(v.str, v.pos)
 		if v.len != -1 {
 			datums = append(datums, types.NewDatum(v.len))
 		}
-		args := datumsToConstants(datums)
-		f, err := fc.getFunction(args, s.ctx)
+		f, err := funcs[v.fn].getFunction(datumsToConstants(datums), s.ctx)
 		c.Assert(err, IsNil)
-		_, err = f.eval(nil)
-		c.Assert(err, NotNil)
+		r, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		if v.result == nil {
+			c.Assert(r.IsNull(), IsTrue, Commentf("%s(%v, %v, %v)", v.fn, v.str, v.pos, v.len))
+			continue
+		}
+		c.Assert(r.GetString(), Equals, v.result, Commentf("%s(%v, %v, %v)", v.fn, v.str, v.pos, v.len))
 	}
 }
 
@@ -499,6 +625,14 @@ func (s *testEvaluatorSuite) TestSubstringIndex(c *C) {
 		{"www.mysql.com", "", 1, ""},
 		{"www.mysql.com", "", -1, ""},
 		{"www.mysql.com", "", 0, ""},
+
+		{"a.b.c.d", ".", -2, "c.d"},
+		{"a.b.c.d", ".", 2, "a.b"},
+		{"a.b.c.d", ".", math.MinInt64, "a.b.c.d"},
+		{"a.b.c.d", ".", math.MaxInt64, "a.b.c.d"},
+		{"a::b:c::d", "::", -2, "b:c::d"},
+		{"a::b:c::d", "::", 2, "a::b:c"},
+		{"a::b:c::d", ":", -2, ":d"},
 	}
 	for _, v := range tbl {
 		fc := funcs[ast.SubstringIndex]
@@ -527,7 +661,7 @@ func (s *testEvaluatorSuite) TestSubstringIndex(c *C) {
 		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(v.str, v.delim, v.count)), s.ctx)
 		c.Assert(err, IsNil)
 		r, err := f.eval(nil)
-		c.Assert(err, NotNil)
+		c.Assert(err, IsNil)
 		c.Assert(r.Kind(), Equals, types.KindNull)
 	}
 }
@@ -596,7 +730,11 @@ func (s *testEvaluatorSuite) TestLocate(c *C) {
 		{[]interface{}{"好世", "你好世界"}, 2},
 		{[]interface{}{"界面", "你好世界"}, 0},
 		{[]interface{}{"b", "中a英b文"}, 4},
-		{[]interface{}{"BaR", "foobArbar"}, 4},
+		{[]interface{}{"ΣΑ", "λόγοςσας"}, 6},
+		{[]interface{}{[]byte("文"), "中a英b文"}, 9},
+		// The strings are compared binarily unless a "_ci" collation is set by COLLATE.
+		{[]interface{}{"BaR", "foobArbar"}, 0},
+		{[]interface{}{"bAr", "foobArbar"}, 4},
 		{[]interface{}{[]byte("BaR"), "foobArbar"}, 0},
 		{[]interface{}{"BaR", []byte("foobArbar")}, 0},
 		{[]interface{}{nil, "foobar"}, nil},
@@ -626,10 +764,25 @@ func (s *testEvaluatorSuite) TestLocate(c *C) {
 		{[]interface{}{"A", "大A写的A", 1}, 2},
 		{[]interface{}{"A", "大A写的A", 2}, 2},
 		{[]interface{}{"A", "大A写的A", 3}, 5},
-		{[]interface{}{"bAr", "foobarBaR", 5}, 7},
+		{[]interface{}{"bAr", "foobarBaR", 5}, 0},
+		{[]interface{}{"BaR", "foobarBaR", 5}, 7},
 		{[]interface{}{[]byte("bAr"), "foobarBaR", 5}, 0},
 		{[]interface{}{"bAr", []byte("foobarBaR"), 5}, 0},
 		{[]interface{}{"bAr", []byte("foobarbAr"), 5}, 7},
+		{[]interface{}{"A", "大A写的A", -1}, 0},
+		{[]interface{}{"", "foobar", 7}, 7},
+		{[]interface{}{"", "foobar", 8}, 0},
+		{[]interface{}{"", "你好", 3}, 3},
+		{[]interface{}{"", "你好", 4}, 0},
+		{[]interface{}{"世界", "你好世界世界", 4}, 5},
+		{[]interface{}{"界", "你好世界", 5}, 0},
+		{[]interface{}{"é", "ÉtÉ", 2}, 0},
+		{[]interface{}{"É", "ÉtÉ", 2}, 3},
+		{[]interface{}{"bar", "foobar", nil}, nil},
+		// The positions of binary strings are counted in bytes.
+		{[]interface{}{[]byte("世"), []byte("你好世界"), 1}, 7},
+		{[]interface{}{"世", []byte("你好世界"), 8}, 0},
+		{[]interface{}{[]byte("界"), "你好世界", 4}, 10},
 	}
 	Dtbl2 := tblToDtbl(tbl2)
 	for i, t := range Dtbl2 {
@@ -678,6 +831,40 @@ func (s *testEvaluatorSuite) TestLocate(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(r.Kind(), Equals, types.KindNull)
 	}
+
+	// The search is case insensitive only under a "_ci" collation set by COLLATE, the positions are counted in
+	// characters unless the charset is binary.
+	for _, t := range []struct {
+		chs, collate string
+		explicit     string
+		subStr       string
+		want         int64
+	}{
+		{charset.CharsetUTF8, "utf8_general_ci", "", "世B", 0},
+		{charset.CharsetUTF8, "utf8_general_ci", "", "世b", 3},
+		{charset.CharsetUTF8, "utf8_bin", "utf8_general_ci", "世B", 3},
+		{charset.CharsetUTF8, "utf8_general_ci", "utf8_bin", "世B", 0},
+		{charset.CharsetUTF8, "utf8_bin", "", "世B", 0},
+		{charset.CharsetUTF8, "utf8_bin", "", "世b", 3},
+		{charset.CharsetUTF8MB4, "utf8mb4_bin", "", "b界", 4},
+		{charset.CharsetBin, charset.CollationBin, "", "世B", 0},
+		{charset.CharsetBin, charset.CollationBin, "", "世b", 7},
+	} {
+		var col Expression = &Column{RetType: types.NewFieldType(mysql.TypeVarString)}
+		col.GetType().Charset, col.GetType().Collate = t.chs, t.collate
+		if t.explicit != "" {
+			ft := *col.GetType()
+			ft.Collate = t.explicit
+			var err error
+			col, err = NewFunction(s.ctx, ast.Collate, &ft, col, datumsToConstants(types.MakeDatums(t.explicit))[0])
+			c.Assert(err, IsNil)
+		}
+		f, err := instr.getFunction([]Expression{datumsToConstants(types.MakeDatums(t.subStr))[0], col}, s.ctx)
+		c.Assert(err, IsNil)
+		r, err := f.eval(types.MakeDatums("你好世b界"))
+		c.Assert(err, IsNil)
+		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.want), Commentf("%v", t))
+	}
 }
 
 func (s *testEvaluatorSuite) TestTrim(c *C) {
@@ -732,7 +919,7 @@ func (s *testEvaluatorSuite) TestHexFunc(c *C) {
 	defer testleak.AfterTest(c)()
 	tbl := []struct {
 		Input  interface{}
-		Expect string
+		Expect interface{}
 	}{
 		{12, "C"},
 		{12.3, "C"},
@@ -742,6 +929,13 @@ func (s *testEvaluatorSuite) TestHexFunc(c *C) {
 		{"12", "3132"},
 		{0x12, "12"},
 		{"", ""},
+		{255, "FF"},
+		{uint64(18446744073709551615), "FFFFFFFFFFFFFFFF"},
+		{types.NewDecFromFloatForTest(254.5), "FF"},
+		{"\x00\x01\xff", "0001FF"},
+		{"abc", "616263"},
+		{"中", "E4B8AD"},
+		{nil, nil},
 	}
 
 	dtbl := tblToDtbl(tbl)
@@ -759,11 +953,18 @@ func (s *testEvaluatorSuite) TestUnhexFunc(c *C) {
 	defer testleak.AfterTest(c)()
 	tbl := []struct {
 		Input  interface{}
-		Expect string
+		Expect interface{}
 	}{
 		{"4D7953514C", "MySQL"},
 		{"31323334", "1234"},
 		{"", ""},
+		{"616263", "abc"},
+		{"e4b8ad", "中"},
+		{1234, "\x12\x34"},
+		{"123", nil},
+		{"4G", nil},
+		{"zz", nil},
+		{nil, nil},
 	}
 
 	dtbl := tblToDtbl(tbl)
@@ -776,6 +977,14 @@ func (s *testEvaluatorSuite) TestUnhexFunc(c *C) {
 		c.Assert(d, testutil.DatumEquals, t["Expect"][0])
 
 	}
+
+	// UNHEX(HEX(str)) is str.
+	for _, str := range []string{"abc", "", "中文", "\x00\xff"} {
+		hexFunc := newFunction(ast.Hex, datumsToConstants(types.MakeDatums(str))[0])
+		d, err := newFunction(ast.Unhex, hexFunc).Eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.GetString(), Equals, str)
+	}
 }
 
 func (s *testEvaluatorSuite) TestRpad(c *C) {
@@ -793,6 +1002,10 @@ func (s *testEvaluatorSuite) TestRpad(c *C) {
 		{"hi", 5, "", nil},
 		{"hi", 5, "ab", "hiaba"},
 		{"hi", 6, "ab", "hiabab"},
+		{"你好", 3, "啊", "你好啊"},
+		{"你好", 1, "?", "你"},
+		{"你好", 5, "ab", "你好aba"},
+		{"hi", 4, "你好啊", "hi你好"},
 	}
 	fc := funcs[ast.Rpad]
 	for _, test := range tests {
@@ -908,6 +1121,40 @@ func (s *testEvaluatorSuite) TestCharLength(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(r, testutil.DatumEquals, types.NewDatum(v.result))
 	}
+
+	// LENGTH counts the bytes while CHAR_LENGTH counts the characters, unless the string is binary.
+	for _, t := range []struct {
+		str        string
+		charset    string
+		length     int64
+		charLength int64
+	}{
+		{"a中文b", charset.CharsetUTF8, 8, 4},
+		{"ß", charset.CharsetUTF8, 2, 1},
+		{"a中文b", charset.CharsetBin, 8, 8},
+	} {
+		arg := &Constant{Value: types.NewDatum(t.str), RetType: &types.FieldType{Tp: mysql.TypeVarString, Charset: t.charset}}
+		f, err := funcs[ast.Length].getFunction([]Expression{arg}, s.ctx)
+		c.Assert(err, IsNil)
+		r, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.length))
+		f, err = funcs[ast.CharLength].getFunction([]Expression{arg}, s.ctx)
+		c.Assert(err, IsNil)
+		r, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.charLength))
+	}
+
+	// A duration is in string class, it's counted by its value.
+	arg := datumsToConstants(types.MakeDatums(types.Duration{Duration: time.Hour}))
+	for _, name := range []string{ast.Length, ast.CharLength} {
+		f, err := funcs[name].getFunction(arg, s.ctx)
+		c.Assert(err, IsNil)
+		r, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r, testutil.DatumEquals, types.NewDatum(int64(8)), Commentf("%s", name))
+	}
 }
 
 func (s *testEvaluatorSuite) TestFindInSet(c *C) {
@@ -937,6 +1184,53 @@ func (s *testEvaluatorSuite) TestFindInSet(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.ret))
 	}
+
+	newStrCol := func(collation string) *Column {
+		ft := types.NewFieldType(mysql.TypeVarString)
+		ft.Charset, ft.Collate = charset.CharsetUTF8, collation
+		return &Column{Index: 0, RetType: ft}
+	}
+	newStrConst := func(str, collation string) *Constant {
+		ft := types.NewFieldType(mysql.TypeVarString)
+		ft.Charset, ft.Collate = charset.CharsetUTF8, collation
+		return &Constant{Value: types.NewStringDatum(str), RetType: ft}
+	}
+	collate := func(arg Expression, collation string) Expression {
+		ft := *arg.GetType()
+		ft.Collate = collation
+		f, err := NewFunction(s.ctx, ast.Collate, &ft, arg, datumsToConstants(types.MakeDatums(collation))[0])
+		c.Assert(err, IsNil)
+		return f
+	}
+	collationTbl := []struct {
+		args   []Expression
+		row    []types.Datum
+		expect int64
+	}{
+		{[]Expression{newStrConst("B", "utf8_bin"), newStrConst("a,b,B", "utf8_bin")}, nil, 3},
+		{[]Expression{newStrConst("b", "utf8_bin"), newStrConst("a,B", "utf8_bin")}, nil, 0},
+		// The strings are compared case insensitively only under a "_ci" collation set by COLLATE, as '=' does.
+		{[]Expression{newStrConst("b", "utf8_general_ci"), newStrConst("a,B", "utf8_general_ci")}, nil, 0},
+		{[]Expression{collate(newStrConst("b", "utf8_bin"), "utf8_general_ci"), newStrConst("a,B", "utf8_bin")}, nil, 2},
+		{[]Expression{collate(newStrConst("b ", "utf8_bin"), "utf8_general_ci"), newStrConst("a,B", "utf8_bin")}, nil, 0},
+		// A string containing a comma is never found, whatever the collation is.
+		{[]Expression{collate(newStrConst("a,b", "utf8_bin"), "utf8_general_ci"), newStrConst("a,b", "utf8_bin")}, nil, 0},
+		{[]Expression{newStrConst(",", "utf8_bin"), newStrConst(",,", "utf8_bin")}, nil, 0},
+		{[]Expression{newStrCol("utf8_general_ci"), newStrConst("a,b", "utf8_bin")}, types.MakeDatums("B"), 0},
+		{[]Expression{collate(newStrCol("utf8_bin"), "utf8_general_ci"), newStrConst("a,b", "utf8_bin")}, types.MakeDatums("B"), 2},
+		{[]Expression{collate(newStrCol("utf8_general_ci"), "utf8_bin"), newStrConst("a,b", "utf8_general_ci")}, types.MakeDatums("B"), 0},
+	}
+	for _, t := range collationTbl {
+		f, err := funcs[ast.FindInSet].getFunction(t.args, s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(t.row)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%v", t.args))
+	}
+
+	// Columns with different collations can't be compared.
+	_, err := funcs[ast.FindInSet].getFunction([]Expression{newStrCol("utf8_bin"), newStrCol("utf8_general_ci")}, s.ctx)
+	c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%v", err))
 }
 
 func (s *testEvaluatorSuite) TestField(c *C) {
@@ -982,6 +1276,10 @@ func (s *testEvaluatorSuite) TestLpad(c *C) {
 		{"hi", 5, "", nil},
 		{"hi", 5, "ab", "abahi"},
 		{"hi", 6, "ab", "ababhi"},
+		{"你好", 3, "啊", "啊你好"},
+		{"你好", 1, "?", "你"},
+		{"你好", 5, "ab", "aba你好"},
+		{"hi", 4, "你好啊", "你好hi"},
 	}
 	fc := funcs[ast.Lpad]
 	for _, test := range tests {
@@ -999,6 +1297,27 @@ func (s *testEvaluatorSuite) TestLpad(c *C) {
 			c.Assert(result.GetString(), Equals, expect)
 		}
 	}
+
+	// A binary string is padded and truncated in bytes.
+	binStr := datumsToConstants(types.MakeDatums("你好", 4, "?"))
+	binStr[0].GetType().Charset = charset.CharsetBin
+	binStr[0].GetType().Tp = mysql.TypeVarString
+	f, err := fc.getFunction(binStr, s.ctx)
+	c.Assert(err, IsNil)
+	result, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(result.GetString(), Equals, "你\xe5")
+	binStr[1].(*Constant).Value.SetInt64(8)
+	result, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(result.GetString(), Equals, "??你好")
+
+	// A null length yields null.
+	f, err = fc.getFunction(datumsToConstants(types.MakeDatums("hi", nil, "?")), s.ctx)
+	c.Assert(err, IsNil)
+	result, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(result.Kind(), Equals, types.KindNull)
 }
 
 func (s *testEvaluatorSuite) TestInstr(c *C) {
@@ -1114,6 +1433,32 @@ func (s *testEvaluatorSuite) TestOct(c *C) {
 	r, err := f.eval(nil)
 	c.Assert(err, IsNil)
 	c.Assert(r.IsNull(), IsTrue)
+
+	// An integer argument is converted as a 64-bit unsigned integer.
+	intTests := []struct {
+		arg      types.Datum
+		unsigned bool
+		ret      interface{}
+	}{
+		{types.NewIntDatum(-1), false, "1777777777777777777777"},
+		{types.NewIntDatum(math.MinInt64), false, "1000000000000000000000"},
+		{types.NewIntDatum(8), false, "10"},
+		{types.NewUintDatum(math.MaxUint64), true, "1777777777777777777777"},
+		{types.Datum{}, false, nil},
+	}
+	for _, t := range intTests {
+		col := &Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+		if t.unsigned {
+			col.RetType.Flag |= mysql.UnsignedFlag
+		}
+		f, err = fc.getFunction([]Expression{col}, s.ctx)
+		c.Assert(err, IsNil)
+		_, ok := f.(*builtinOctIntSig)
+		c.Assert(ok, IsTrue)
+		r, err = f.eval([]types.Datum{t.arg})
+		c.Assert(err, IsNil)
+		c.Assert(r, testutil.DatumEquals, types.NewDatum(t.ret))
+	}
 }
 
 func (s *testEvaluatorSuite) TestFormat(c *C) {
@@ -1124,7 +1469,7 @@ func (s *testEvaluatorSuite) TestFormat(c *C) {
 		locale    string
 		ret       interface{}
 	}{
-		{12332.1234561111111111111111111111111111111111111, 4, "en_US", "12,332.1234"},
+		{12332.1234561111111111111111111111111111111111111, 4, "en_US", "12,332.1235"},
 		{nil, 22, "en_US", nil},
 	}
 	formatTests1 := []struct {
@@ -1132,22 +1477,22 @@ func (s *testEvaluatorSuite) TestFormat(c *C) {
 		precision interface{}
 		ret       interface{}
 	}{
-		{12332.123456, 4, "12,332.1234"},
+		{12332.123456, 4, "12,332.1235"},
 		{12332.123456, 0, "12,332"},
 		{12332.123456, -4, "12,332"},
-		{-12332.123456, 4, "-12,332.1234"},
+		{-12332.123456, 4, "-12,332.1235"},
 		{-12332.123456, 0, "-12,332"},
 		{-12332.123456, -4, "-12,332"},
-		{"12332.123456", "4", "12,332.1234"},
-		{"12332.123456A", "4", "12,332.1234"},
-		{"-12332.123456", "4", "-12,332.1234"},
-		{"-12332.123456A", "4", "-12,332.1234"},
+		{"12332.123456", "4", "12,332.1235"},
+		{"12332.123456A", "4", "12,332.1235"},
+		{"-12332.123456", "4", "-12,332.1235"},
+		{"-12332.123456A", "4", "-12,332.1235"},
 		{"A123345", "4", "0.0000"},
 		{"-A123345", "4", "0.0000"},
 		{"-12332.123456", "A", "-12,332"},
 		{"12332.123456", "A", "12,332"},
-		{"-12332.123456", "4A", "-12,332.1234"},
-		{"12332.123456", "4A", "12,332.1234"},
+		{"-12332.123456", "4A", "-12,332.1235"},
+		{"12332.123456", "4A", "12,332.1235"},
 		{"-A12332.123456", "A", "0"},
 		{"A12332.123456", "A", "0"},
 		{"-A12332.123456", "4A", "0.0000"},
@@ -1155,6 +1500,12 @@ func (s *testEvaluatorSuite) TestFormat(c *C) {
 		{"-.12332.123456", "4A", "-0.1233"},
 		{".12332.123456", "4A", "0.1233"},
 		{"12332.1234567890123456789012345678901", 22, "12,332.1234567890123456789012"},
+		{"12332.1234567890123456789012345678901", 40, "12,332.123456789012345678901234567890"},
+		{12332.5, 0, "12,333"},
+		{-12332.5, 0, "-12,333"},
+		{"0.99995", 4, "1.0000"},
+		{999999.5, 0, "1,000,000"},
+		{12332.123456, nil, nil},
 		{nil, 22, nil},
 	}
 	formatTests2 := struct {
@@ -1162,13 +1513,13 @@ func (s *testEvaluatorSuite) TestFormat(c *C) {
 		precision interface{}
 		locale    string
 		ret       interface{}
-	}{-12332.123456, -4, "zh_CN", nil}
+	}{-12332.123456, -4, "zh_CN", "-12,332"}
 	formatTests3 := struct {
 		number    interface{}
 		precision interface{}
 		locale    string
 		ret       interface{}
-	}{"-12332.123456", "4", "de_GE", nil}
+	}{"-12332.123456", "4", "de_GE", "-12,332.1235"}
 
 	for _, tt := range formatTests {
 		fc := funcs[ast.Format]
@@ -1192,15 +1543,20 @@ func (s *testEvaluatorSuite) TestFormat(c *C) {
 	f2, err := fc2.getFunction(datumsToConstants(types.MakeDatums(formatTests2.number, formatTests2.precision, formatTests2.locale)), s.ctx)
 	c.Assert(err, IsNil)
 	r2, err := f2.eval(nil)
-	c.Assert(types.NewDatum(err), testutil.DatumEquals, types.NewDatum(errors.New("not implemented")))
+	c.Assert(err, IsNil)
 	c.Assert(r2, testutil.DatumEquals, types.NewDatum(formatTests2.ret))
 
 	fc3 := funcs[ast.Format]
 	f3, err := fc3.getFunction(datumsToConstants(types.MakeDatums(formatTests3.number, formatTests3.precision, formatTests3.locale)), s.ctx)
 	c.Assert(err, IsNil)
+	sc := s.ctx.GetSessionVars().StmtCtx
+	warnCnt := len(sc.GetWarnings())
 	r3, err := f3.eval(nil)
-	c.Assert(types.NewDatum(err), testutil.DatumEquals, types.NewDatum(errors.New("not support for the specific locale")))
+	c.Assert(err, IsNil)
 	c.Assert(r3, testutil.DatumEquals, types.NewDatum(formatTests3.ret))
+	warnings := sc.GetWarnings()
+	c.Assert(len(warnings), Equals, warnCnt+1)
+	c.Assert(terror.ErrorEqual(warnings[len(warnings)-1], errUnknownLocale), IsTrue)
 }
 
 func (s *testEvaluatorSuite) TestFromBase64(c *C) {
@@ -1230,6 +1586,10 @@ func (s *testEvaluatorSuite) TestFromBase64(c *C) {
 			string("QUJDREVGR0hJSkt\tMTU5PUFFSU1RVVld\nYWVphYmNkZ\rWZnaGlqa2xt   bm9wcXJzdHV2d3h5ejAxMjM0NTY3ODkrLw=="),
 			string("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"),
 		},
+		{string("YWJj*"), nil},
+		{string("YWJ"), nil},
+		{string("!@#$"), nil},
+		{nil, nil},
 	}
 	fc := funcs[ast.FromBase64]
 	for _, test := range tests {
@@ -1270,6 +1630,14 @@ func (s *testEvaluatorSuite) TestInsert(c *C) {
 		{[]interface{}{"我叫小雨呀", 3, 4, nil}, nil},
 		{[]interface{}{"我叫小雨呀", 3, -1, "王雨叶"}, "我叫王雨叶"},
 		{[]interface{}{"我叫小雨呀", 3, 1, "王雨叶"}, "我叫王雨叶雨呀"},
+		{[]interface{}{"我叫小雨呀", 5, 1, "啊"}, "我叫小雨啊"},
+		{[]interface{}{"我叫小雨呀", 6, 1, "啊"}, "我叫小雨呀"},
+		{[]interface{}{"我叫小雨呀", 0, 1, "啊"}, "我叫小雨呀"},
+		{[]interface{}{"", 1, 1, "a"}, ""},
+		// The positions of a binary string are counted in bytes.
+		{[]interface{}{[]byte("我叫"), 4, 3, "a"}, "我a"},
+		{[]interface{}{[]byte("我叫"), 7, 3, "a"}, "我叫"},
+		{[]interface{}{[]byte("abc"), 2, -1, []byte("xy")}, "axy"},
 	}
 	fc := funcs[ast.InsertFunc]
 	for _, test := range tests {
@@ -1466,6 +1834,7 @@ func (s *testEvaluatorSuite) TestToBase64(c *C) {
 			string("ABCD  EFGHI\nJKLMNOPQRSTUVWXY\tZabcdefghijklmnopqrstuv  wxyz012\r3456789+/"),
 			string("QUJDRCAgRUZHSEkKSktMTU5PUFFSU1RVVldYWQlaYWJjZGVmZ2hpamtsbW5vcHFyc3R1diAgd3h5\nejAxMg0zNDU2Nzg5Ky8="),
 		},
+		{nil, nil},
 	}
 	fc := funcs[ast.ToBase64]
 	for _, test := range tests {
@@ -1482,6 +1851,94 @@ func (s *testEvaluatorSuite) TestToBase64(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestBase64RoundTrip(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []string{
+		"\x00",
+		"a\x00b\x00\x00c",
+		"\x00\x01\x02\xfe\xff",
+		strings.Repeat("\x00\xff", 100),
+	}
+	toFc, fromFc := funcs[ast.ToBase64], funcs[ast.FromBase64]
+	for _, t := range tests {
+		f, err := toFc.getFunction(datumsToConstants(types.MakeDatums([]byte(t))), s.ctx)
+		c.Assert(err, IsNil)
+		encoded, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		f, err = fromFc.getFunction(datumsToConstants([]types.Datum{encoded}), s.ctx)
+		c.Assert(err, IsNil)
+		decoded, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(decoded.GetString(), Equals, t)
+	}
+}
+
+func (s *testEvaluatorSuite) TestToBase64PacketOverflow(c *C) {
+	defer testleak.AfterTest(c)()
+	sessionVars := s.ctx.GetSessionVars()
+	err := varsutil.SetSessionSystemVar(sessionVars, variable.MaxAllowedPacket, types.NewIntDatum(8))
+	c.Assert(err, IsNil)
+	defer delete(sessionVars.Systems, variable.MaxAllowedPacket)
+
+	fc := funcs[ast.ToBase64]
+	// "abcdef" is encoded to "YWJjZGVm" whose length is exactly 8.
+	f, err := fc.getFunction(datumsToConstants(types.MakeDatums("abcdef")), s.ctx)
+	c.Assert(err, IsNil)
+	r, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(r.GetString(), Equals, "YWJjZGVm")
+
+	sc := sessionVars.StmtCtx
+	warnCnt := len(sc.GetWarnings())
+	f, err = fc.getFunction(datumsToConstants(types.MakeDatums("abcdefg")), s.ctx)
+	c.Assert(err, IsNil)
+	r, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(r.IsNull(), IsTrue)
+	warnings := sc.GetWarnings()
+	c.Assert(len(warnings), Equals, warnCnt+1)
+	c.Assert(terror.ErrorEqual(warnings[len(warnings)-1], errWarnAllowedPacketOverflowed), IsTrue)
+}
+
+func (s *testEvaluatorSuite) TestPacketOverflow(c *C) {
+	defer testleak.AfterTest(c)()
+	sessionVars := s.ctx.GetSessionVars()
+	err := varsutil.SetSessionSystemVar(sessionVars, variable.MaxAllowedPacket, types.NewIntDatum(8))
+	c.Assert(err, IsNil)
+	defer delete(sessionVars.Systems, variable.MaxAllowedPacket)
+
+	// Every function gets a result of exactly 8 bytes from fit, and a result longer than 8 bytes from overflow.
+	tbl := []struct {
+		funcName string
+		fit      []interface{}
+		res      string
+		overflow []interface{}
+	}{
+		// The skipped NULL doesn't count.
+		{ast.ConcatWS, []interface{}{",", "abc", nil, "defg"}, "abc,defg", []interface{}{",", "abc", "defgh"}},
+		{ast.InsertFunc, []interface{}{"abcdef", 2, 1, "xyz"}, "axyzcdef", []interface{}{"abcdef", 2, 1, "wxyz"}},
+	}
+	sc := sessionVars.StmtCtx
+	for _, t := range tbl {
+		fc := funcs[t.funcName]
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.fit...)), s.ctx)
+		c.Assert(err, IsNil)
+		r, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r.GetString(), Equals, t.res, Commentf("%s", t.funcName))
+
+		warnCnt := len(sc.GetWarnings())
+		f, err = fc.getFunction(datumsToConstants(types.MakeDatums(t.overflow...)), s.ctx)
+		c.Assert(err, IsNil)
+		r, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(r.IsNull(), IsTrue, Commentf("%s", t.funcName))
+		warnings := sc.GetWarnings()
+		c.Assert(len(warnings), Equals, warnCnt+1)
+		c.Assert(terror.ErrorEqual(warnings[len(warnings)-1], errWarnAllowedPacketOverflowed), IsTrue)
+	}
+}
+
 func (s *testEvaluatorSuite) TestStringRight(c *C) {
 	defer testleak.AfterTest(c)()
 	fc := funcs[ast.Right]
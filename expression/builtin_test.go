@@ -18,6 +18,8 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
 	"github.com/pingcap/tidb/util/types"
@@ -140,6 +142,110 @@ func (s *testEvaluatorSuite) TestGreatestLeastFuncs(c *C) {
 	c.Assert(v.IsNull(), IsTrue)
 }
 
+func (s *testEvaluatorSuite) TestGreatestLeastTime(c *C) {
+	defer testleak.AfterTest(c)()
+	date := func(y, m, d int) types.Time {
+		return types.Time{Time: types.FromDate(y, m, d, 0, 0, 0, 0), Type: mysql.TypeDate}
+	}
+	datetime := func(y, m, d, h int) types.Time {
+		return types.Time{Time: types.FromDate(y, m, d, h, 0, 0, 0), Type: mysql.TypeDatetime}
+	}
+	zeroDate := types.Time{Time: types.ZeroTime, Type: mysql.TypeDate}
+	tbl := []struct {
+		args     []interface{}
+		greatest interface{}
+		least    interface{}
+	}{
+		// The dates are compared as dates, and so are the results.
+		{[]interface{}{date(2020, 6, 1), date(2020, 1, 1), date(2019, 12, 31)}, "2020-06-01", "2019-12-31"},
+		{[]interface{}{date(2020, 1, 1), datetime(2020, 1, 1, 10)}, "2020-01-01 10:00:00", "2020-01-01 00:00:00"},
+		{[]interface{}{zeroDate, date(2020, 1, 1)}, "2020-01-01", "0000-00-00"},
+		// The strings are converted to the temporal type, '2020-1-10' is less than '2020-06-01' as a date.
+		{[]interface{}{date(2020, 6, 1), "2020-1-10"}, "2020-06-01", "2020-01-10"},
+		{[]interface{}{"2020-1-10", date(2020, 1, 9), "2020-01-09 23:59:59"}, "2020-01-10", "2020-01-09"},
+		{[]interface{}{datetime(2020, 1, 1, 10), "2020-01-01 9:30:00"}, "2020-01-01 10:00:00", "2020-01-01 09:30:00"},
+		{[]interface{}{date(2020, 6, 1), nil}, nil, nil},
+		{[]interface{}{nil, date(2020, 6, 1), "2020-1-10"}, nil, nil},
+	}
+	for _, t := range tbl {
+		for name, expect := range map[string]interface{}{ast.Greatest: t.greatest, ast.Least: t.least} {
+			f, err := funcs[name].getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+			c.Assert(err, IsNil)
+			switch name {
+			case ast.Greatest:
+				c.Assert(f, FitsTypeOf, &builtinGreatestTimeSig{}, Commentf("%v", t.args))
+			default:
+				c.Assert(f, FitsTypeOf, &builtinLeastTimeSig{}, Commentf("%v", t.args))
+			}
+			v, err := f.eval(nil)
+			c.Assert(err, IsNil)
+			if expect == nil {
+				c.Assert(v.IsNull(), IsTrue, Commentf("%s%v", name, t.args))
+				continue
+			}
+			c.Assert(v.Kind(), Equals, types.KindMysqlTime, Commentf("%s%v", name, t.args))
+			c.Assert(v.GetMysqlTime().String(), Equals, expect, Commentf("%s%v", name, t.args))
+		}
+	}
+
+	// An invalid string is a zero date with a warning.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	warnCnt := len(sc.GetWarnings())
+	f, err := funcs[ast.Least].getFunction(datumsToConstants(types.MakeDatums(date(2020, 6, 1), "abc")), s.ctx)
+	c.Assert(err, IsNil)
+	v, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.GetMysqlTime().String(), Equals, "0000-00-00")
+	c.Assert(len(sc.GetWarnings()), Equals, warnCnt+1)
+
+	// The temporal arguments mixed with numbers are compared as datums.
+	f, err = funcs[ast.Greatest].getFunction(datumsToConstants(types.MakeDatums(date(2020, 6, 1), 1)), s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(f, FitsTypeOf, &builtinGreatestSig{})
+	f, err = funcs[ast.Least].getFunction(datumsToConstants(types.MakeDatums("b", "a")), s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(f, FitsTypeOf, &builtinLeastSig{})
+}
+
+func (s *testEvaluatorSuite) TestGreatestLeastIncompatibleArgs(c *C) {
+	defer testleak.AfterTest(c)()
+	strType := types.NewFieldType(mysql.TypeVarString)
+	jsonDoc, err := NewFunction(s.ctx, ast.JSONSet, strType,
+		datumsToConstants(types.MakeDatums(`{"a": 1}`, "$.b", 2))...)
+	c.Assert(err, IsNil)
+	decimal := datumsToConstants(types.MakeDatums(types.NewDecFromStringForTest("1.5")))[0]
+	geometry := &Column{RetType: types.NewFieldType(mysql.TypeGeometry)}
+	str := datumsToConstants(types.MakeDatums("abc"))[0]
+	null := datumsToConstants(types.MakeDatums(nil))[0]
+	for _, args := range [][]Expression{
+		{jsonDoc, decimal},
+		{decimal, str, jsonDoc},
+		{geometry, decimal},
+		{str, geometry},
+		{jsonDoc, geometry},
+	} {
+		for _, name := range []string{ast.Greatest, ast.Least} {
+			_, err := funcs[name].getFunction(args, s.ctx)
+			c.Assert(terror.ErrorEqual(err, errInvalidOperation), IsTrue, Commentf("%s%v", name, args))
+		}
+	}
+	_, err = funcs[ast.Greatest].getFunction([]Expression{jsonDoc, decimal}, s.ctx)
+	c.Assert(err, ErrorMatches, ".*Incompatible types json and decimal in the arguments of greatest.*")
+
+	// The compatible arguments still build.
+	for _, args := range [][]Expression{
+		{jsonDoc, str},
+		{jsonDoc, null},
+		{decimal, str, null},
+		{geometry, geometry, null},
+	} {
+		for _, name := range []string{ast.Greatest, ast.Least} {
+			_, err := funcs[name].getFunction(args, s.ctx)
+			c.Assert(err, IsNil, Commentf("%s%v", name, args))
+		}
+	}
+}
+
 func (s *testEvaluatorSuite) TestIntervalFunc(c *C) {
 	defer testleak.AfterTest(c)()
 
@@ -187,11 +293,30 @@ func (s *testEvaluatorSuite) TestIsNullFunc(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v.GetInt64(), Equals, int64(0))
 
+	// A NULL argument returns 1 rather than NULL.
 	f, err = fc.getFunction(datumsToConstants(types.MakeDatums(nil)), s.ctx)
 	c.Assert(err, IsNil)
 	v, err = f.eval(nil)
 	c.Assert(err, IsNil)
-	c.Assert(v.GetInt64(), Equals, int64(1))
+	c.Assert(v, testutil.DatumEquals, types.NewIntDatum(1))
+	i, isNull, err := f.evalInt(nil)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(i, Equals, int64(1))
+
+	// The argument of any type is evaluated.
+	col := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeVarString)}
+	f, err = fc.getFunction([]Expression{col}, s.ctx)
+	c.Assert(err, IsNil)
+	for _, t := range []struct {
+		arg    interface{}
+		expect int64
+	}{{"abc", 0}, {"", 0}, {nil, 1}} {
+		i, isNull, err = f.evalInt(types.MakeDatums(t.arg))
+		c.Assert(err, IsNil)
+		c.Assert(isNull, IsFalse)
+		c.Assert(i, Equals, t.expect)
+	}
 }
 
 func (s *testEvaluatorSuite) TestLock(c *C) {
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,9 +35,10 @@ import (
 )
 
 const ( // GET_FORMAT first argument.
-	dateFormat     = "DATE"
-	datetimeFormat = "DATETIME"
-	timeFormat     = "TIME"
+	dateFormat      = "DATE"
+	datetimeFormat  = "DATETIME"
+	timeFormat      = "TIME"
+	timestampFormat = "TIMESTAMP"
 )
 
 const ( // GET_FORMAT location.
@@ -121,14 +123,16 @@ var (
 	_ builtinFunc = &builtinYearWeekSig{}
 	_ builtinFunc = &builtinFromUnixTimeSig{}
 	_ builtinFunc = &builtinGetFormatSig{}
-	_ builtinFunc = &builtinStrToDateSig{}
+	_ builtinFunc = &builtinStrToDateDateSig{}
+	_ builtinFunc = &builtinStrToDateDatetimeSig{}
 	_ builtinFunc = &builtinSysDateSig{}
 	_ builtinFunc = &builtinCurrentDateSig{}
 	_ builtinFunc = &builtinCurrentTimeSig{}
 	_ builtinFunc = &builtinTimeSig{}
 	_ builtinFunc = &builtinUTCDateSig{}
 	_ builtinFunc = &builtinUTCTimestampSig{}
-	_ builtinFunc = &builtinExtractSig{}
+	_ builtinFunc = &builtinExtractDatetimeSig{}
+	_ builtinFunc = &builtinExtractDurationSig{}
 	_ builtinFunc = &builtinArithmeticSig{}
 	_ builtinFunc = &builtinUnixTimestampSig{}
 	_ builtinFunc = &builtinAddTimeSig{}
@@ -337,7 +341,8 @@ func (b *builtinDateFormatSig) eval(row []types.Datum) (types.Datum, error) {
 	return builtinDateFormat(args, b.ctx)
 }
 
-// builtinDateFormat ...
+// builtinDateFormat formats the date by the format, the names of the months and the weekdays are in the locale
+// of lc_time_names. The result is NULL if the date lacks the parts the format needs, e.g. '%M' of a zero month.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_date-format
 func builtinDateFormat(args []types.Datum, ctx context.Context) (types.Datum, error) {
 	var d types.Datum
@@ -346,11 +351,22 @@ func builtinDateFormat(args []types.Datum, ctx context.Context) (types.Datum, er
 		return d, errors.Trace(err)
 	}
 
-	if date.IsNull() {
+	if date.IsNull() || args[1].IsNull() {
 		return d, nil
 	}
+	locale, err := getLcTimeNames(ctx)
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	format, err := args[1].ToString()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
 	t := date.GetMysqlTime()
-	str, err := t.DateFormat(args[1].GetString())
+	str, err := t.DateFormatWithLocale(format, locale)
+	if terror.ErrorEqual(err, types.ErrInvalidTimeFormat) {
+		return d, nil
+	}
 	if err != nil {
 		return d, errors.Trace(err)
 	}
@@ -547,40 +563,38 @@ type monthNameFunctionClass struct {
 }
 
 func (c *monthNameFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinMonthNameSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinMonthNameSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinMonthNameSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-func (b *builtinMonthNameSig) eval(row []types.Datum) (types.Datum, error) {
+// evalString evals a builtinMonthNameSig, the name is in the locale of lc_time_names.
+// The result is NULL if the date is invalid or its month is zero.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_monthname
+func (b *builtinMonthNameSig) evalString(row []types.Datum) (string, bool, error) {
 	args, err := b.evalArgs(row)
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+		return "", true, errors.Trace(err)
 	}
-	return builtinMonthName(args, b.ctx)
-}
-
-// builtinMonthName ...
-// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_monthname
-func builtinMonthName(args []types.Datum, ctx context.Context) (types.Datum, error) {
-	d, err := builtinMonth(args, ctx)
-	if err != nil || d.IsNull() {
-		return d, errors.Trace(err)
+	d, err := builtinMonth(args, b.ctx)
+	if err != nil {
+		return "", true, errorOrWarning(err, b.ctx)
+	}
+	if d.IsNull() {
+		return "", true, nil
 	}
-
 	mon := int(d.GetInt64())
 	if mon <= 0 || mon > len(types.MonthNames) {
-		d.SetNull()
-		if mon == 0 {
-			return d, nil
-		}
-		return d, errors.Errorf("no name for invalid month: %d.", mon)
+		return "", true, nil
 	}
-	d.SetString(types.MonthNames[mon-1])
-
-	return d, nil
+	locale, err := getLcTimeNames(b.ctx)
+	if err != nil {
+		return "", true, errors.Trace(err)
+	}
+	return types.MonthNameWithLocale(mon, locale), false, nil
 }
 
 type nowFunctionClass struct {
@@ -588,26 +602,52 @@ type nowFunctionClass struct {
 }
 
 func (c *nowFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinNowSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	if err := c.verifyArgs(args); err != nil {
+		return nil, errors.Trace(err)
+	}
+	fsp := 0
+	if len(args) == 1 {
+		con, ok := args[0].(*Constant)
+		if !ok {
+			return nil, errInvalidOperation.Gen("the fsp of %s must be a constant", c.funcName)
+		}
+		if !con.Value.IsNull() {
+			var err error
+			if fsp, err = checkFsp(ctx.GetSessionVars().StmtCtx, con.Value); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	bf := newBaseBuiltinFunc(args, ctx)
+	bf.deterministic = false
+	sig := &builtinNowSig{bf, fsp}
+	return sig.setSelf(sig), nil
 }
 
 type builtinNowSig struct {
 	baseBuiltinFunc
+
+	fsp int
 }
 
-func (b *builtinNowSig) eval(row []types.Datum) (types.Datum, error) {
-	args, err := b.evalArgs(row)
+// eval evals a builtinNowSig, the result is the start time of the statement truncated to fsp,
+// so it's the same for all the calls within a statement.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_now
+func (b *builtinNowSig) eval(_ []types.Datum) (d types.Datum, err error) {
+	now := b.ctx.GetSessionVars().StmtCtx.GetNowTime()
+	now = now.Truncate(time.Duration(math.Pow10(9 - b.fsp)))
+	t, err := convertTimeToMysqlTime(now, b.fsp)
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+		return d, errors.Trace(err)
 	}
-	return builtinNow(args, b.ctx)
+	d.SetMysqlTime(t)
+	return d, nil
 }
 
-// builtinNow ...
-// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_now
+// builtinNow returns the current time of the wall clock with the fsp of args[0] if any. Unlike NOW,
+// it may differ between the calls within a statement.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_sysdate
 func builtinNow(args []types.Datum, ctx context.Context) (d types.Datum, err error) {
-	// TODO: if NOW is used in stored function or trigger, NOW will return the beginning time
-	// of the execution.
 	fsp := 0
 	sc := ctx.GetSessionVars().StmtCtx
 	if len(args) == 1 && !args[0].IsNull() {
@@ -630,31 +670,41 @@ type dayNameFunctionClass struct {
 }
 
 func (c *dayNameFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinDayNameSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinDayNameSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinDayNameSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinDayNameSig.
+// evalString evals a builtinDayNameSig, the name is in the locale of lc_time_names.
+// The result is NULL if the date is invalid or has zero parts.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_dayname
-func (b *builtinDayNameSig) eval(row []types.Datum) (types.Datum, error) {
+func (b *builtinDayNameSig) evalString(row []types.Datum) (string, bool, error) {
 	args, err := b.evalArgs(row)
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+		return "", true, errors.Trace(err)
+	}
+	t, err := convertToTime(b.ctx.GetSessionVars().StmtCtx, args[0], mysql.TypeDate)
+	if err != nil {
+		return "", true, errorOrWarning(err, b.ctx)
+	}
+	if t.IsNull() {
+		return "", true, nil
+	}
+	if tm := t.GetMysqlTime().Time; tm.Month() == 0 || tm.Day() == 0 {
+		return "", true, nil
 	}
 	d, err := builtinWeekDay(args, b.ctx)
-	if err != nil || d.IsNull() {
-		return d, errors.Trace(err)
+	if err != nil {
+		return "", true, errors.Trace(err)
 	}
-	weekday := d.GetInt64()
-	if (weekday < 0) || (weekday >= int64(len(types.WeekdayNames))) {
-		d.SetNull()
-		return d, errors.Errorf("no name for invalid weekday: %d.", weekday)
+	locale, err := getLcTimeNames(b.ctx)
+	if err != nil {
+		return "", true, errors.Trace(err)
 	}
-	d.SetString(types.WeekdayNames[weekday])
-	return d, nil
+	return types.WeekdayNameWithLocale(int(d.GetInt64()), locale), false, nil
 }
 
 type dayOfMonthFunctionClass struct {
@@ -771,50 +821,58 @@ type weekFunctionClass struct {
 }
 
 func (c *weekFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinWeekSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinWeekSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
-type builtinWeekSig struct {
-	baseBuiltinFunc
+// evalWeekDate evaluates the date argument of the week functions, the dates whose month or day is zero are NULL.
+func evalWeekDate(arg Expression, row []types.Datum, sc *variable.StatementContext) (types.Time, bool, error) {
+	val, err := arg.Eval(row)
+	if val.IsNull() || err != nil {
+		return types.Time{}, true, errors.Trace(err)
+	}
+	d, err := convertToTime(sc, val, mysql.TypeDate)
+	if d.IsNull() || err != nil {
+		return types.Time{}, true, errors.Trace(err)
+	}
+	t := d.GetMysqlTime()
+	return t, t.InvalidZero(), nil
 }
 
-func (b *builtinWeekSig) eval(row []types.Datum) (types.Datum, error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+// evalWeekMode evaluates the optional mode argument of the week functions, a NULL mode is taken as defaultMode
+// like a missing one.
+func evalWeekMode(args []Expression, row []types.Datum, sc *variable.StatementContext, defaultMode int) (int, error) {
+	if len(args) < 2 {
+		return defaultMode, nil
+	}
+	mode, isNull, err := args[1].EvalInt(row, sc)
+	if isNull || err != nil {
+		return defaultMode, errors.Trace(err)
 	}
-	return builtinWeek(args, b.ctx)
+	return int(mode), nil
+}
+
+type builtinWeekSig struct {
+	baseIntBuiltinFunc
 }
 
-// builtinWeek ...
+// evalInt evals WEEK(date[,mode]), the default mode is the value of the system variable default_week_format.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_week
-func builtinWeek(args []types.Datum, ctx context.Context) (types.Datum, error) {
-	d, err := convertToTime(ctx.GetSessionVars().StmtCtx, args[0], mysql.TypeDate)
-	if err != nil || d.IsNull() {
-		return d, errors.Trace(err)
+func (b *builtinWeekSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	t, isNull, err := evalWeekDate(b.args[0], row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	// No need to check type here.
-	t := d.GetMysqlTime()
-	if t.IsZero() {
-		// TODO: log warning or return error?
-		d.SetNull()
-		return d, nil
+	defaultMode, err := getDefaultWeekFormat(b.ctx)
+	if err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	var mode int
-	if len(args) > 1 {
-		v, err := args[1].ToInt64(ctx.GetSessionVars().StmtCtx)
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		mode = int(v)
+	mode, err := evalWeekMode(b.args, row, sc, defaultMode)
+	if err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	week := t.Time.Week(mode)
-	wi := int64(week)
-	d.SetInt64(wi)
-	return d, nil
+	return int64(t.Time.Week(mode)), false, nil
 }
 
 type weekDayFunctionClass struct {
@@ -866,24 +924,22 @@ type weekOfYearFunctionClass struct {
 }
 
 func (c *weekOfYearFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinWeekOfYearSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinWeekOfYearSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinWeekOfYearSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinWeekOfYearSig.
+// evalInt evals WEEKOFYEAR(date), which is equivalent to WEEK(date,3).
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_weekofyear
-func (b *builtinWeekOfYearSig) eval(row []types.Datum) (types.Datum, error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+func (b *builtinWeekOfYearSig) evalInt(row []types.Datum) (int64, bool, error) {
+	t, isNull, err := evalWeekDate(b.args[0], row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-	// WeekOfYear is equivalent to to Week(date, 3)
-	d := types.Datum{}
-	d.SetInt64(3)
-	return builtinWeek([]types.Datum{args[0], d}, b.ctx)
+	return int64(t.Time.Week(3)), false, nil
 }
 
 type yearFunctionClass struct {
@@ -926,48 +982,33 @@ type yearWeekFunctionClass struct {
 }
 
 func (c *yearWeekFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinYearWeekSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinYearWeekSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinYearWeekSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinYearWeekSig.
+// evalInt evals YEARWEEK(date[,mode]), the default mode is 0. The year may differ from the one of date
+// in the first and the last week of the year, e.g. YEARWEEK('2000-01-01') is 199952.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_yearweek
-func (b *builtinYearWeekSig) eval(row []types.Datum) (types.Datum, error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
-	}
-	d, err := convertToTime(b.ctx.GetSessionVars().StmtCtx, args[0], mysql.TypeDate)
-	if err != nil || d.IsNull() {
-		return d, errors.Trace(err)
-	}
-
-	// No need to check type here.
-	t := d.GetMysqlTime()
-	if t.InvalidZero() {
-		d.SetNull()
-		// TODO: log warning or return error?
-		return d, nil
+func (b *builtinYearWeekSig) evalInt(row []types.Datum) (int64, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	t, isNull, err := evalWeekDate(b.args[0], row, sc)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	var mode int64
-	if len(args) > 1 {
-		v, err := args[1].ToInt64(b.ctx.GetSessionVars().StmtCtx)
-		if err != nil {
-			return d, errors.Trace(err)
-		}
-		mode = v
+	mode, err := evalWeekMode(b.args, row, sc, 0)
+	if err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	year, week := t.Time.YearWeek(int(mode))
-	d.SetInt64(int64(week + year*100))
-	if d.GetInt64() < 0 {
-		d.SetInt64(math.MaxUint32)
+	year, week := t.Time.YearWeek(mode)
+	res := int64(week + year*100)
+	if res < 0 {
+		return math.MaxUint32, false, nil
 	}
-	return d, nil
+	return res, false, nil
 }
 
 type fromUnixTimeFunctionClass struct {
@@ -1058,62 +1099,58 @@ type getFormatFunctionClass struct {
 }
 
 func (c *getFormatFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinGetFormatSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinGetFormatSig{baseStringBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinGetFormatSig struct {
-	baseBuiltinFunc
+	baseStringBuiltinFunc
 }
 
-// eval evals a builtinGetFormatSig.
+// evalString evals a builtinGetFormatSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_get-format
-func (b *builtinGetFormatSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	t := args[0].GetString()
-	l := args[1].GetString()
-	switch t {
-	case dateFormat:
-		switch l {
-		case usaLocation:
-			d.SetString("%m.%d.%Y")
-		case jisLocation:
-			d.SetString("%Y-%m-%d")
-		case isoLocation:
-			d.SetString("%Y-%m-%d")
-		case eurLocation:
-			d.SetString("%d.%m.%Y")
-		case internalLocation:
-			d.SetString("%Y%m%d")
-		}
-	case datetimeFormat:
-		switch l {
-		case usaLocation:
-			d.SetString("%Y-%m-%d %H.%i.%s")
-		case jisLocation:
-			d.SetString("%Y-%m-%d %H:%i:%s")
-		case isoLocation:
-			d.SetString("%Y-%m-%d %H:%i:%s")
-		case eurLocation:
-			d.SetString("%Y-%m-%d %H.%i.%s")
-		case internalLocation:
-			d.SetString("%Y%m%d%H%i%s")
-		}
-	case timeFormat:
-		switch l {
-		case usaLocation:
-			d.SetString("%h:%i:%s %p")
-		case jisLocation:
-			d.SetString("%H:%i:%s")
-		case isoLocation:
-			d.SetString("%H:%i:%s")
-		case eurLocation:
-			d.SetString("%H.%i.%s")
-		case internalLocation:
-			d.SetString("%H%i%s")
-		}
-	}
-
-	return d, nil
+func (b *builtinGetFormatSig) evalString(row []types.Datum) (string, bool, error) {
+	strs, isNull, err := EvalArgsString(b.args, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return "", true, errors.Trace(err)
+	}
+	format, ok := getFormatTable[strings.ToUpper(strs[0])][strings.ToUpper(strs[1])]
+	if !ok {
+		return "", true, nil
+	}
+	return format, false, nil
+}
+
+// getFormatTable maps the type and the location of GET_FORMAT to the format string.
+var getFormatTable = map[string]map[string]string{
+	dateFormat: {
+		usaLocation:      "%m.%d.%Y",
+		jisLocation:      "%Y-%m-%d",
+		isoLocation:      "%Y-%m-%d",
+		eurLocation:      "%d.%m.%Y",
+		internalLocation: "%Y%m%d",
+	},
+	datetimeFormat: {
+		usaLocation:      "%Y-%m-%d %H.%i.%s",
+		jisLocation:      "%Y-%m-%d %H:%i:%s",
+		isoLocation:      "%Y-%m-%d %H:%i:%s",
+		eurLocation:      "%Y-%m-%d %H.%i.%s",
+		internalLocation: "%Y%m%d%H%i%s",
+	},
+	timeFormat: {
+		usaLocation:      "%h:%i:%s %p",
+		jisLocation:      "%H:%i:%s",
+		isoLocation:      "%H:%i:%s",
+		eurLocation:      "%H.%i.%s",
+		internalLocation: "%H%i%s",
+	},
+	timestampFormat: {
+		usaLocation:      "%Y-%m-%d %H.%i.%s",
+		jisLocation:      "%Y-%m-%d %H:%i:%s",
+		isoLocation:      "%Y-%m-%d %H:%i:%s",
+		eurLocation:      "%Y-%m-%d %H.%i.%s",
+		internalLocation: "%Y%m%d%H%i%s",
+	},
 }
 
 type strToDateFunctionClass struct {
@@ -1121,33 +1158,76 @@ type strToDateFunctionClass struct {
 }
 
 func (c *strToDateFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinStrToDateSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	bf := newBaseBuiltinFunc(args, ctx)
+	err := c.verifyArgs(args)
+	// The type of the result depends on the specifiers of the format, it's decided only if the format is
+	// a constant, otherwise it's a datetime.
+	tp, fsp := mysql.TypeDatetime, types.MaxFsp
+	if err == nil {
+		if con, ok := args[1].(*Constant); ok && !con.Value.IsNull() {
+			var format string
+			if format, err = con.Value.ToString(); err == nil {
+				tp, fsp = types.StrToDateFormatType(format)
+			}
+		}
+	}
+	if err != nil {
+		sig := &builtinStrToDateDatetimeSig{bf, fsp}
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	if tp == mysql.TypeDate {
+		sig := &builtinStrToDateDateSig{bf}
+		return sig.setSelf(sig), nil
+	}
+	sig := &builtinStrToDateDatetimeSig{bf, fsp}
+	return sig.setSelf(sig), nil
 }
 
-type builtinStrToDateSig struct {
+type builtinStrToDateDateSig struct {
 	baseBuiltinFunc
 }
 
-// eval evals a builtinStrToDateSig.
-// See https://dev.mysql.com/doc/refman/5.5/en/date-and-time-functions.html#function_str-to-date
-func (b *builtinStrToDateSig) eval(row []types.Datum) (types.Datum, error) {
+// eval evals a builtinStrToDateDateSig, the format only contains the date specifiers.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_str-to-date
+func (b *builtinStrToDateDateSig) eval(row []types.Datum) (types.Datum, error) {
+	return evalStrToDate(b.baseBuiltinFunc, row, mysql.TypeDate, types.DefaultFsp)
+}
+
+type builtinStrToDateDatetimeSig struct {
+	baseBuiltinFunc
+
+	fsp int
+}
+
+// eval evals a builtinStrToDateDatetimeSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_str-to-date
+func (b *builtinStrToDateDatetimeSig) eval(row []types.Datum) (types.Datum, error) {
+	return evalStrToDate(b.baseBuiltinFunc, row, mysql.TypeDatetime, b.fsp)
+}
+
+// evalStrToDate parses the date string args[0] by the format args[1] to a time of type tp. The result is NULL
+// with a warning if the string doesn't match the format. The characters after the matched part are ignored,
+// and so is the rest of the format if the string is shorter than the format.
+func evalStrToDate(b baseBuiltinFunc, row []types.Datum, tp byte, fsp int) (d types.Datum, err error) {
 	args, err := b.evalArgs(row)
+	if err != nil || args[0].IsNull() || args[1].IsNull() {
+		return d, errors.Trace(err)
+	}
+	date, err := args[0].ToString()
 	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+		return d, errors.Trace(err)
 	}
-	date := args[0].GetString()
-	format := args[1].GetString()
-	var (
-		d types.Datum
-		t types.Time
-	)
-
-	succ := t.StrToDate(date, format)
-	if !succ {
-		d.SetNull()
+	format, err := args[1].ToString()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	var t types.Time
+	if !t.StrToDate(date, format) {
+		sc := b.ctx.GetSessionVars().StmtCtx
+		sc.AppendWarning(errWrongValueForType.GenByArgs(types.TypeStr(tp), date, ast.StrToDate))
 		return d, nil
 	}
-
+	t.Type, t.Fsp = tp, fsp
 	d.SetMysqlTime(t)
 	return d, nil
 }
@@ -1171,9 +1251,7 @@ func (b *builtinSysDateSig) eval(row []types.Datum) (types.Datum, error) {
 	if err != nil {
 		return types.Datum{}, errors.Trace(err)
 	}
-	// SYSDATE is not the same as NOW if NOW is used in a stored function or trigger.
-	// But here we can just think they are the same because we don't support stored function
-	// and trigger now.
+	// Unlike NOW, SYSDATE returns the time at which it executes rather than the start time of the statement.
 	return builtinNow(args, b.ctx)
 }
 
@@ -1337,49 +1415,83 @@ type extractFunctionClass struct {
 }
 
 func (c *extractFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinExtractSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	bf := newBaseBuiltinFunc(args, ctx)
+	if err := c.verifyArgs(args); err != nil {
+		sig := &builtinExtractDatetimeSig{bf}
+		return sig.setSelf(sig), errors.Trace(err)
+	}
+	// A TIME argument is extracted as a duration, which may be negative or longer than a day,
+	// anything else is converted to a datetime.
+	if args[1].GetType().Tp == mysql.TypeDuration {
+		sig := &builtinExtractDurationSig{bf}
+		return sig.setSelf(sig), nil
+	}
+	sig := &builtinExtractDatetimeSig{bf}
+	return sig.setSelf(sig), nil
 }
 
-type builtinExtractSig struct {
+type builtinExtractDatetimeSig struct {
 	baseBuiltinFunc
 }
 
-// eval evals a builtinExtractSig.
+// eval evals a builtinExtractDatetimeSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_extract
-func (b *builtinExtractSig) eval(row []types.Datum) (d types.Datum, err error) {
+func (b *builtinExtractDatetimeSig) eval(row []types.Datum) (d types.Datum, err error) {
 	args, err := b.evalArgs(row)
-	if err != nil {
-		return types.Datum{}, errors.Trace(err)
+	if err != nil || args[0].IsNull() || args[1].IsNull() {
+		return d, errors.Trace(err)
 	}
-	unit := args[0].GetString()
-	vd := args[1]
-
-	if vd.IsNull() {
-		d.SetNull()
-		return d, nil
+	unit, err := args[0].ToString()
+	if err != nil {
+		return d, errors.Trace(err)
 	}
-
 	f := types.NewFieldType(mysql.TypeDatetime)
 	f.Decimal = types.MaxFsp
-	val, err := vd.ConvertTo(b.ctx.GetSessionVars().StmtCtx, f)
+	sc := b.ctx.GetSessionVars().StmtCtx
+	val, err := args[1].ConvertTo(sc, f)
 	if err != nil {
-		d.SetNull()
-		return d, errors.Trace(err)
+		// An invalid date is extracted to NULL with a warning.
+		sc.AppendWarning(err)
+		return d, nil
 	}
 	if val.IsNull() {
-		d.SetNull()
 		return d, nil
 	}
+	n, err := types.ExtractTimeNum(unit, val.GetMysqlTime())
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	d.SetInt64(n)
+	return d, nil
+}
 
-	if val.Kind() != types.KindMysqlTime {
-		d.SetNull()
-		return d, errors.Errorf("need time type, but got %T", val)
+type builtinExtractDurationSig struct {
+	baseBuiltinFunc
+}
+
+// eval evals a builtinExtractDurationSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_extract
+func (b *builtinExtractDurationSig) eval(row []types.Datum) (d types.Datum, err error) {
+	args, err := b.evalArgs(row)
+	if err != nil || args[0].IsNull() || args[1].IsNull() {
+		return d, errors.Trace(err)
 	}
-	t := val.GetMysqlTime()
-	n, err1 := types.ExtractTimeNum(unit, t)
-	if err1 != nil {
-		d.SetNull()
-		return d, errors.Trace(err1)
+	unit, err := args[0].ToString()
+	if err != nil {
+		return d, errors.Trace(err)
+	}
+	f := types.NewFieldType(mysql.TypeDuration)
+	f.Decimal = types.MaxFsp
+	val, err := args[1].ConvertTo(b.ctx.GetSessionVars().StmtCtx, f)
+	if err != nil {
+		return d, errorOrWarning(err, b.ctx)
+	}
+	if val.IsNull() {
+		return d, nil
+	}
+	n, err := types.ExtractDurationNum(unit, val.GetMysqlDuration())
+	if err != nil {
+		return d, errors.Trace(err)
 	}
 	d.SetInt64(n)
 	return d, nil
@@ -1750,17 +1862,116 @@ type convertTzFunctionClass struct {
 }
 
 func (c *convertTzFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinConvertTzSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinConvertTzSig{baseTimeBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinConvertTzSig struct {
-	baseBuiltinFunc
+	baseTimeBuiltinFunc
 }
 
-// eval evals a builtinConvertTzSig.
+// evalTime evals CONVERT_TZ(dt, from_tz, to_tz), it returns NULL if dt isn't a valid datetime or a zone is unknown.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_convert-tz
-func (b *builtinConvertTzSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("CONVERT_TZ")
+func (b *builtinConvertTzSig) evalTime(row []types.Datum) (types.Time, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	dt, isNull, err := b.evalDatetime(row)
+	if isNull || err != nil {
+		return types.Time{}, true, errors.Trace(err)
+	}
+	fromTz, isNull, err := b.args[1].EvalString(row, sc)
+	if isNull || err != nil {
+		return types.Time{}, true, errors.Trace(err)
+	}
+	toTz, isNull, err := b.args[2].EvalString(row, sc)
+	if isNull || err != nil {
+		return types.Time{}, true, errors.Trace(err)
+	}
+	fromLoc, toLoc := parseConvertTzZone(fromTz), parseConvertTzZone(toTz)
+	if fromLoc == nil || toLoc == nil {
+		return types.Time{}, true, nil
+	}
+	// A time in the gap of a daylight saving time transition is normalized forward.
+	t := time.Date(dt.Time.Year(), time.Month(dt.Time.Month()), dt.Time.Day(), dt.Time.Hour(), dt.Time.Minute(),
+		dt.Time.Second(), dt.Time.Microsecond()*1000, fromLoc).In(toLoc)
+	if t.Year() < 1 || t.Year() > 9999 {
+		return types.Time{}, true, nil
+	}
+	return types.Time{Time: types.FromGoTime(t), Type: mysql.TypeDatetime, Fsp: dt.Fsp}, false, nil
+}
+
+// evalDatetime evaluates the first argument of CONVERT_TZ to a datetime, a string keeps the fsp it's written with.
+// It returns NULL if the argument isn't a valid datetime.
+func (b *builtinConvertTzSig) evalDatetime(row []types.Datum) (types.Time, bool, error) {
+	sc := b.ctx.GetSessionVars().StmtCtx
+	var (
+		dt     types.Time
+		isNull bool
+		err    error
+	)
+	switch b.args[0].GetType().ToClass() {
+	case types.ClassString:
+		// A datetime is in string class too, it's converted by its value.
+		var s string
+		s, isNull, err = evalStringByValue(b.args[0], row)
+		if isNull || err != nil {
+			return types.Time{}, true, errors.Trace(err)
+		}
+		dt, err = types.ParseTime(s, mysql.TypeDatetime, getFsp(s))
+	case types.ClassInt:
+		var num int64
+		num, isNull, err = b.args[0].EvalInt(row, sc)
+		if isNull || err != nil {
+			return types.Time{}, true, errors.Trace(err)
+		}
+		dt, err = types.ParseDatetimeFromNum(num)
+	default:
+		dt, isNull, err = b.args[0].EvalTime(row, sc)
+		if isNull {
+			return types.Time{}, true, nil
+		}
+	}
+	if err != nil || dt.Time.Month() == 0 || dt.Time.Day() == 0 {
+		return types.Time{}, true, nil
+	}
+	return dt, false, nil
+}
+
+// parseConvertTzZone parses the time zone argument of CONVERT_TZ, which is either a named zone like 'Europe/Paris'
+// or an offset in the form of '[+-]HH:MM'. The offset must be in the range from '-12:59' to '+13:00'.
+// It returns nil for an unknown zone.
+func parseConvertTzZone(s string) *time.Location {
+	if s == "" {
+		return nil
+	}
+	if strings.EqualFold(s, "SYSTEM") {
+		return time.Local
+	}
+	if s[0] != '+' && s[0] != '-' {
+		loc, err := time.LoadLocation(s)
+		if err != nil {
+			return nil
+		}
+		return loc
+	}
+	if len(s) != 6 || s[3] != ':' {
+		return nil
+	}
+	hour, err := strconv.Atoi(s[1:3])
+	if err != nil || s[1] == '+' || s[1] == '-' {
+		return nil
+	}
+	minute, err := strconv.Atoi(s[4:6])
+	if err != nil || s[4] == '+' || s[4] == '-' || minute >= 60 {
+		return nil
+	}
+	offset := hour*3600 + minute*60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	if offset < -(12*3600+59*60) || offset > 13*3600 {
+		return nil
+	}
+	return time.FixedZone(s, offset)
 }
 
 type makeDateFunctionClass struct {
@@ -1768,34 +1979,25 @@ type makeDateFunctionClass struct {
 }
 
 func (c *makeDateFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinMakeDateSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinMakeDateSig{baseTimeBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinMakeDateSig struct {
-	baseBuiltinFunc
+	baseTimeBuiltinFunc
 }
 
-// eval evals a builtinMakeDateSig.
+// evalTime evals a builtinMakeDateSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_makedate
-func (b *builtinMakeDateSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	if args[0].IsNull() || args[1].IsNull() {
-		return d, nil
-	}
+func (b *builtinMakeDateSig) evalTime(row []types.Datum) (types.Time, bool, error) {
 	sc := b.ctx.GetSessionVars().StmtCtx
-	year, err := args[0].ToInt64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	dayOfYear, err := args[1].ToInt64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	args, isNull, err := EvalArgsInt(b.args, row, sc)
+	if isNull || err != nil {
+		return types.Time{}, true, errors.Trace(err)
 	}
+	year, dayOfYear := args[0], args[1]
 	if dayOfYear <= 0 || year < 0 || year > 9999 {
-		return d, nil
+		return types.Time{}, true, nil
 	}
 	if year < 70 {
 		year += 2000
@@ -1809,14 +2011,13 @@ func (b *builtinMakeDateSig) eval(row []types.Datum) (d types.Datum, err error)
 	}
 	retTimestamp := types.TimestampDiff("DAY", types.ZeroDate, startTime)
 	if retTimestamp == 0 {
-		return d, errorOrWarning(types.ErrInvalidTimeFormat, b.ctx)
+		return types.Time{}, true, errorOrWarning(types.ErrInvalidTimeFormat, b.ctx)
 	}
 	ret := types.TimeFromDays(retTimestamp + dayOfYear - 1)
 	if ret.IsZero() || ret.Time.Year() > 9999 {
-		return d, nil
+		return types.Time{}, true, nil
 	}
-	d.SetMysqlTime(ret)
-	return d, nil
+	return ret, false, nil
 }
 
 type makeTimeFunctionClass struct {
@@ -1824,66 +2025,41 @@ type makeTimeFunctionClass struct {
 }
 
 func (c *makeTimeFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinMakeTimeSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinMakeTimeSig{baseDurationBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinMakeTimeSig struct {
-	baseBuiltinFunc
+	baseDurationBuiltinFunc
 }
 
-// eval evals a builtinMakeTimeSig.
+// evalDuration evals a builtinMakeTimeSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_maketime
-func (b *builtinMakeTimeSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-	// MAKETIME(hour, minute, second)
-	if args[0].IsNull() || args[1].IsNull() || args[2].IsNull() {
-		return d, nil
-	}
-
-	var (
-		hour     int64
-		minute   int64
-		second   float64
-		overflow bool
-	)
+func (b *builtinMakeTimeSig) evalDuration(row []types.Datum) (types.Duration, bool, error) {
 	sc := b.ctx.GetSessionVars().StmtCtx
-	hour, _ = args[0].ToInt64(sc)
-	// MySQL TIME datatype: https://dev.mysql.com/doc/refman/5.7/en/time.html
-	// ranges from '-838:59:59.000000' to '838:59:59.000000'
-	if hour < -838 {
-		hour = -838
-		overflow = true
-	} else if hour > 838 {
-		hour = 838
-		overflow = true
-	}
-
-	minute, _ = args[1].ToInt64(sc)
-	if minute < 0 || minute >= 60 {
-		return d, nil
+	// MAKETIME(hour, minute, second), a string argument is truncated silently, e.g. MAKETIME('h', 0, 0) is '00:00:00'.
+	hour, isNull, err := b.args[0].EvalInt(row, sc)
+	if isNull || (err != nil && !terror.ErrorEqual(err, types.ErrTruncated)) {
+		return types.Duration{}, true, errors.Trace(err)
 	}
-
-	second, _ = args[2].ToFloat64(sc)
-	if second < 0 || second >= 60 {
-		return d, nil
+	minute, isNull, err := b.args[1].EvalInt(row, sc)
+	if isNull || (err != nil && !terror.ErrorEqual(err, types.ErrTruncated)) {
+		return types.Duration{}, true, errors.Trace(err)
 	}
-	if hour == -838 || hour == 838 {
-		if second > 59 {
-			second = 59
-		}
+	second, isNull, err := b.args[2].EvalReal(row, sc)
+	if isNull || (err != nil && !terror.ErrorEqual(err, types.ErrTruncated)) {
+		return types.Duration{}, true, errors.Trace(err)
 	}
-	if overflow {
-		minute = 59
-		second = 59
+	if minute < 0 || minute >= 60 || second < 0 || second >= 60 {
+		return types.Duration{}, true, nil
 	}
 
-	var dur types.Duration
 	fsp := types.MaxFsp
-	if args[2].Kind() != types.KindString {
-		sec, _ := args[2].ToString()
+	if b.args[2].GetType().ToClass() != types.ClassString {
+		sec, _, err := b.args[2].EvalString(row, sc)
+		if err != nil {
+			return types.Duration{}, true, errors.Trace(err)
+		}
 		secs := strings.Split(sec, ".")
 		if len(secs) <= 1 {
 			fsp = 0
@@ -1891,12 +2067,24 @@ func (b *builtinMakeTimeSig) eval(row []types.Datum) (d types.Datum, err error)
 			fsp = len(secs[1])
 		}
 	}
-	dur, err = types.ParseDuration(fmt.Sprintf("%02d:%02d:%v", hour, minute, second), fsp)
+
+	// MySQL TIME datatype: https://dev.mysql.com/doc/refman/5.7/en/time.html
+	// ranges from '-838:59:59.000000' to '838:59:59.000000', the fractional part of second may exceed it too,
+	// e.g. '838:59:59.5'.
+	if hour < -838 || hour > 838 || (hour == -838 || hour == 838) && minute == 59 && second > 59 {
+		sc.AppendWarning(errTruncatedWrongValue.GenByArgs("time", fmt.Sprintf("%02d:%02d:%02v", hour, minute, second)))
+		if hour < 0 {
+			hour = -838
+		} else {
+			hour = 838
+		}
+		minute, second = 59, 59
+	}
+	dur, err := types.ParseDuration(fmt.Sprintf("%02d:%02d:%v", hour, minute, second), fsp)
 	if err != nil {
-		return d, errors.Trace(err)
+		return types.Duration{}, true, errors.Trace(err)
 	}
-	d.SetMysqlDuration(dur)
-	return d, nil
+	return dur, false, nil
 }
 
 type periodAddFunctionClass struct {
@@ -1904,60 +2092,60 @@ type periodAddFunctionClass struct {
 }
 
 func (c *periodAddFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinPeriodAddSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinPeriodAddSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
-type builtinPeriodAddSig struct {
-	baseBuiltinFunc
-}
-
-// eval evals a builtinPeriodAddSig.
-// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_period-add
-func (b *builtinPeriodAddSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+// period2Month converts a period of the format YYMM or YYYYMM to the number of months since year 0,
+// a two-digit year YY is 20YY if YY < 70, or 19YY otherwise.
+// The month part isn't checked, so an invalid period like 202013 is normalized to 202101 in the end.
+func period2Month(period int64) int64 {
+	if period == 0 {
+		return 0
 	}
-
-	if args[0].IsNull() || args[1].IsNull() {
-		return d, errors.Trace(err)
+	year, month := period/100, period%100
+	if year < 70 {
+		year += 2000
+	} else if year < 100 {
+		year += 1900
 	}
+	return year*12 + month - 1
+}
 
-	sc := b.ctx.GetSessionVars().StmtCtx
-	period, err := args[0].ToInt64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+// month2Period converts the number of months since year 0 back to a period of the format YYYYMM.
+func month2Period(month int64) int64 {
+	if month == 0 {
+		return 0
 	}
-
-	//Check zero
-	if period <= 0 {
-		d.SetInt64(0)
-		return d, nil
+	year := month / 12
+	if year < 70 {
+		year += 2000
+	} else if year < 100 {
+		year += 1900
 	}
+	return year*100 + month%12 + 1
+}
 
-	y := period / 100
-	m := period % 100
+type builtinPeriodAddSig struct {
+	baseIntBuiltinFunc
+}
 
-	// YYMM, 00-69 year: 2000-2069
-	// YYMM, 70-99 year: 1970-1999
-	if y <= 69 {
-		y += 2000
-	} else if y <= 99 {
-		y += 1900
+// evalInt evals a builtinPeriodAddSig.
+// See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_period-add
+func (b *builtinPeriodAddSig) evalInt(row []types.Datum) (int64, bool, error) {
+	vals, isNull, err := EvalArgsInt(b.args, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	months, err := args[1].ToInt64(sc)
-	if err != nil {
-		return d, errors.Trace(err)
+	period, months := vals[0], vals[1]
+	if period <= 0 {
+		return 0, false, nil
 	}
-
-	sum := time.Month(m + months)
-	// TODO: Consider time_zone variable.
-	t := time.Date(int(y), sum, 1, 0, 0, 0, 0, time.Local)
-
-	ret := int64(t.Year())*100 + int64(t.Month())
-	d.SetInt64(ret)
-	return d, nil
+	sum := period2Month(period) + months
+	if sum <= 0 {
+		return 0, false, nil
+	}
+	return month2Period(sum), false, nil
 }
 
 type periodDiffFunctionClass struct {
@@ -1965,17 +2153,22 @@ type periodDiffFunctionClass struct {
 }
 
 func (c *periodDiffFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinPeriodDiffSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinPeriodDiffSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinPeriodDiffSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinPeriodDiffSig.
+// evalInt evals a builtinPeriodDiffSig.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_period-diff
-func (b *builtinPeriodDiffSig) eval(row []types.Datum) (d types.Datum, err error) {
-	return d, errFunctionNotExists.GenByArgs("PERIOD_DIFF")
+func (b *builtinPeriodDiffSig) evalInt(row []types.Datum) (int64, bool, error) {
+	periods, isNull, err := EvalArgsInt(b.args, row, b.ctx.GetSessionVars().StmtCtx)
+	if isNull || err != nil {
+		return 0, true, errors.Trace(err)
+	}
+	return period2Month(periods[0]) - period2Month(periods[1]), false, nil
 }
 
 type quarterFunctionClass struct {
@@ -2029,78 +2222,71 @@ type secToTimeFunctionClass struct {
 }
 
 func (c *secToTimeFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinSecToTimeSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinSecToTimeSig{baseDurationBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinSecToTimeSig struct {
-	baseBuiltinFunc
+	baseDurationBuiltinFunc
 }
 
-// eval evals a builtinSecToTimeSig.
+// evalDuration evals a builtinSecToTimeSig.
+// The seconds are converted to decimal so the fractional part is kept exactly, and the result is
+// clamped to the range of TIME with a warning.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_sec-to-time
-func (b *builtinSecToTimeSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
+func (b *builtinSecToTimeSig) evalDuration(row []types.Datum) (types.Duration, bool, error) {
+	arg, err := b.args[0].Eval(row)
+	if arg.IsNull() || err != nil {
+		return types.Duration{}, true, errors.Trace(err)
 	}
-	if args[0].IsNull() {
-		return d, nil
-	}
-
-	var (
-		hour          int64
-		minute        int64
-		second        int64
-		demical       float64
-		secondDemical float64
-		negative      string
-	)
-
 	sc := b.ctx.GetSessionVars().StmtCtx
-	secondsFloat, err := args[0].ToFloat64(sc)
-	if err != nil {
-		if args[0].Kind() == types.KindString && terror.ErrorEqual(err, types.ErrTruncated) {
-			secondsFloat = float64(0)
-		} else {
-			return d, errors.Trace(err)
+	secs, err := arg.ToDecimal(sc)
+	if err != nil {
+		// The string that isn't a number is taken as 0, and the invalid suffix is ignored.
+		if arg.Kind() != types.KindString {
+			return types.Duration{}, true, errors.Trace(err)
+		} else if terror.ErrorEqual(err, types.ErrBadNumber) {
+			secs = new(types.MyDecimal)
+		} else if !terror.ErrorEqual(err, types.ErrTruncated) {
+			return types.Duration{}, true, errors.Trace(err)
 		}
 	}
-
-	if secondsFloat < 0 {
-		negative = "-"
-		secondsFloat = math.Abs(secondsFloat)
+	fsp := types.MaxFsp
+	if arg.Kind() != types.KindString {
+		if _, frac := secs.PrecisionAndFrac(); frac < fsp {
+			fsp = frac
+		}
+	}
+	if err = secs.Round(secs, fsp, types.ModeHalfEven); err != nil {
+		return types.Duration{}, true, errors.Trace(err)
 	}
-	seconds := int64(secondsFloat)
-	demical = secondsFloat - float64(seconds)
 
-	hour = seconds / 3600
-	if hour > 838 {
-		hour = 838
-		minute = 59
-		second = 59
-	} else {
-		minute = seconds % 3600 / 60
-		second = seconds % 60
+	var negative string
+	str := string(secs.ToString())
+	if strings.HasPrefix(str, "-") {
+		negative, str = "-", str[1:]
+	}
+	intPart, fracPart := str, ""
+	if dot := strings.IndexByte(str, '.'); dot >= 0 {
+		intPart, fracPart = str[:dot], str[dot+1:]
+	}
+	if fsp > 0 {
+		fracPart = "." + (fracPart + strings.Repeat("0", fsp))[:fsp]
 	}
-	secondDemical = float64(second) + demical
 
-	var dur types.Duration
-	fsp := types.MaxFsp
-	if args[0].Kind() != types.KindString {
-		sec, _ := args[0].ToString()
-		secs := strings.Split(sec, ".")
-		if len(secs) <= 1 {
-			fsp = 0
-		} else if len(secs[1]) < fsp {
-			fsp = len(secs[1])
+	seconds, err := strconv.ParseUint(intPart, 10, 64)
+	if err != nil || seconds/3600 > 838 {
+		sc.AppendWarning(errTruncatedWrongValue.GenByArgs("time", arg))
+		seconds = 838*3600 + 59*60 + 59
+		if fsp > 0 {
+			fracPart = "." + strings.Repeat("0", fsp)
 		}
 	}
-	dur, err = types.ParseDuration(fmt.Sprintf("%s%02d:%02d:%v", negative, hour, minute, secondDemical), fsp)
+	dur, err := types.ParseDuration(fmt.Sprintf("%s%02d:%02d:%02d%s", negative, seconds/3600, seconds%3600/60, seconds%60, fracPart), fsp)
 	if err != nil {
-		return d, errors.Trace(err)
+		return types.Duration{}, true, errors.Trace(err)
 	}
-	d.SetMysqlDuration(dur)
-	return d, nil
+	return dur, false, nil
 }
 
 type subTimeFunctionClass struct {
@@ -2144,35 +2330,26 @@ type timeToSecFunctionClass struct {
 }
 
 func (c *timeToSecFunctionClass) getFunction(args []Expression, ctx context.Context) (builtinFunc, error) {
-	return &builtinTimeToSecSig{newBaseBuiltinFunc(args, ctx)}, errors.Trace(c.verifyArgs(args))
+	sig := &builtinTimeToSecSig{baseIntBuiltinFunc{newBaseBuiltinFunc(args, ctx)}}
+	return sig.setSelf(sig), errors.Trace(c.verifyArgs(args))
 }
 
 type builtinTimeToSecSig struct {
-	baseBuiltinFunc
+	baseIntBuiltinFunc
 }
 
-// eval evals a builtinTimeToSecSig.
+// evalInt evals a builtinTimeToSecSig, the fractional seconds are truncated.
 // See https://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_time-to-sec
-func (b *builtinTimeToSecSig) eval(row []types.Datum) (d types.Datum, err error) {
-	args, err := b.evalArgs(row)
-	if err != nil {
-		return d, errors.Trace(err)
-	}
-
-	d, err = convertToDuration(b.ctx.GetSessionVars().StmtCtx, args[0], 0)
-	if err != nil || d.IsNull() {
-		return d, errors.Trace(err)
+func (b *builtinTimeToSecSig) evalInt(row []types.Datum) (int64, bool, error) {
+	arg, err := b.args[0].Eval(row)
+	if arg.IsNull() || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	t := d.GetMysqlDuration()
-	// TODO: select TIME_TO_SEC('-2:-2:-2') not handle well.
-	if t.Compare(types.ZeroDuration) < 0 {
-		d.SetInt64(int64(-1 * (t.Hour()*3600 + t.Minute()*60 + t.Second())))
-	} else {
-		d.SetInt64(int64(t.Hour()*3600 + t.Minute()*60 + t.Second()))
+	d, err := convertToDuration(b.ctx.GetSessionVars().StmtCtx, arg, types.MaxFsp)
+	if d.IsNull() || err != nil {
+		return 0, true, errors.Trace(err)
 	}
-
-	return d, nil
+	return int64(d.GetMysqlDuration().Duration / time.Second), false, nil
 }
 
 type timestampAddFunctionClass struct {
@@ -15,11 +15,16 @@ package expression
 
 import (
 	"math"
+	"reflect"
 	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -246,6 +251,42 @@ func (s *testEvaluatorSuite) TestDate(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestDayNameMonthNameLocale(c *C) {
+	defer testleak.AfterTest(c)()
+	sessionVars := s.ctx.GetSessionVars()
+	defer delete(sessionVars.Systems, variable.LcTimeNames)
+	for _, t := range []struct {
+		locale    string
+		date      interface{}
+		dayName   interface{}
+		monthName interface{}
+	}{
+		{"en_US", "2013-03-03", "Sunday", "March"},
+		{"de_DE", "2013-03-03", "Sonntag", "März"},
+		{"fr_FR", "2013-03-03", "dimanche", "mars"},
+		{"es_ES", "2013-03-03", "domingo", "marzo"},
+		// The unsupported locales fall back to en_US.
+		{"xx_XX", "2013-03-03", "Sunday", "March"},
+		{"de_DE", "0000-00-00", nil, nil},
+		{"de_DE", "2013-00-03", nil, nil},
+		{"de_DE", nil, nil, nil},
+	} {
+		err := varsutil.SetSessionSystemVar(sessionVars, variable.LcTimeNames, types.NewStringDatum(t.locale))
+		c.Assert(err, IsNil)
+		f, err := funcs[ast.DayName].getFunction(datumsToConstants(types.MakeDatums(t.date)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(t.dayName), Commentf("%s %v", t.locale, t.date))
+
+		f, err = funcs[ast.MonthName].getFunction(datumsToConstants(types.MakeDatums(t.date)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err = f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(t.monthName), Commentf("%s %v", t.locale, t.date))
+	}
+}
+
 func (s *testEvaluatorSuite) TestDateFormat(c *C) {
 	defer testleak.AfterTest(c)()
 
@@ -291,6 +332,72 @@ func (s *testEvaluatorSuite) TestDateFormat(c *C) {
 		c.Assert(v, testutil.DatumEquals, t["Expect"][0], Commentf("no.%d \nobtain:%v \nexpect:%v\n", i,
 			v.GetValue(), t["Expect"][0].GetValue()))
 	}
+
+	// Each specifier against a Sunday, which is in the first week of 2010 if the weeks start from Sunday,
+	// but in the last week of 2009 if they start from Monday.
+	specifiers := []struct {
+		format string
+		expect interface{}
+	}{
+		{"%a", "Sun"}, {"%b", "Jan"}, {"%c", "1"}, {"%D", "3rd"}, {"%d", "03"}, {"%e", "3"},
+		{"%f", "000012"}, {"%H", "13"}, {"%h", "01"}, {"%I", "01"}, {"%i", "05"}, {"%j", "003"},
+		{"%k", "13"}, {"%l", "1"}, {"%M", "January"}, {"%m", "01"}, {"%p", "PM"}, {"%r", "01:05:09 PM"},
+		{"%S", "09"}, {"%s", "09"}, {"%T", "13:05:09"}, {"%W", "Sunday"}, {"%w", "0"}, {"%Y", "2010"},
+		{"%y", "10"}, {"%%", "%"},
+		{"%U", "01"}, {"%u", "00"}, {"%V %X", "01 2010"}, {"%v %x", "53 2009"},
+		// The unknown specifiers are the characters themselves, and a trailing '%' is kept.
+		{"%Q%z", "Qz"}, {"100%", "100%"},
+	}
+	for _, t := range specifiers {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums("2010-01-03 13:05:09.000012", t.format)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%s", t.format))
+	}
+
+	// The names of the months and the weekdays are in the locale of lc_time_names.
+	sessionVars := s.ctx.GetSessionVars()
+	defer delete(sessionVars.Systems, variable.LcTimeNames)
+	for locale, expect := range map[string]string{
+		"en_US": "Sunday Sun January Jan 3rd",
+		"de_DE": "Sonntag So März Mär 3rd",
+		"fr_FR": "dimanche dim mars mars 3rd",
+		"es_ES": "domingo dom marzo mar 3rd",
+	} {
+		err := varsutil.SetSessionSystemVar(sessionVars, variable.LcTimeNames, types.NewStringDatum(locale))
+		c.Assert(err, IsNil)
+		date := "2010-01-03"
+		if locale != "en_US" {
+			date = "2013-03-03"
+		}
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(date, "%W %a %M %b %D")), s.ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(expect), Commentf("%s", locale))
+	}
+	delete(sessionVars.Systems, variable.LcTimeNames)
+
+	// The result is NULL if the date lacks the parts the format needs.
+	for _, t := range []struct {
+		date   string
+		format string
+		expect interface{}
+	}{
+		{"0000-00-00", "%Y-%m-%d", "0000-00-00"},
+		{"0000-00-00", "%W", nil},
+		{"0000-00-00", "%w", nil},
+		{"2010-00-05", "%M", nil},
+		{"2010-00-05", "%b", nil},
+		{"2010-00-05", "%Y %c", "2010 0"},
+	} {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.date, t.format)), s.ctx)
+		c.Assert(err, IsNil)
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%s %s", t.date, t.format))
+	}
 }
 
 func (s *testEvaluatorSuite) TestClock(c *C) {
@@ -440,6 +547,8 @@ func (s *testEvaluatorSuite) TestNowAndUTCTimestamp(c *C) {
 		{funcs[ast.Now], func() time.Time { return time.Now() }},
 		{funcs[ast.UTCTimestamp], func() time.Time { return time.Now().UTC() }},
 	} {
+		// NOW reads the current time of the statement.
+		s.ctx.GetSessionVars().StmtCtx.SetNowTime(time.Now())
 		f, err := x.fc.getFunction(datumsToConstants(nil), s.ctx)
 		c.Assert(err, IsNil)
 		v, err := f.eval(nil)
@@ -460,18 +569,78 @@ func (s *testEvaluatorSuite) TestNowAndUTCTimestamp(c *C) {
 		c.Assert(strings.Contains(t.String(), "."), IsTrue)
 		c.Assert(ts.Sub(gotime(t, ts.Location())), LessEqual, time.Millisecond)
 
+		// NOW checks the constant fsp when it's built.
 		f, err = x.fc.getFunction(datumsToConstants(types.MakeDatums(8)), s.ctx)
-		c.Assert(err, IsNil)
-		_, err = f.eval(nil)
+		if err == nil {
+			_, err = f.eval(nil)
+		}
 		c.Assert(err, NotNil)
 
+		// NOW checks the constant fsp when it's built.
 		f, err = x.fc.getFunction(datumsToConstants(types.MakeDatums(-2)), s.ctx)
-		c.Assert(err, IsNil)
-		_, err = f.eval(nil)
+		if err == nil {
+			_, err = f.eval(nil)
+		}
 		c.Assert(err, NotNil)
 	}
 }
 
+func (s *testEvaluatorSuite) TestNow(c *C) {
+	defer testleak.AfterTest(c)()
+	sc := s.ctx.GetSessionVars().StmtCtx
+	now := time.Date(2017, 7, 31, 12, 34, 56, 123456789, time.Local)
+	sc.SetNowTime(now)
+
+	tests := []struct {
+		args   []types.Datum
+		result string
+	}{
+		{nil, "2017-07-31 12:34:56"},
+		{types.MakeDatums(nil), "2017-07-31 12:34:56"},
+		{types.MakeDatums(0), "2017-07-31 12:34:56"},
+		{types.MakeDatums(3), "2017-07-31 12:34:56.123"},
+		{types.MakeDatums(6), "2017-07-31 12:34:56.123456"},
+		{types.MakeDatums("4"), "2017-07-31 12:34:56.1234"},
+	}
+	for _, name := range []string{ast.Now, ast.CurrentTimestamp, ast.LocalTime, ast.LocalTimestamp} {
+		for _, t := range tests {
+			f, err := funcs[name].getFunction(datumsToConstants(t.args), s.ctx)
+			c.Assert(err, IsNil)
+			c.Assert(f.isDeterministic(), IsFalse)
+			v, err := f.eval(nil)
+			c.Assert(err, IsNil)
+			c.Assert(v.GetMysqlTime().String(), Equals, t.result, Commentf("%s(%v)", name, t.args))
+		}
+	}
+
+	// The result is the same within a statement, even if it's evaluated later.
+	sc.SetNowTime(time.Time{})
+	f, err := funcs[ast.Now].getFunction(datumsToConstants(types.MakeDatums(6)), s.ctx)
+	c.Assert(err, IsNil)
+	first, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	time.Sleep(2 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		v, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(v.GetMysqlTime().Compare(first.GetMysqlTime()), Equals, 0)
+	}
+	g, err := funcs[ast.CurrentTimestamp].getFunction(datumsToConstants(types.MakeDatums(6)), s.ctx)
+	c.Assert(err, IsNil)
+	v, err := g.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(v.GetMysqlTime().Compare(first.GetMysqlTime()), Equals, 0)
+
+	// The fsp must be a constant in [0, 6].
+	_, err = funcs[ast.Now].getFunction(datumsToConstants(types.MakeDatums(7)), s.ctx)
+	c.Assert(err, NotNil)
+	_, err = funcs[ast.Now].getFunction(datumsToConstants(types.MakeDatums(-1)), s.ctx)
+	c.Assert(err, NotNil)
+	_, err = funcs[ast.Now].getFunction([]Expression{newColumn("a")}, s.ctx)
+	c.Assert(err, NotNil)
+	sc.SetNowTime(time.Time{})
+}
+
 func (s *testEvaluatorSuite) TestSysDate(c *C) {
 	defer testleak.AfterTest(c)()
 	fc := funcs[ast.Sysdate]
@@ -681,6 +850,57 @@ func (s *testEvaluatorSuite) TestStrToDate(c *C) {
 		t1, _ := value.Time.GoTime(time.Local)
 		c.Assert(t1, Equals, test.Expect)
 	}
+
+	// The result is a date if the format only contains the date specifiers, otherwise a datetime.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	col := &Column{RetType: types.NewFieldType(mysql.TypeVarString), Index: 0}
+	typedTests := []struct {
+		args   []Expression
+		sig    builtinFunc
+		expect interface{}
+	}{
+		{datumsToConstants(types.MakeDatums("2016-11-22", "%Y-%m-%d")), &builtinStrToDateDateSig{}, "2016-11-22"},
+		{datumsToConstants(types.MakeDatums("Nov 22, 16", "%b %d, %y")), &builtinStrToDateDateSig{}, "2016-11-22"},
+		{datumsToConstants(types.MakeDatums("200442 Monday", "%X%V %W")), &builtinStrToDateDateSig{}, "2004-10-18"},
+		{datumsToConstants(types.MakeDatums("11:30:00 PM", "%h:%i:%s %p")), &builtinStrToDateDatetimeSig{}, "0000-00-00 23:30:00"},
+		{datumsToConstants(types.MakeDatums("November 22 2016", "%M %e %Y")), &builtinStrToDateDateSig{}, "2016-11-22"},
+		{datumsToConstants(types.MakeDatums("2016-11-22 16:50:22", "%Y-%m-%d %H:%i:%s")), &builtinStrToDateDatetimeSig{}, "2016-11-22 16:50:22"},
+		{datumsToConstants(types.MakeDatums("22/11/2016 04:50:22 PM", "%d/%m/%Y %r")), &builtinStrToDateDatetimeSig{}, "2016-11-22 16:50:22"},
+		{datumsToConstants(types.MakeDatums("2016-11-22 16:50:22.123", "%Y-%m-%d %T.%f")), &builtinStrToDateDatetimeSig{}, "2016-11-22 16:50:22.123000"},
+		{datumsToConstants(types.MakeDatums("16:50:22", "%H:%i:%s")), &builtinStrToDateDatetimeSig{}, "0000-00-00 16:50:22"},
+		// The characters after the matched part and the rest of the format are ignored.
+		{datumsToConstants(types.MakeDatums("2016-11-22 16:50:22", "%Y-%m-%d")), &builtinStrToDateDateSig{}, "2016-11-22"},
+		{datumsToConstants(types.MakeDatums("2016-11-22", "%Y-%m-%d %H:%i:%s")), &builtinStrToDateDatetimeSig{}, "2016-11-22 00:00:00"},
+		// A non-constant format results in a datetime.
+		{[]Expression{datumsToConstants(types.MakeDatums("2016-11-22"))[0], col}, &builtinStrToDateDatetimeSig{}, "2016-11-22 00:00:00.000000"},
+		// NULL arguments.
+		{datumsToConstants(types.MakeDatums(nil, "%Y-%m-%d")), &builtinStrToDateDateSig{}, nil},
+		{datumsToConstants(types.MakeDatums("2016-11-22", nil)), &builtinStrToDateDatetimeSig{}, nil},
+	}
+	for _, t := range typedTests {
+		f, err := fc.getFunction(t.args, s.ctx)
+		c.Assert(err, IsNil)
+		c.Assert(reflect.TypeOf(f), Equals, reflect.TypeOf(t.sig), Commentf("%v", t.args))
+		d, err := f.eval(types.MakeDatums("%Y-%m-%d"))
+		c.Assert(err, IsNil)
+		if t.expect == nil {
+			c.Assert(d.IsNull(), IsTrue, Commentf("%v", t.args))
+			continue
+		}
+		c.Assert(d.Kind(), Equals, types.KindMysqlTime, Commentf("%v", t.args))
+		c.Assert(d.GetMysqlTime().String(), Equals, t.expect, Commentf("%v", t.args))
+	}
+
+	// A failed parse returns NULL with a warning.
+	warnCnt := len(sc.GetWarnings())
+	f, err := fc.getFunction(datumsToConstants(types.MakeDatums("2016/11/22", "%Y-%m-%d")), s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+	warnings := sc.GetWarnings()
+	c.Assert(len(warnings), Equals, warnCnt+1)
+	c.Assert(terror.ErrorEqual(warnings[warnCnt], errWrongValueForType), IsTrue)
 }
 
 func (s *testEvaluatorSuite) TestFromDays(c *C) {
@@ -861,6 +1081,86 @@ func (s *testEvaluatorSuite) TestYearWeek(c *C) {
 	c.Assert(result.IsNull(), IsTrue)
 }
 
+func (s *testEvaluatorSuite) TestWeekModes(c *C) {
+	defer testleak.AfterTest(c)()
+	// The expected weeks and yearweeks of modes 0 to 7, the dates are around the year boundaries.
+	tests := []struct {
+		t         string
+		weeks     [8]int64
+		yearWeeks [8]int64
+	}{
+		{"2000-01-01", [8]int64{0, 0, 52, 52, 0, 0, 52, 52}, [8]int64{199952, 199952, 199952, 199952, 199952, 199952, 199952, 199952}},
+		{"2000-01-02", [8]int64{1, 0, 1, 52, 1, 0, 1, 52}, [8]int64{200001, 199952, 200001, 199952, 200001, 199952, 200001, 199952}},
+		{"2000-01-03", [8]int64{1, 1, 1, 1, 1, 1, 1, 1}, [8]int64{200001, 200001, 200001, 200001, 200001, 200001, 200001, 200001}},
+		{"2000-12-31", [8]int64{53, 52, 53, 52, 53, 52, 1, 52}, [8]int64{200053, 200052, 200053, 200052, 200101, 200052, 200101, 200052}},
+		{"2001-01-01", [8]int64{0, 1, 53, 1, 1, 1, 1, 1}, [8]int64{200053, 200101, 200053, 200101, 200101, 200101, 200101, 200101}},
+		{"2004-12-31", [8]int64{52, 53, 52, 53, 52, 52, 52, 52}, [8]int64{200452, 200453, 200452, 200453, 200452, 200452, 200452, 200452}},
+		{"2005-01-01", [8]int64{0, 0, 52, 53, 0, 0, 52, 52}, [8]int64{200452, 200453, 200452, 200453, 200452, 200452, 200452, 200452}},
+		{"2005-01-02", [8]int64{1, 0, 1, 53, 1, 0, 1, 52}, [8]int64{200501, 200453, 200501, 200453, 200501, 200452, 200501, 200452}},
+		{"2008-12-29", [8]int64{52, 53, 52, 1, 53, 52, 53, 52}, [8]int64{200852, 200901, 200852, 200901, 200853, 200852, 200853, 200852}},
+		{"2008-12-31", [8]int64{52, 53, 52, 1, 53, 52, 53, 52}, [8]int64{200852, 200901, 200852, 200901, 200853, 200852, 200853, 200852}},
+		{"2009-01-01", [8]int64{0, 1, 52, 1, 0, 0, 53, 52}, [8]int64{200852, 200901, 200852, 200901, 200853, 200852, 200853, 200852}},
+		{"2010-01-03", [8]int64{1, 0, 1, 53, 1, 0, 1, 52}, [8]int64{201001, 200953, 201001, 200953, 201001, 200952, 201001, 200952}},
+		{"2012-12-31", [8]int64{53, 53, 53, 1, 53, 53, 1, 53}, [8]int64{201253, 201301, 201253, 201301, 201301, 201253, 201301, 201253}},
+		{"2016-01-01", [8]int64{0, 0, 52, 53, 0, 0, 52, 52}, [8]int64{201552, 201553, 201552, 201553, 201552, 201552, 201552, 201552}},
+		{"1987-01-01", [8]int64{0, 1, 52, 1, 0, 0, 53, 52}, [8]int64{198652, 198701, 198652, 198701, 198653, 198652, 198653, 198652}},
+		{"2008-02-20", [8]int64{7, 8, 7, 8, 8, 7, 8, 7}, [8]int64{200807, 200808, 200807, 200808, 200808, 200807, 200808, 200807}},
+	}
+	for _, test := range tests {
+		for mode := 0; mode < 8; mode++ {
+			args := datumsToConstants(types.MakeDatums(test.t, mode))
+			f, err := funcs[ast.Week].getFunction(args, s.ctx)
+			c.Assert(err, IsNil)
+			week, isNull, err := f.evalInt(nil)
+			c.Assert(err, IsNil)
+			c.Assert(isNull, IsFalse)
+			c.Assert(week, Equals, test.weeks[mode], Commentf("week(%s, %d)", test.t, mode))
+
+			f, err = funcs[ast.YearWeek].getFunction(args, s.ctx)
+			c.Assert(err, IsNil)
+			yearWeek, isNull, err := f.evalInt(nil)
+			c.Assert(err, IsNil)
+			c.Assert(isNull, IsFalse)
+			c.Assert(yearWeek, Equals, test.yearWeeks[mode], Commentf("yearweek(%s, %d)", test.t, mode))
+		}
+		// WEEKOFYEAR is WEEK(date,3).
+		f, err := funcs[ast.WeekOfYear].getFunction(datumsToConstants(types.MakeDatums(test.t)), s.ctx)
+		c.Assert(err, IsNil)
+		week, _, err := f.evalInt(nil)
+		c.Assert(err, IsNil)
+		c.Assert(week, Equals, test.weeks[3], Commentf("weekofyear(%s)", test.t))
+	}
+
+	// The default mode of WEEK is default_week_format, while the one of YEARWEEK is 0.
+	sessionVars := s.ctx.GetSessionVars()
+	err := varsutil.SetSessionSystemVar(sessionVars, variable.DefaultWeekFormat, types.NewIntDatum(1))
+	c.Assert(err, IsNil)
+	defer delete(sessionVars.Systems, variable.DefaultWeekFormat)
+	for _, args := range [][]interface{}{{"2008-12-31"}, {"2008-12-31", nil}} {
+		f, err := funcs[ast.Week].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		week, _, err := f.evalInt(nil)
+		c.Assert(err, IsNil)
+		c.Assert(week, Equals, int64(53))
+		f, err = funcs[ast.YearWeek].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		yearWeek, _, err := f.evalInt(nil)
+		c.Assert(err, IsNil)
+		c.Assert(yearWeek, Equals, int64(200852))
+	}
+
+	// The zero dates and the dates whose month or day is zero are NULL.
+	for _, t := range []string{"0000-00-00", "2016-00-05", "2016-05-00"} {
+		for _, name := range []string{ast.Week, ast.WeekOfYear, ast.YearWeek} {
+			f, err := funcs[name].getFunction(datumsToConstants(types.MakeDatums(t)), s.ctx)
+			c.Assert(err, IsNil)
+			_, isNull, err := f.evalInt(nil)
+			c.Assert(err, IsNil)
+			c.Assert(isNull, IsTrue, Commentf("%s(%s)", name, t))
+		}
+	}
+}
+
 func (s *testEvaluatorSuite) TestTimestampDiff(c *C) {
 	tests := []struct {
 		unit   string
@@ -1063,6 +1363,14 @@ func (s *testEvaluatorSuite) TestMakeDate(c *C) {
 		{[]interface{}{"71", 1}, "1971-01-01"},
 		{[]interface{}{71, "1"}, "1971-01-01"},
 		{[]interface{}{"71", "1"}, "1971-01-01"},
+		{[]interface{}{2011, 365}, "2011-12-31"},
+		{[]interface{}{2011, 366}, "2012-01-01"},
+		{[]interface{}{2012, 366}, "2012-12-31"},
+		{[]interface{}{2012, 367}, "2013-01-01"},
+		{[]interface{}{11, 366}, "2012-01-01"},
+		{[]interface{}{9999, 365}, "9999-12-31"},
+		{[]interface{}{9999, 366}, nil},
+		{[]interface{}{2011, 0}, nil},
 	}
 	Dtbl := tblToDtbl(tbl)
 	maketime := funcs[ast.MakeDate]
@@ -1081,6 +1389,68 @@ func (s *testEvaluatorSuite) TestMakeDate(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestConvertTz(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		Args []interface{}
+		Want interface{}
+	}{
+		{[]interface{}{"2004-01-01 12:00:00", "+00:00", "+10:00"}, "2004-01-01 22:00:00"},
+		{[]interface{}{"2004-01-01 12:00:00.01", "+10:00", "-05:30"}, "2003-12-31 20:30:00.01"},
+		{[]interface{}{"2004-01-01 12:00:00", "+13:00", "-12:59"}, "2003-12-31 10:01:00"},
+		{[]interface{}{20040101120000, "+00:00", "+01:00"}, "2004-01-01 13:00:00"},
+		{[]interface{}{"2004-01-01 12:00:00", "GMT", "Europe/Paris"}, "2004-01-01 13:00:00"},
+		{[]interface{}{"2004-07-01 12:00:00", "GMT", "Europe/Paris"}, "2004-07-01 14:00:00"},
+		{[]interface{}{"2004-07-01 12:00:00", "Asia/Shanghai", "+00:00"}, "2004-07-01 04:00:00"},
+		// The daylight saving time of America/New_York starts at 2017-03-12 07:00:00 UTC.
+		{[]interface{}{"2017-03-12 06:59:59", "UTC", "America/New_York"}, "2017-03-12 01:59:59"},
+		{[]interface{}{"2017-03-12 07:00:00", "UTC", "America/New_York"}, "2017-03-12 03:00:00"},
+		{[]interface{}{"2017-03-12 03:00:00", "America/New_York", "+00:00"}, "2017-03-12 07:00:00"},
+		{[]interface{}{"9999-12-31 23:00:00", "+00:00", "+02:00"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "Unknown/Zone", "+00:00"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "+00:00", "+13:01"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "+00:00", "-13:00"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "+00:00", "+1:00"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "+00:00", "+01:60"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "", "+00:00"}, nil},
+		{[]interface{}{"0000-00-00 00:00:00", "+00:00", "+01:00"}, nil},
+		{[]interface{}{"abc", "+00:00", "+01:00"}, nil},
+		{[]interface{}{nil, "+00:00", "+01:00"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", nil, "+01:00"}, nil},
+		{[]interface{}{"2004-01-01 12:00:00", "+00:00", nil}, nil},
+	}
+	Dtbl := tblToDtbl(tbl)
+	convertTz := funcs[ast.ConvertTz]
+	for idx, t := range Dtbl {
+		f, err := convertTz.getFunction(datumsToConstants(t["Args"]), s.ctx)
+		c.Assert(err, IsNil)
+		got, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		if t["Want"][0].Kind() == types.KindNull {
+			c.Assert(got.Kind(), Equals, types.KindNull, Commentf("[%v] - args:%v", idx, t["Args"]))
+		} else {
+			want, err := t["Want"][0].ToString()
+			c.Assert(err, IsNil)
+			c.Assert(got.GetMysqlTime().String(), Equals, want, Commentf("[%v] - args:%v", idx, t["Args"]))
+		}
+	}
+
+	// A datetime argument keeps its fsp.
+	col := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeDatetime)}
+	f, err := convertTz.getFunction(append([]Expression{col}, datumsToConstants(types.MakeDatums("+00:00", "+01:00"))...), s.ctx)
+	c.Assert(err, IsNil)
+	dt, err := types.ParseTime("2004-01-01 12:00:00.123", mysql.TypeDatetime, 3)
+	c.Assert(err, IsNil)
+	got, isNull, err := f.evalTime(types.MakeDatums(dt))
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(got.String(), Equals, "2004-01-01 13:00:00.123")
+	str, isNull, err := f.evalString(types.MakeDatums(dt))
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(str, Equals, "2004-01-01 13:00:00.123")
+}
+
 func (s *testEvaluatorSuite) TestMakeTime(c *C) {
 	defer testleak.AfterTest(c)()
 	tbl := []struct {
@@ -1119,6 +1489,7 @@ func (s *testEvaluatorSuite) TestMakeTime(c *C) {
 		{[]interface{}{837, 59, 59.1}, "837:59:59.1"},
 		{[]interface{}{838, 59, 59.1}, "838:59:59.0"},
 		{[]interface{}{-838, 59, 59.1}, "-838:59:59.0"},
+		{[]interface{}{838, 58, 59.1}, "838:58:59.1"},
 		{[]interface{}{1000, 1, 1}, "838:59:59"},
 		{[]interface{}{-1000, 1, 1.23}, "-838:59:59.00"},
 		{[]interface{}{1000, 59.1, 1}, "838:59:59"},
@@ -1152,6 +1523,37 @@ func (s *testEvaluatorSuite) TestMakeTime(c *C) {
 			c.Assert(got.GetMysqlDuration().String(), Equals, want, Commentf("[%v] - args:%v", idx, t["Args"]))
 		}
 	}
+
+	// A warning is appended when the time is clamped to the max time range.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	sc.SetWarnings(nil)
+	f, err := maketime.getFunction(datumsToConstants(types.MakeDatums(1000, 1, 1)), s.ctx)
+	c.Assert(err, IsNil)
+	got, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(got.GetMysqlDuration().String(), Equals, "838:59:59")
+	warnings := sc.GetWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(terror.ErrorEqual(warnings[0], errTruncatedWrongValue), IsTrue)
+	c.Assert(warnings[0].Error(), Matches, ".*'1000:01:01'.*")
+
+	// The fractional part of second is truncated if it exceeds the max time range.
+	sc.SetWarnings(nil)
+	f, err = maketime.getFunction(datumsToConstants(types.MakeDatums(838, 59, 59.5)), s.ctx)
+	c.Assert(err, IsNil)
+	got, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(got.GetMysqlDuration().String(), Equals, "838:59:59.0")
+	warnings = sc.GetWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Assert(terror.ErrorEqual(warnings[0], errTruncatedWrongValue), IsTrue)
+
+	sc.SetWarnings(nil)
+	f, err = maketime.getFunction(datumsToConstants(types.MakeDatums(837, 1, 1)), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(sc.GetWarnings(), HasLen, 0)
 }
 
 func (s *testEvaluatorSuite) TestQuarter(c *C) {
@@ -1214,6 +1616,14 @@ func (s *testEvaluatorSuite) TestGetFormat(c *C) {
 		{"TIME", "ISO", "%H:%i:%s"},
 		{"TIME", "EUR", "%H.%i.%s"},
 		{"TIME", "INTERNAL", "%H%i%s"},
+
+		{"TIMESTAMP", "USA", "%Y-%m-%d %H.%i.%s"},
+		{"TIMESTAMP", "JIS", "%Y-%m-%d %H:%i:%s"},
+		{"TIMESTAMP", "ISO", "%Y-%m-%d %H:%i:%s"},
+		{"TIMESTAMP", "EUR", "%Y-%m-%d %H.%i.%s"},
+		{"TIMESTAMP", "INTERNAL", "%Y%m%d%H%i%s"},
+
+		{"DATE", "usa", "%m.%d.%Y"},
 	}
 
 	fc := funcs[ast.GetFormat]
@@ -1225,6 +1635,22 @@ func (s *testEvaluatorSuite) TestGetFormat(c *C) {
 		c.Assert(err, IsNil)
 		result, _ := d.ToString()
 		c.Assert(result, Equals, test.expect)
+		c.Assert(f.isDeterministic(), IsTrue)
+	}
+
+	// Unknown location and null arguments return null.
+	nullTests := [][]interface{}{
+		{"DATE", "CHN"},
+		{"YEAR", "USA"},
+		{nil, "USA"},
+		{"DATE", nil},
+	}
+	for _, test := range nullTests {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(test...)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.IsNull(), IsTrue)
 	}
 }
 
@@ -1343,6 +1769,14 @@ func (s *testEvaluatorSuite) TestPeriodAdd(c *C) {
 		{7011, 3, true, 197102},
 		{12323, 10, true, 12509},
 		{0, 3, true, 0},
+		// Two-digit years below 70 are in the 21st century.
+		{6912, 1, true, 207001},
+		{7001, -1, true, 196912},
+		{9912, 1, true, 200001},
+		// Invalid months are normalized.
+		{202013, 0, true, 202101},
+		{201600, 1, true, 201601},
+		{201601, -24192, true, 0},
 	}
 
 	fc := funcs[ast.PeriodAdd]
@@ -1363,6 +1797,42 @@ func (s *testEvaluatorSuite) TestPeriodAdd(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestPeriodDiff(c *C) {
+	defer testleak.AfterTest(c)()
+	tests := []struct {
+		P1     interface{}
+		P2     interface{}
+		Expect interface{}
+	}{
+		{200802, 200703, 11},
+		{200703, 200802, -11},
+		{"200802", 200703.2, 11},
+		{1612, 201701, -1},
+		{6912, 7001, 1199},
+		{202013, 202101, 0},
+		{0, 201701, -24204},
+		{nil, 201701, nil},
+		{201701, nil, nil},
+	}
+	fc := funcs[ast.PeriodDiff]
+	for _, t := range tests {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.P1, t.P2)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.Expect), Commentf("%v %v", t.P1, t.P2))
+	}
+
+	// PERIOD_ADD returns NULL for the NULL arguments.
+	for _, args := range [][]interface{}{{nil, 1}, {201701, nil}} {
+		f, err := funcs[ast.PeriodAdd].getFunction(datumsToConstants(types.MakeDatums(args...)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.IsNull(), IsTrue)
+	}
+}
+
 func (s *testEvaluatorSuite) TestTimeToSec(c *C) {
 	tests := []struct {
 		t      string
@@ -1378,6 +1848,10 @@ func (s *testEvaluatorSuite) TestTimeToSec(c *C) {
 		{"1:00", 3600},
 		{"1:0:0", 3600},
 		{"-02:00", -7200},
+		{"-838:59:59", -3020399},
+		{"100:00:00", 360000},
+		{"00:00:01.9", 1},
+		{"-00:00:01.9", -1},
 	}
 	fc := funcs[ast.TimeToSec]
 	for _, test := range tests {
@@ -1415,6 +1889,11 @@ func (s *testEvaluatorSuite) TestSecToTime(c *C) {
 		{"123.4567891", "00:02:03.456789"},
 		{"123", "00:02:03.000000"},
 		{"abc", "00:00:00.000000"},
+		{"12abc", "00:00:12.000000"},
+		{"59.9999999", "00:01:00.000000"},
+		{-0.5, "-00:00:00.5"},
+		{types.NewDecFromStringForTest("1.500"), "00:00:01.500"},
+		{types.NewDecFromStringForTest("-3723.000001"), "-01:02:03.000001"},
 	}
 	for _, test := range tests {
 		t := []types.Datum{types.NewDatum(test.param)}
@@ -1422,7 +1901,90 @@ func (s *testEvaluatorSuite) TestSecToTime(c *C) {
 		c.Assert(err, IsNil)
 		d, err := f.eval(nil)
 		c.Assert(err, IsNil)
+		// The result agrees with the inferred type TIME.
+		c.Assert(d.Kind(), Equals, types.KindMysqlDuration)
 		result, _ := d.ToString()
 		c.Assert(result, Equals, test.expect)
 	}
+
+	// The result is clamped to the range of TIME with a warning.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	overflowTests := []struct {
+		param  interface{}
+		expect string
+	}{
+		{3020400, "838:59:59"},
+		{-3020400.5, "-838:59:59.0"},
+		{"99999999999999999999999", "838:59:59.000000"},
+	}
+	for _, test := range overflowTests {
+		warnCnt := len(sc.GetWarnings())
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(test.param)), s.ctx)
+		c.Assert(err, IsNil)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.GetMysqlDuration().String(), Equals, test.expect)
+		c.Assert(sc.GetWarnings(), HasLen, warnCnt+1)
+	}
+}
+
+func (s *testEvaluatorSuite) TestExtractDatetimeAndDuration(c *C) {
+	defer testleak.AfterTest(c)()
+	fc := funcs[ast.Extract]
+	dtTests := []struct {
+		unit   string
+		date   interface{}
+		expect interface{}
+	}{
+		{"DAY_HOUR", "2020-03-15 10:00", int64(1510)},
+		{"YEAR_MONTH", "2020-03-15 10:00", int64(202003)},
+		{"DAY_SECOND", "2020-03-15 10:20:30", int64(15102030)},
+		{"HOUR_MICROSECOND", "2020-03-15 10:20:30.000012", int64(102030000012)},
+		{"DAY_HOUR", "2020-13-15 10:00", nil},
+		{"DAY_HOUR", nil, nil},
+	}
+	for _, t := range dtTests {
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.unit, t.date)), s.ctx)
+		c.Assert(err, IsNil)
+		_, ok := f.(*builtinExtractDatetimeSig)
+		c.Assert(ok, IsTrue)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil, Commentf("%s of %v", t.unit, t.date))
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.expect), Commentf("%s of %v", t.unit, t.date))
+	}
+
+	// An invalid date is extracted to NULL with a warning, even in the strict mode.
+	sc := s.ctx.GetSessionVars().StmtCtx
+	warnCnt := len(sc.GetWarnings())
+	f, err := fc.getFunction(datumsToConstants(types.MakeDatums("DAY_HOUR", "2020-13-15 10:00")), s.ctx)
+	c.Assert(err, IsNil)
+	d, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+	c.Assert(sc.GetWarnings(), HasLen, warnCnt+1)
+
+	durTests := []struct {
+		unit     string
+		duration string
+		expect   int64
+	}{
+		{"HOUR", "50:10:20", 50},
+		{"DAY_HOUR", "50:10:20", 50},
+		{"DAY", "50:10:20", 0},
+		{"MINUTE_SECOND", "50:10:20", 1020},
+		{"HOUR_MICROSECOND", "10:20:30.5", 102030500000},
+		{"SECOND", "-10:20:30", -30},
+		{"HOUR_SECOND", "-10:20:30", -102030},
+	}
+	for _, t := range durTests {
+		dur, err := types.ParseDuration(t.duration, types.MaxFsp)
+		c.Assert(err, IsNil)
+		f, err := fc.getFunction(datumsToConstants(types.MakeDatums(t.unit, dur)), s.ctx)
+		c.Assert(err, IsNil)
+		_, ok := f.(*builtinExtractDurationSig)
+		c.Assert(ok, IsTrue)
+		d, err := f.eval(nil)
+		c.Assert(err, IsNil)
+		c.Assert(d.GetInt64(), Equals, t.expect, Commentf("%s of %s", t.unit, t.duration))
+	}
 }
@@ -43,6 +43,18 @@ func (col *CorrelatedColumn) Eval(row []types.Datum) (types.Datum, error) {
 	return *col.Data, nil
 }
 
+// EvalTime returns DATE/DATETIME/TIMESTAMP representation of CorrelatedColumn.
+func (col *CorrelatedColumn) EvalTime(row []types.Datum, sc *variable.StatementContext) (types.Time, bool, error) {
+	val, isNull, err := evalExprToTime(col, row, sc)
+	return val, isNull, errors.Trace(err)
+}
+
+// EvalDuration returns duration representation of CorrelatedColumn.
+func (col *CorrelatedColumn) EvalDuration(row []types.Datum, sc *variable.StatementContext) (types.Duration, bool, error) {
+	val, isNull, err := evalExprToDuration(col, row, sc)
+	return val, isNull, errors.Trace(err)
+}
+
 // Equal implements Expression interface.
 func (col *CorrelatedColumn) Equal(expr Expression, ctx context.Context) bool {
 	if cc, ok := expr.(*CorrelatedColumn); ok {
@@ -64,10 +76,23 @@ func (col *CorrelatedColumn) Decorrelate(schema *Schema) Expression {
 	return &col.Column
 }
 
+// ReplaceColumn implements Expression interface.
+func (col *CorrelatedColumn) ReplaceColumn(_ *Schema, _ []Expression) Expression {
+	return col
+}
+
 // ResolveIndices implements Expression interface.
 func (col *CorrelatedColumn) ResolveIndices(_ *Schema) {
 }
 
+// ResetCache clears the value of the outer row cached in col, the correlated columns sharing the Data with it
+// are cleared as well. Then col is evaluated to NULL until the value of the next outer row is set.
+func (col *CorrelatedColumn) ResetCache() {
+	if col.Data != nil {
+		col.Data.SetNull()
+	}
+}
+
 // Column represents a column.
 type Column struct {
 	FromID  string
@@ -87,9 +112,25 @@ type Column struct {
 	// Index is only used for execution.
 	Index int
 
+	// VirtualExpr is the expression of a generated column. If it's not nil, the column is computed by
+	// evaluating it against the row instead of reading row[Index]. Use SetVirtualExpr to set it.
+	VirtualExpr Expression
+
 	hashcode []byte
 }
 
+// SetVirtualExpr sets the VirtualExpr of col, a cast is added if the type of expr differs from col and
+// CAST supports the type of col. Otherwise Eval returns the value of expr as is, and the caller converts
+// it to the column type like the values read from storage.
+func (col *Column) SetVirtualExpr(expr Expression, ctx context.Context) {
+	_, castable := castableTypes[col.RetType.Tp]
+	if castable && expr.GetType().CompactStr() != col.RetType.CompactStr() {
+		expr = NewCastFunc(col.RetType, expr, ctx)
+	}
+	col.VirtualExpr = expr
+	col.hashcode = nil
+}
+
 // Equal implements Expression interface.
 func (col *Column) Equal(expr Expression, _ context.Context) bool {
 	if newCol, ok := expr.(*Column); ok {
@@ -123,36 +164,79 @@ func (col *Column) GetType() *types.FieldType {
 
 // Eval implements Expression interface.
 func (col *Column) Eval(row []types.Datum) (types.Datum, error) {
+	if col.VirtualExpr != nil {
+		d, err := col.VirtualExpr.Eval(row)
+		return d, errors.Trace(err)
+	}
 	return row[col.Index], nil
 }
 
 // EvalInt returns int representation of Column.
 func (col *Column) EvalInt(row []types.Datum, sc *variable.StatementContext) (int64, bool, error) {
+	if col.VirtualExpr != nil {
+		val, isNull, err := col.VirtualExpr.EvalInt(row, sc)
+		return val, isNull, errors.Trace(err)
+	}
 	val, isNull, err := evalExprToInt(col, row, sc)
 	return val, isNull, errors.Trace(err)
 }
 
 // EvalReal returns real representation of Column.
 func (col *Column) EvalReal(row []types.Datum, sc *variable.StatementContext) (float64, bool, error) {
+	if col.VirtualExpr != nil {
+		val, isNull, err := col.VirtualExpr.EvalReal(row, sc)
+		return val, isNull, errors.Trace(err)
+	}
 	val, isNull, err := evalExprToReal(col, row, sc)
 	return val, isNull, errors.Trace(err)
 }
 
 // EvalString returns string representation of Column.
 func (col *Column) EvalString(row []types.Datum, sc *variable.StatementContext) (string, bool, error) {
+	if col.VirtualExpr != nil {
+		val, isNull, err := col.VirtualExpr.EvalString(row, sc)
+		return val, isNull, errors.Trace(err)
+	}
 	val, isNull, err := evalExprToString(col, row, sc)
 	return val, isNull, errors.Trace(err)
 }
 
 // EvalDecimal returns decimal representation of Column.
 func (col *Column) EvalDecimal(row []types.Datum, sc *variable.StatementContext) (*types.MyDecimal, bool, error) {
+	if col.VirtualExpr != nil {
+		val, isNull, err := col.VirtualExpr.EvalDecimal(row, sc)
+		return val, isNull, errors.Trace(err)
+	}
 	val, isNull, err := evalExprToDecimal(col, row, sc)
 	return val, isNull, errors.Trace(err)
 }
 
+// EvalTime returns DATE/DATETIME/TIMESTAMP representation of Column.
+func (col *Column) EvalTime(row []types.Datum, sc *variable.StatementContext) (types.Time, bool, error) {
+	if col.VirtualExpr != nil {
+		val, isNull, err := col.VirtualExpr.EvalTime(row, sc)
+		return val, isNull, errors.Trace(err)
+	}
+	val, isNull, err := evalExprToTime(col, row, sc)
+	return val, isNull, errors.Trace(err)
+}
+
+// EvalDuration returns duration representation of Column.
+func (col *Column) EvalDuration(row []types.Datum, sc *variable.StatementContext) (types.Duration, bool, error) {
+	if col.VirtualExpr != nil {
+		val, isNull, err := col.VirtualExpr.EvalDuration(row, sc)
+		return val, isNull, errors.Trace(err)
+	}
+	val, isNull, err := evalExprToDuration(col, row, sc)
+	return val, isNull, errors.Trace(err)
+}
+
 // Clone implements Expression interface.
 func (col *Column) Clone() Expression {
 	newCol := *col
+	if col.VirtualExpr != nil {
+		newCol.VirtualExpr = col.VirtualExpr.Clone()
+	}
 	return &newCol
 }
 
@@ -166,17 +250,35 @@ func (col *Column) Decorrelate(_ *Schema) Expression {
 	return col
 }
 
+// ReplaceColumn implements Expression interface.
+func (col *Column) ReplaceColumn(schema *Schema, newExprs []Expression) Expression {
+	id := schema.ColumnIndex(col)
+	if id == -1 {
+		return col
+	}
+	return newExprs[id].Clone()
+}
+
 // HashCode implements Expression interface.
 func (col *Column) HashCode() []byte {
 	if len(col.hashcode) != 0 {
 		return col.hashcode
 	}
-	col.hashcode, _ = codec.EncodeValue(col.hashcode, types.NewStringDatum(col.FromID), types.NewIntDatum(int64(col.Position)))
+	// The type isn't taken into account, since Equal identifies a column by its FromID and Position only.
+	values := []types.Datum{types.NewStringDatum(col.FromID), types.NewIntDatum(int64(col.Position))}
+	if col.VirtualExpr != nil {
+		values = append(values, types.NewBytesDatum(col.VirtualExpr.HashCode()))
+	}
+	col.hashcode, _ = codec.EncodeValue(col.hashcode, values...)
 	return col.hashcode
 }
 
 // ResolveIndices implements Expression interface.
 func (col *Column) ResolveIndices(schema *Schema) {
+	if col.VirtualExpr != nil {
+		col.ResolveIndicesByVirtualColumn(schema)
+		return
+	}
 	col.Index = schema.ColumnIndex(col)
 	// If col's index equals to -1, it means a internal logic error happens.
 	if col.Index == -1 {
@@ -184,6 +286,18 @@ func (col *Column) ResolveIndices(schema *Schema) {
 	}
 }
 
+// ResolveIndicesByVirtualColumn resolves the indices of a generated column. A generated column is computed
+// from the other columns of the row, so its own index is useless and the columns referenced by VirtualExpr
+// are resolved instead, recursively if they are generated columns too. It's the same as ResolveIndices for
+// an ordinary column, and it can be called more than once.
+func (col *Column) ResolveIndicesByVirtualColumn(schema *Schema) {
+	if col.VirtualExpr == nil {
+		col.ResolveIndices(schema)
+		return
+	}
+	col.VirtualExpr.ResolveIndices(schema)
+}
+
 // Column2Exprs will transfer column slice to expression slice.
 func Column2Exprs(cols []*Column) []Expression {
 	result := make([]Expression, 0, len(cols))
@@ -15,6 +15,9 @@ package expression
 
 import (
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // FoldConstant does constant folding optimization on an expression.
@@ -32,7 +35,29 @@ func FoldConstant(expr Expression) Expression {
 			canFold = false
 		}
 	}
+	scalarFunc.resetHashCode()
+	// COLLATE is kept, a constant loses the explicit coercibility of the collation.
+	if scalarFunc.FuncName.L == ast.Collate {
+		canFold = false
+	}
+	// CHARSET and COLLATION only depend on the type of the argument.
+	if scalarFunc.FuncName.L == ast.Charset || scalarFunc.FuncName.L == ast.Collation {
+		canFold = true
+	}
 	if !canFold {
+		if _, ok := nullRejectedCmps[scalarFunc.FuncName.L]; ok && (IsNullConstant(args[0]) || IsNullConstant(args[1])) {
+			// "x = NULL" is NULL whatever x is.
+			return &Constant{Value: types.Datum{}, RetType: scalarFunc.RetType}
+		}
+		if scalarFunc.FuncName.L == ast.NullEQ {
+			return foldNullEQ(scalarFunc)
+		}
+		if scalarFunc.FuncName.L == ast.Case {
+			return foldCaseWhen(scalarFunc)
+		}
+		if scalarFunc.FuncName.L == ast.LogicXor {
+			return foldLogicXor(scalarFunc)
+		}
 		return expr
 	}
 	value, err := scalarFunc.Eval(nil)
@@ -45,3 +70,139 @@ func FoldConstant(expr Expression) Expression {
 		RetType: scalarFunc.RetType,
 	}
 }
+
+// FoldConstants does constant folding on every predicate of a CNF list. The predicates folded to
+// constant true are dropped, and the whole list collapses to a single Zero when any predicate is folded
+// to constant false or NULL. The order of the other predicates is kept.
+func FoldConstants(exprs []Expression) []Expression {
+	result := make([]Expression, 0, len(exprs))
+	for _, expr := range exprs {
+		sc := new(variable.StatementContext)
+		if sf, ok := expr.(*ScalarFunction); ok && sf.GetCtx() != nil {
+			sc = sf.GetCtx().GetSessionVars().StmtCtx
+		}
+		folded := FoldConstant(expr)
+		con, ok := folded.(*Constant)
+		if !ok {
+			result = append(result, folded)
+			continue
+		}
+		if con.IsNull() {
+			return []Expression{Zero}
+		}
+		isTrue, err := con.Value.ToBool(sc)
+		if err != nil {
+			result = append(result, folded)
+			continue
+		}
+		if isTrue == 0 {
+			return []Expression{Zero}
+		}
+	}
+	return result
+}
+
+// nullRejectedCmps are the comparisons which are NULL if either argument is NULL.
+var nullRejectedCmps = map[string]struct{}{
+	ast.EQ: {},
+	ast.NE: {},
+	ast.LT: {},
+	ast.LE: {},
+	ast.GT: {},
+	ast.GE: {},
+}
+
+// foldNullEQ rewrites "a <=> NULL" and "NULL <=> a" to "a IS NULL", so that the rest of
+// the optimizer can treat it the same as the IS NULL predicate.
+func foldNullEQ(sf *ScalarFunction) Expression {
+	args := sf.GetArgs()
+	var arg Expression
+	if IsNullConstant(args[0]) {
+		arg = args[1]
+	} else if IsNullConstant(args[1]) {
+		arg = args[0]
+	} else {
+		return sf
+	}
+	isNull, err := NewFunction(sf.GetCtx(), ast.IsNull, sf.RetType, arg)
+	if err != nil {
+		return sf
+	}
+	return isNull
+}
+
+// foldLogicXor folds "x XOR NULL" to NULL. When x is an integer, "x XOR false" is rewritten to "x != 0"
+// and "x XOR true" to "x = 0", which are more friendly to the range builder. The rewriting needs a context
+// and the type of x.
+func foldLogicXor(sf *ScalarFunction) Expression {
+	args := sf.GetArgs()
+	x, arg := args[0], args[1]
+	if _, ok := arg.(*Constant); !ok {
+		x, arg = args[1], args[0]
+	}
+	con, ok := arg.(*Constant)
+	if !ok {
+		return sf
+	}
+	if con.IsNull() {
+		return &Constant{Value: types.Datum{}, RetType: sf.RetType}
+	}
+	ctx := sf.GetCtx()
+	if ctx == nil || x.GetType() == nil || x.GetType().ToClass() != types.ClassInt {
+		return sf
+	}
+	isTrue, err := con.Value.ToBool(ctx.GetSessionVars().StmtCtx)
+	if err != nil {
+		return sf
+	}
+	op := ast.NE
+	if isTrue == 1 {
+		op = ast.EQ
+	}
+	newFunc, err := NewFunction(ctx, op, sf.RetType, x, Zero)
+	if err != nil {
+		return sf
+	}
+	return newFunc
+}
+
+// foldCaseWhen folds a CASE function whose leading WHEN conditions are constants. Conditions that
+// are null or false are skipped, and the first true one selects its THEN branch even if the branch
+// itself is not a constant. When nothing matches, the ELSE branch or NULL is returned.
+// The function isn't folded without a context, which is needed to convert the conditions and the result.
+func foldCaseWhen(sf *ScalarFunction) Expression {
+	ctx := sf.GetCtx()
+	if ctx == nil {
+		return sf
+	}
+	args := sf.GetArgs()
+	sc := ctx.GetSessionVars().StmtCtx
+	l := len(args)
+	var result Expression
+	for i := 0; i < l-1 && result == nil; i += 2 {
+		con, ok := args[i].(*Constant)
+		if !ok {
+			return sf
+		}
+		if con.IsNull() {
+			continue
+		}
+		isTrue, err := con.Value.ToBool(sc)
+		if err != nil {
+			return sf
+		}
+		if isTrue == 1 {
+			result = args[i+1]
+		}
+	}
+	if result == nil {
+		if l%2 == 0 {
+			return &Constant{Value: types.Datum{}, RetType: sf.RetType}
+		}
+		result = args[l-1]
+	}
+	if sf.RetType != nil && result.GetType().ToClass() != sf.RetType.ToClass() {
+		return NewCastFunc(sf.RetType, result, ctx)
+	}
+	return result
+}
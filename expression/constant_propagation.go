@@ -129,7 +129,7 @@ func (s *propagateConstantSolver) propagateEQ() {
 		}
 		for i, cond := range s.conditions {
 			if !visited[i] {
-				s.conditions[i] = ColumnSubstitute(cond, NewSchema(cols...), cons)
+				s.conditions[i] = FoldConstant(ColumnSubstitute(cond, NewSchema(cols...), cons))
 			}
 		}
 	}
@@ -155,6 +155,31 @@ func (s *propagateConstantSolver) validPropagateCond(cond Expression, funNameMap
 	return nil, nil
 }
 
+// validIsNullCond checks if the cond is an expression like [column is null], the column can be substituted by null.
+func (s *propagateConstantSolver) validIsNullCond(cond Expression) *Column {
+	if f, ok := cond.(*ScalarFunction); ok && f.FuncName.L == ast.IsNull {
+		if col, ok := f.GetArgs()[0].(*Column); ok {
+			return col
+		}
+	}
+	return nil
+}
+
+// hasNullOperand checks if the cond is a comparison with a null constant operand, which is never true.
+// It happens after a column in [column is null] has been substituted by null.
+func (s *propagateConstantSolver) hasNullOperand(cond Expression) bool {
+	f, ok := cond.(*ScalarFunction)
+	if !ok || (!eqFuncNameMap[f.FuncName.L] && !inEqFuncNameMap[f.FuncName.L]) {
+		return false
+	}
+	for _, arg := range f.GetArgs() {
+		if con, ok := arg.(*Constant); ok && con.Value.IsNull() {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *propagateConstantSolver) setConds2ConstFalse() {
 	s.conditions = []Expression{&Constant{
 		Value:   types.NewDatum(false),
@@ -170,6 +195,13 @@ func (s *propagateConstantSolver) pickNewEQConds(visited []bool) (retMapper map[
 			continue
 		}
 		col, con := s.validPropagateCond(cond, eqFuncNameMap)
+		isNull := false
+		if col == nil {
+			if col = s.validIsNullCond(cond); col != nil {
+				con = &Constant{Value: types.Datum{}, RetType: types.NewFieldType(mysql.TypeNull)}
+				isNull = true
+			}
+		}
 		// Then we check if this CNF item is a false constant. If so, we will set the whole condition to false.
 		ok := false
 		if col == nil {
@@ -180,10 +212,14 @@ func (s *propagateConstantSolver) pickNewEQConds(visited []bool) (retMapper map[
 					return nil
 				}
 			}
+			if s.hasNullOperand(cond) {
+				s.setConds2ConstFalse()
+				return nil
+			}
 			continue
 		}
 		visited[i] = true
-		updated, foreverFalse := s.tryToUpdateEQList(col, con)
+		updated, foreverFalse := s.tryToUpdateEQList(col, con, isNull)
 		if foreverFalse {
 			s.setConds2ConstFalse()
 			return nil
@@ -196,9 +232,10 @@ func (s *propagateConstantSolver) pickNewEQConds(visited []bool) (retMapper map[
 }
 
 // tryToUpdateEQList tries to update the eqList. When the eqList has store this column with a different constant, like
-// a = 1 and a = 2, we set the second return value to false.
-func (s *propagateConstantSolver) tryToUpdateEQList(col *Column, con *Constant) (bool, bool) {
-	if con.Value.IsNull() {
+// a = 1 and a = 2, we set the second return value to true. A null constant comes from [column is null] when isNull
+// is true, otherwise it comes from [column = null] which can never be true.
+func (s *propagateConstantSolver) tryToUpdateEQList(col *Column, con *Constant, isNull bool) (bool, bool) {
+	if con.Value.IsNull() && !isNull {
 		return false, true
 	}
 	id := s.getColID(col)
@@ -252,6 +289,7 @@ func (s *propagateConstantSolver) insertCol(col *Column) {
 }
 
 // PropagateConstant propagate constant values of equality predicates and inequality predicates in a condition.
+// A column in [column is null] is substituted by null, and the predicates are folded again after substitution.
 func PropagateConstant(ctx context.Context, conditions []Expression) []Expression {
 	solver := &propagateConstantSolver{
 		colMapper: make(map[string]int),
@@ -14,6 +14,7 @@
 package expression
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -68,7 +69,7 @@ func (*testExpressionSuite) TestConstantPropagation(c *C) {
 				newFunction(ast.EQ, newColumn("d"), newLonglong(1)),
 				newFunction(ast.OrOr, newLonglong(1), newColumn("a")),
 			},
-			result: "eq(test.t.a, 1), eq(test.t.b, 1), eq(test.t.c, 1), eq(test.t.d, 1), or(1, 1)",
+			result: "1, eq(test.t.a, 1), eq(test.t.b, 1), eq(test.t.c, 1), eq(test.t.d, 1)",
 		},
 		{
 			conditions: []Expression{
@@ -115,6 +116,41 @@ func (*testExpressionSuite) TestConstantPropagation(c *C) {
 			},
 			result: "0",
 		},
+		{
+			conditions: []Expression{
+				newFunction(ast.EQ, newColumn("a"), newColumn("b")),
+				newFunction(ast.EQ, newColumn("b"), newLonglong(5)),
+			},
+			result: "eq(test.t.a, 5), eq(test.t.b, 5)",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.EQ, newColumn("a"), newLonglong(1)),
+				newFunction(ast.EQ, newColumn("a"), newLonglong(2)),
+			},
+			result: "0",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.IsNull, newColumn("a")),
+				newFunction(ast.EQ, newColumn("a"), newLonglong(1)),
+			},
+			result: "0",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.IsNull, newColumn("a")),
+				newFunction(ast.GT, newColumn("a"), newColumn("b")),
+			},
+			result: "0",
+		},
+		{
+			conditions: []Expression{
+				newFunction(ast.IsNull, newColumn("a")),
+				newFunction(ast.EQ, newFunction(ast.Ifnull, newColumn("a"), newLonglong(3)), newColumn("b")),
+			},
+			result: "eq(3, test.t.b), isnull(test.t.a)",
+		},
 	}
 	for _, tt := range tests {
 		ctx := mock.NewContext()
@@ -154,6 +190,26 @@ func (*testExpressionSuite) TestConstantFolding(c *C) {
 			condition: newFunction(ast.IsNull, newLonglong(1)),
 			result:    "0",
 		},
+		{
+			condition: newFunction(ast.LogicXor, newColumn("a"), newLonglong(0)),
+			result:    "ne(test.t.a, 0)",
+		},
+		{
+			condition: newFunction(ast.LogicXor, newLonglong(2), newColumn("a")),
+			result:    "eq(test.t.a, 0)",
+		},
+		{
+			condition: newFunction(ast.LogicXor, newColumn("a"), Null),
+			result:    "<nil>",
+		},
+		{
+			condition: newFunction(ast.LogicXor, newColumn("a"), newFunction(ast.LogicXor, newLonglong(1), newLonglong(0))),
+			result:    "eq(test.t.a, 0)",
+		},
+		{
+			condition: newFunction(ast.LogicXor, newColumn("a"), newColumn("b")),
+			result:    "xor(test.t.a, test.t.b)",
+		},
 		{
 			condition: newFunction(ast.EQ, newColumn("a"), newFunction(ast.UnaryNot, newFunction(ast.Plus, newLonglong(1), newLonglong(1)))),
 			result:    "eq(test.t.a, 0)",
@@ -162,9 +218,176 @@ func (*testExpressionSuite) TestConstantFolding(c *C) {
 			condition: newFunction(ast.LT, newColumn("a"), newFunction(ast.Plus, newColumn("b"), newFunction(ast.Plus, newLonglong(2), newLonglong(1)))),
 			result:    "lt(test.t.a, plus(test.t.b, 3))",
 		},
+		{
+			condition: newFunction(ast.NullEQ, newLonglong(1), newFunction(ast.Plus, newLonglong(0), newLonglong(1))),
+			result:    "1",
+		},
+		{
+			condition: newFunction(ast.NullEQ, Null, newFunction(ast.Plus, Null, newLonglong(1))),
+			result:    "1",
+		},
+		{
+			condition: newFunction(ast.NullEQ, newLonglong(1), Null),
+			result:    "0",
+		},
+		{
+			condition: newFunction(ast.NullEQ, newColumn("a"), Null),
+			result:    "isnull(test.t.a)",
+		},
+		{
+			condition: newFunction(ast.NullEQ, Null, newColumn("a")),
+			result:    "isnull(test.t.a)",
+		},
+		{
+			condition: newFunction(ast.NullEQ, newColumn("a"), newLonglong(1)),
+			result:    "nulleq(test.t.a, 1)",
+		},
+		{
+			condition: newFunction(ast.EQ, newColumn("a"), Null),
+			result:    "<nil>",
+		},
+		{
+			condition: newFunction(ast.LT, Null, newFunction(ast.Plus, newColumn("a"), newLonglong(1))),
+			result:    "<nil>",
+		},
+		{
+			condition: newFunction(ast.Case, newLonglong(0), newColumn("a"), newLonglong(1), newColumn("b")),
+			result:    "test.t.b",
+		},
+		{
+			condition: newFunction(ast.Case, Null, newColumn("a"), newLonglong(1), newFunction(ast.Plus, newLonglong(1), newLonglong(2))),
+			result:    "3",
+		},
+		{
+			condition: newFunction(ast.Case, newLonglong(0), newColumn("a"), newLonglong(0), newColumn("b"), newColumn("c")),
+			result:    "test.t.c",
+		},
+		{
+			condition: newFunction(ast.Case, newLonglong(0), newColumn("a"), newLonglong(0), newColumn("b")),
+			result:    "<nil>",
+		},
+		{
+			condition: newFunction(ast.Case, newColumn("a"), newLonglong(1), newLonglong(1), newColumn("b")),
+			result:    "case(test.t.a, 1, 1, test.t.b)",
+		},
 	}
 	for _, tt := range tests {
 		newConds := FoldConstant(tt.condition)
 		c.Assert(newConds.String(), Equals, tt.result, Commentf("different for expr %s", tt.condition))
 	}
 }
+
+func (*testExpressionSuite) TestConstantFoldingWithoutCtx(c *C) {
+	defer testleak.AfterTest(c)()
+	typeLong := types.NewFieldType(mysql.TypeLonglong)
+	caseWhen, err := NewFunction(nil, ast.Case, typeLong, newLonglong(0), newColumn("a"), newLonglong(1), newColumn("b"))
+	c.Assert(err, IsNil)
+	c.Assert(FoldConstant(caseWhen).String(), Equals, "case(0, test.t.a, 1, test.t.b)")
+
+	xor, err := NewFunction(nil, ast.LogicXor, typeLong, newColumn("a"), newLonglong(0))
+	c.Assert(err, IsNil)
+	c.Assert(FoldConstant(xor).String(), Equals, "xor(test.t.a, 0)")
+	xor, err = NewFunction(nil, ast.LogicXor, typeLong, newColumn("a"), Null)
+	c.Assert(err, IsNil)
+	c.Assert(FoldConstant(xor).String(), Equals, "<nil>")
+	// The column without a type isn't rewritten.
+	xor = newFunction(ast.LogicXor, &Column{FromID: "t", ColName: model.NewCIStr("a")}, newLonglong(0))
+	c.Assert(FoldConstant(xor).String(), Equals, "xor(a, 0)")
+}
+
+func (*testExpressionSuite) TestEvaluateXorWithNull(c *C) {
+	defer testleak.AfterTest(c)()
+	a, b := newColumn("a"), newColumn("b")
+	res, err := EvaluateExprWithNull(mock.NewContext(), NewSchema(a), newFunction(ast.LogicXor, a, b))
+	c.Assert(err, IsNil)
+	c.Assert(IsNullConstant(res), IsTrue)
+	res, err = EvaluateExprWithNull(mock.NewContext(), NewSchema(a), newFunction(ast.LogicXor, b, newFunction(ast.IsNull, a)))
+	c.Assert(err, IsNil)
+	c.Assert(res.String(), Equals, "eq(test.t.b, 0)")
+}
+
+func (*testExpressionSuite) TestFoldConstants(c *C) {
+	defer testleak.AfterTest(c)()
+	a, b := newColumn("a"), newColumn("b")
+	tests := []struct {
+		conds  []Expression
+		result string
+	}{
+		{
+			conds:  nil,
+			result: "[]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.LT, a, newLonglong(1)), newFunction(ast.GT, b, newLonglong(2))},
+			result: "[lt(test.t.a, 1) gt(test.t.b, 2)]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.LT, a, newFunction(ast.Plus, newLonglong(1), newLonglong(2))), One, newFunction(ast.GT, b, a)},
+			result: "[lt(test.t.a, 3) gt(test.t.b, test.t.a)]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.LT, newLonglong(1), newLonglong(2)), newFunction(ast.GT, b, a), newFunction(ast.EQ, newLonglong(1), newLonglong(1))},
+			result: "[gt(test.t.b, test.t.a)]",
+		},
+		{
+			conds:  []Expression{One, newFunction(ast.Plus, newLonglong(1), newLonglong(1))},
+			result: "[]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.GT, b, a), newFunction(ast.LT, newLonglong(2), newLonglong(1)), newFunction(ast.LT, a, newLonglong(1))},
+			result: "[0]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.GT, b, a), Null},
+			result: "[0]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.EQ, a, Null), newFunction(ast.GT, b, a)},
+			result: "[eq(test.t.a, <nil>) gt(test.t.b, test.t.a)]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.GT, b, a), newFunction(ast.Minus, newLonglong(1), newLonglong(1))},
+			result: "[0]",
+		},
+		{
+			conds:  []Expression{newFunction(ast.LT, a, newLonglong(1)), &Constant{Value: types.NewDatum(1.5), RetType: types.NewFieldType(mysql.TypeDouble)}},
+			result: "[lt(test.t.a, 1)]",
+		},
+	}
+	for _, t := range tests {
+		c.Check(fmt.Sprintf("%s", FoldConstants(t.conds)), Equals, t.result, Commentf("different for %s", t.conds))
+	}
+}
+
+func (*testExpressionSuite) TestIsNullConstant(c *C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(IsNullConstant(Null), IsTrue)
+	c.Assert(IsNullConstant(&Constant{Value: types.Datum{}}), IsTrue)
+	c.Assert(IsNullConstant(newLonglong(0)), IsFalse)
+	c.Assert(IsNullConstant(newColumn("a")), IsFalse)
+	c.Assert(IsNullConstant(newFunction(ast.Plus, newColumn("a"), Null)), IsFalse)
+}
+
+func (*testExpressionSuite) TestComposeCNFConditionPruned(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	a, b := newColumn("a"), newColumn("b")
+	gt := newFunction(ast.GT, a, newLonglong(1))
+	lt := newFunction(ast.LT, b, newLonglong(2))
+	tests := []struct {
+		conditions []Expression
+		result     string
+	}{
+		{[]Expression{gt, One, lt}, "and(gt(test.t.a, 1), lt(test.t.b, 2))"},
+		{[]Expression{gt, newFunction(ast.EQ, newLonglong(1), newLonglong(1))}, "gt(test.t.a, 1)"},
+		{[]Expression{One, newFunction(ast.Plus, newLonglong(1), newLonglong(1))}, "1"},
+		{[]Expression{gt, Zero, lt}, "0"},
+		{[]Expression{gt, Null}, "0"},
+		// "b = NULL" is folded to NULL, which prunes the whole condition.
+		{[]Expression{gt, newFunction(ast.EQ, b, Null)}, "0"},
+		{nil, "1"},
+	}
+	for _, tt := range tests {
+		c.Assert(ComposeCNFConditionPruned(ctx, tt.conditions...).String(), Equals, tt.result, Commentf("%v", tt.conditions))
+	}
+}
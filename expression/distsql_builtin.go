@@ -84,17 +84,35 @@ func newDistSQLFunction(sc *variable.StatementContext, exprType tipb.ExprType, a
 
 // PBToExpr converts pb structure to expression.
 func PBToExpr(expr *tipb.Expr, colIDs map[int64]int, sc *variable.StatementContext) (Expression, error) {
+	return pbToExpr(expr, sc, func(id int64) (Expression, error) {
+		offset, ok := colIDs[id]
+		if !ok {
+			return nil, errors.Errorf("Can't find column id %d", id)
+		}
+		return &Column{Index: offset}, nil
+	})
+}
+
+// PBToExpression converts pb structure built by ExpressionToPB to expression,
+// the column offsets in pb are resolved against cols.
+func PBToExpression(pb *tipb.Expr, cols []*Column, sc *variable.StatementContext) (Expression, error) {
+	return pbToExpr(pb, sc, func(offset int64) (Expression, error) {
+		if offset < 0 || offset >= int64(len(cols)) {
+			return nil, errors.Errorf("Can't find column offset %d", offset)
+		}
+		return cols[offset], nil
+	})
+}
+
+func pbToExpr(expr *tipb.Expr, sc *variable.StatementContext, getColumn func(int64) (Expression, error)) (Expression, error) {
 	switch expr.Tp {
 	case tipb.ExprType_ColumnRef:
 		_, id, err := codec.DecodeInt(expr.Val)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		offset, ok := colIDs[id]
-		if !ok {
-			return nil, errors.Errorf("Can't find column id %d", id)
-		}
-		return &Column{Index: offset}, nil
+		col, err := getColumn(id)
+		return col, errors.Trace(err)
 	case tipb.ExprType_Null:
 		return &Constant{}, nil
 	case tipb.ExprType_Int64:
@@ -128,7 +146,7 @@ func PBToExpr(expr *tipb.Expr, colIDs map[int64]int, sc *variable.StatementConte
 			args = append(args, results...)
 			continue
 		}
-		arg, err := PBToExpr(child, colIDs, sc)
+		arg, err := pbToExpr(child, sc, getColumn)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
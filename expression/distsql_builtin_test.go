@@ -14,11 +14,15 @@
 package expression
 
 import (
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -285,6 +289,59 @@ func (s *testEvalSuite) TestEval(c *C) {
 	}
 }
 
+func (s *testEvalSuite) TestExpressionToPBRoundTrip(c *C) {
+	ctx := mock.NewContext()
+	sc := ctx.GetSessionVars().StmtCtx
+	cols := make([]*Column, 0, 3)
+	for i := 0; i < 3; i++ {
+		cols = append(cols, &Column{
+			FromID:   "t",
+			Position: i,
+			Index:    i,
+			RetType:  types.NewFieldType(mysql.TypeLonglong),
+		})
+	}
+	plus, err := NewFunction(ctx, ast.Plus, types.NewFieldType(mysql.TypeLonglong), cols[0], newLonglong(1))
+	c.Assert(err, IsNil)
+	gt, err := NewFunction(ctx, ast.GT, types.NewFieldType(mysql.TypeTiny), plus, cols[2])
+	c.Assert(err, IsNil)
+	isNull, err := NewFunction(ctx, ast.IsNull, types.NewFieldType(mysql.TypeTiny), cols[1])
+	c.Assert(err, IsNil)
+	and, err := NewFunction(ctx, ast.AndAnd, types.NewFieldType(mysql.TypeTiny), gt, isNull)
+	c.Assert(err, IsNil)
+
+	exprs := []Expression{
+		&Constant{Value: types.NewIntDatum(1), RetType: types.NewFieldType(mysql.TypeLonglong)},
+		&Constant{Value: types.NewStringDatum("abc"), RetType: types.NewFieldType(mysql.TypeVarString)},
+		&Constant{Value: types.NewDecimalDatum(types.NewDecFromFloatForTest(1.1)), RetType: types.NewFieldType(mysql.TypeNewDecimal)},
+		&Constant{Value: types.Datum{}, RetType: types.NewFieldType(mysql.TypeNull)},
+		cols[1],
+		and,
+	}
+	for _, expr := range exprs {
+		pb, err := ExpressionToPB(expr, sc)
+		c.Assert(err, IsNil, Commentf("%s", expr))
+		got, err := PBToExpression(pb, cols, sc)
+		c.Assert(err, IsNil, Commentf("%s", expr))
+		c.Assert(got.Equal(expr, ctx), IsTrue, Commentf("%s != %s", got, expr))
+	}
+
+	// Column offsets out of the column slice can't be resolved.
+	pb, err := ExpressionToPB(cols[2], sc)
+	c.Assert(err, IsNil)
+	_, err = PBToExpression(pb, cols[:2], sc)
+	c.Assert(err, NotNil)
+
+	// Unsupported function reports its name.
+	length, err := NewFunction(ctx, ast.Length, types.NewFieldType(mysql.TypeLonglong), cols[0])
+	c.Assert(err, IsNil)
+	eq, err := NewFunction(ctx, ast.EQ, types.NewFieldType(mysql.TypeTiny), length, newLonglong(1))
+	c.Assert(err, IsNil)
+	_, err = ExpressionToPB(eq, sc)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), ast.Length), IsTrue, Commentf("%v", err))
+}
+
 func buildExpr(tp tipb.ExprType, children ...interface{}) *tipb.Expr {
 	expr := new(tipb.Expr)
 	expr.Tp = tp
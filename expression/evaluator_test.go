@@ -15,14 +15,19 @@ package expression
 
 import (
 	"fmt"
+	"math"
+	"reflect"
 	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -90,6 +95,33 @@ func (s *testEvaluatorSuite) TestSleep(c *C) {
 	c.Assert(ret, DeepEquals, types.NewIntDatum(0))
 	sub := time.Since(start)
 	c.Assert(sub.Nanoseconds(), GreaterEqual, int64(0.5*1e9))
+
+	// SLEEP is never folded, even though its argument is a constant.
+	d[0].SetFloat64(0)
+	sleep, err := NewFunction(ctx, ast.Sleep, types.NewFieldType(mysql.TypeLonglong), datumsToConstants(d)...)
+	c.Assert(err, IsNil)
+	sf, ok := sleep.(*ScalarFunction)
+	c.Assert(ok, IsTrue)
+	c.Assert(sf.FuncName.L, Equals, ast.Sleep)
+	c.Assert(FoldConstant(sleep), Equals, sleep)
+	_, ok = FoldConstant(newFunction(ast.Plus, sleep, One)).(*Constant)
+	c.Assert(ok, IsFalse)
+
+	// SLEEP is interrupted when the query is canceled, and it returns 1.
+	// The canceled context can't be used anymore, so a context of its own is used.
+	cancelCtx := mock.NewContext()
+	d[0].SetInt64(60)
+	f, err = fc.getFunction(datumsToConstants(d), cancelCtx)
+	c.Assert(err, IsNil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancelCtx.Cancel()
+	}()
+	start = time.Now()
+	ret, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(ret, DeepEquals, types.NewIntDatum(1))
+	c.Assert(time.Since(start), Less, 10*time.Second)
 }
 
 func (s *testEvaluatorSuite) TestBinopComparison(c *C) {
@@ -128,6 +160,14 @@ func (s *testEvaluatorSuite) TestBinopComparison(c *C) {
 		{1, ast.LT, 2, 1},
 		{1, ast.LT, 1, 0},
 		{1, ast.LE, 1, 1},
+
+		// test NullEQ
+		{1, ast.NullEQ, 1, 1},
+		{1, ast.NullEQ, 2, 0},
+		{nil, ast.NullEQ, nil, 1},
+		{nil, ast.NullEQ, 1, 0},
+		{1, ast.NullEQ, nil, 0},
+		{"1", ast.NullEQ, 1, 1},
 	}
 	for _, t := range tbl {
 		fc := funcs[t.op]
@@ -193,6 +233,11 @@ func (s *testEvaluatorSuite) TestBinopLogic(c *C) {
 		{1, ast.LogicXor, 1, 0},
 		{0, ast.LogicXor, 0, 0},
 		{0, ast.LogicXor, 1, 1},
+		{1, ast.LogicXor, nil, nil},
+		{nil, ast.LogicXor, nil, nil},
+		{2, ast.LogicXor, -1, 0},
+		{0.4, ast.LogicXor, 1, 1},
+		{"1", ast.LogicXor, "0", 1},
 	}
 	for _, t := range tbl {
 		fc := funcs[t.op]
@@ -209,6 +254,164 @@ func (s *testEvaluatorSuite) TestBinopLogic(c *C) {
 	}
 }
 
+// builtinCountingSig returns its argument and counts how many times it's evaluated.
+type builtinCountingSig struct {
+	baseBuiltinFunc
+
+	count *int
+}
+
+func (b *builtinCountingSig) eval(row []types.Datum) (types.Datum, error) {
+	*b.count++
+	return b.args[0].Eval(row)
+}
+
+func (s *testEvaluatorSuite) TestBetween(c *C) {
+	defer testleak.AfterTest(c)()
+	tm := types.Time{Time: types.FromDate(2017, 1, 2, 3, 4, 5, 0), Type: mysql.TypeDatetime}
+	tbl := []struct {
+		args []interface{}
+		sig  builtinFunc
+		ret  interface{}
+	}{
+		{[]interface{}{2, 1, 3}, &builtinBetweenIntSig{}, 1},
+		{[]interface{}{1, 1, 1}, &builtinBetweenIntSig{}, 1},
+		{[]interface{}{0, 1, 3}, &builtinBetweenIntSig{}, 0},
+		{[]interface{}{4, 1, 3}, &builtinBetweenIntSig{}, 0},
+		{[]interface{}{2, 3, 1}, &builtinBetweenIntSig{}, 0},
+		{[]interface{}{uint64(math.MaxUint64), -1, uint64(math.MaxUint64)}, &builtinBetweenIntSig{}, 1},
+		{[]interface{}{-1, 0, uint64(math.MaxUint64)}, &builtinBetweenIntSig{}, 0},
+		{[]interface{}{1.5, 1, 2}, &builtinBetweenRealSig{}, 1},
+		{[]interface{}{"10", 9, 11}, &builtinBetweenRealSig{}, 1},
+		{[]interface{}{types.NewDecFromFloatForTest(1.5), 1, types.NewDecFromFloatForTest(1.5)}, &builtinBetweenDecimalSig{}, 1},
+		{[]interface{}{types.NewDecFromFloatForTest(1.5), 2, 3}, &builtinBetweenDecimalSig{}, 0},
+		{[]interface{}{"10", "9", "11"}, &builtinBetweenStringSig{}, 0},
+		{[]interface{}{"b", "a", "c"}, &builtinBetweenStringSig{}, 1},
+		{[]interface{}{tm, "2017-01-01", "2017-01-02 03:04:05"}, &builtinBetweenTimeSig{}, 1},
+		{[]interface{}{tm, "2017-01-03", "2017-01-04"}, &builtinBetweenTimeSig{}, 0},
+		{[]interface{}{tm, 20170102000000, 20170103000000}, &builtinBetweenSig{}, 1},
+		// NULLs follow the three-valued logic.
+		{[]interface{}{nil, 1, 3}, &builtinBetweenIntSig{}, nil},
+		{[]interface{}{2, nil, 3}, &builtinBetweenIntSig{}, nil},
+		{[]interface{}{2, 1, nil}, &builtinBetweenIntSig{}, nil},
+		{[]interface{}{4, nil, 3}, &builtinBetweenIntSig{}, 0},
+		{[]interface{}{0, 1, nil}, &builtinBetweenIntSig{}, 0},
+		{[]interface{}{nil, nil, nil}, &builtinBetweenSig{}, nil},
+	}
+	for _, t := range tbl {
+		for _, name := range []string{ast.Between, ast.NotBetween} {
+			f, err := funcs[name].getFunction(datumsToConstants(types.MakeDatums(t.args...)), s.ctx)
+			c.Assert(err, IsNil)
+			c.Assert(reflect.TypeOf(f), Equals, reflect.TypeOf(t.sig), Commentf("%v", t.args))
+			d, err := f.eval(nil)
+			c.Assert(err, IsNil)
+			switch x := t.ret.(type) {
+			case nil:
+				c.Assert(d.IsNull(), IsTrue, Commentf("%s %v", name, t.args))
+			case int:
+				if name == ast.NotBetween {
+					x = 1 - x
+				}
+				c.Assert(d, testutil.DatumEquals, types.NewDatum(int64(x)), Commentf("%s %v", name, t.args))
+			}
+		}
+	}
+
+	// The first argument is evaluated only once.
+	count := 0
+	counting := &builtinCountingSig{newBaseBuiltinFunc([]Expression{newLonglong(2)}, s.ctx), &count}
+	counting.deterministic = false
+	arg := &ScalarFunction{FuncName: model.NewCIStr("counting"), RetType: types.NewFieldType(mysql.TypeLonglong), Function: counting.setSelf(counting)}
+	between, err := NewFunction(s.ctx, ast.Between, types.NewFieldType(mysql.TypeLonglong), arg, newLonglong(1), newLonglong(3))
+	c.Assert(err, IsNil)
+	d, err := between.Eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewDatum(int64(1)))
+	c.Assert(count, Equals, 1)
+}
+
+func (s *testEvaluatorSuite) TestNewComparison(c *C) {
+	defer testleak.AfterTest(c)()
+	// The operators and the function names are normalized.
+	col := &Column{RetType: types.NewFieldType(mysql.TypeLonglong), Index: 0}
+	for op, funcName := range map[string]string{
+		"=": ast.EQ, "<=>": ast.NullEQ, "!=": ast.NE, "<>": ast.NE, "<": ast.LT, "<=": ast.LE, ">": ast.GT, " >= ": ast.GE,
+		ast.EQ: ast.EQ, "NE": ast.NE, " Lt ": ast.LT, "nulleq": ast.NullEQ,
+	} {
+		cmp, err := NewComparison(s.ctx, op, col, Zero)
+		c.Assert(err, IsNil, Commentf("%s", op))
+		c.Assert(cmp.(*ScalarFunction).FuncName.L, Equals, funcName, Commentf("%s", op))
+	}
+	for _, op := range []string{"==", "=~", "in", "like", ""} {
+		_, err := NewComparison(s.ctx, op, One, Zero)
+		c.Assert(terror.ErrorEqual(err, errFunctionNotExists), IsTrue, Commentf("%s", op))
+	}
+
+	// The column and the constant are compared in the merged type.
+	intCol := &Column{RetType: types.NewFieldType(mysql.TypeLonglong), Index: 0}
+	decCol := &Column{RetType: types.NewFieldType(mysql.TypeNewDecimal), Index: 1}
+	strCol := &Column{RetType: types.NewFieldType(mysql.TypeVarString), Index: 2}
+	timeCol := &Column{RetType: types.NewFieldType(mysql.TypeDatetime), Index: 3}
+	tm := types.Time{Time: types.FromDate(2017, 1, 2, 0, 0, 0, 0), Type: mysql.TypeDatetime}
+	row := types.MakeDatums(10, types.NewDecFromStringForTest("1.5"), "10", tm)
+	tbl := []struct {
+		op       string
+		lhs, rhs Expression
+		sig      builtinFunc
+		ret      int64
+	}{
+		{"=", intCol, datumsToConstants(types.MakeDatums(10))[0], &builtinCompareIntSig{}, 1},
+		{">", datumsToConstants(types.MakeDatums(uint64(math.MaxUint64)))[0], intCol, &builtinCompareIntSig{}, 1},
+		{"<", intCol, datumsToConstants(types.MakeDatums(10.5))[0], &builtinCompareRealSig{}, 1},
+		{"=", intCol, datumsToConstants(types.MakeDatums("10.0"))[0], &builtinCompareRealSig{}, 1},
+		{">", decCol, datumsToConstants(types.MakeDatums(1))[0], &builtinCompareDecimalSig{}, 1},
+		{"=", decCol, datumsToConstants(types.MakeDatums(types.NewDecFromStringForTest("1.50")))[0], &builtinCompareDecimalSig{}, 1},
+		{"<", strCol, datumsToConstants(types.MakeDatums("9"))[0], &builtinCompareStringSig{}, 1},
+		{"<", strCol, datumsToConstants(types.MakeDatums(9))[0], &builtinCompareRealSig{}, 0},
+		{"=", timeCol, datumsToConstants(types.MakeDatums("2017-01-02"))[0], &builtinCompareSig{}, 1},
+	}
+	for _, t := range tbl {
+		cmp, err := NewComparison(s.ctx, t.op, t.lhs, t.rhs)
+		c.Assert(err, IsNil)
+		sf := cmp.(*ScalarFunction)
+		c.Assert(reflect.TypeOf(sf.Function), Equals, reflect.TypeOf(t.sig), Commentf("%s", sf))
+		d, err := sf.Eval(row)
+		c.Assert(err, IsNil)
+		c.Assert(d, testutil.DatumEquals, types.NewDatum(t.ret), Commentf("%s", sf))
+	}
+
+	// The rows are compared element-wise.
+	rowFunc := newFunction(ast.RowFunc, intCol, decCol)
+	cmp, err := NewComparison(s.ctx, "<>", rowFunc, rowFunc)
+	c.Assert(err, IsNil)
+	c.Assert(cmp.(*ScalarFunction).FuncName.L, Equals, ast.OrOr)
+
+	// The comparisons of constants are folded.
+	for _, t := range []struct {
+		op       string
+		lhs, rhs Expression
+		ret      interface{}
+	}{
+		{"<", One, Zero, int64(0)},
+		{">=", datumsToConstants(types.MakeDatums(types.NewDecFromStringForTest("1.5")))[0], One, int64(1)},
+		{"=", newFunction(ast.RowFunc, One, Zero), newFunction(ast.RowFunc, One, Zero), int64(1)},
+		{"=", One, Null, nil},
+		{"=", intCol, Null, nil},
+	} {
+		cmp, err = NewComparison(s.ctx, t.op, t.lhs, t.rhs)
+		c.Assert(err, IsNil)
+		con, ok := cmp.(*Constant)
+		c.Assert(ok, IsTrue, Commentf("%s", cmp))
+		c.Assert(con.Value, testutil.DatumEquals, types.NewDatum(t.ret))
+	}
+	cmp, err = NewComparison(s.ctx, "<=>", intCol, Null)
+	c.Assert(err, IsNil)
+	c.Assert(cmp.(*ScalarFunction).FuncName.L, Equals, ast.IsNull)
+	d, err := cmp.Eval(row)
+	c.Assert(err, IsNil)
+	c.Assert(d, testutil.DatumEquals, types.NewDatum(int64(0)))
+}
+
 func (s *testEvaluatorSuite) TestBinopBitop(c *C) {
 	defer testleak.AfterTest(c)()
 	tbl := []struct {
@@ -465,6 +668,41 @@ func (s *testEvaluatorSuite) TestRegexp(c *C) {
 		c.Assert(err, IsNil)
 		c.Assert(match, testutil.DatumEquals, types.NewDatum(tt.match), Commentf("%v", tt))
 	}
+
+	// A "_ci" collation makes the matching case insensitive, unless some argument is binary.
+	args := datumsToConstants(types.MakeDatums("ABC", "^abc$"))
+	args[0].GetType().Collate = "utf8_general_ci"
+	f, err := funcs[ast.Regexp].getFunction(args, s.ctx)
+	c.Assert(err, IsNil)
+	match, err := f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(match, testutil.DatumEquals, types.NewDatum(1))
+	args[1].GetType().Collate = charset.CollationBin
+	f, err = funcs[ast.Regexp].getFunction(args, s.ctx)
+	c.Assert(err, IsNil)
+	match, err = f.eval(nil)
+	c.Assert(err, IsNil)
+	c.Assert(match, testutil.DatumEquals, types.NewDatum(0))
+
+	// The pattern can come from a column, and is compiled for each row.
+	strType := types.NewFieldType(mysql.TypeVarString)
+	f, err = funcs[ast.Regexp].getFunction([]Expression{&Column{Index: 0, RetType: strType}, &Column{Index: 1, RetType: strType}}, s.ctx)
+	c.Assert(err, IsNil)
+	match, err = f.eval(types.MakeDatums("abc", "b"))
+	c.Assert(err, IsNil)
+	c.Assert(match, testutil.DatumEquals, types.NewDatum(1))
+	match, err = f.eval(types.MakeDatums("abc", "d"))
+	c.Assert(err, IsNil)
+	c.Assert(match, testutil.DatumEquals, types.NewDatum(0))
+	match, err = f.eval(types.MakeDatums("abc", nil))
+	c.Assert(err, IsNil)
+	c.Assert(match.IsNull(), IsTrue)
+
+	// An invalid pattern is reported as an error.
+	f, err = funcs[ast.Regexp].getFunction(datumsToConstants(types.MakeDatums("abc", "(")), s.ctx)
+	c.Assert(err, IsNil)
+	_, err = f.eval(nil)
+	c.Assert(terror.ErrorEqual(err, errRegexp), IsTrue, Commentf("%v", err))
 }
 
 func (s *testEvaluatorSuite) TestUnaryOp(c *C) {
@@ -560,6 +798,50 @@ func (s *testEvaluatorSuite) TestUnaryOp(c *C) {
 	}
 }
 
+func (s *testEvaluatorSuite) TestIsTrueOp(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		arg     interface{}
+		isTrue  int64
+		isFalse int64
+	}{
+		{nil, 0, 0},
+		{1, 1, 0},
+		{-2, 1, 0},
+		{0, 0, 1},
+		{uint64(0), 0, 1},
+		{0.0, 0, 1},
+		{0.1, 1, 0},
+		{-0.5, 1, 0},
+		{types.NewDecFromInt(0), 0, 1},
+		{types.NewDecFromFloatForTest(0.01), 1, 0},
+		{types.NewDecFromFloatForTest(-0.4), 1, 0},
+		{"0", 0, 1},
+		{"0.2", 1, 0},
+	}
+	for _, t := range tbl {
+		args := datumsToConstants(types.MakeDatums(t.arg))
+		for _, op := range []string{ast.IsTruth, ast.IsFalsity} {
+			f, err := funcs[op].getFunction(args, s.ctx)
+			c.Assert(err, IsNil)
+			expect := t.isTrue
+			if op == ast.IsFalsity {
+				expect = t.isFalse
+			}
+			ret, isNull, err := f.evalInt(nil)
+			c.Assert(err, IsNil)
+			c.Assert(isNull, IsFalse)
+			c.Assert(ret, Equals, expect, Commentf("%s(%v)", op, t.arg))
+
+			// The negations are never null either.
+			not := newFunction(ast.UnaryNot, &ScalarFunction{FuncName: model.NewCIStr(op), RetType: types.NewFieldType(mysql.TypeLonglong), Function: f})
+			d, err := not.Eval(nil)
+			c.Assert(err, IsNil)
+			c.Assert(d, testutil.DatumEquals, types.NewDatum(1-expect), Commentf("not %s(%v)", op, t.arg))
+		}
+	}
+}
+
 func (s *testEvaluatorSuite) TestMod(c *C) {
 	fc := funcs[ast.Mod]
 	f, err := fc.getFunction(datumsToConstants(types.MakeDatums(234, 10)), s.ctx)
@@ -581,22 +863,28 @@ func (s *testEvaluatorSuite) TestMod(c *C) {
 
 func (s *testEvaluatorSuite) TestDynamic(c *C) {
 	var dynamicFuncs = map[string]int{
-		ast.Rand:         0,
-		ast.ConnectionID: 0,
-		ast.CurrentUser:  0,
-		ast.User:         0,
-		ast.Database:     0,
-		ast.Schema:       0,
-		ast.FoundRows:    0,
-		ast.LastInsertId: 0,
-		ast.Version:      0,
-		ast.Sleep:        0,
-		ast.GetVar:       0,
-		ast.SetVar:       0,
-		ast.Values:       0,
-		ast.SessionUser:  0,
-		ast.SystemUser:   0,
-		ast.RowCount:     0,
+		ast.Rand:             0,
+		ast.ConnectionID:     0,
+		ast.CurrentUser:      0,
+		ast.User:             0,
+		ast.Database:         0,
+		ast.Schema:           0,
+		ast.FoundRows:        0,
+		ast.LastInsertId:     0,
+		ast.Version:          0,
+		ast.Sleep:            0,
+		ast.GetVar:           0,
+		ast.SetVar:           0,
+		ast.Values:           0,
+		ast.DefaultFunc:      0,
+		ast.SessionUser:      0,
+		ast.SystemUser:       0,
+		ast.RowCount:         0,
+		ast.Benchmark:        0,
+		ast.Now:              0,
+		ast.CurrentTimestamp: 0,
+		ast.LocalTime:        0,
+		ast.LocalTimestamp:   0,
 	}
 	for name, fc := range funcs {
 		f, _ := fc.getFunction(nil, s.ctx)
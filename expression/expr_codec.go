@@ -0,0 +1,304 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// exprCodecVersion is the leading byte of an encoded expression, it must be increased
+// whenever the format changes.
+const exprCodecVersion byte = 1
+
+// Flags of the encoded expression nodes.
+const (
+	constantFlag       byte = 1
+	columnFlag         byte = 2
+	scalarFunctionFlag byte = 3
+)
+
+// EncodeExpression encodes an expression into bytes, so that it can be rebuilt by DecodeExpression.
+// A column is encoded by its Index, so the indices of the columns must have been resolved.
+func EncodeExpression(expr Expression) ([]byte, error) {
+	b, err := encodeExpression([]byte{exprCodecVersion}, expr)
+	return b, errors.Trace(err)
+}
+
+// DecodeExpression decodes an expression encoded by EncodeExpression, a column with index i is
+// decoded to cols[i].
+func DecodeExpression(data []byte, cols []*Column, ctx context.Context) (Expression, error) {
+	if len(data) == 0 {
+		return nil, errors.New("insufficient bytes to decode expression")
+	}
+	if data[0] != exprCodecVersion {
+		return nil, errors.Errorf("unsupported expression codec version %d", data[0])
+	}
+	remain, expr, err := decodeExpression(data[1:], cols, ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(remain) > 0 {
+		return nil, errors.Errorf("%d trailing bytes after the encoded expression", len(remain))
+	}
+	return expr, nil
+}
+
+func encodeExpression(b []byte, expr Expression) ([]byte, error) {
+	switch x := expr.(type) {
+	case *Constant:
+		b = append(b, constantFlag)
+		b = encodeFieldType(b, x.RetType)
+		return encodeDatum(b, x.Value)
+	case *CorrelatedColumn:
+		return nil, errors.Errorf("correlated column %s can't be encoded", x)
+	case *Column:
+		if x.VirtualExpr != nil {
+			return nil, errors.Errorf("generated column %s can't be encoded", x)
+		}
+		b = append(b, columnFlag)
+		return codec.EncodeVarint(b, int64(x.Index)), nil
+	case *ScalarFunction:
+		switch x.Function.(type) {
+		case *builtinValuesSig, *builtinDefaultSig:
+			return nil, errors.Errorf("function %s can't be encoded", x.FuncName.L)
+		}
+		if _, ok := funcs[x.FuncName.L]; !ok && x.FuncName.L != ast.Cast {
+			return nil, errors.Errorf("function %s can't be encoded", x.FuncName.L)
+		}
+		b = append(b, scalarFunctionFlag)
+		b = codec.EncodeBytes(b, []byte(x.FuncName.L))
+		b = encodeFieldType(b, x.RetType)
+		args := x.GetArgs()
+		b = codec.EncodeVarint(b, int64(len(args)))
+		var err error
+		for _, arg := range args {
+			if b, err = encodeExpression(b, arg); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return b, nil
+	}
+	return nil, errors.Errorf("expression %s can't be encoded", expr)
+}
+
+func decodeExpression(b []byte, cols []*Column, ctx context.Context) ([]byte, Expression, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("insufficient bytes to decode expression")
+	}
+	flag := b[0]
+	b = b[1:]
+	switch flag {
+	case constantFlag:
+		b, ft, err := decodeFieldType(b)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		b, d, err := decodeDatum(b)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		return b, &Constant{Value: d, RetType: ft}, nil
+	case columnFlag:
+		b, idx, err := codec.DecodeVarint(b)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if idx < 0 || int(idx) >= len(cols) {
+			return nil, nil, errors.Errorf("column index %d out of range [0, %d)", idx, len(cols))
+		}
+		return b, cols[idx], nil
+	case scalarFunctionFlag:
+		b, name, err := codec.DecodeBytes(b)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		b, retType, err := decodeFieldType(b)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		b, argCnt, err := codec.DecodeVarint(b)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		args := make([]Expression, 0, argCnt)
+		for i := int64(0); i < argCnt; i++ {
+			var arg Expression
+			if b, arg, err = decodeExpression(b, cols, ctx); err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			args = append(args, arg)
+		}
+		if string(name) == ast.Cast {
+			if len(args) != 1 {
+				return nil, nil, errors.Errorf("cast function has %d arguments", len(args))
+			}
+			return b, NewCastFunc(retType, args[0], ctx), nil
+		}
+		fun, err := NewFunction(ctx, string(name), retType, args...)
+		return b, fun, errors.Trace(err)
+	}
+	return nil, nil, errors.Errorf("invalid expression flag %d", flag)
+}
+
+func encodeFieldType(b []byte, ft *types.FieldType) []byte {
+	if ft == nil {
+		return append(b, 0)
+	}
+	b = append(b, 1, ft.Tp)
+	b = codec.EncodeUvarint(b, uint64(ft.Flag))
+	b = codec.EncodeVarint(b, int64(ft.Flen))
+	b = codec.EncodeVarint(b, int64(ft.Decimal))
+	b = codec.EncodeBytes(b, []byte(ft.Charset))
+	b = codec.EncodeBytes(b, []byte(ft.Collate))
+	b = codec.EncodeVarint(b, int64(len(ft.Elems)))
+	for _, elem := range ft.Elems {
+		b = codec.EncodeBytes(b, []byte(elem))
+	}
+	return b
+}
+
+func decodeFieldType(b []byte) ([]byte, *types.FieldType, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("insufficient bytes to decode field type")
+	}
+	if b[0] == 0 {
+		return b[1:], nil, nil
+	}
+	if len(b) < 2 {
+		return nil, nil, errors.New("insufficient bytes to decode field type")
+	}
+	ft := types.NewFieldType(b[1])
+	b = b[2:]
+	var (
+		flag                     uint64
+		flen, decimal, elemCnt   int64
+		charset, collation, elem []byte
+		err                      error
+	)
+	if b, flag, err = codec.DecodeUvarint(b); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if b, flen, err = codec.DecodeVarint(b); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if b, decimal, err = codec.DecodeVarint(b); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if b, charset, err = codec.DecodeBytes(b); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if b, collation, err = codec.DecodeBytes(b); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if b, elemCnt, err = codec.DecodeVarint(b); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	for i := int64(0); i < elemCnt; i++ {
+		if b, elem, err = codec.DecodeBytes(b); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		ft.Elems = append(ft.Elems, string(elem))
+	}
+	ft.Flag, ft.Flen, ft.Decimal = uint(flag), int(flen), int(decimal)
+	ft.Charset, ft.Collate = string(charset), string(collation)
+	return b, ft, nil
+}
+
+// encodeDatum encodes the kind of the datum before its value, because util/codec decodes
+// strings as bytes, and temporal values without their types and fsp.
+func encodeDatum(b []byte, d types.Datum) ([]byte, error) {
+	b = append(b, d.Kind())
+	switch d.Kind() {
+	case types.KindNull:
+		return b, nil
+	case types.KindInt64, types.KindUint64, types.KindFloat32, types.KindFloat64,
+		types.KindString, types.KindBytes, types.KindMysqlDecimal:
+		b, err := codec.EncodeValue(b, d)
+		return b, errors.Trace(err)
+	case types.KindMysqlDuration:
+		dur := d.GetMysqlDuration()
+		b, err := codec.EncodeValue(b, d)
+		return codec.EncodeVarint(b, int64(dur.Fsp)), errors.Trace(err)
+	case types.KindMysqlTime:
+		t := d.GetMysqlTime()
+		// Pack the time as a datetime, so that a timestamp is not converted to UTC.
+		packed, err := types.Time{Time: t.Time, Type: mysql.TypeDatetime}.ToPackedUint()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		b = codec.EncodeUint(b, packed)
+		b = append(b, t.Type)
+		return codec.EncodeVarint(b, int64(t.Fsp)), nil
+	}
+	return nil, errors.Errorf("datum of kind %d can't be encoded", d.Kind())
+}
+
+func decodeDatum(b []byte) ([]byte, types.Datum, error) {
+	var d types.Datum
+	if len(b) == 0 {
+		return nil, d, errors.New("insufficient bytes to decode datum")
+	}
+	kind := b[0]
+	b = b[1:]
+	var err error
+	switch kind {
+	case types.KindNull:
+		return b, d, nil
+	case types.KindInt64, types.KindUint64, types.KindFloat64, types.KindBytes, types.KindMysqlDecimal:
+		b, d, err = codec.DecodeOne(b)
+	case types.KindFloat32:
+		b, d, err = codec.DecodeOne(b)
+		d.SetFloat32(float32(d.GetFloat64()))
+	case types.KindString:
+		b, d, err = codec.DecodeOne(b)
+		d.SetString(string(d.GetBytes()))
+	case types.KindMysqlDuration:
+		var fsp int64
+		if b, d, err = codec.DecodeOne(b); err != nil {
+			return nil, d, errors.Trace(err)
+		}
+		b, fsp, err = codec.DecodeVarint(b)
+		d.SetMysqlDuration(types.Duration{Duration: d.GetMysqlDuration().Duration, Fsp: int(fsp)})
+	case types.KindMysqlTime:
+		var (
+			packed uint64
+			fsp    int64
+		)
+		if b, packed, err = codec.DecodeUint(b); err != nil {
+			return nil, d, errors.Trace(err)
+		}
+		if len(b) == 0 {
+			return nil, d, errors.New("insufficient bytes to decode time")
+		}
+		t := types.Time{Type: mysql.TypeDatetime}
+		if err = t.FromPackedUint(packed); err != nil {
+			return nil, d, errors.Trace(err)
+		}
+		t.Type = b[0]
+		b, fsp, err = codec.DecodeVarint(b[1:])
+		t.Fsp = int(fsp)
+		d.SetMysqlTime(t)
+	default:
+		return nil, d, errors.Errorf("datum of kind %d can't be decoded", kind)
+	}
+	if err != nil {
+		return nil, d, errors.Trace(err)
+	}
+	return b, d, nil
+}
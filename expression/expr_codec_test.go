@@ -0,0 +1,196 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"math/rand"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var _ = Suite(&testExprCodecSuite{})
+
+type testExprCodecSuite struct{}
+
+type exprGenerator struct {
+	r    *rand.Rand
+	cols []*Column
+}
+
+func (g *exprGenerator) randConstant() *Constant {
+	var d types.Datum
+	switch g.r.Intn(10) {
+	case 0:
+	case 1:
+		d.SetInt64(g.r.Int63() - g.r.Int63())
+	case 2:
+		d.SetUint64(uint64(g.r.Int63()) << 1)
+	case 3:
+		d.SetFloat64(g.r.NormFloat64() * 1e6)
+	case 4:
+		d.SetString(string(g.randBytes()))
+	case 5:
+		d.SetBytes(g.randBytes())
+	case 6:
+		d.SetMysqlDecimal(types.NewDecFromFloatForTest(float64(g.r.Intn(2000000)-1000000) / 1000))
+	case 7:
+		d.SetMysqlDuration(types.Duration{Duration: time.Duration(g.r.Int63n(int64(800*time.Hour))) - 400*time.Hour, Fsp: g.r.Intn(types.MaxFsp + 1)})
+	default:
+		t := types.Time{
+			Time: types.FromDate(1970+g.r.Intn(100), 1+g.r.Intn(12), 1+g.r.Intn(28), g.r.Intn(24), g.r.Intn(60), g.r.Intn(60), g.r.Intn(1000000)),
+			Type: []byte{mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp}[g.r.Intn(3)],
+			Fsp:  g.r.Intn(types.MaxFsp + 1),
+		}
+		d.SetMysqlTime(t)
+	}
+	return datumsToConstants([]types.Datum{d})[0].(*Constant)
+}
+
+func (g *exprGenerator) randBytes() []byte {
+	b := make([]byte, g.r.Intn(16))
+	for i := range b {
+		b[i] = byte(g.r.Intn(256))
+	}
+	return b
+}
+
+func (g *exprGenerator) randExpr(depth int) Expression {
+	if depth == 0 || g.r.Intn(4) == 0 {
+		if g.r.Intn(2) == 0 {
+			return g.cols[g.r.Intn(len(g.cols))]
+		}
+		return g.randConstant()
+	}
+	retType := types.NewFieldType(mysql.TypeLonglong)
+	if g.r.Intn(8) == 0 {
+		return NewCastFunc(types.NewFieldType(mysql.TypeVarString), g.randExpr(depth-1), mock.NewContext())
+	}
+	names := []string{ast.Plus, ast.Minus, ast.LT, ast.EQ, ast.AndAnd, ast.OrOr, ast.Concat, ast.IsNull, ast.If}
+	name := names[g.r.Intn(len(names))]
+	argCnt := 2
+	switch name {
+	case ast.IsNull:
+		argCnt = 1
+	case ast.If:
+		argCnt = 3
+	}
+	args := make([]Expression, 0, argCnt)
+	for i := 0; i < argCnt; i++ {
+		args = append(args, g.randExpr(depth-1))
+	}
+	f, err := NewFunction(mock.NewContext(), name, retType, args...)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func checkExprEqual(c *C, a, b Expression) {
+	switch x := a.(type) {
+	case *Column:
+		c.Assert(b, Equals, x)
+	case *Constant:
+		y, ok := b.(*Constant)
+		c.Assert(ok, IsTrue)
+		c.Assert(y.RetType, DeepEquals, x.RetType)
+		c.Assert(y.Value.Kind(), Equals, x.Value.Kind())
+		cmp, err := y.Value.CompareDatum(mock.NewContext().GetSessionVars().StmtCtx, x.Value)
+		c.Assert(err, IsNil)
+		c.Assert(cmp, Equals, 0, Commentf("%v != %v", y.Value, x.Value))
+		if x.Value.Kind() == types.KindMysqlTime {
+			c.Assert(y.Value.GetMysqlTime().Type, Equals, x.Value.GetMysqlTime().Type)
+			c.Assert(y.Value.GetMysqlTime().Fsp, Equals, x.Value.GetMysqlTime().Fsp)
+		} else if x.Value.Kind() == types.KindMysqlDuration {
+			c.Assert(y.Value.GetMysqlDuration(), Equals, x.Value.GetMysqlDuration())
+		}
+	case *ScalarFunction:
+		y, ok := b.(*ScalarFunction)
+		c.Assert(ok, IsTrue)
+		c.Assert(y.FuncName, Equals, x.FuncName)
+		c.Assert(y.RetType, DeepEquals, x.RetType)
+		c.Assert(y.GetArgs(), HasLen, len(x.GetArgs()))
+		for i, arg := range x.GetArgs() {
+			checkExprEqual(c, arg, y.GetArgs()[i])
+		}
+	default:
+		c.Fatalf("unexpected expression %s", a)
+	}
+}
+
+func (s *testExprCodecSuite) TestRoundTrip(c *C) {
+	defer testleak.AfterTest(c)()
+	// A fixed seed keeps the generated expressions reproducible.
+	const seed = 20170728
+	g := &exprGenerator{r: rand.New(rand.NewSource(seed))}
+	colTypes := []byte{mysql.TypeLonglong, mysql.TypeDouble, mysql.TypeVarString, mysql.TypeNewDecimal, mysql.TypeDatetime}
+	for i, tp := range colTypes {
+		g.cols = append(g.cols, &Column{FromID: "t", Position: i, Index: i, RetType: types.NewFieldType(tp)})
+	}
+	ctx := mock.NewContext()
+	for i := 0; i < 500; i++ {
+		expr := g.randExpr(4)
+		data, err := EncodeExpression(expr)
+		c.Assert(err, IsNil, Commentf("expr %s", expr))
+		decoded, err := DecodeExpression(data, g.cols, ctx)
+		c.Assert(err, IsNil, Commentf("expr %s", expr))
+		c.Assert(decoded.String(), Equals, expr.String())
+		checkExprEqual(c, expr, decoded)
+	}
+}
+
+func (s *testExprCodecSuite) TestEncodeDecodeErrors(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	col := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+
+	// Unsupported expressions.
+	_, err := EncodeExpression(NewValuesFunc(0, types.NewFieldType(mysql.TypeLonglong), ctx))
+	c.Assert(err, NotNil)
+	corCol := &CorrelatedColumn{Column: *col, Data: new(types.Datum)}
+	_, err = EncodeExpression(newFunction(ast.Plus, corCol, newLonglong(1)))
+	c.Assert(err, NotNil)
+	_, err = EncodeExpression(&Constant{Value: types.NewDatum(types.Hex{Value: 1})})
+	c.Assert(err, NotNil)
+	virtualCol := &Column{RetType: types.NewFieldType(mysql.TypeLonglong)}
+	virtualCol.SetVirtualExpr(newFunction(ast.Plus, col, newLonglong(1)), ctx)
+	_, err = EncodeExpression(virtualCol)
+	c.Assert(err, NotNil)
+
+	data, err := EncodeExpression(newFunction(ast.Plus, col, newLonglong(1)))
+	c.Assert(err, IsNil)
+	c.Assert(data[0], Equals, exprCodecVersion)
+	_, err = DecodeExpression(data, []*Column{col}, ctx)
+	c.Assert(err, IsNil)
+
+	// The version is checked.
+	badVersion := append([]byte{exprCodecVersion + 1}, data[1:]...)
+	_, err = DecodeExpression(badVersion, []*Column{col}, ctx)
+	c.Assert(err, ErrorMatches, ".*unsupported expression codec version.*")
+	// The columns must be provided.
+	_, err = DecodeExpression(data, nil, ctx)
+	c.Assert(err, NotNil)
+	// Truncated or redundant data can't be decoded.
+	_, err = DecodeExpression(data[:len(data)-1], []*Column{col}, ctx)
+	c.Assert(err, NotNil)
+	_, err = DecodeExpression(append(data, 0), []*Column{col}, ctx)
+	c.Assert(err, NotNil)
+	_, err = DecodeExpression(nil, []*Column{col}, ctx)
+	c.Assert(err, NotNil)
+}
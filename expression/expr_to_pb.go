@@ -14,6 +14,7 @@
 package expression
 
 import (
+	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/kv"
@@ -56,9 +57,45 @@ func ExpressionsToPBList(sc *variable.StatementContext, exprs []Expression, clie
 	return
 }
 
+// ExpressionToPB converts an expression to tipb.Expr without checking whether the storage supports it.
+// Column references are encoded as their offsets in the row, which can be resolved by PBToExpression.
+func ExpressionToPB(expr Expression, sc *variable.StatementContext) (*tipb.Expr, error) {
+	pc := pbConverter{sc: sc, useColOffset: true}
+	pbExpr := pc.exprToPB(expr)
+	if pbExpr == nil {
+		return nil, errors.Trace(pc.findUnsupported(expr))
+	}
+	return pbExpr, nil
+}
+
 type pbConverter struct {
 	client kv.Client
 	sc     *variable.StatementContext
+	// useColOffset indicates encoding a column as its offset instead of its column id.
+	useColOffset bool
+}
+
+// canPushDown checks whether the expression type is supported by the client, a nil client supports all types.
+func (pc pbConverter) canPushDown(tp tipb.ExprType) bool {
+	return pc.client == nil || pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tp))
+}
+
+// findUnsupported returns an error describing the innermost sub-expression of expr that can't be converted to pb.
+func (pc pbConverter) findUnsupported(expr Expression) error {
+	switch x := expr.(type) {
+	case *Constant:
+		return errors.Errorf("constant %s of kind %d can't be converted to pb", x, x.Value.Kind())
+	case *Column:
+		return errors.Errorf("column %s of type %s can't be converted to pb", x, x.GetType())
+	case *ScalarFunction:
+		for _, arg := range x.GetArgs() {
+			if pc.exprToPB(arg) == nil {
+				return pc.findUnsupported(arg)
+			}
+		}
+		return errors.Errorf("function %s can't be converted to pb", x.FuncName.O)
+	}
+	return errors.Errorf("expression %s can't be converted to pb", expr)
 }
 
 func (pc pbConverter) exprToPB(expr Expression) *tipb.Expr {
@@ -106,14 +143,14 @@ func (pc pbConverter) datumToPBExpr(d types.Datum) *tipb.Expr {
 	default:
 		return nil
 	}
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
+	if !pc.canPushDown(tp) {
 		return nil
 	}
 	return &tipb.Expr{Tp: tp, Val: val}
 }
 
 func (pc pbConverter) columnToPBExpr(column *Column) *tipb.Expr {
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_ColumnRef)) {
+	if !pc.canPushDown(tipb.ExprType_ColumnRef) {
 		return nil
 	}
 	switch column.GetType().Tp {
@@ -121,6 +158,11 @@ func (pc pbConverter) columnToPBExpr(column *Column) *tipb.Expr {
 		return nil
 	}
 
+	if pc.useColOffset {
+		return &tipb.Expr{
+			Tp:  tipb.ExprType_ColumnRef,
+			Val: codec.EncodeInt(nil, int64(column.Index))}
+	}
 	id := column.ID
 	// Zero Column ID is not a column from table, can not support for now.
 	if id == 0 || id == -1 {
@@ -176,7 +218,7 @@ func (pc pbConverter) compareOpsToPBExpr(expr *ScalarFunction) *tipb.Expr {
 }
 
 func (pc pbConverter) likeToPBExpr(expr *ScalarFunction) *tipb.Expr {
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_Like)) {
+	if !pc.canPushDown(tipb.ExprType_Like) {
 		return nil
 	}
 	// Only patterns like 'abc', '%abc', 'abc%', '%abc%' can be converted to *tipb.Expr for now.
@@ -265,7 +307,7 @@ func (pc pbConverter) bitwiseFuncToPBExpr(expr *ScalarFunction) *tipb.Expr {
 }
 
 func (pc pbConverter) inToPBExpr(expr *ScalarFunction) *tipb.Expr {
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_In)) {
+	if !pc.canPushDown(tipb.ExprType_In) {
 		return nil
 	}
 
@@ -283,7 +325,7 @@ func (pc pbConverter) inToPBExpr(expr *ScalarFunction) *tipb.Expr {
 }
 
 func (pc pbConverter) constListToPB(list []Expression) *tipb.Expr {
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_ValueList)) {
+	if !pc.canPushDown(tipb.ExprType_ValueList) {
 		return nil
 	}
 
@@ -420,7 +462,7 @@ func (pc pbConverter) controlFuncsToPBExpr(expr *ScalarFunction) *tipb.Expr {
 }
 
 func (pc pbConverter) convertToPBExpr(expr *ScalarFunction, tp tipb.ExprType) *tipb.Expr {
-	if !pc.client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
+	if !pc.canPushDown(tp) {
 		return nil
 	}
 	children := make([]*tipb.Expr, 0, len(expr.GetArgs()))
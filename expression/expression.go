@@ -31,21 +31,40 @@ import (
 
 // Error instances.
 var (
-	errInvalidOperation        = terror.ClassExpression.New(codeInvalidOperation, "invalid operation")
-	errIncorrectParameterCount = terror.ClassExpression.New(codeIncorrectParameterCount, "Incorrect parameter count in the call to native function '%s'")
-	errFunctionNotExists       = terror.ClassExpression.New(codeFunctionNotExists, "FUNCTION %s does not exist")
+	errInvalidOperation            = terror.ClassExpression.New(codeInvalidOperation, "invalid operation")
+	errIncorrectParameterCount     = terror.ClassExpression.New(codeIncorrectParameterCount, "Incorrect parameter count in the call to native function '%s'")
+	errFunctionNotExists           = terror.ClassExpression.New(codeFunctionNotExists, "FUNCTION %s does not exist")
+	ErrOperandColumns              = terror.ClassExpression.New(codeOperandColumns, "Operand should contain %d column(s)")
+	errUnknownLocale               = terror.ClassExpression.New(codeUnknownLocale, mysql.MySQLErrName[mysql.ErrUnknownLocale])
+	errWarnAllowedPacketOverflowed = terror.ClassExpression.New(codeWarnAllowedPacketOverflowed, "Result of %s() was larger than max_allowed_packet (%d) - truncated")
+	errRegexp                      = terror.ClassExpression.New(codeRegexp, mysql.MySQLErrName[mysql.ErrRegexp])
+	errWrongValueForType           = terror.ClassExpression.New(codeWrongValueForType, mysql.MySQLErrName[mysql.ErrWrongValueForType])
+	errTruncatedWrongValue         = terror.ClassExpression.New(codeTruncatedWrongValue, mysql.MySQLErrName[mysql.ErrTruncatedWrongValue])
 )
 
 // Error codes.
 const (
-	codeInvalidOperation        terror.ErrCode = 1
-	codeIncorrectParameterCount                = 1582
-	codeFunctionNotExists                      = 1305
+	codeInvalidOperation            terror.ErrCode = 1
+	codeIncorrectParameterCount                    = 1582
+	codeFunctionNotExists                          = 1305
+	codeOperandColumns                             = 1241
+	codeUnknownLocale                              = 1649
+	codeWarnAllowedPacketOverflowed                = 1301
+	codeRegexp                                     = 1139
+	codeWrongValueForType                          = 1411
+	codeTruncatedWrongValue                        = 1292
 )
 
 // EvalAstExpr evaluates ast expression directly.
 var EvalAstExpr func(expr ast.ExprNode, ctx context.Context) (types.Datum, error)
 
+// GetColDefaultValue gets the default value of a column, it's used by the default function.
+var GetColDefaultValue func(ctx context.Context, col *model.ColumnInfo) (types.Datum, error)
+
+// TypeInferHook is called by NewFunction with the types of the arguments and the result type of the function
+// if it's not nil, it's used to trace how the result types are derived.
+var TypeInferHook func(funcName string, argTypes []*types.FieldType, resultType *types.FieldType)
+
 // Expression represents all scalar expression in SQL.
 type Expression interface {
 	fmt.Stringer
@@ -66,6 +85,12 @@ type Expression interface {
 	// EvalDecimal returns the decimal representation of expression.
 	EvalDecimal(row []types.Datum, sc *variable.StatementContext) (val *types.MyDecimal, isNull bool, err error)
 
+	// EvalTime returns the DATE/DATETIME/TIMESTAMP representation of expression.
+	EvalTime(row []types.Datum, sc *variable.StatementContext) (val types.Time, isNull bool, err error)
+
+	// EvalDuration returns the duration representation of expression.
+	EvalDuration(row []types.Datum, sc *variable.StatementContext) (val types.Duration, isNull bool, err error)
+
 	// GetType gets the type that the expression returns.
 	GetType() *types.FieldType
 
@@ -84,6 +109,9 @@ type Expression interface {
 	// Decorrelate try to decorrelate the expression by schema.
 	Decorrelate(schema *Schema) Expression
 
+	// ReplaceColumn replaces the columns found in schema with the newExprs at the same positions.
+	ReplaceColumn(schema *Schema, newExprs []Expression) Expression
+
 	// ResolveIndices resolves indices by the given schema.
 	ResolveIndices(schema *Schema)
 }
@@ -100,6 +128,19 @@ func (e CNFExprs) Clone() CNFExprs {
 	return cnf
 }
 
+// CloneInto clones itself into dst and returns the result. The backing array of dst will be reused
+// if its capacity is sufficient, so callers can pool the destination slice across iterations.
+func (e CNFExprs) CloneInto(dst CNFExprs) CNFExprs {
+	if cap(dst) < len(e) {
+		dst = make(CNFExprs, 0, len(e))
+	}
+	dst = dst[:0]
+	for _, expr := range e {
+		dst = append(dst, expr.Clone())
+	}
+	return dst
+}
+
 // EvalBool evaluates expression list to a boolean value.
 func EvalBool(exprList CNFExprs, row []types.Datum, ctx context.Context) (bool, error) {
 	for _, expr := range exprList {
@@ -178,6 +219,107 @@ func evalExprToString(expr Expression, row []types.Datum, _ *variable.StatementC
 	return res, false, errors.Trace(err)
 }
 
+// evalExprToTime evaluates `expr` to time type. A value which isn't a time is converted to the type of expr
+// if it's a time type, otherwise it's converted to a datetime with the fraction kept.
+func evalExprToTime(expr Expression, row []types.Datum, sc *variable.StatementContext) (res types.Time, isNull bool, err error) {
+	val, err := expr.Eval(row)
+	if val.IsNull() || err != nil {
+		return res, val.IsNull(), errors.Trace(err)
+	}
+	if val.Kind() == types.KindMysqlTime {
+		return val.GetMysqlTime(), false, nil
+	}
+	ft := types.NewFieldType(mysql.TypeDatetime)
+	ft.Decimal = types.MaxFsp
+	switch tp := expr.GetType(); tp.Tp {
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		ft.Tp = tp.Tp
+		if tp.Decimal != types.UnspecifiedLength {
+			ft.Decimal = tp.Decimal
+		}
+	}
+	d, err := val.ConvertTo(sc, ft)
+	if err != nil {
+		return res, true, errors.Trace(err)
+	}
+	return d.GetMysqlTime(), false, nil
+}
+
+// evalExprToDuration evaluates `expr` to duration type. A value which isn't a duration is converted with
+// the fsp of expr if it's a duration type, otherwise the fraction is kept.
+func evalExprToDuration(expr Expression, row []types.Datum, sc *variable.StatementContext) (res types.Duration, isNull bool, err error) {
+	val, err := expr.Eval(row)
+	if val.IsNull() || err != nil {
+		return res, val.IsNull(), errors.Trace(err)
+	}
+	if val.Kind() == types.KindMysqlDuration {
+		return val.GetMysqlDuration(), false, nil
+	}
+	ft := types.NewFieldType(mysql.TypeDuration)
+	ft.Decimal = types.MaxFsp
+	if tp := expr.GetType(); tp.Tp == mysql.TypeDuration && tp.Decimal != types.UnspecifiedLength {
+		ft.Decimal = tp.Decimal
+	}
+	d, err := val.ConvertTo(sc, ft)
+	if err != nil {
+		return res, true, errors.Trace(err)
+	}
+	return d.GetMysqlDuration(), false, nil
+}
+
+// EvalArgsInt evaluates all the args to int for the functions whose result is NULL if any argument is NULL,
+// it returns as soon as an argument is NULL, and the rest of the args aren't evaluated.
+func EvalArgsInt(args []Expression, row []types.Datum, sc *variable.StatementContext) (vals []int64, anyNull bool, err error) {
+	vals = make([]int64, 0, len(args))
+	for _, arg := range args {
+		val, isNull, err := arg.EvalInt(row, sc)
+		if isNull || err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		vals = append(vals, val)
+	}
+	return vals, false, nil
+}
+
+// EvalArgsReal is like EvalArgsInt but evaluates the args to real.
+func EvalArgsReal(args []Expression, row []types.Datum, sc *variable.StatementContext) (vals []float64, anyNull bool, err error) {
+	vals = make([]float64, 0, len(args))
+	for _, arg := range args {
+		val, isNull, err := arg.EvalReal(row, sc)
+		if isNull || err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		vals = append(vals, val)
+	}
+	return vals, false, nil
+}
+
+// EvalArgsString is like EvalArgsInt but evaluates the args to string.
+func EvalArgsString(args []Expression, row []types.Datum, sc *variable.StatementContext) (vals []string, anyNull bool, err error) {
+	vals = make([]string, 0, len(args))
+	for _, arg := range args {
+		val, isNull, err := arg.EvalString(row, sc)
+		if isNull || err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		vals = append(vals, val)
+	}
+	return vals, false, nil
+}
+
+// EvalArgsDecimal is like EvalArgsInt but evaluates the args to decimal.
+func EvalArgsDecimal(args []Expression, row []types.Datum, sc *variable.StatementContext) (vals []*types.MyDecimal, anyNull bool, err error) {
+	vals = make([]*types.MyDecimal, 0, len(args))
+	for _, arg := range args {
+		val, isNull, err := arg.EvalDecimal(row, sc)
+		if isNull || err != nil {
+			return nil, true, errors.Trace(err)
+		}
+		vals = append(vals, val)
+	}
+	return vals, false, nil
+}
+
 // One stands for a number 1.
 var One = &Constant{
 	Value:   types.NewDatum(1),
@@ -253,6 +395,18 @@ func (c *Constant) EvalDecimal(_ []types.Datum, sc *variable.StatementContext) (
 	return val, isNull, errors.Trace(err)
 }
 
+// EvalTime returns DATE/DATETIME/TIMESTAMP representation of Constant.
+func (c *Constant) EvalTime(_ []types.Datum, sc *variable.StatementContext) (types.Time, bool, error) {
+	val, isNull, err := evalExprToTime(c, nil, sc)
+	return val, isNull, errors.Trace(err)
+}
+
+// EvalDuration returns duration representation of Constant.
+func (c *Constant) EvalDuration(_ []types.Datum, sc *variable.StatementContext) (types.Duration, bool, error) {
+	val, isNull, err := evalExprToDuration(c, nil, sc)
+	return val, isNull, errors.Trace(err)
+}
+
 // Equal implements Expression interface.
 func (c *Constant) Equal(b Expression, ctx context.Context) bool {
 	y, ok := b.(*Constant)
@@ -266,6 +420,17 @@ func (c *Constant) Equal(b Expression, ctx context.Context) bool {
 	return true
 }
 
+// IsNull returns whether the value of the constant is null.
+func (c *Constant) IsNull() bool {
+	return c.Value.IsNull()
+}
+
+// IsNullConstant returns whether expr is a constant whose value is null.
+func IsNullConstant(expr Expression) bool {
+	con, ok := expr.(*Constant)
+	return ok && con.IsNull()
+}
+
 // IsCorrelated implements Expression interface.
 func (c *Constant) IsCorrelated() bool {
 	return false
@@ -276,13 +441,34 @@ func (c *Constant) Decorrelate(_ *Schema) Expression {
 	return c
 }
 
+// ReplaceColumn implements Expression interface.
+func (c *Constant) ReplaceColumn(_ *Schema, _ []Expression) Expression {
+	return c
+}
+
 // HashCode implements Expression interface.
 func (c *Constant) HashCode() []byte {
 	var bytes []byte
-	bytes, _ = codec.EncodeValue(bytes, c.Value)
+	bytes, _ = codec.EncodeValue(bytes, c.Value, fieldTypeHashDatum(c.RetType))
 	return bytes
 }
 
+// fieldTypeHashDatum encodes the parts of ft that change how a value is compared,
+// so the same value of different types, like int 1 and decimal 1.0, has different hashcodes.
+// Only the codec is used here, so the hashcode is stable across processes.
+func fieldTypeHashDatum(ft *types.FieldType) types.Datum {
+	if ft == nil {
+		return types.Datum{}
+	}
+	var bytes []byte
+	bytes, _ = codec.EncodeValue(bytes,
+		types.NewIntDatum(int64(ft.Tp)),
+		types.NewIntDatum(int64(ft.Flag&mysql.UnsignedFlag)),
+		types.NewIntDatum(int64(ft.Decimal)),
+		types.NewStringDatum(ft.Collate))
+	return types.NewBytesDatum(bytes)
+}
+
 // ResolveIndices implements Expression interface.
 func (c *Constant) ResolveIndices(_ *Schema) {
 }
@@ -308,6 +494,31 @@ func ComposeCNFCondition(ctx context.Context, conditions ...Expression) Expressi
 	return composeConditionWithBinaryOp(ctx, conditions, ast.AndAnd)
 }
 
+// ComposeCNFConditionPruned is like ComposeCNFCondition, but the conditions are folded and pruned first: the
+// constant true ones are dropped, and the result is Zero if any of them is a constant false or NULL, which
+// filters out every row like false does. One is returned if no condition is left.
+func ComposeCNFConditionPruned(ctx context.Context, conditions ...Expression) Expression {
+	sc := new(variable.StatementContext)
+	if ctx != nil {
+		sc = ctx.GetSessionVars().StmtCtx
+	}
+	pruned := make([]Expression, 0, len(conditions))
+	for _, cond := range conditions {
+		cond = FoldConstant(cond)
+		if IsNullConstant(cond) || IsConstantFalse(cond, sc) {
+			return Zero
+		}
+		if IsConstantTrue(cond, sc) {
+			continue
+		}
+		pruned = append(pruned, cond)
+	}
+	if len(pruned) == 0 {
+		return One
+	}
+	return ComposeCNFCondition(ctx, pruned...)
+}
+
 // ComposeDNFCondition composes DNF items into a balance deep DNF tree.
 func ComposeDNFCondition(ctx context.Context, conditions ...Expression) Expression {
 	return composeConditionWithBinaryOp(ctx, conditions, ast.OrOr)
@@ -451,25 +662,46 @@ func NewCastFunc(tp *types.FieldType, arg Expression, ctx context.Context) *Scal
 	return &ScalarFunction{
 		FuncName: model.NewCIStr(ast.Cast),
 		RetType:  tp,
-		Function: bt,
+		Function: bt.setSelf(bt),
 	}
 }
 
 // NewValuesFunc creates a new values function.
 func NewValuesFunc(offset int, retTp *types.FieldType, ctx context.Context) *ScalarFunction {
-	fc := &valuesFunctionClass{baseFunctionClass{ast.Values, 0, 0}, offset}
+	fc := &valuesFunctionClass{baseFunctionClass{ast.Values, 0, 0}, offset, retTp}
 	bt, _ := fc.getFunction(nil, ctx)
 	return &ScalarFunction{
 		FuncName: model.NewCIStr(ast.Values),
 		RetType:  retTp,
-		Function: bt,
+		Function: bt.setSelf(bt),
 	}
 }
 
+// NewDefaultFunc creates a new default function, which returns the default value of col.
+// colInfo is the meta of col in its table.
+func NewDefaultFunc(col *Column, colInfo *model.ColumnInfo, ctx context.Context) (*ScalarFunction, error) {
+	fc := &defaultFunctionClass{baseFunctionClass{ast.DefaultFunc, 1, 1}, colInfo}
+	bt, err := fc.getFunction([]Expression{col}, ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &ScalarFunction{
+		FuncName: model.NewCIStr(ast.DefaultFunc),
+		RetType:  col.GetType(),
+		Function: bt.setSelf(bt),
+	}, nil
+}
+
 func init() {
 	expressionMySQLErrCodes := map[terror.ErrCode]uint16{
-		codeIncorrectParameterCount: mysql.ErrWrongParamcountToNativeFct,
-		codeFunctionNotExists:       mysql.ErrSpDoesNotExist,
+		codeIncorrectParameterCount:     mysql.ErrWrongParamcountToNativeFct,
+		codeFunctionNotExists:           mysql.ErrSpDoesNotExist,
+		codeOperandColumns:              mysql.ErrOperandColumns,
+		codeUnknownLocale:               mysql.ErrUnknownLocale,
+		codeWarnAllowedPacketOverflowed: mysql.ErrWarnAllowedPacketOverflowed,
+		codeRegexp:                      mysql.ErrRegexp,
+		codeWrongValueForType:           mysql.ErrWrongValueForType,
+		codeTruncatedWrongValue:         mysql.ErrTruncatedWrongValue,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassExpression] = expressionMySQLErrCodes
 }
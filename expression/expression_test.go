@@ -0,0 +1,358 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testExpressionSuite) TestCNFExprsCloneInto(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	cnf := CNFExprs{
+		newFunction(ast.EQ, newColumn("a"), newLonglong(1)),
+		newColumn("b"),
+		newLonglong(2),
+	}
+
+	// dst without enough capacity.
+	dst := cnf.CloneInto(nil)
+	c.Assert(len(dst), Equals, len(cnf))
+	for i := range cnf {
+		c.Assert(dst[i].Equal(cnf[i], ctx), IsTrue)
+		c.Assert(dst[i] != cnf[i], IsTrue)
+	}
+
+	// dst with enough capacity reuses its backing array.
+	buf := make(CNFExprs, 1, 8)
+	dst = cnf.CloneInto(buf)
+	c.Assert(len(dst), Equals, len(cnf))
+	c.Assert(&dst[0], Equals, &buf[0])
+	for i := range cnf {
+		c.Assert(dst[i].Equal(cnf[i], ctx), IsTrue)
+		c.Assert(dst[i] != cnf[i], IsTrue)
+	}
+}
+
+func (s *testExpressionSuite) TestColumnVirtualExpr(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	sc := ctx.GetSessionVars().StmtCtx
+	a, b := newColumn("a"), newColumn("b")
+	row := types.MakeDatums(1, 2)
+
+	v := &Column{FromID: "v", ColName: model.NewCIStr("v"), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	v.SetVirtualExpr(newFunction(ast.Plus, a, b), ctx)
+	c.Assert(v.VirtualExpr.String(), Equals, "plus(test.t.a, test.t.b)")
+	v.ResolveIndices(NewSchema(b, a))
+	d, err := v.Eval(row)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetInt64(), Equals, int64(3))
+	i, isNull, err := v.EvalInt(row, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(i, Equals, int64(3))
+	d, err = v.Eval(types.MakeDatums(nil, 2))
+	c.Assert(err, IsNil)
+	c.Assert(d.IsNull(), IsTrue)
+
+	// The columns referenced by a virtual column are resolved to their offsets, even the nested ones.
+	x, y := newColumn("x"), newColumn("y")
+	xy := &Column{FromID: "v", Position: 2, ColName: model.NewCIStr("xy"), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	xy.SetVirtualExpr(newFunction(ast.Mul, x, y), ctx)
+	nested := &Column{FromID: "v", Position: 3, ColName: model.NewCIStr("nested"), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	nested.SetVirtualExpr(newFunction(ast.Plus, xy, x), ctx)
+	schema := NewSchema(newColumn("z"), y, x)
+	for i := 0; i < 2; i++ {
+		nested.ResolveIndicesByVirtualColumn(schema)
+		c.Assert(x.Index, Equals, 2)
+		c.Assert(y.Index, Equals, 1)
+		d, err = nested.Eval(types.MakeDatums(0, 3, 4))
+		c.Assert(err, IsNil)
+		c.Assert(d.GetInt64(), Equals, int64(16))
+	}
+
+	// A cast is added when the type doesn't match.
+	str := &Column{FromID: "v", Position: 1, ColName: model.NewCIStr("s"), RetType: types.NewFieldType(mysql.TypeString)}
+	str.SetVirtualExpr(newFunction(ast.Plus, newColumn("a"), newColumn("b")), ctx)
+	fun, ok := str.VirtualExpr.(*ScalarFunction)
+	c.Assert(ok, IsTrue)
+	c.Assert(fun.FuncName.L, Equals, ast.Cast)
+	c.Assert(str.VirtualExpr.GetType(), Equals, str.RetType)
+	str.ResolveIndices(NewSchema(newColumn("a"), newColumn("b")))
+	d, err = str.Eval(row)
+	c.Assert(err, IsNil)
+	c.Assert(d.GetString(), Equals, "3")
+
+	// CAST doesn't support VARCHAR, the expression is kept as it is.
+	varStr := &Column{FromID: "v", Position: 1, ColName: model.NewCIStr("vs"), RetType: types.NewFieldType(mysql.TypeVarString)}
+	varStr.SetVirtualExpr(newFunction(ast.Plus, newColumn("a"), newColumn("b")), ctx)
+	c.Assert(varStr.VirtualExpr.String(), Equals, "plus(test.t.a, test.t.b)")
+	varStr.ResolveIndices(NewSchema(newColumn("a"), newColumn("b")))
+	s1, isNull, err := varStr.EvalString(row, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(s1, Equals, "3")
+
+	// Clone deep copies the virtual expression.
+	cloned := v.Clone().(*Column)
+	c.Assert(cloned.VirtualExpr, NotNil)
+	c.Assert(cloned.VirtualExpr != v.VirtualExpr, IsTrue)
+	c.Assert(cloned.VirtualExpr.Equal(v.VirtualExpr, ctx), IsTrue)
+	cloned.VirtualExpr.(*ScalarFunction).GetArgs()[0].(*Column).Index = 5
+	c.Assert(v.VirtualExpr.(*ScalarFunction).GetArgs()[0].(*Column).Index, Equals, 1)
+
+	// HashCode takes the virtual expression into account.
+	plain := &Column{FromID: "v", RetType: types.NewFieldType(mysql.TypeLonglong)}
+	minus := &Column{FromID: "v", RetType: types.NewFieldType(mysql.TypeLonglong)}
+	minus.SetVirtualExpr(newFunction(ast.Minus, a, b), ctx)
+	c.Assert(v.HashCode(), DeepEquals, cloned.HashCode())
+	c.Assert(v.HashCode(), Not(DeepEquals), plain.HashCode())
+	c.Assert(v.HashCode(), Not(DeepEquals), minus.HashCode())
+}
+
+func (s *testExpressionSuite) TestHashCode(c *C) {
+	defer testleak.AfterTest(c)()
+	intOne := &Constant{Value: types.NewIntDatum(1), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	decOne := &Constant{Value: types.NewDecimalDatum(types.NewDecFromStringForTest("1.0")), RetType: types.NewFieldType(mysql.TypeNewDecimal)}
+	decOne.RetType.Decimal = 1
+	c.Assert(intOne.HashCode(), Not(DeepEquals), decOne.HashCode())
+	c.Assert(intOne.HashCode(), DeepEquals, intOne.Clone().HashCode())
+
+	// The unsigned flag, the decimal scale and the collation are taken into account.
+	unsignedOne := &Constant{Value: types.NewIntDatum(1), RetType: types.NewFieldType(mysql.TypeLonglong)}
+	unsignedOne.RetType.Flag |= mysql.UnsignedFlag
+	c.Assert(intOne.HashCode(), Not(DeepEquals), unsignedOne.HashCode())
+	decOne2 := &Constant{Value: decOne.Value, RetType: types.NewFieldType(mysql.TypeNewDecimal)}
+	decOne2.RetType.Decimal = 2
+	c.Assert(decOne.HashCode(), Not(DeepEquals), decOne2.HashCode())
+	bin := &Constant{Value: types.NewStringDatum("a"), RetType: types.NewFieldType(mysql.TypeVarString)}
+	bin.RetType.Collate = "binary"
+	ci := &Constant{Value: types.NewStringDatum("a"), RetType: types.NewFieldType(mysql.TypeVarString)}
+	ci.RetType.Collate = "utf8_general_ci"
+	c.Assert(bin.HashCode(), Not(DeepEquals), ci.HashCode())
+
+	// The columns are equal regardless of their types, so are their hashcodes.
+	intCol := &Column{FromID: "t", Position: 1, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	decCol := &Column{FromID: "t", Position: 1, RetType: types.NewFieldType(mysql.TypeNewDecimal)}
+	c.Assert(intCol.Equal(decCol, nil), IsTrue)
+	c.Assert(intCol.HashCode(), DeepEquals, decCol.HashCode())
+
+	// The scalar functions differ in the return type as well as the name and the arguments.
+	ctx := mock.NewContext()
+	castInt := NewCastFunc(types.NewFieldType(mysql.TypeLonglong), intCol, ctx)
+	castDec := NewCastFunc(types.NewFieldType(mysql.TypeNewDecimal), intCol, ctx)
+	c.Assert(castInt.HashCode(), Not(DeepEquals), castDec.HashCode())
+	c.Assert(newFunction(ast.Plus, intCol, intOne).HashCode(), Not(DeepEquals), newFunction(ast.Plus, intCol, decOne).HashCode())
+	c.Assert(newFunction(ast.Plus, intCol, intOne).HashCode(), Not(DeepEquals), newFunction(ast.Minus, intCol, intOne).HashCode())
+	c.Assert(newFunction(ast.Plus, intCol, intOne).HashCode(), DeepEquals, newFunction(ast.Plus, intCol, intOne).HashCode())
+}
+
+func (s *testExpressionSuite) TestScalarFunctionHashCodeCache(c *C) {
+	defer testleak.AfterTest(c)()
+	a, b := newColumn("a"), newColumn("b")
+	f := newFunction(ast.Plus, a, newFunction(ast.Mul, b, newLonglong(2))).(*ScalarFunction)
+	code := f.HashCode()
+	c.Assert(f.HashCode(), DeepEquals, code)
+	// The cached hashcode is returned by the later calls.
+	c.Assert(&f.HashCode()[0], Equals, &code[0])
+	cloned := f.Clone().(*ScalarFunction)
+	c.Assert(cloned.hashcode, IsNil)
+	c.Assert(cloned.HashCode(), DeepEquals, code)
+
+	// Modifying the arguments in place resets the cache.
+	g := newFunction(ast.Plus, a, newFunction(ast.Plus, newLonglong(1), newLonglong(1))).(*ScalarFunction)
+	unfolded := g.HashCode()
+	c.Assert(FoldConstant(g), Equals, g)
+	c.Assert(g.HashCode(), Not(DeepEquals), unfolded)
+	c.Assert(g.HashCode(), DeepEquals, newFunction(ast.Plus, a, newLonglong(2)).HashCode())
+}
+
+func (s *testExpressionSuite) TestEvalArgs(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	sc := ctx.GetSessionVars().StmtCtx
+	args := datumsToConstants(types.MakeDatums(1, "2", types.NewDecFromStringForTest("-3.25")))
+
+	ints, isNull, err := EvalArgsInt(args, nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(ints, DeepEquals, []int64{1, 2, -3})
+	reals, isNull, err := EvalArgsReal(args, nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(reals, DeepEquals, []float64{1, 2, -3.25})
+	strs, isNull, err := EvalArgsString(args, nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(strs, DeepEquals, []string{"1", "2", "-3.25"})
+	decs, isNull, err := EvalArgsDecimal(args, nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(decs, HasLen, 3)
+	c.Assert(decs[2].String(), Equals, "-3.25")
+
+	// The evaluation stops at the first NULL.
+	count := 0
+	counting := &builtinCountingSig{newBaseBuiltinFunc([]Expression{newLonglong(2)}, ctx), &count}
+	counted := &ScalarFunction{FuncName: model.NewCIStr("counting"), RetType: types.NewFieldType(mysql.TypeLonglong), Function: counting.setSelf(counting)}
+	ints, isNull, err = EvalArgsInt([]Expression{One, Null, counted}, nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsTrue)
+	c.Assert(ints, IsNil)
+	c.Assert(count, Equals, 0)
+	_, isNull, err = EvalArgsString([]Expression{counted, Null}, nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsTrue)
+	c.Assert(count, Equals, 1)
+}
+
+func (s *testExpressionSuite) TestEvalTimeAndDuration(c *C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	sc := ctx.GetSessionVars().StmtCtx
+	newTp := func(tp byte, fsp int) *types.FieldType {
+		ft := types.NewFieldType(tp)
+		ft.Decimal = fsp
+		return ft
+	}
+	tm, err := types.ParseTime("2017-01-02 10:11:12.123456", mysql.TypeDatetime, 3)
+	c.Assert(err, IsNil)
+	dur, err := types.ParseDuration("-10:11:12.56", 2)
+	c.Assert(err, IsNil)
+
+	// The temporal values are returned as they are, with their fsp.
+	timeCon := &Constant{Value: types.NewDatum(tm), RetType: newTp(mysql.TypeDatetime, 3)}
+	t, isNull, err := timeCon.EvalTime(nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(t, DeepEquals, tm)
+	col := &Column{Index: 1, RetType: newTp(mysql.TypeDuration, 2)}
+	d, isNull, err := col.EvalDuration(types.MakeDatums(nil, dur), sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(d, Equals, dur)
+	_, isNull, err = col.EvalDuration(types.MakeDatums(nil, nil), sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsTrue)
+
+	// The other values are converted with the fraction kept.
+	strCon := datumsToConstants(types.MakeDatums("2017-01-02 10:11:12.123456"))[0]
+	t, isNull, err = strCon.EvalTime(nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(t.Fsp, Equals, types.MaxFsp)
+	c.Assert(t.String(), Equals, "2017-01-02 10:11:12.123456")
+	d, isNull, err = datumsToConstants(types.MakeDatums("10:11:12.5"))[0].EvalDuration(nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(d.String(), Equals, "10:11:12.500000")
+	_, isNull, err = Null.EvalTime(nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsTrue)
+
+	// The fsp is kept through a chain of functions.
+	chain := NewCastFunc(newTp(mysql.TypeDatetime, 6), NewCastFunc(newTp(mysql.TypeDatetime, 3), strCon, ctx), ctx)
+	t, isNull, err = chain.EvalTime(nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(t.String(), Equals, "2017-01-02 10:11:12.123000")
+	chain = NewCastFunc(newTp(mysql.TypeDuration, 2), timeCon, ctx)
+	d, isNull, err = chain.EvalDuration(nil, sc)
+	c.Assert(err, IsNil)
+	c.Assert(isNull, IsFalse)
+	c.Assert(d.Fsp, Equals, 2)
+	c.Assert(d.String(), Equals, "10:11:12.12")
+}
+
+func (s *testExpressionSuite) TestTypeInferHook(c *C) {
+	defer testleak.AfterTest(c)()
+	type call struct {
+		funcName   string
+		argTypes   []*types.FieldType
+		resultType *types.FieldType
+	}
+	var calls []call
+	TypeInferHook = func(funcName string, argTypes []*types.FieldType, resultType *types.FieldType) {
+		calls = append(calls, call{funcName, argTypes, resultType})
+	}
+	defer func() {
+		TypeInferHook = nil
+	}()
+
+	a, b := newColumn("a"), newColumn("b")
+	b.RetType = types.NewFieldType(mysql.TypeDouble)
+	retType := types.NewFieldType(mysql.TypeDouble)
+	_, err := NewFunction(mock.NewContext(), ast.Plus, retType, a, b)
+	c.Assert(err, IsNil)
+	c.Assert(calls, HasLen, 1)
+	c.Assert(calls[0].funcName, Equals, ast.Plus)
+	c.Assert(calls[0].argTypes, HasLen, 2)
+	c.Assert(calls[0].argTypes[0], Equals, a.RetType)
+	c.Assert(calls[0].argTypes[1], Equals, b.RetType)
+	c.Assert(calls[0].resultType, Equals, retType)
+
+	// The hook isn't called if the function can't be built.
+	_, err = NewFunction(mock.NewContext(), "not_exists", retType, a)
+	c.Assert(err, NotNil)
+	c.Assert(calls, HasLen, 1)
+}
+
+func (s *testExpressionSuite) TestScalarFunctionFlag(c *C) {
+	defer testleak.AfterTest(c)()
+	a := newColumn("a")
+	sf := newFunction(ast.GT, a, One).(*ScalarFunction)
+	c.Assert(sf.HasFlag(FlagConstantFolded), IsFalse)
+	sf.SetFlag(FlagConstantFolded)
+	c.Assert(sf.HasFlag(FlagConstantFolded), IsTrue)
+	c.Assert(sf.HasFlag(FlagPushedDown), IsFalse)
+	c.Assert(sf.HasFlag(FlagConstantFolded|FlagPushedDown), IsFalse)
+
+	// Clone keeps the flags, and the flags take no part in Equal and HashCode.
+	cloned := sf.Clone().(*ScalarFunction)
+	c.Assert(cloned.HasFlag(FlagConstantFolded), IsTrue)
+	c.Assert(cloned.HasFlag(FlagPushedDown), IsFalse)
+	other := newFunction(ast.GT, a, One)
+	c.Assert(sf.Equal(other, nil), IsTrue)
+	c.Assert(sf.HashCode(), DeepEquals, other.HashCode())
+
+	cloned.SetFlag(FlagPushedDown)
+	c.Assert(cloned.HasFlag(FlagConstantFolded|FlagPushedDown), IsTrue)
+	c.Assert(sf.HasFlag(FlagPushedDown), IsFalse)
+
+	cast := NewCastFunc(types.NewFieldType(mysql.TypeDouble), a, mock.NewContext())
+	cast.SetFlag(FlagConstantPropagated)
+	c.Assert(cast.Clone().(*ScalarFunction).HasFlag(FlagConstantPropagated), IsTrue)
+}
+
+func (s *testExpressionSuite) TestCloneDefaultFunc(c *C) {
+	defer testleak.AfterTest(c)()
+	a := newColumn("a")
+	colInfo := &model.ColumnInfo{Name: model.NewCIStr("a")}
+	f, err := NewDefaultFunc(a, colInfo, mock.NewContext())
+	c.Assert(err, IsNil)
+	cloned, ok := f.Clone().(*ScalarFunction)
+	c.Assert(ok, IsTrue)
+	c.Assert(cloned != f, IsTrue)
+	c.Assert(cloned.FuncName.L, Equals, ast.DefaultFunc)
+	c.Assert(cloned.Function.(*builtinDefaultSig).colInfo, Equals, colInfo)
+	cloned.GetArgs()[0].(*Column).Index = 5
+	c.Assert(a.Index, Equals, 0)
+}
@@ -14,6 +14,7 @@
 package expression
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -134,6 +136,32 @@ func IsCurrentTimeExpr(e ast.ExprNode) bool {
 	return x.FnName.L == currentTimestampL
 }
 
+// getMaxAllowedPacket gets the value of system variable max_allowed_packet.
+func getMaxAllowedPacket(ctx context.Context) (uint64, error) {
+	val, err := varsutil.GetSessionSystemVar(ctx.GetSessionVars(), variable.MaxAllowedPacket)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	maxAllowedPacket, err := strconv.ParseUint(val, 10, 64)
+	return maxAllowedPacket, errors.Trace(err)
+}
+
+// getDefaultWeekFormat gets the value of system variable default_week_format.
+func getDefaultWeekFormat(ctx context.Context) (int, error) {
+	val, err := varsutil.GetSessionSystemVar(ctx.GetSessionVars(), variable.DefaultWeekFormat)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	mode, err := strconv.Atoi(val)
+	return mode, errors.Trace(err)
+}
+
+// getLcTimeNames gets the value of system variable lc_time_names.
+func getLcTimeNames(ctx context.Context) (string, error) {
+	val, err := varsutil.GetSessionSystemVar(ctx.GetSessionVars(), variable.LcTimeNames)
+	return val, errors.Trace(err)
+}
+
 func getSystemTimestamp(ctx context.Context) (time.Time, error) {
 	value := time.Now()
 
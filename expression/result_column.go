@@ -0,0 +1,195 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// ResultColumn is a column buffer holding the results of an expression over a batch of rows, it's filled
+// by EvalIntoColumn. Only the slice matching the type of the expression is used: Int64s, Float64s, Decimals
+// and Strings for the type classes, Times for the dates, datetimes and timestamps, and Durations for the
+// times. The buffer can be reused for another batch, its slices are kept and truncated.
+type ResultColumn struct {
+	// Tp is the type of the expression which fills the column.
+	Tp *types.FieldType
+
+	Int64s    []int64
+	Float64s  []float64
+	Decimals  []*types.MyDecimal
+	Strings   []string
+	Times     []types.Time
+	Durations []types.Duration
+
+	length int
+	// nullBitmap has the i-th bit set if the i-th value is NULL.
+	nullBitmap []byte
+}
+
+// Len returns the number of the values in the column.
+func (col *ResultColumn) Len() int {
+	return col.length
+}
+
+// IsNull checks whether the i-th value is NULL.
+func (col *ResultColumn) IsNull(i int) bool {
+	return col.nullBitmap[i>>3]&(1<<uint(i&7)) != 0
+}
+
+func (col *ResultColumn) setNull(i int) {
+	col.nullBitmap[i>>3] |= 1 << uint(i&7)
+}
+
+// reset prepares col for n values of type tp, all of them are zero and not NULL.
+func (col *ResultColumn) reset(tp *types.FieldType, n int) {
+	col.Tp, col.length = tp, n
+	col.Int64s, col.Float64s, col.Decimals = col.Int64s[:0], col.Float64s[:0], col.Decimals[:0]
+	col.Strings, col.Times, col.Durations = col.Strings[:0], col.Times[:0], col.Durations[:0]
+	switch resultColumnKind(tp) {
+	case resultInt:
+		col.Int64s = append(col.Int64s, make([]int64, n)...)
+	case resultReal:
+		col.Float64s = append(col.Float64s, make([]float64, n)...)
+	case resultDecimal:
+		col.Decimals = append(col.Decimals, make([]*types.MyDecimal, n)...)
+	case resultTime:
+		col.Times = append(col.Times, make([]types.Time, n)...)
+	case resultDuration:
+		col.Durations = append(col.Durations, make([]types.Duration, n)...)
+	default:
+		col.Strings = append(col.Strings, make([]string, n)...)
+	}
+	col.nullBitmap = col.nullBitmap[:0]
+	col.nullBitmap = append(col.nullBitmap, make([]byte, (n+7)>>3)...)
+}
+
+type resultKind byte
+
+const (
+	resultString resultKind = iota
+	resultInt
+	resultReal
+	resultDecimal
+	resultTime
+	resultDuration
+)
+
+// resultColumnKind returns which slice of a ResultColumn holds the values of type tp.
+func resultColumnKind(tp *types.FieldType) resultKind {
+	switch {
+	case tp == nil:
+		return resultString
+	case isTemporalType(tp.Tp):
+		return resultTime
+	case tp.Tp == mysql.TypeDuration:
+		return resultDuration
+	}
+	switch tp.ToClass() {
+	case types.ClassInt:
+		return resultInt
+	case types.ClassReal:
+		return resultReal
+	case types.ClassDecimal:
+		return resultDecimal
+	}
+	return resultString
+}
+
+// EvalIntoColumn evaluates expr over all the rows of input and writes the results into out, so the executor
+// can fill a whole column at once. A constant is evaluated only once and its value is broadcast to all the
+// rows, and any other expression, like a column copying its value of each row, is evaluated row by row by
+// the EvalXXX method matching its type.
+func EvalIntoColumn(expr Expression, input [][]types.Datum, sc *variable.StatementContext, out *ResultColumn) error {
+	out.reset(expr.GetType(), len(input))
+	if _, ok := expr.(*Constant); ok {
+		if len(input) == 0 {
+			return nil
+		}
+		if err := evalIntoColumnAt(expr, nil, sc, out, 0); err != nil {
+			return errors.Trace(err)
+		}
+		broadcastFirst(out)
+		return nil
+	}
+	for i, row := range input {
+		if err := evalIntoColumnAt(expr, row, sc, out, i); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// evalIntoColumnAt evaluates expr on row and writes the result to the i-th value of out.
+func evalIntoColumnAt(expr Expression, row []types.Datum, sc *variable.StatementContext, out *ResultColumn, i int) (err error) {
+	var isNull bool
+	switch resultColumnKind(out.Tp) {
+	case resultInt:
+		out.Int64s[i], isNull, err = expr.EvalInt(row, sc)
+	case resultReal:
+		out.Float64s[i], isNull, err = expr.EvalReal(row, sc)
+	case resultDecimal:
+		var dec *types.MyDecimal
+		dec, isNull, err = expr.EvalDecimal(row, sc)
+		if dec != nil {
+			// The decimal may be shared with the input rows or a constant, it's copied so that modifying a value
+			// of the column in place changes nothing else.
+			copied := *dec
+			dec = &copied
+		}
+		out.Decimals[i] = dec
+	case resultTime:
+		out.Times[i], isNull, err = expr.EvalTime(row, sc)
+	case resultDuration:
+		out.Durations[i], isNull, err = expr.EvalDuration(row, sc)
+	default:
+		out.Strings[i], isNull, err = expr.EvalString(row, sc)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if isNull {
+		out.setNull(i)
+	}
+	return nil
+}
+
+// broadcastFirst copies the first value of col to all the others. The decimals are copied by value, so that
+// modifying a row in place doesn't change the other rows.
+func broadcastFirst(col *ResultColumn) {
+	for i := 1; i < col.length; i++ {
+		switch resultColumnKind(col.Tp) {
+		case resultInt:
+			col.Int64s[i] = col.Int64s[0]
+		case resultReal:
+			col.Float64s[i] = col.Float64s[0]
+		case resultDecimal:
+			if col.Decimals[0] != nil {
+				d := *col.Decimals[0]
+				col.Decimals[i] = &d
+			}
+		case resultTime:
+			col.Times[i] = col.Times[0]
+		case resultDuration:
+			col.Durations[i] = col.Durations[0]
+		default:
+			col.Strings[i] = col.Strings[0]
+		}
+		if col.IsNull(0) {
+			col.setNull(i)
+		}
+	}
+}
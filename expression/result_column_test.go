@@ -0,0 +1,106 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (s *testExpressionSuite) TestEvalIntoColumn(c *C) {
+	defer testleak.AfterTest(c)()
+	sc := mock.NewContext().GetSessionVars().StmtCtx
+	input := [][]types.Datum{
+		types.MakeDatums(1, "a"),
+		types.MakeDatums(nil, "b"),
+		types.MakeDatums(3, nil),
+	}
+	intCol := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	strCol := &Column{Index: 1, RetType: types.NewFieldType(mysql.TypeVarString)}
+
+	// A column copies the values of the input column.
+	var out ResultColumn
+	c.Assert(EvalIntoColumn(intCol, input, sc, &out), IsNil)
+	c.Assert(out.Len(), Equals, 3)
+	c.Assert(out.Int64s, DeepEquals, []int64{1, 0, 3})
+	c.Assert(out.IsNull(0), IsFalse)
+	c.Assert(out.IsNull(1), IsTrue)
+	c.Assert(out.IsNull(2), IsFalse)
+
+	// The buffer is reused for the values of another type.
+	c.Assert(EvalIntoColumn(strCol, input, sc, &out), IsNil)
+	c.Assert(out.Int64s, HasLen, 0)
+	c.Assert(out.Strings, DeepEquals, []string{"a", "b", ""})
+	c.Assert(out.IsNull(1), IsFalse)
+	c.Assert(out.IsNull(2), IsTrue)
+
+	// A constant is broadcast to all the rows.
+	c.Assert(EvalIntoColumn(newLonglong(7), input, sc, &out), IsNil)
+	c.Assert(out.Int64s, DeepEquals, []int64{7, 7, 7})
+	null := &Constant{Value: types.Datum{}, RetType: types.NewFieldType(mysql.TypeDouble)}
+	c.Assert(EvalIntoColumn(null, input, sc, &out), IsNil)
+	c.Assert(out.Float64s, HasLen, 3)
+	for i := 0; i < out.Len(); i++ {
+		c.Assert(out.IsNull(i), IsTrue)
+	}
+	c.Assert(EvalIntoColumn(newLonglong(7), nil, sc, &out), IsNil)
+	c.Assert(out.Len(), Equals, 0)
+
+	// Each row of a broadcast decimal is a copy, modifying one of them changes neither the others nor the constant.
+	dec := &Constant{Value: types.NewDecimalDatum(types.NewDecFromInt(5)), RetType: types.NewFieldType(mysql.TypeNewDecimal)}
+	c.Assert(EvalIntoColumn(dec, input, sc, &out), IsNil)
+	c.Assert(out.Decimals, HasLen, 3)
+	*out.Decimals[0] = *types.NewDecFromInt(6)
+	c.Assert(out.Decimals[1].String(), Equals, "5")
+	c.Assert(out.Decimals[2].String(), Equals, "5")
+	c.Assert(dec.Value.GetMysqlDecimal().String(), Equals, "5")
+
+	// Each row read from a decimal column is a copy as well, even if the input rows share the decimal.
+	shared := types.NewDecimalDatum(types.NewDecFromInt(8))
+	decInput := [][]types.Datum{{shared}, {shared}, {types.NewDecimalDatum(types.NewDecFromInt(9))}}
+	decCol := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeNewDecimal)}
+	c.Assert(EvalIntoColumn(decCol, decInput, sc, &out), IsNil)
+	*out.Decimals[0] = *types.NewDecFromInt(6)
+	*out.Decimals[2] = *types.NewDecFromInt(6)
+	c.Assert(out.Decimals[1].String(), Equals, "8")
+	c.Assert(shared.GetMysqlDecimal().String(), Equals, "8")
+	c.Assert(decInput[2][0].GetMysqlDecimal().String(), Equals, "9")
+
+	// A function is evaluated row by row.
+	plus := newFunction(ast.Plus, intCol, newLonglong(10))
+	c.Assert(EvalIntoColumn(plus, input, sc, &out), IsNil)
+	c.Assert(out.Int64s, DeepEquals, []int64{11, 0, 13})
+	c.Assert(out.IsNull(1), IsTrue)
+
+	// More than 8 rows span several bytes of the null bitmap.
+	many := make([][]types.Datum, 0, 20)
+	for i := 0; i < 20; i++ {
+		if i%3 == 0 {
+			many = append(many, types.MakeDatums(nil))
+			continue
+		}
+		many = append(many, types.MakeDatums(i))
+	}
+	c.Assert(EvalIntoColumn(intCol, many, sc, &out), IsNil)
+	for i := 0; i < 20; i++ {
+		c.Assert(out.IsNull(i), Equals, i%3 == 0)
+		if i%3 != 0 {
+			c.Assert(out.Int64s[i], Equals, int64(i))
+		}
+	}
+}
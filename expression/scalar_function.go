@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -32,6 +33,32 @@ type ScalarFunction struct {
 	// TODO: Implement type inference here, now we use ast's return type temporarily.
 	RetType  *types.FieldType
 	Function builtinFunc
+	// flag is the set of the advisory flags, see HasFlag.
+	flag uint8
+	// hashcode caches the result of HashCode. The function is assumed to be immutable after it's built,
+	// so whoever modifies its arguments or RetType in place must reset the cache by resetHashCode.
+	hashcode []byte
+}
+
+const (
+	// FlagConstantFolded marks a function which has been folded as far as possible.
+	FlagConstantFolded uint8 = 1 << iota
+	// FlagPushedDown marks a function which has been pushed down to the storage layer.
+	FlagPushedDown
+	// FlagConstantPropagated marks a function derived from constant propagation.
+	FlagConstantPropagated
+)
+
+// HasFlag checks if all the bits of f are set on the function.
+// The flags are advisory marks for the rewrites to avoid reprocessing, they are kept by Clone but
+// they take no part in Equal and HashCode.
+func (sf *ScalarFunction) HasFlag(f uint8) bool {
+	return sf.flag&f == f
+}
+
+// SetFlag sets the bits of f on the function.
+func (sf *ScalarFunction) SetFlag(f uint8) {
+	sf.flag |= f
 }
 
 // GetArgs gets arguments of function.
@@ -65,6 +92,9 @@ func (sf *ScalarFunction) MarshalJSON() ([]byte, error) {
 
 // NewFunction creates a new scalar function or constant.
 func NewFunction(ctx context.Context, funcName string, retType *types.FieldType, args ...Expression) (Expression, error) {
+	if isRowComparison(funcName, args) {
+		return expandRowComparison(ctx, funcName, retType, args)
+	}
 	fc, ok := funcs[funcName]
 	if !ok {
 		return nil, errFunctionNotExists.GenByArgs(funcName)
@@ -75,10 +105,21 @@ func NewFunction(ctx context.Context, funcName string, retType *types.FieldType,
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if funcName == ast.RowFunc {
+		// A row is typed by its datum kind, as the rows built by the planner are.
+		retType = types.NewFieldType(types.KindRow)
+	}
+	if TypeInferHook != nil {
+		argTypes := make([]*types.FieldType, 0, len(funcArgs))
+		for _, arg := range funcArgs {
+			argTypes = append(argTypes, arg.GetType())
+		}
+		TypeInferHook(funcName, argTypes, retType)
+	}
 	return &ScalarFunction{
 		FuncName: model.NewCIStr(funcName),
 		RetType:  retType,
-		Function: f,
+		Function: f.setSelf(f),
 	}, nil
 }
 
@@ -97,13 +138,26 @@ func (sf *ScalarFunction) Clone() Expression {
 	for _, arg := range sf.GetArgs() {
 		newArgs = append(newArgs, arg.Clone())
 	}
+	var newFunc *ScalarFunction
 	switch v := sf.Function.(type) {
 	case *builtinCastSig:
-		return NewCastFunc(v.tp, newArgs[0], sf.GetCtx())
+		newFunc = NewCastFunc(v.tp, newArgs[0], sf.GetCtx())
 	case *builtinValuesSig:
-		return NewValuesFunc(v.offset, sf.GetType(), sf.GetCtx())
+		newFunc = NewValuesFunc(v.offset, sf.GetType(), sf.GetCtx())
+	case *builtinDefaultSig:
+		// The argument has been checked when sf was built, so the sig is copied with the cloned argument.
+		bt := &builtinDefaultSig{newBaseBuiltinFunc(newArgs, sf.GetCtx()), v.colInfo}
+		bt.deterministic = false
+		newFunc = &ScalarFunction{FuncName: sf.FuncName, RetType: sf.RetType, Function: bt.setSelf(bt)}
+	default:
+		expr, _ := NewFunction(sf.GetCtx(), sf.FuncName.L, sf.RetType, newArgs...)
+		f, ok := expr.(*ScalarFunction)
+		if !ok {
+			return expr
+		}
+		newFunc = f
 	}
-	newFunc, _ := NewFunction(sf.GetCtx(), sf.FuncName.L, sf.RetType, newArgs...)
+	newFunc.flag = sf.flag
 	return newFunc
 }
 
@@ -139,9 +193,30 @@ func (sf *ScalarFunction) Decorrelate(schema *Schema) Expression {
 	for i, arg := range sf.GetArgs() {
 		sf.GetArgs()[i] = arg.Decorrelate(schema)
 	}
+	sf.resetHashCode()
 	return sf
 }
 
+// ReplaceColumn implements Expression interface.
+func (sf *ScalarFunction) ReplaceColumn(schema *Schema, newExprs []Expression) Expression {
+	if sf.FuncName.L == ast.Cast {
+		newFunc := sf.Clone().(*ScalarFunction)
+		newFunc.GetArgs()[0] = newFunc.GetArgs()[0].ReplaceColumn(schema, newExprs)
+		newFunc.resetHashCode()
+		return newFunc
+	}
+	if _, ok := sf.Function.(*builtinDefaultSig); ok {
+		// The default value doesn't depend on the value of the column.
+		return sf.Clone()
+	}
+	newArgs := make([]Expression, 0, len(sf.GetArgs()))
+	for _, arg := range sf.GetArgs() {
+		newArgs = append(newArgs, arg.ReplaceColumn(schema, newExprs))
+	}
+	fun, _ := NewFunction(sf.GetCtx(), sf.FuncName.L, sf.RetType, newArgs...)
+	return fun
+}
+
 // Eval implements Expression interface.
 func (sf *ScalarFunction) Eval(row []types.Datum) (types.Datum, error) {
 	return sf.Function.eval(row)
@@ -167,18 +242,39 @@ func (sf *ScalarFunction) EvalString(row []types.Datum, sc *variable.StatementCo
 	return sf.Function.evalString(row)
 }
 
+// EvalTime implements Expression interface.
+// The functions return the time values as datums, so their fsp and types are kept.
+func (sf *ScalarFunction) EvalTime(row []types.Datum, sc *variable.StatementContext) (types.Time, bool, error) {
+	val, isNull, err := evalExprToTime(sf, row, sc)
+	return val, isNull, errors.Trace(err)
+}
+
+// EvalDuration implements Expression interface.
+func (sf *ScalarFunction) EvalDuration(row []types.Datum, sc *variable.StatementContext) (types.Duration, bool, error) {
+	val, isNull, err := evalExprToDuration(sf, row, sc)
+	return val, isNull, errors.Trace(err)
+}
+
 // HashCode implements Expression interface.
+// The hashcode is computed once and cached, a cloned function computes its own.
 func (sf *ScalarFunction) HashCode() []byte {
+	if len(sf.hashcode) != 0 {
+		return sf.hashcode
+	}
 	var bytes []byte
 	v := make([]types.Datum, 0, len(sf.GetArgs())+1)
-	bytes, _ = codec.EncodeValue(bytes, types.NewStringDatum(sf.FuncName.L))
+	bytes, _ = codec.EncodeValue(bytes, types.NewStringDatum(sf.FuncName.L), fieldTypeHashDatum(sf.RetType))
 	v = append(v, types.NewBytesDatum(bytes))
 	for _, arg := range sf.GetArgs() {
 		v = append(v, types.NewBytesDatum(arg.HashCode()))
 	}
-	bytes = bytes[:0]
-	bytes, _ = codec.EncodeValue(bytes, v...)
-	return bytes
+	sf.hashcode, _ = codec.EncodeValue(nil, v...)
+	return sf.hashcode
+}
+
+// resetHashCode clears the cached hashcode, it must be called after the arguments are modified in place.
+func (sf *ScalarFunction) resetHashCode() {
+	sf.hashcode = nil
 }
 
 // ResolveIndices implements Expression interface.
@@ -14,6 +14,7 @@
 package expression
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/juju/errors"
@@ -40,22 +41,44 @@ type Schema struct {
 }
 
 // String implements fmt.Stringer interface.
+// Each column is printed as 'TblName.ColName#Position(type)'. A virtual column is marked by '(virtual)', and it's
+// also marked by '(cor)' if its expression references correlated columns, e.g.
+// 'Column: [t.a#0(bigint),t.v#1(bigint)(virtual),t.w#2(bigint)(virtual)(cor)] Unique key: [[t.a#0]]'.
 func (s *Schema) String() string {
 	colStrs := make([]string, 0, len(s.Columns))
 	for _, col := range s.Columns {
-		colStrs = append(colStrs, col.String())
+		colStr := schemaColumnString(col)
+		if col.RetType != nil {
+			colStr += "(" + col.RetType.CompactStr() + ")"
+		}
+		if col.VirtualExpr != nil {
+			colStr += "(virtual)"
+			if len(ExtractCorrelatedColumns(col.VirtualExpr)) > 0 {
+				colStr += "(cor)"
+			}
+		}
+		colStrs = append(colStrs, colStr)
 	}
 	ukStrs := make([]string, 0, len(s.Keys))
 	for _, key := range s.Keys {
 		ukColStrs := make([]string, 0, len(key))
 		for _, col := range key {
-			ukColStrs = append(ukColStrs, col.String())
+			ukColStrs = append(ukColStrs, schemaColumnString(col))
 		}
 		ukStrs = append(ukStrs, "["+strings.Join(ukColStrs, ",")+"]")
 	}
 	return "Column: [" + strings.Join(colStrs, ",") + "] Unique key: [" + strings.Join(ukStrs, ",") + "]"
 }
 
+// schemaColumnString returns 'TblName.ColName#Position', the position tells the columns of the same name apart.
+func schemaColumnString(col *Column) string {
+	name := col.ColName.L
+	if col.TblName.L != "" {
+		name = col.TblName.L + "." + name
+	}
+	return name + "#" + strconv.Itoa(col.Position)
+}
+
 // Clone copies the total schema.
 func (s *Schema) Clone() *Schema {
 	cols := make([]*Column, 0, s.Len())
@@ -63,8 +86,18 @@ func (s *Schema) Clone() *Schema {
 	for _, col := range s.Columns {
 		cols = append(cols, col.Clone().(*Column))
 	}
+	// The key columns are remapped to the cloned columns, so that the cloned keys don't alias the
+	// columns of s.
 	for _, key := range s.Keys {
-		keys = append(keys, key.Clone())
+		newKey := make(KeyInfo, 0, len(key))
+		for _, col := range key {
+			if idx := s.ColumnIndex(col); idx != -1 {
+				newKey = append(newKey, cols[idx])
+			} else {
+				newKey = append(newKey, col.Clone().(*Column))
+			}
+		}
+		keys = append(keys, newKey)
 	}
 	schema := NewSchema(cols...)
 	schema.SetUniqueKeys(keys)
@@ -102,16 +135,43 @@ func (s *Schema) RetrieveColumn(col *Column) *Column {
 	return nil
 }
 
-// IsUniqueKey checks if this column is a unique key.
-func (s *Schema) IsUniqueKey(col *Column) bool {
+// IsUniqueKey checks if the column set exactly matches one of the unique keys, the order of columns is ignored.
+func (s *Schema) IsUniqueKey(cols []*Column) bool {
 	for _, key := range s.Keys {
-		if len(key) == 1 && key[0].Equal(col, nil) {
+		if columnsContainAll(cols, key) && columnsContainAll(key, cols) {
 			return true
 		}
 	}
 	return false
 }
 
+// ContainsUniqueKey checks if the column set contains all the columns of some unique key.
+func (s *Schema) ContainsUniqueKey(cols []*Column) bool {
+	for _, key := range s.Keys {
+		if columnsContainAll(cols, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnsContainAll checks if every column of sub can be found in cols.
+func columnsContainAll(cols, sub []*Column) bool {
+	for _, subCol := range sub {
+		found := false
+		for _, col := range cols {
+			if col.Equal(subCol, nil) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // ColumnIndex finds the index for a column.
 func (s *Schema) ColumnIndex(col *Column) int {
 	for i, c := range s.Columns {
@@ -137,6 +197,45 @@ func (s *Schema) Append(col ...*Column) {
 	s.Columns = append(s.Columns, col...)
 }
 
+// ReplaceColumn replaces the first column of s that matches old with newCol, and the unique keys referencing
+// old are updated to reference newCol. It returns whether a column is replaced.
+// The keys are often shared with other schemas, so the updated keys are copied rather than modified in place.
+// The columns are modified in place, so the caller should Clone s first if the schema is shared and the other
+// owners mustn't see the replacement.
+func (s *Schema) ReplaceColumn(old, newCol *Column) bool {
+	idx := s.ColumnIndex(old)
+	if idx == -1 {
+		return false
+	}
+	s.Columns[idx] = newCol
+	var keys []KeyInfo
+	for i, key := range s.Keys {
+		var newKey KeyInfo
+		for j, col := range key {
+			if !col.Equal(old, nil) {
+				continue
+			}
+			if newKey == nil {
+				newKey = make(KeyInfo, len(key))
+				copy(newKey, key)
+			}
+			newKey[j] = newCol
+		}
+		if newKey == nil {
+			continue
+		}
+		if keys == nil {
+			keys = make([]KeyInfo, len(s.Keys))
+			copy(keys, s.Keys)
+		}
+		keys[i] = newKey
+	}
+	if keys != nil {
+		s.SetUniqueKeys(keys)
+	}
+	return true
+}
+
 // SetUniqueKeys will set the value of Schema.Keys.
 func (s *Schema) SetUniqueKeys(keys []KeyInfo) {
 	s.Keys = keys
@@ -157,6 +256,57 @@ func (s *Schema) ColumnsIndices(cols []*Column) (ret []int) {
 	return
 }
 
+// Join types accepted by Schema.MergeKeys. The join types defined in plan package are made of them.
+const (
+	// JoinTypeInner means inner join.
+	JoinTypeInner = iota
+	// JoinTypeLeftOuter means left outer join, the right side is the null-producing side.
+	JoinTypeLeftOuter
+	// JoinTypeRightOuter means right outer join, the left side is the null-producing side.
+	JoinTypeRightOuter
+	// JoinTypeSemi means semi join, only the left side is output.
+	JoinTypeSemi
+	// JoinTypeLeftOuterSemi means left outer semi join, only the left side and an auxiliary column are output.
+	JoinTypeLeftOuterSemi
+)
+
+// MergeKeys derives the unique keys of a join schema from its children's schemas and sets them by SetUniqueKeys.
+// For inner join, a left key combined with a right key always survives.
+// For outer join, the keys of the null-producing side are dropped, so are the combined keys. The keys of the outer
+// side survive only if every outer row matches at most one inner row, which depends on the join conditions and
+// is left to the caller.
+// For semi join, only the keys of the left side survive because the right side isn't output.
+// The key columns are remapped to the columns of s, and a key with a column that can't be found in s is dropped.
+func (s *Schema) MergeKeys(left, right *Schema, joinType int) {
+	var candidates []KeyInfo
+	switch joinType {
+	case JoinTypeInner:
+		for _, lKey := range left.Keys {
+			for _, rKey := range right.Keys {
+				key := make(KeyInfo, 0, len(lKey)+len(rKey))
+				candidates = append(candidates, append(append(key, lKey...), rKey...))
+			}
+		}
+	case JoinTypeSemi, JoinTypeLeftOuterSemi:
+		candidates = left.Keys
+	}
+	keys := make([]KeyInfo, 0, len(candidates))
+	for _, key := range candidates {
+		newKey := make(KeyInfo, 0, len(key))
+		for _, col := range key {
+			newCol := s.RetrieveColumn(col)
+			if newCol == nil {
+				break
+			}
+			newKey = append(newKey, newCol)
+		}
+		if len(newKey) == len(key) {
+			keys = append(keys, newKey)
+		}
+	}
+	s.SetUniqueKeys(keys)
+}
+
 // MergeSchema will merge two schema into one schema.
 func MergeSchema(lSchema, rSchema *Schema) *Schema {
 	tmpL := lSchema.Clone()
@@ -0,0 +1,203 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var _ = Suite(&testSchemaSuite{})
+
+type testSchemaSuite struct{}
+
+// generateSchema will generate a schema for test. Its columns' FromID are the given name and their positions
+// are from 0 to colCount - 1. Every column forms a unique key by itself.
+func generateSchema(name string, colCount int) *Schema {
+	cols := make([]*Column, 0, colCount)
+	keys := make([]KeyInfo, 0, colCount)
+	for i := 0; i < colCount; i++ {
+		col := newColumn(name)
+		col.Position = i
+		cols = append(cols, col)
+		keys = append(keys, KeyInfo{col})
+	}
+	schema := NewSchema(cols...)
+	schema.SetUniqueKeys(keys)
+	return schema
+}
+
+func (s *testSchemaSuite) TestMergeKeys(c *C) {
+	defer testleak.AfterTest(c)()
+	left := generateSchema("l", 2)
+	right := generateSchema("r", 3)
+	combined := "[[t.l#0,t.r#0],[t.l#0,t.r#1],[t.l#0,t.r#2],[t.l#1,t.r#0],[t.l#1,t.r#1],[t.l#1,t.r#2]]"
+	tests := []struct {
+		joinType int
+		keys     string
+	}{
+		{JoinTypeInner, combined},
+		{JoinTypeLeftOuter, "[]"},
+		{JoinTypeRightOuter, "[]"},
+		{JoinTypeSemi, "[[t.l#0],[t.l#1]]"},
+		{JoinTypeLeftOuterSemi, "[[t.l#0],[t.l#1]]"},
+	}
+	for _, tt := range tests {
+		joinSchema := MergeSchema(left, right)
+		joinSchema.MergeKeys(left, right, tt.joinType)
+		c.Assert(joinSchema.String(), Equals, "Column: [t.l#0(bigint),t.l#1(bigint),t.r#0(bigint),t.r#1(bigint),t.r#2(bigint)] Unique key: "+tt.keys)
+		// The key columns should be the ones of the join schema.
+		for _, key := range joinSchema.Keys {
+			for _, col := range key {
+				c.Assert(col, Equals, joinSchema.RetrieveColumn(col))
+			}
+		}
+	}
+
+	// The keys whose columns aren't in the schema are dropped.
+	semiSchema := left.Clone()
+	semiSchema.MergeKeys(left, right, JoinTypeInner)
+	c.Assert(semiSchema.Keys, HasLen, 0)
+	semiSchema.MergeKeys(left, right, JoinTypeSemi)
+	c.Assert(semiSchema.Keys, HasLen, 2)
+}
+
+func (s *testSchemaSuite) TestSchemaString(c *C) {
+	defer testleak.AfterTest(c)()
+	a := newColumn("a")
+	b := &Column{FromID: "b", ColName: model.NewCIStr("b"), Position: 1, RetType: types.NewFieldType(mysql.TypeVarchar)}
+	b.RetType.Flen = 10
+	v := &Column{FromID: "v", ColName: model.NewCIStr("v"), TblName: model.NewCIStr("t"), Position: 2, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	v.SetVirtualExpr(newFunction(ast.Plus, a, One), mock.NewContext())
+	// w references the correlated column o.
+	o := &CorrelatedColumn{Column: *newColumn("o"), Data: new(types.Datum)}
+	w := &Column{FromID: "w", ColName: model.NewCIStr("w"), TblName: model.NewCIStr("t"), Position: 3, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	w.SetVirtualExpr(newFunction(ast.Plus, a, o), mock.NewContext())
+	schema := NewSchema(a, b, v, w)
+	schema.SetUniqueKeys([]KeyInfo{{a}, {a, b}})
+	c.Assert(schema.String(), Equals, "Column: [t.a#0(bigint),b#1(varchar(10)),t.v#2(bigint)(virtual),t.w#3(bigint)(virtual)(cor)] Unique key: [[t.a#0],[t.a#0,b#1]]")
+	c.Assert(fmt.Sprintf("%v", schema), Equals, schema.String())
+	c.Assert(NewSchema().String(), Equals, "Column: [] Unique key: []")
+}
+
+func (s *testSchemaSuite) TestIsUniqueKey(c *C) {
+	defer testleak.AfterTest(c)()
+	schema := generateSchema("t", 4)
+	cols := schema.Columns
+	// Add a composite key (col2, col3).
+	schema.Keys = append(schema.Keys, KeyInfo{cols[2], cols[3]})
+	// Use copied columns to make sure the columns are compared by identity rather than pointer.
+	col := func(i int) *Column {
+		return cols[i].Clone().(*Column)
+	}
+	tests := []struct {
+		cols        []*Column
+		isKey       bool
+		containsKey bool
+	}{
+		{[]*Column{col(0)}, true, true},
+		{[]*Column{col(2), col(3)}, true, true},
+		{[]*Column{col(3), col(2)}, true, true},
+		{[]*Column{col(0), col(1)}, false, true},
+		{[]*Column{col(1), col(2), col(3)}, false, true},
+		{[]*Column{}, false, false},
+		{[]*Column{newColumn("other")}, false, false},
+	}
+	for i, tt := range tests {
+		c.Assert(schema.IsUniqueKey(tt.cols), Equals, tt.isKey, Commentf("case %d", i))
+		c.Assert(schema.ContainsUniqueKey(tt.cols), Equals, tt.containsKey, Commentf("case %d", i))
+	}
+
+	// Only the composite key is registered.
+	schema.Keys = []KeyInfo{{cols[2], cols[3]}}
+	c.Assert(schema.IsUniqueKey([]*Column{col(2)}), IsFalse)
+	c.Assert(schema.ContainsUniqueKey([]*Column{col(2)}), IsFalse)
+	c.Assert(schema.ContainsUniqueKey([]*Column{col(0), col(3), col(2)}), IsTrue)
+}
+
+func (s *testSchemaSuite) TestSchemaClone(c *C) {
+	defer testleak.AfterTest(c)()
+	schema := generateSchema("t", 3)
+	schema.Keys = append(schema.Keys, KeyInfo{schema.Columns[1], schema.Columns[2]})
+	for i, col := range schema.Columns {
+		col.Index = i
+	}
+	cloned := schema.Clone()
+	c.Assert(cloned.String(), Equals, schema.String())
+	for i, col := range cloned.Columns {
+		c.Assert(col, Not(Equals), schema.Columns[i])
+	}
+	// The keys reference the cloned columns.
+	c.Assert(cloned.Keys, HasLen, len(schema.Keys))
+	for _, key := range cloned.Keys {
+		for _, col := range key {
+			c.Assert(col, Equals, cloned.RetrieveColumn(col))
+		}
+	}
+	c.Assert(cloned.Keys[3][0], Equals, cloned.Columns[1])
+	c.Assert(cloned.Keys[3][1], Equals, cloned.Columns[2])
+
+	// Mutating the clone leaves the source schema unchanged.
+	for _, col := range cloned.Columns {
+		col.Index = 10
+	}
+	for i, col := range schema.Columns {
+		c.Assert(col.Index, Equals, i)
+	}
+	for _, key := range schema.Keys {
+		for _, col := range key {
+			c.Assert(col, Equals, schema.RetrieveColumn(col))
+		}
+	}
+}
+
+func (s *testSchemaSuite) TestReplaceColumn(c *C) {
+	defer testleak.AfterTest(c)()
+	schema := generateSchema("t", 3)
+	// Only t#0 and t#1 are in the unique keys, and the keys are shared with another schema.
+	schema.Keys = []KeyInfo{{schema.Columns[0]}, {schema.Columns[0], schema.Columns[1]}}
+	shared := NewSchema(schema.Columns[0], schema.Columns[1])
+	shared.SetUniqueKeys(schema.Keys)
+	newCol := func(pos int) *Column {
+		col := newColumn("n")
+		col.Position = pos
+		return col
+	}
+
+	// A column outside the unique keys.
+	c.Assert(schema.ReplaceColumn(schema.Columns[2], newCol(2)), IsTrue)
+	c.Assert(schema.String(), Equals, "Column: [t.t#0(bigint),t.t#1(bigint),t.n#2(bigint)] Unique key: [[t.t#0],[t.t#0,t.t#1]]")
+
+	// A column inside the unique keys, the shared keys are left unchanged.
+	old := schema.Columns[0]
+	c.Assert(schema.ReplaceColumn(old, newCol(0)), IsTrue)
+	c.Assert(schema.String(), Equals, "Column: [t.n#0(bigint),t.t#1(bigint),t.n#2(bigint)] Unique key: [[t.n#0],[t.n#0,t.t#1]]")
+	c.Assert(schema.IsUniqueKey([]*Column{newCol(0)}), IsTrue)
+	c.Assert(schema.IsUniqueKey([]*Column{old}), IsFalse)
+	c.Assert(shared.String(), Equals, "Column: [t.t#0(bigint),t.t#1(bigint)] Unique key: [[t.t#0],[t.t#0,t.t#1]]")
+
+	// The column is matched by its FromID and Position.
+	c.Assert(schema.ReplaceColumn(old, newCol(3)), IsFalse)
+	c.Assert(schema.ReplaceColumn(newCol(5), newCol(3)), IsFalse)
+	c.Assert(schema.ReplaceColumn(newCol(1), newCol(3)), IsFalse)
+	c.Assert(schema.ReplaceColumn(schema.Columns[1], newCol(1)), IsTrue)
+	c.Assert(schema.String(), Equals, "Column: [t.n#0(bigint),t.n#1(bigint),t.n#2(bigint)] Unique key: [[t.n#0],[t.n#0,t.n#1]]")
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+const (
+	// eqSelectivity is the selectivity of an equal condition, e.g. 'a = 1'.
+	eqSelectivity = 0.1
+	// rangeSelectivity is the selectivity of a range condition, e.g. 'a < 1'.
+	rangeSelectivity = 0.33
+	// isNullSelectivity is the selectivity of 'a is null' when a may be null.
+	isNullSelectivity = 0.1
+	// unknownSelectivity is the selectivity of the conditions whose structure tells nothing.
+	unknownSelectivity = 0.8
+)
+
+// DefaultSelectivity estimates the fraction of rows satisfying expr from the structure of expr only,
+// it's the deterministic fallback when the statistics are not available.
+// The conditions combined by AND are treated as independent, so their selectivities are multiplied,
+// OR follows the inclusion-exclusion principle and NOT takes the complement. 'col is null' never
+// matches when col has the not null flag.
+func DefaultSelectivity(expr Expression) float64 {
+	switch x := expr.(type) {
+	case *Constant:
+		if truth, ok := constantTruth(x, new(variable.StatementContext)); ok && truth {
+			return 1
+		}
+		return 0
+	case *ScalarFunction:
+		args := x.GetArgs()
+		switch x.FuncName.L {
+		case ast.EQ, ast.NullEQ:
+			return eqSelectivity
+		case ast.NE:
+			return 1 - eqSelectivity
+		case ast.LT, ast.LE, ast.GT, ast.GE:
+			return rangeSelectivity
+		case ast.In:
+			// 'a in (x, y)' is 'a = x or a = y'.
+			sel := 0.0
+			for range args[1:] {
+				sel = sel + eqSelectivity - sel*eqSelectivity
+			}
+			return sel
+		case ast.IsNull:
+			if col, ok := args[0].(*Column); ok && mysql.HasNotNullFlag(col.GetType().Flag) {
+				return 0
+			}
+			return isNullSelectivity
+		case ast.AndAnd:
+			return DefaultSelectivity(args[0]) * DefaultSelectivity(args[1])
+		case ast.OrOr:
+			l, r := DefaultSelectivity(args[0]), DefaultSelectivity(args[1])
+			return l + r - l*r
+		case ast.UnaryNot:
+			return 1 - DefaultSelectivity(args[0])
+		}
+	}
+	return unknownSelectivity
+}
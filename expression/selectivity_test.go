@@ -0,0 +1,59 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"math"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (*testExpressionSuite) TestDefaultSelectivity(c *C) {
+	defer testleak.AfterTest(c)()
+	a, b := newColumn("a"), newColumn("b")
+	notNullCol := newColumn("c")
+	notNullCol.RetType.Flag |= mysql.NotNullFlag
+	eq := newFunction(ast.EQ, a, One)
+	lt := newFunction(ast.LT, b, Zero)
+	tests := []struct {
+		expr Expression
+		sel  float64
+	}{
+		{One, 1},
+		{Zero, 0},
+		{Null, 0},
+		{a, 0.8},
+		{eq, 0.1},
+		{lt, 0.33},
+		{newFunction(ast.GE, a, b), 0.33},
+		{newFunction(ast.NE, a, One), 0.9},
+		{newFunction(ast.In, a, One, Zero), 0.19},
+		{newFunction(ast.AndAnd, eq, lt), 0.033},
+		{newFunction(ast.OrOr, eq, lt), 0.1 + 0.33 - 0.033},
+		{newFunction(ast.UnaryNot, eq), 0.9},
+		{newFunction(ast.UnaryNot, newFunction(ast.AndAnd, eq, lt)), 0.967},
+		{newFunction(ast.AndAnd, newFunction(ast.OrOr, eq, eq), newFunction(ast.UnaryNot, lt)), 0.19 * 0.67},
+		{newFunction(ast.IsNull, a), 0.1},
+		{newFunction(ast.IsNull, notNullCol), 0},
+		{newFunction(ast.UnaryNot, newFunction(ast.IsNull, notNullCol)), 1},
+		{newFunction(ast.Plus, a, b), 0.8},
+	}
+	for _, t := range tests {
+		sel := DefaultSelectivity(t.expr)
+		c.Assert(math.Abs(sel-t.sel) < 1e-9, IsTrue, Commentf("%s: %v, expected %v", t.expr, sel, t.sel))
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxSimplifyRounds bounds the number of rounds Simplify runs before reaching a fixed point.
+const maxSimplifyRounds = 8
+
+// booleanFuncs are the functions whose results are always 0, 1 or NULL.
+var booleanFuncs = map[string]struct{}{
+	ast.LT:        {},
+	ast.LE:        {},
+	ast.GT:        {},
+	ast.GE:        {},
+	ast.EQ:        {},
+	ast.NE:        {},
+	ast.NullEQ:    {},
+	ast.AndAnd:    {},
+	ast.OrOr:      {},
+	ast.LogicXor:  {},
+	ast.UnaryNot:  {},
+	ast.IsNull:    {},
+	ast.IsTruth:   {},
+	ast.IsFalsity: {},
+	ast.In:        {},
+	ast.Like:      {},
+	ast.Regexp:    {},
+}
+
+// Simplify applies the cheap algebraic identities to expr until it doesn't change any more:
+// 'x and x' -> 'x', 'x or x' -> 'x', 'x and true' -> 'x', 'x or false' -> 'x', 'x and false' -> 'false',
+// 'not not x' -> 'x', and the deterministic functions of constants are folded.
+// The value of expr is kept, so the identities resulting in x are only applied when x is a boolean
+// expression, and 'x and x' is never deduplicated if x is not deterministic. expr itself isn't modified.
+func Simplify(ctx context.Context, expr Expression) Expression {
+	sc := new(variable.StatementContext)
+	if ctx != nil {
+		sc = ctx.GetSessionVars().StmtCtx
+	}
+	for i := 0; i < maxSimplifyRounds; i++ {
+		var changed bool
+		expr, changed = simplify(sc, expr)
+		if !changed {
+			break
+		}
+	}
+	return expr
+}
+
+func simplify(sc *variable.StatementContext, expr Expression) (Expression, bool) {
+	f, ok := expr.(*ScalarFunction)
+	if !ok {
+		return expr, false
+	}
+	changed := false
+	args := make([]Expression, 0, len(f.GetArgs()))
+	for _, arg := range f.GetArgs() {
+		newArg, argChanged := simplify(sc, arg)
+		args = append(args, newArg)
+		changed = changed || argChanged
+	}
+	if changed {
+		if f.FuncName.L == ast.Cast {
+			expr = NewCastFunc(f.RetType, args[0], f.GetCtx())
+		} else {
+			newFunc, err := NewFunction(f.GetCtx(), f.FuncName.L, f.RetType, args...)
+			if err != nil {
+				return f, false
+			}
+			expr = newFunc
+		}
+	}
+	if newExpr := simplifyIdentity(sc, expr); newExpr != expr {
+		return newExpr, true
+	}
+	if _, ok := expr.(*ScalarFunction); ok {
+		allConst := true
+		for _, arg := range args {
+			if _, ok := arg.(*Constant); !ok {
+				allConst = false
+				break
+			}
+		}
+		if allConst {
+			if folded := FoldConstant(expr); folded != expr {
+				return folded, true
+			}
+		}
+	}
+	return expr, changed
+}
+
+// simplifyIdentity applies the identities to the top level of expr, it returns expr itself if none applies.
+func simplifyIdentity(sc *variable.StatementContext, expr Expression) Expression {
+	f, ok := expr.(*ScalarFunction)
+	if !ok {
+		return expr
+	}
+	args := f.GetArgs()
+	switch f.FuncName.L {
+	case ast.UnaryNot:
+		if inner, ok := args[0].(*ScalarFunction); ok && inner.FuncName.L == ast.UnaryNot && isBooleanExpr(inner.GetArgs()[0]) {
+			return inner.GetArgs()[0]
+		}
+	case ast.AndAnd, ast.OrOr:
+		isAnd := f.FuncName.L == ast.AndAnd
+		for i := 0; i < 2; i++ {
+			x, other := args[1-i], args[i]
+			con, ok := other.(*Constant)
+			if !ok || con.Value.IsNull() {
+				continue
+			}
+			truth, err := con.Value.ToBool(sc)
+			if err != nil {
+				continue
+			}
+			if isAnd && truth == 0 {
+				return Zero.Clone()
+			}
+			if isAnd == (truth != 0) && isBooleanExpr(x) {
+				return x
+			}
+		}
+		// Equal is always false for the non-deterministic functions, so 'rand() < 0.5 and rand() < 0.5' is kept.
+		if f.GetCtx() != nil && isBooleanExpr(args[0]) && args[0].Equal(args[1], f.GetCtx()) {
+			return args[0]
+		}
+	}
+	return expr
+}
+
+// isBooleanExpr checks whether the value of expr is always 0, 1 or NULL.
+func isBooleanExpr(expr Expression) bool {
+	switch x := expr.(type) {
+	case *ScalarFunction:
+		_, ok := booleanFuncs[x.FuncName.L]
+		return ok
+	case *Constant:
+		switch x.Value.Kind() {
+		case types.KindNull:
+			return true
+		case types.KindInt64:
+			return x.Value.GetInt64() == 0 || x.Value.GetInt64() == 1
+		case types.KindUint64:
+			return x.Value.GetUint64() == 0 || x.Value.GetUint64() == 1
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
+)
+
+func (*testExpressionSuite) TestSimplify(c *C) {
+	defer testleak.AfterTest(c)()
+	a := newColumn("a")
+	x := newFunction(ast.GT, a, One)
+	y := newFunction(ast.EQ, newColumn("b"), Zero)
+	tests := []struct {
+		expr   Expression
+		result string
+	}{
+		{newFunction(ast.AndAnd, x, x), x.String()},
+		{newFunction(ast.OrOr, x, x), x.String()},
+		{newFunction(ast.AndAnd, x, One), x.String()},
+		{newFunction(ast.AndAnd, One, x), x.String()},
+		{newFunction(ast.OrOr, x, Zero), x.String()},
+		{newFunction(ast.OrOr, Zero, x), x.String()},
+		{newFunction(ast.AndAnd, x, Zero), "0"},
+		{newFunction(ast.AndAnd, Zero, a), "0"},
+		{newFunction(ast.UnaryNot, newFunction(ast.UnaryNot, x)), x.String()},
+		{newFunction(ast.Plus, One, One), "2"},
+		// The identities are applied until a fixed point is reached.
+		{newFunction(ast.AndAnd, x, newFunction(ast.LT, One, newLonglong(2))), x.String()},
+		{newFunction(ast.OrOr, newFunction(ast.AndAnd, x, x), newFunction(ast.AndAnd, y, Zero)), x.String()},
+		{newFunction(ast.UnaryNot, newFunction(ast.UnaryNot, newFunction(ast.OrOr, x, x))), x.String()},
+		// The value is kept if x isn't a boolean expression.
+		{newFunction(ast.AndAnd, a, One), "and(test.t.a, 1)"},
+		{newFunction(ast.UnaryNot, newFunction(ast.UnaryNot, a)), "not(not(test.t.a))"},
+		// Different expressions are kept.
+		{newFunction(ast.AndAnd, x, y), newFunction(ast.AndAnd, x, y).String()},
+		{newFunction(ast.OrOr, x, Null), newFunction(ast.OrOr, x, Null).String()},
+	}
+	ctx := mock.NewContext()
+	for _, t := range tests {
+		origin := t.expr.String()
+		c.Assert(Simplify(ctx, t.expr).String(), Equals, t.result, Commentf("%s", origin))
+		c.Assert(t.expr.String(), Equals, origin)
+	}
+
+	// The non-deterministic expressions are never deduplicated.
+	half := &Constant{Value: types.NewFloat64Datum(0.5), RetType: types.NewFieldType(mysql.TypeDouble)}
+	r := newFunction(ast.LT, newFunction(ast.Rand), half)
+	expr := newFunction(ast.AndAnd, r, r)
+	c.Assert(Simplify(ctx, expr).String(), Equals, expr.String())
+	expr = newFunction(ast.OrOr, r, newFunction(ast.LT, newFunction(ast.Rand), half))
+	c.Assert(Simplify(ctx, expr).String(), Equals, expr.String())
+	// But the identities with constants still apply to them.
+	c.Assert(Simplify(ctx, newFunction(ast.AndAnd, r, One)).String(), Equals, r.String())
+}
@@ -14,51 +14,261 @@
 package expression
 
 import (
+	"bytes"
 	"unicode"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/mvmap"
 	"github.com/pingcap/tidb/util/types"
 )
 
-// ExtractColumns extracts all columns from an expression.
-func ExtractColumns(expr Expression) (cols []*Column) {
+// ExtractColumns extracts the columns referenced by an expression in left-to-right order. A column referenced
+// more than once is only returned once, the columns are identified by their FromIDs and Positions like Column.Equal.
+func ExtractColumns(expr Expression) []*Column {
+	cnt := countColumns(expr)
+	if cnt == 0 {
+		return nil
+	}
+	result := make([]*Column, 0, cnt)
+	return extractColumns(result, expr, make(map[ColumnKey]struct{}, cnt))
+}
+
+// ColumnKey identifies a column like Column.Equal, by its FromID and Position.
+type ColumnKey struct {
+	FromID   string
+	Position int
+}
+
+func countColumns(expr Expression) (cnt int) {
 	switch v := expr.(type) {
 	case *Column:
-		return []*Column{v}
+		return 1
 	case *ScalarFunction:
 		for _, arg := range v.GetArgs() {
-			cols = append(cols, ExtractColumns(arg)...)
+			cnt += countColumns(arg)
 		}
 	}
 	return
 }
 
+func extractColumns(result []*Column, expr Expression, seen map[ColumnKey]struct{}) []*Column {
+	switch v := expr.(type) {
+	case *Column:
+		key := ColumnKey{FromID: v.FromID, Position: v.Position}
+		if _, ok := seen[key]; ok {
+			return result
+		}
+		seen[key] = struct{}{}
+		return append(result, v)
+	case *ScalarFunction:
+		for _, arg := range v.GetArgs() {
+			result = extractColumns(result, arg, seen)
+		}
+	}
+	return result
+}
+
+// ExtractCorrelatedColumns extracts all correlated columns from an expression in left-to-right order.
+// Different correlated columns may share the same column and are all returned, because each of them
+// needs its own data to be set, but the same correlated column is only returned once.
+func ExtractCorrelatedColumns(expr Expression) []*CorrelatedColumn {
+	result := make([]*CorrelatedColumn, 0, countCorrelatedColumns(expr))
+	return extractCorrelatedColumns(result, expr)
+}
+
+func countCorrelatedColumns(expr Expression) (cnt int) {
+	switch v := expr.(type) {
+	case *CorrelatedColumn:
+		return 1
+	case *ScalarFunction:
+		for _, arg := range v.GetArgs() {
+			cnt += countCorrelatedColumns(arg)
+		}
+	}
+	return
+}
+
+func extractCorrelatedColumns(result []*CorrelatedColumn, expr Expression) []*CorrelatedColumn {
+	switch v := expr.(type) {
+	case *CorrelatedColumn:
+		for _, col := range result {
+			if col == v {
+				return result
+			}
+		}
+		return append(result, v)
+	case *ScalarFunction:
+		for _, arg := range v.GetArgs() {
+			result = extractCorrelatedColumns(result, arg)
+		}
+	}
+	return result
+}
+
+// ExtractEquivalences splits exprs into the top-level equalities between two columns, like 'a = b', which
+// can be used as join keys, and the remaining predicates. Each pair holds the left and the right column of
+// an equality. Equalities with anything other than a plain column on either side, like 'a = b + 1' or
+// 'a = 1', and the null-safe equalities are kept in remaining, in their original order.
+func ExtractEquivalences(exprs []Expression) (pairs [][2]*Column, remaining []Expression) {
+	for _, expr := range exprs {
+		if f, ok := expr.(*ScalarFunction); ok && f.FuncName.L == ast.EQ {
+			lCol, lOK := f.GetArgs()[0].(*Column)
+			rCol, rOK := f.GetArgs()[1].(*Column)
+			if lOK && rOK {
+				pairs = append(pairs, [2]*Column{lCol, rCol})
+				continue
+			}
+		}
+		remaining = append(remaining, expr)
+	}
+	return
+}
+
+// SplitConditionsByColumns splits the top-level conjuncts of conditions by the columns they reference, so the
+// range builder can build the ranges of each index column from its own predicates. perColumn[i] holds the
+// conjuncts referencing no other column than cols[i], like 'a > 1' or 'a in (1, 2)', and the conjuncts
+// referencing none of cols or more than one column, like 'a = b' or 'a + b > 1', are kept in residual. The
+// conjuncts are kept in their original order.
+func SplitConditionsByColumns(conditions []Expression, cols []*Column) (perColumn [][]Expression, residual []Expression) {
+	perColumn = make([][]Expression, len(cols))
+	for _, cond := range conditions {
+		for _, item := range SplitCNFItems(cond) {
+			if i := singleColumnIndex(item, cols); i >= 0 {
+				perColumn[i] = append(perColumn[i], item)
+			} else {
+				residual = append(residual, item)
+			}
+		}
+	}
+	return
+}
+
+// singleColumnIndex returns the index in cols of the only column expr references, or -1 if expr references
+// none or more than one column, or its column isn't in cols.
+func singleColumnIndex(expr Expression, cols []*Column) int {
+	refs := ExtractColumns(expr)
+	if len(refs) != 1 {
+		return -1
+	}
+	for i, col := range cols {
+		if col.Equal(refs[0], nil) {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsUncorrelatedAfter checks whether expr won't be correlated any more after expr.Decorrelate(schema),
+// i.e. all the correlated columns in it refer to the columns of schema. Unlike Decorrelate, expr isn't modified,
+// so the planner can use it to find the sub queries that only need to be evaluated once.
+func IsUncorrelatedAfter(expr Expression, schema *Schema) bool {
+	switch v := expr.(type) {
+	case *CorrelatedColumn:
+		return schema.Contains(&v.Column)
+	case *ScalarFunction:
+		for _, arg := range v.GetArgs() {
+			if !IsUncorrelatedAfter(arg, schema) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Contains checks whether haystack or any of its descendants is Equal to needle, e.g. whether a
+// predicate references a column or a scalar function. A column needle is matched by its FromID and
+// Position like Column.Equal. ctx is only used to compare constants, so it can be nil if needle isn't
+// a constant.
+func Contains(haystack Expression, needle Expression, ctx context.Context) bool {
+	if haystack.Equal(needle, ctx) {
+		return true
+	}
+	if f, ok := haystack.(*ScalarFunction); ok {
+		for _, arg := range f.GetArgs() {
+			if Contains(arg, needle, ctx) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EqualByPosition checks whether a and b have the same structure, it's like Equal except that the columns are
+// matched by their Positions only, so the same expressions of two plan instances, whose columns have different
+// FromIDs, are equal. The functions must have the same names and return types, and be deterministic like Equal
+// requires, and the constants must have the same values and types. ctx is only used to compare the constants.
+func EqualByPosition(a, b Expression, ctx context.Context) bool {
+	switch x := a.(type) {
+	case *CorrelatedColumn:
+		y, ok := b.(*CorrelatedColumn)
+		return ok && x.Position == y.Position
+	case *Column:
+		y, ok := b.(*Column)
+		return ok && x.Position == y.Position
+	case *Constant:
+		y, ok := b.(*Constant)
+		return ok && sameCmpType(x.RetType, y.RetType) && x.Equal(y, ctx)
+	case *ScalarFunction:
+		y, ok := b.(*ScalarFunction)
+		if !ok || x.FuncName.L != y.FuncName.L || !sameCmpType(x.RetType, y.RetType) {
+			return false
+		}
+		if !x.Function.isDeterministic() || !y.Function.isDeterministic() {
+			return false
+		}
+		xArgs, yArgs := x.GetArgs(), y.GetArgs()
+		if len(xArgs) != len(yArgs) {
+			return false
+		}
+		for i := range xArgs {
+			if !EqualByPosition(xArgs[i], yArgs[i], ctx) {
+				return false
+			}
+		}
+		return true
+	}
+	return a.Equal(b, ctx)
+}
+
+// sameCmpType checks whether a and b are the same in the parts which change how a value is compared,
+// i.e. the parts taken into account by the hashcodes.
+func sameCmpType(a, b *types.FieldType) bool {
+	da, db := fieldTypeHashDatum(a), fieldTypeHashDatum(b)
+	return bytes.Equal(da.GetBytes(), db.GetBytes())
+}
+
 // ColumnSubstitute substitutes the columns in filter to expressions in select fields.
 // e.g. select * from (select b as a from t) k where a < 10 => select * from (select b as a from t where b < 10) k.
+// Every column found in schema is replaced by a clone of the newExprs entry at the same position, and the
+// enclosing scalar functions are rebuilt by NewFunction. Columns not in schema are left alone.
+// The substitution is single-pass, so the caller must make sure newExprs are already fully substituted.
 func ColumnSubstitute(expr Expression, schema *Schema, newExprs []Expression) Expression {
+	return expr.ReplaceColumn(schema, newExprs)
+}
+
+// RemapColumns replaces the columns of expr whose IDs are keys of mapping with the mapped columns, it's used to
+// relocate an expression on the columns of a child plan to the columns of its parent. The columns not in mapping
+// are kept. The enclosing functions are cloned with the remapped arguments and keep their signatures, since a
+// column is remapped to a column of the same type, so expr itself isn't modified and no error can happen.
+func RemapColumns(expr Expression, mapping map[int64]*Column) Expression {
 	switch v := expr.(type) {
 	case *Column:
-		id := schema.ColumnIndex(v)
-		if id == -1 {
-			return v
+		if col, ok := mapping[v.ID]; ok {
+			return col
 		}
-		return newExprs[id].Clone()
 	case *ScalarFunction:
-		if v.FuncName.L == ast.Cast {
-			newFunc := v.Clone().(*ScalarFunction)
-			newFunc.GetArgs()[0] = ColumnSubstitute(newFunc.GetArgs()[0], schema, newExprs)
-			return newFunc
-		}
-		newArgs := make([]Expression, 0, len(v.GetArgs()))
-		for _, arg := range v.GetArgs() {
-			newArgs = append(newArgs, ColumnSubstitute(arg, schema, newExprs))
+		newFunc := v.Clone().(*ScalarFunction)
+		newArgs := newFunc.GetArgs()
+		for i, arg := range v.GetArgs() {
+			newArgs[i] = RemapColumns(arg, mapping)
 		}
-		fun, _ := NewFunction(v.GetCtx(), v.FuncName.L, v.RetType, newArgs...)
-		return fun
+		newFunc.resetHashCode()
+		return newFunc
 	}
 	return expr
 }
@@ -66,11 +276,128 @@ func ColumnSubstitute(expr Expression, schema *Schema, newExprs []Expression) Ex
 func datumsToConstants(datums []types.Datum) []Expression {
 	constants := make([]Expression, 0, len(datums))
 	for _, d := range datums {
-		constants = append(constants, &Constant{Value: d})
+		ft := &types.FieldType{}
+		types.DefaultTypeForValue(d.GetValue(), ft)
+		constants = append(constants, &Constant{Value: d, RetType: ft})
 	}
 	return constants
 }
 
+// ConstantsToDatums returns the values of the constants.
+func ConstantsToDatums(consts []*Constant) []types.Datum {
+	datums := make([]types.Datum, 0, len(consts))
+	for _, con := range consts {
+		datums = append(datums, con.Value)
+	}
+	return datums
+}
+
+// ExpressionsToConstants converts the expressions to constants, ok is false if any of them isn't a constant.
+func ExpressionsToConstants(exprs []Expression) ([]*Constant, bool) {
+	consts := make([]*Constant, 0, len(exprs))
+	for _, expr := range exprs {
+		con, ok := expr.(*Constant)
+		if !ok {
+			return nil, false
+		}
+		consts = append(consts, con)
+	}
+	return consts, true
+}
+
+// IsConstantTrue checks whether expr is a non-null constant whose value is true.
+func IsConstantTrue(expr Expression, sc *variable.StatementContext) bool {
+	truth, ok := constantTruth(expr, sc)
+	return ok && truth
+}
+
+// IsConstantFalse checks whether expr is a non-null constant whose value is false.
+// A NULL constant is neither true nor false.
+func IsConstantFalse(expr Expression, sc *variable.StatementContext) bool {
+	truth, ok := constantTruth(expr, sc)
+	return ok && !truth
+}
+
+// notNullPreservingFuncs are the functions whose results are never null if none of their arguments is null.
+var notNullPreservingFuncs = map[string]struct{}{
+	ast.LT:         {},
+	ast.LE:         {},
+	ast.GT:         {},
+	ast.GE:         {},
+	ast.EQ:         {},
+	ast.NE:         {},
+	ast.AndAnd:     {},
+	ast.OrOr:       {},
+	ast.LogicXor:   {},
+	ast.UnaryNot:   {},
+	ast.Plus:       {},
+	ast.Minus:      {},
+	ast.Mul:        {},
+	ast.UnaryMinus: {},
+}
+
+// NotNull checks whether expr is provably never null. It's conservative, false means expr may be null.
+func NotNull(expr Expression) bool {
+	switch x := expr.(type) {
+	case *Constant:
+		return !x.Value.IsNull()
+	case *Column:
+		return mysql.HasNotNullFlag(x.RetType.Flag)
+	case *CorrelatedColumn:
+		return mysql.HasNotNullFlag(x.RetType.Flag)
+	case *ScalarFunction:
+		if mysql.HasNotNullFlag(x.RetType.Flag) {
+			return true
+		}
+		args := x.GetArgs()
+		switch x.FuncName.L {
+		case ast.IsNull, ast.IsTruth, ast.IsFalsity, ast.NullEQ:
+			return true
+		case ast.Ifnull:
+			return NotNull(args[0]) || NotNull(args[1])
+		case ast.Coalesce:
+			for _, arg := range args {
+				if NotNull(arg) {
+					return true
+				}
+			}
+			return false
+		}
+		if _, ok := notNullPreservingFuncs[x.FuncName.L]; !ok {
+			return false
+		}
+		for _, arg := range args {
+			if !NotNull(arg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// MaybeNull checks whether expr may be null, it's the inverse of NotNull with a fast path for columns.
+func MaybeNull(expr Expression) bool {
+	if col, ok := expr.(*Column); ok {
+		return !mysql.HasNotNullFlag(col.RetType.Flag)
+	}
+	return !NotNull(expr)
+}
+
+// constantTruth returns the boolean value of expr, ok is false if expr isn't a non-null constant
+// or its value can't be converted to a boolean.
+func constantTruth(expr Expression, sc *variable.StatementContext) (truth bool, ok bool) {
+	con, ok := expr.(*Constant)
+	if !ok || con.Value.IsNull() {
+		return false, false
+	}
+	isTrue, err := con.Value.ToBool(sc)
+	if err != nil {
+		return false, false
+	}
+	return isTrue != 0, true
+}
+
 // calculateSum adds v to sum.
 func calculateSum(sc *variable.StatementContext, sum, v types.Datum) (data types.Datum, err error) {
 	// for avg and sum calculation
@@ -200,7 +527,7 @@ func SubstituteCorCol2Constant(expr Expression) (Expression, error) {
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			return &Constant{Value: val}, nil
+			return &Constant{Value: val, RetType: x.GetType()}, nil
 		}
 		var newSf Expression
 		if x.FuncName.L == ast.Cast {
@@ -216,6 +543,98 @@ func SubstituteCorCol2Constant(expr Expression) (Expression, error) {
 	}
 }
 
+var oppositeOp = map[string]string{
+	ast.LT:     ast.GE,
+	ast.GE:     ast.LT,
+	ast.GT:     ast.LE,
+	ast.LE:     ast.GT,
+	ast.EQ:     ast.NE,
+	ast.NE:     ast.EQ,
+	ast.AndAnd: ast.OrOr,
+	ast.OrOr:   ast.AndAnd,
+}
+
+// PushDownNot pushes the negations in expr down to the leaves, e.g. 'not (a > 1 and b = 1)' is converted to
+// 'a <= 1 or b != 1', so that the conditions can be split and pushed down. The argument not means whether
+// expr itself should be negated. Double negations are canceled, and a negation which can't be pushed down
+// any more is kept as a not function.
+func PushDownNot(ctx context.Context, expr Expression, not bool) (Expression, error) {
+	if f, ok := expr.(*ScalarFunction); ok {
+		switch f.FuncName.L {
+		case ast.UnaryNot:
+			return PushDownNot(f.GetCtx(), f.GetArgs()[0], !not)
+		case ast.LT, ast.GE, ast.GT, ast.LE, ast.EQ, ast.NE:
+			if not {
+				nf, err := NewFunction(f.GetCtx(), oppositeOp[f.FuncName.L], f.GetType(), f.GetArgs()...)
+				return nf, errors.Trace(err)
+			}
+			return f, nil
+		case ast.AndAnd, ast.OrOr:
+			args := make([]Expression, 0, len(f.GetArgs()))
+			for _, arg := range f.GetArgs() {
+				newArg, err := PushDownNot(f.GetCtx(), arg, not)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				args = append(args, newArg)
+			}
+			funcName := f.FuncName.L
+			if not {
+				funcName = oppositeOp[funcName]
+			}
+			nf, err := NewFunction(f.GetCtx(), funcName, f.GetType(), args...)
+			return nf, errors.Trace(err)
+		}
+	}
+	if not {
+		nf, err := NewFunction(ctx, ast.UnaryNot, types.NewFieldType(mysql.TypeTiny), expr)
+		return nf, errors.Trace(err)
+	}
+	return expr, nil
+}
+
+// Negate returns the logical negation of expr. Unlike PushDownNot, only expr itself is rewritten:
+// a comparison is flipped, e.g. 'a < 1' becomes 'a >= 1', an AND or an OR becomes the OR or the AND of
+// the negated arguments by De Morgan's laws, where the arguments are only wrapped in NOT, a NOT is removed,
+// so 'a IS NULL' and 'NOT (a IS NULL)', i.e. 'a IS NOT NULL', are the negations of each other, and anything
+// else is wrapped in NOT.
+// Note the three-valued logic, the negation of a predicate is NULL where the predicate is NULL, so the rows
+// on which a nullable predicate is NULL satisfy neither the predicate nor its negation, e.g. neither 'a = 1'
+// nor 'a != 1' holds when a is NULL. The callers which need the complementary rows, like anti-joins, should
+// take care of those rows themselves.
+func Negate(ctx context.Context, expr Expression) (Expression, error) {
+	if f, ok := expr.(*ScalarFunction); ok {
+		switch f.FuncName.L {
+		case ast.UnaryNot:
+			return f.GetArgs()[0], nil
+		case ast.LT, ast.GE, ast.GT, ast.LE, ast.EQ, ast.NE:
+			nf, err := NewFunction(ctx, oppositeOp[f.FuncName.L], f.GetType(), f.GetArgs()...)
+			return nf, errors.Trace(err)
+		case ast.AndAnd, ast.OrOr:
+			args := make([]Expression, 0, len(f.GetArgs()))
+			for _, arg := range f.GetArgs() {
+				notArg, err := wrapNot(ctx, arg)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				args = append(args, notArg)
+			}
+			nf, err := NewFunction(ctx, oppositeOp[f.FuncName.L], f.GetType(), args...)
+			return nf, errors.Trace(err)
+		}
+	}
+	return wrapNot(ctx, expr)
+}
+
+// wrapNot wraps expr in NOT, or removes the NOT if expr is already one.
+func wrapNot(ctx context.Context, expr Expression) (Expression, error) {
+	if f, ok := expr.(*ScalarFunction); ok && f.FuncName.L == ast.UnaryNot {
+		return f.GetArgs()[0], nil
+	}
+	nf, err := NewFunction(ctx, ast.UnaryNot, types.NewFieldType(mysql.TypeTiny), expr)
+	return nf, errors.Trace(err)
+}
+
 // ConvertCol2CorCol will convert the column in the condition which can be found in outerSchema to a correlated column whose
 // Column is this column. And please make sure the outerSchema.Columns[i].Equal(corCols[i].Column)) holds when you call this.
 func ConvertCol2CorCol(cond Expression, corCols []*CorrelatedColumn, outerSchema *Schema) Expression {
@@ -240,3 +659,31 @@ func ConvertCol2CorCol(cond Expression, corCols []*CorrelatedColumn, outerSchema
 	}
 	return cond
 }
+
+// SubstituteCorrelatedColumns replaces the correlated columns in expr whose columns, identified by FromID and
+// Position, are found in mapping with the mapped outer columns, it's used to flatten a correlated sub query into
+// a join. The correlated columns not in mapping are kept, so the result is still correlated if there are any.
+// The enclosing functions are rebuilt and expr itself isn't modified.
+func SubstituteCorrelatedColumns(expr Expression, mapping map[ColumnKey]*Column) (Expression, error) {
+	switch x := expr.(type) {
+	case *CorrelatedColumn:
+		if col, ok := mapping[ColumnKey{FromID: x.FromID, Position: x.Position}]; ok {
+			return col, nil
+		}
+	case *ScalarFunction:
+		newArgs := make([]Expression, 0, len(x.GetArgs()))
+		for _, arg := range x.GetArgs() {
+			newArg, err := SubstituteCorrelatedColumns(arg, mapping)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			newArgs = append(newArgs, newArg)
+		}
+		if x.FuncName.L == ast.Cast {
+			return NewCastFunc(x.RetType, newArgs[0], x.GetCtx()), nil
+		}
+		newSf, err := NewFunction(x.GetCtx(), x.FuncName.L, x.GetType(), newArgs...)
+		return newSf, errors.Trace(err)
+	}
+	return expr, nil
+}
@@ -16,7 +16,10 @@ package expression
 import (
 	"github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -65,3 +68,477 @@ func (s *testUtilSuite) TestSubstituteCorCol2Constant(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(newCol.Equal(col1, ctx), check.IsTrue)
 }
+
+func (s *testUtilSuite) TestExtractColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, d := newColumn("a"), newColumn("b"), newColumn("d")
+	// a + b > d and (b = 1 or a < d)
+	expr := newFunction(ast.AndAnd,
+		newFunction(ast.GT, newFunction(ast.Plus, a, b), d),
+		newFunction(ast.OrOr, newFunction(ast.EQ, newColumn("b"), One), newFunction(ast.LT, a, d)))
+	cols := ExtractColumns(expr)
+	c.Assert(cols, check.HasLen, 3)
+	c.Assert(cols[0], check.Equals, a)
+	c.Assert(cols[1], check.Equals, b)
+	c.Assert(cols[2], check.Equals, d)
+
+	c.Assert(ExtractColumns(a), check.DeepEquals, []*Column{a})
+	c.Assert(ExtractColumns(One), check.HasLen, 0)
+}
+
+func (s *testUtilSuite) TestExtractCorrelatedColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	corCol1 := &CorrelatedColumn{Column: *newColumn("a"), Data: new(types.Datum)}
+	corCol2 := &CorrelatedColumn{Column: *newColumn("b"), Data: new(types.Datum)}
+	// corCol3 refers to the same column as corCol1, but owns different data.
+	corCol3 := &CorrelatedColumn{Column: *newColumn("a"), Data: new(types.Datum)}
+	expr := newFunction(ast.AndAnd,
+		newFunction(ast.EQ, corCol1, newColumn("c")),
+		newFunction(ast.OrOr, newFunction(ast.LT, corCol2, corCol1), newFunction(ast.GT, corCol3, One)))
+	corCols := ExtractCorrelatedColumns(expr)
+	c.Assert(corCols, check.HasLen, 3)
+	c.Assert(corCols[0], check.Equals, corCol1)
+	c.Assert(corCols[1], check.Equals, corCol2)
+	c.Assert(corCols[2], check.Equals, corCol3)
+
+	c.Assert(ExtractCorrelatedColumns(newColumn("a")), check.HasLen, 0)
+}
+
+func (s *testUtilSuite) TestIsUncorrelatedAfter(c *check.C) {
+	defer testleak.AfterTest(c)()
+	outerA, outerB := newColumn("a"), newColumn("b")
+	corA := &CorrelatedColumn{Column: *outerA, Data: new(types.Datum)}
+	corB := &CorrelatedColumn{Column: *outerB, Data: new(types.Datum)}
+	// The condition of the sub query in 'select * from t1 where t1.x = (select max(y) from t2 where t2.c = t1.a and t2.d < t1.b)'.
+	expr := newFunction(ast.AndAnd, newFunction(ast.EQ, newColumn("c"), corA), newFunction(ast.LT, newColumn("d"), corB))
+	c.Assert(expr.IsCorrelated(), check.IsTrue)
+	c.Assert(IsUncorrelatedAfter(expr, NewSchema(outerA)), check.IsFalse)
+	c.Assert(IsUncorrelatedAfter(expr, NewSchema(newColumn("c"), newColumn("d"))), check.IsFalse)
+	c.Assert(IsUncorrelatedAfter(expr, NewSchema(outerB, outerA)), check.IsTrue)
+	c.Assert(IsUncorrelatedAfter(newFunction(ast.EQ, newColumn("c"), One), NewSchema()), check.IsTrue)
+
+	// expr isn't modified until it's decorrelated, and then it isn't correlated any more.
+	c.Assert(expr.IsCorrelated(), check.IsTrue)
+	schema := NewSchema(outerA, outerB)
+	c.Assert(expr.Decorrelate(schema).IsCorrelated(), check.IsFalse)
+}
+
+func (s *testUtilSuite) TestCorrelatedColumnResetCache(c *check.C) {
+	defer testleak.AfterTest(c)()
+	data := types.NewIntDatum(1)
+	corCol1 := &CorrelatedColumn{Column: *newColumn("a"), Data: &data}
+	corCol2 := &CorrelatedColumn{Column: *newColumn("a"), Data: &data}
+	d, err := corCol2.Eval(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(d.GetInt64(), check.Equals, int64(1))
+	corCol1.ResetCache()
+	d, err = corCol2.Eval(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(d.IsNull(), check.IsTrue)
+
+	(&CorrelatedColumn{Column: *newColumn("a")}).ResetCache()
+}
+
+func (s *testUtilSuite) TestContains(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	a, b, d := newColumn("a"), newColumn("b"), newColumn("d")
+	plus := newFunction(ast.Plus, a, newFunction(ast.Mul, b, newLonglong(2)))
+	// a + b * 2 > d and (b = 1 or a < 3)
+	expr := newFunction(ast.AndAnd,
+		newFunction(ast.GT, plus, d),
+		newFunction(ast.OrOr, newFunction(ast.EQ, b, One), newFunction(ast.LT, a, newLonglong(3))))
+
+	c.Assert(Contains(expr, expr, ctx), check.IsTrue)
+	// The columns are matched by the unique id rather than the pointer.
+	c.Assert(Contains(expr, newColumn("d"), nil), check.IsTrue)
+	c.Assert(Contains(expr, newColumn("e"), nil), check.IsFalse)
+	c.Assert(Contains(expr, &Column{FromID: "d", Position: 1}, nil), check.IsFalse)
+	c.Assert(Contains(expr, &CorrelatedColumn{Column: *a}, nil), check.IsFalse)
+	// The needle is buried inside the nested arguments.
+	c.Assert(Contains(expr, newFunction(ast.Mul, newColumn("b"), newLonglong(2)), ctx), check.IsTrue)
+	c.Assert(Contains(expr, newFunction(ast.Mul, newColumn("b"), newLonglong(3)), ctx), check.IsFalse)
+	c.Assert(Contains(expr, newFunction(ast.Plus, newColumn("a"), newColumn("b")), ctx), check.IsFalse)
+	c.Assert(Contains(expr, newLonglong(2), ctx), check.IsTrue)
+	c.Assert(Contains(expr, newLonglong(4), ctx), check.IsFalse)
+	// The non-deterministic functions are never equal.
+	rand := newFunction(ast.Rand)
+	c.Assert(Contains(newFunction(ast.LT, rand, One), rand, ctx), check.IsFalse)
+}
+
+func (s *testUtilSuite) TestExpressionsToConstants(c *check.C) {
+	defer testleak.AfterTest(c)()
+	consts, ok := ExpressionsToConstants([]Expression{newLonglong(1), Null, newLonglong(3)})
+	c.Assert(ok, check.IsTrue)
+	c.Assert(consts, check.HasLen, 3)
+	c.Assert(consts[1], check.Equals, Null)
+	datums := ConstantsToDatums(consts)
+	c.Assert(datums, check.DeepEquals, []types.Datum{types.NewIntDatum(1), {}, types.NewIntDatum(3)})
+
+	consts, ok = ExpressionsToConstants([]Expression{newLonglong(1), newColumn("a")})
+	c.Assert(ok, check.IsFalse)
+	c.Assert(consts, check.IsNil)
+}
+
+func (s *testUtilSuite) TestIsConstantTrueFalse(c *check.C) {
+	defer testleak.AfterTest(c)()
+	sc := mock.NewContext().GetSessionVars().StmtCtx
+	tests := []struct {
+		expr    Expression
+		isTrue  bool
+		isFalse bool
+	}{
+		{One, true, false},
+		{Zero, false, true},
+		{newLonglong(-2), true, false},
+		{Null, false, false},
+		{newColumn("a"), false, false},
+		{newFunction(ast.EQ, One, One), false, false},
+	}
+	for _, t := range tests {
+		c.Assert(IsConstantTrue(t.expr, sc), check.Equals, t.isTrue, check.Commentf("%s", t.expr))
+		c.Assert(IsConstantFalse(t.expr, sc), check.Equals, t.isFalse, check.Commentf("%s", t.expr))
+	}
+}
+
+func (s *testUtilSuite) TestNotNullAndMaybeNull(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b := newColumn("a"), newColumn("b")
+	b.RetType.Flag |= mysql.NotNullFlag
+	corCol := &CorrelatedColumn{Column: *b}
+	tests := []struct {
+		expr    Expression
+		notNull bool
+	}{
+		{One, true},
+		{Null, false},
+		{a, false},
+		{b, true},
+		{corCol, true},
+		{newFunction(ast.EQ, b, One), true},
+		{newFunction(ast.EQ, a, One), false},
+		{newFunction(ast.Plus, b, newFunction(ast.UnaryMinus, b)), true},
+		{newFunction(ast.Div, b, One), false},
+		{newFunction(ast.AndAnd, newFunction(ast.GT, b, One), newFunction(ast.LT, b, a)), false},
+		{newFunction(ast.IsNull, a), true},
+		{newFunction(ast.NullEQ, a, Null), true},
+		{newFunction(ast.Ifnull, a, b), true},
+		{newFunction(ast.Ifnull, a, Null), false},
+		{newFunction(ast.Coalesce, a, Null, One), true},
+		{newFunction(ast.Coalesce, a, Null), false},
+		{newFunction(ast.Length, b), false},
+	}
+	for _, t := range tests {
+		c.Assert(NotNull(t.expr), check.Equals, t.notNull, check.Commentf("%s", t.expr))
+		c.Assert(MaybeNull(t.expr), check.Equals, !t.notNull, check.Commentf("%s", t.expr))
+	}
+}
+
+func (s *testUtilSuite) TestColumnSubstitute(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, x := newColumn("a"), newColumn("b"), newColumn("x")
+	schema := NewSchema(a, b)
+	newExprs := []Expression{newFunction(ast.Plus, x, newLonglong(1)), newLonglong(2)}
+
+	expr := newFunction(ast.AndAnd, newFunction(ast.LT, a, newColumn("c")), newFunction(ast.EQ, b, x))
+	newExpr := ColumnSubstitute(expr, schema, newExprs)
+	c.Assert(newExpr.String(), check.Equals, "and(lt(plus(test.t.x, 1), test.t.c), eq(2, test.t.x))")
+	// The original expression is not modified.
+	c.Assert(expr.String(), check.Equals, "and(lt(test.t.a, test.t.c), eq(test.t.b, test.t.x))")
+
+	// The substituted expressions are cloned.
+	newCol := ColumnSubstitute(a, schema, newExprs)
+	c.Assert(newCol.String(), check.Equals, "plus(test.t.x, 1)")
+	c.Assert(newCol, check.Not(check.Equals), newExprs[0])
+
+	c.Assert(ColumnSubstitute(x, schema, newExprs), check.Equals, x)
+}
+
+func (s *testUtilSuite) TestRemapColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, x := newColumn("a"), newColumn("b"), newColumn("x")
+	a.ID, b.ID, x.ID = 1, 2, 3
+	pa, pb := newColumn("pa"), newColumn("pb")
+	mapping := map[int64]*Column{a.ID: pa, b.ID: pb}
+
+	expr := newFunction(ast.OrOr, newFunction(ast.GT, newFunction(ast.Plus, a, x), One), NewCastFunc(types.NewFieldType(mysql.TypeVarString), b, mock.NewContext()))
+	newExpr := RemapColumns(expr, mapping)
+	c.Assert(newExpr.String(), check.Equals, "or(gt(plus(test.t.pa, test.t.x), 1), cast(test.t.pb))")
+	// x isn't in the mapping, so it's untouched, and the original expression isn't modified.
+	cols := ExtractColumns(newExpr)
+	c.Assert(cols, check.HasLen, 3)
+	c.Assert(cols[0], check.Equals, pa)
+	c.Assert(cols[1], check.Equals, x)
+	c.Assert(cols[2], check.Equals, pb)
+	c.Assert(expr.String(), check.Equals, "or(gt(plus(test.t.a, test.t.x), 1), cast(test.t.b))")
+	c.Assert(newExpr.HashCode(), check.Not(check.DeepEquals), expr.HashCode())
+
+	for _, t := range []struct {
+		expr   Expression
+		result Expression
+	}{
+		{b, pb},
+		{x, x},
+		{One, One},
+	} {
+		c.Assert(RemapColumns(t.expr, mapping), check.Equals, t.result)
+	}
+}
+
+func (s *testUtilSuite) TestPushDownNot(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, x := newColumn("a"), newColumn("b"), newColumn("x")
+	not := func(arg Expression) Expression {
+		return newFunction(ast.UnaryNot, arg)
+	}
+	tests := []struct {
+		expr   Expression
+		not    bool
+		result string
+	}{
+		{newFunction(ast.EQ, a, b), false, "eq(test.t.a, test.t.b)"},
+		{newFunction(ast.EQ, a, b), true, "ne(test.t.a, test.t.b)"},
+		{not(newFunction(ast.EQ, a, b)), false, "ne(test.t.a, test.t.b)"},
+		{not(newFunction(ast.GT, a, b)), false, "le(test.t.a, test.t.b)"},
+		{not(newFunction(ast.LE, a, b)), false, "gt(test.t.a, test.t.b)"},
+		{not(newFunction(ast.LT, a, b)), false, "ge(test.t.a, test.t.b)"},
+		{not(newFunction(ast.GE, a, b)), false, "lt(test.t.a, test.t.b)"},
+		{not(newFunction(ast.NE, a, b)), false, "eq(test.t.a, test.t.b)"},
+		// Double negations are canceled.
+		{not(not(newFunction(ast.LT, a, b))), false, "lt(test.t.a, test.t.b)"},
+		{not(not(x)), true, "not(test.t.x)"},
+		{not(not(not(x))), false, "not(test.t.x)"},
+		// De Morgan's laws.
+		{not(newFunction(ast.AndAnd, newFunction(ast.GT, a, b), newFunction(ast.EQ, b, x))), false,
+			"or(le(test.t.a, test.t.b), ne(test.t.b, test.t.x))"},
+		{not(newFunction(ast.OrOr, newFunction(ast.GT, a, b), x)), false,
+			"and(le(test.t.a, test.t.b), not(test.t.x))"},
+		{not(newFunction(ast.AndAnd, not(newFunction(ast.OrOr, a, not(b))), newFunction(ast.EQ, a, x))), false,
+			"or(or(test.t.a, not(test.t.b)), ne(test.t.a, test.t.x))"},
+		{newFunction(ast.OrOr, not(newFunction(ast.LT, a, b)), not(not(x))), false,
+			"or(ge(test.t.a, test.t.b), test.t.x)"},
+		// IS NULL is kept as it is, so NOT (a IS NOT NULL) becomes a IS NULL.
+		{not(newFunction(ast.IsNull, a)), false, "not(isnull(test.t.a))"},
+		{not(not(newFunction(ast.IsNull, a))), false, "isnull(test.t.a)"},
+		{newFunction(ast.IsNull, a), true, "not(isnull(test.t.a))"},
+	}
+	ctx := mock.NewContext()
+	for _, t := range tests {
+		origin := t.expr.String()
+		pushed, err := PushDownNot(ctx, t.expr, t.not)
+		c.Assert(err, check.IsNil, check.Commentf("%s", origin))
+		c.Assert(pushed.String(), check.Equals, t.result, check.Commentf("%s", origin))
+		// The original expression is not modified.
+		c.Assert(t.expr.String(), check.Equals, origin)
+	}
+
+	// The error of building a negated function is returned, e.g. the row lengths of a comparison mismatch.
+	row := &Constant{Value: types.NewDatum(types.MakeDatums(1, 2)), RetType: types.NewFieldType(types.KindRow)}
+	cmp := &builtinCompareSig{baseBuiltinFunc: newBaseBuiltinFunc([]Expression{row, a}, ctx), op: opcode.EQ}
+	eq := &ScalarFunction{FuncName: model.NewCIStr(ast.EQ), RetType: types.NewFieldType(mysql.TypeTiny), Function: cmp.setSelf(cmp)}
+	_, err := PushDownNot(ctx, not(eq), false)
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), check.IsTrue, check.Commentf("%v", err))
+	_, err = PushDownNot(ctx, newFunction(ast.OrOr, x, not(eq)), false)
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), check.IsTrue, check.Commentf("%v", err))
+}
+
+func (s *testUtilSuite) TestNegate(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, x := newColumn("a"), newColumn("b"), newColumn("x")
+	not := func(arg Expression) Expression {
+		return newFunction(ast.UnaryNot, arg)
+	}
+	tests := []struct {
+		expr   Expression
+		result string
+	}{
+		// The comparisons are flipped.
+		{newFunction(ast.EQ, a, b), "ne(test.t.a, test.t.b)"},
+		{newFunction(ast.NE, a, b), "eq(test.t.a, test.t.b)"},
+		{newFunction(ast.LT, a, b), "ge(test.t.a, test.t.b)"},
+		{newFunction(ast.GE, a, b), "lt(test.t.a, test.t.b)"},
+		{newFunction(ast.GT, a, b), "le(test.t.a, test.t.b)"},
+		{newFunction(ast.LE, a, b), "gt(test.t.a, test.t.b)"},
+		// De Morgan's laws, the arguments are only wrapped in NOT.
+		{newFunction(ast.AndAnd, newFunction(ast.GT, a, b), x), "or(not(gt(test.t.a, test.t.b)), not(test.t.x))"},
+		{newFunction(ast.OrOr, not(newFunction(ast.LT, a, b)), x), "and(lt(test.t.a, test.t.b), not(test.t.x))"},
+		// IS NULL and IS NOT NULL.
+		{newFunction(ast.IsNull, a), "not(isnull(test.t.a))"},
+		{not(newFunction(ast.IsNull, a)), "isnull(test.t.a)"},
+		// The others are wrapped in NOT, and a NOT is removed.
+		{x, "not(test.t.x)"},
+		{newFunction(ast.NullEQ, a, b), "not(nulleq(test.t.a, test.t.b))"},
+		{newFunction(ast.Plus, a, One), "not(plus(test.t.a, 1))"},
+		{not(not(x)), "not(test.t.x)"},
+	}
+	ctx := mock.NewContext()
+	for _, t := range tests {
+		origin := t.expr.String()
+		negated, err := Negate(ctx, t.expr)
+		c.Assert(err, check.IsNil)
+		c.Assert(negated.String(), check.Equals, t.result, check.Commentf("%s", origin))
+		if f, ok := t.expr.(*ScalarFunction); !ok || f.FuncName.L != ast.UnaryNot {
+			// The negation is built on the context passed in.
+			c.Assert(negated.(*ScalarFunction).GetCtx(), check.Equals, ctx, check.Commentf("%s", origin))
+		}
+		// The original expression is not modified.
+		c.Assert(t.expr.String(), check.Equals, origin)
+	}
+
+	// The error of building the negation is returned, e.g. the row lengths of a comparison mismatch.
+	row := &Constant{Value: types.NewDatum(types.MakeDatums(1, 2)), RetType: types.NewFieldType(types.KindRow)}
+	cmp := &builtinCompareSig{baseBuiltinFunc: newBaseBuiltinFunc([]Expression{row, a}, ctx), op: opcode.EQ}
+	_, err := Negate(ctx, &ScalarFunction{FuncName: model.NewCIStr(ast.EQ), RetType: types.NewFieldType(mysql.TypeTiny), Function: cmp.setSelf(cmp)})
+	c.Assert(terror.ErrorEqual(err, ErrOperandColumns), check.IsTrue, check.Commentf("%v", err))
+}
+
+func (s *testUtilSuite) TestExtractEquivalences(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, x, y := newColumn("a"), newColumn("b"), newColumn("x"), newColumn("y")
+	exprs := []Expression{
+		newFunction(ast.EQ, a, b),
+		newFunction(ast.GT, a, x),
+		newFunction(ast.EQ, a, One),
+		newFunction(ast.EQ, newFunction(ast.Plus, x, One), y),
+		newFunction(ast.NullEQ, x, y),
+		newFunction(ast.EQ, y, x),
+		newFunction(ast.UnaryNot, newFunction(ast.EQ, a, y)),
+		newFunction(ast.OrOr, newFunction(ast.EQ, a, y), newFunction(ast.EQ, b, x)),
+	}
+	pairs, remaining := ExtractEquivalences(exprs)
+	c.Assert(pairs, check.HasLen, 2)
+	c.Assert(pairs[0][0], check.Equals, a)
+	c.Assert(pairs[0][1], check.Equals, b)
+	c.Assert(pairs[1][0], check.Equals, y)
+	c.Assert(pairs[1][1], check.Equals, x)
+	c.Assert(remaining, check.HasLen, 6)
+	for i, expr := range []Expression{exprs[1], exprs[2], exprs[3], exprs[4], exprs[6], exprs[7]} {
+		c.Assert(remaining[i], check.Equals, expr)
+	}
+
+	pairs, remaining = ExtractEquivalences(nil)
+	c.Assert(pairs, check.HasLen, 0)
+	c.Assert(remaining, check.HasLen, 0)
+}
+
+func (s *testUtilSuite) TestSplitConditionsByColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b, x := newColumn("a"), newColumn("b"), newColumn("x")
+	aGT := newFunction(ast.GT, a, One)
+	bEQ := newFunction(ast.EQ, b, newLonglong(2))
+	aLT := newFunction(ast.LT, newFunction(ast.Plus, a, One), newLonglong(10))
+	aEQb := newFunction(ast.EQ, a, b)
+	aGTx := newFunction(ast.GT, a, x)
+	xEQ := newFunction(ast.EQ, x, One)
+	aOrB := newFunction(ast.OrOr, newFunction(ast.EQ, a, One), newFunction(ast.EQ, b, One))
+	conditions := []Expression{
+		newFunction(ast.AndAnd, aGT, aEQb),
+		bEQ,
+		newFunction(ast.AndAnd, newFunction(ast.AndAnd, xEQ, aLT), aGTx),
+		aOrB,
+		One,
+	}
+	perColumn, residual := SplitConditionsByColumns(conditions, []*Column{a, b})
+	c.Assert(perColumn, check.HasLen, 2)
+	c.Assert(perColumn[0], check.DeepEquals, []Expression{aGT, aLT})
+	c.Assert(perColumn[1], check.DeepEquals, []Expression{bEQ})
+	// The predicates referencing both a and b, a column out of cols, or no column at all are residual.
+	c.Assert(residual, check.DeepEquals, []Expression{aEQb, xEQ, aGTx, aOrB, One})
+
+	perColumn, residual = SplitConditionsByColumns(nil, []*Column{a})
+	c.Assert(perColumn, check.HasLen, 1)
+	c.Assert(perColumn[0], check.HasLen, 0)
+	c.Assert(residual, check.HasLen, 0)
+}
+
+func (s *testUtilSuite) TestReplaceColumn(c *check.C) {
+	defer testleak.AfterTest(c)()
+	a, b := newColumn("a"), newColumn("b")
+	schema := NewSchema(a)
+	newExprs := []Expression{newLonglong(2)}
+
+	c.Assert(One.ReplaceColumn(schema, newExprs), check.Equals, One)
+	corCol := &CorrelatedColumn{Column: *a}
+	c.Assert(corCol.ReplaceColumn(schema, newExprs), check.Equals, corCol)
+	c.Assert(b.ReplaceColumn(schema, newExprs), check.Equals, b)
+	c.Assert(a.ReplaceColumn(schema, newExprs).String(), check.Equals, "2")
+
+	cast := NewCastFunc(types.NewFieldType(mysql.TypeDouble), newFunction(ast.Plus, a, b), mock.NewContext())
+	c.Assert(cast.ReplaceColumn(schema, newExprs).String(), check.Equals, "cast(plus(2, test.t.b))")
+	c.Assert(cast.String(), check.Equals, "cast(plus(test.t.a, test.t.b))")
+}
+
+func (s *testUtilSuite) TestSubstituteCorrelatedColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	outerA, outerB := newColumn("a"), newColumn("b")
+	corA := &CorrelatedColumn{Column: *outerA, Data: new(types.Datum)}
+	corB := &CorrelatedColumn{Column: *outerB, Data: new(types.Datum)}
+	keyA := ColumnKey{FromID: outerA.FromID, Position: outerA.Position}
+	keyB := ColumnKey{FromID: outerB.FromID, Position: outerB.Position}
+	// t2.c = t1.a and t2.d < t1.b + 1
+	expr := newFunction(ast.AndAnd,
+		newFunction(ast.EQ, newColumn("c"), corA),
+		newFunction(ast.LT, newColumn("d"), newFunction(ast.Plus, corB, One)))
+
+	ret, err := SubstituteCorrelatedColumns(expr, map[ColumnKey]*Column{keyA: outerA})
+	c.Assert(err, check.IsNil)
+	c.Assert(ret.String(), check.Equals, "and(eq(test.t.c, test.t.a), lt(test.t.d, plus(test.t.b, 1)))")
+	c.Assert(ret.IsCorrelated(), check.IsTrue)
+	c.Assert(ExtractCorrelatedColumns(ret), check.DeepEquals, []*CorrelatedColumn{corB})
+	args := ret.(*ScalarFunction).GetArgs()[0].(*ScalarFunction).GetArgs()
+	c.Assert(args[1], check.Equals, outerA)
+
+	// expr itself isn't modified.
+	c.Assert(ExtractCorrelatedColumns(expr), check.DeepEquals, []*CorrelatedColumn{corA, corB})
+
+	ret, err = SubstituteCorrelatedColumns(expr, map[ColumnKey]*Column{keyA: outerA, keyB: outerB})
+	c.Assert(err, check.IsNil)
+	c.Assert(ret.IsCorrelated(), check.IsFalse)
+
+	// The columns are matched by both FromID and Position.
+	ret, err = SubstituteCorrelatedColumns(corA, map[ColumnKey]*Column{{FromID: "a", Position: 1}: outerB})
+	c.Assert(err, check.IsNil)
+	c.Assert(ret, check.Equals, corA)
+	ret, err = SubstituteCorrelatedColumns(corB, map[ColumnKey]*Column{keyA: outerA})
+	c.Assert(err, check.IsNil)
+	c.Assert(ret, check.Equals, corB)
+}
+
+func (s *testUtilSuite) TestEqualByPosition(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := mock.NewContext()
+	newCol := func(fromID string, id int64, position int) *Column {
+		col := newColumn(fromID)
+		col.ID, col.Position = id, position
+		return col
+	}
+	// The same 'a + 1 > b and c = 2' of two plan instances, whose columns have different ids.
+	build := func(a, b, d *Column) Expression {
+		return newFunction(ast.AndAnd,
+			newFunction(ast.GT, newFunction(ast.Plus, a, One), b),
+			newFunction(ast.EQ, d, newLonglong(2)))
+	}
+	expr1 := build(newCol("t_1", 1, 0), newCol("t_1", 2, 1), newCol("t_1", 3, 2))
+	expr2 := build(newCol("t_5", 11, 0), newCol("t_5", 12, 1), newCol("t_5", 13, 2))
+	c.Assert(expr1.Equal(expr2, ctx), check.IsFalse)
+	c.Assert(EqualByPosition(expr1, expr2, ctx), check.IsTrue)
+	c.Assert(EqualByPosition(expr2, expr1, ctx), check.IsTrue)
+
+	// The columns at different positions, the different constants and the different functions aren't equal.
+	c.Assert(EqualByPosition(expr1, build(newCol("t_5", 11, 1), newCol("t_5", 12, 0), newCol("t_5", 13, 2)), ctx), check.IsFalse)
+	expr3 := newFunction(ast.AndAnd,
+		newFunction(ast.GT, newFunction(ast.Plus, newCol("t_5", 11, 0), One), newCol("t_5", 12, 1)),
+		newFunction(ast.EQ, newCol("t_5", 13, 2), newLonglong(3)))
+	c.Assert(EqualByPosition(expr1, expr3, ctx), check.IsFalse)
+	expr4 := newFunction(ast.AndAnd,
+		newFunction(ast.GE, newFunction(ast.Plus, newCol("t_5", 11, 0), One), newCol("t_5", 12, 1)),
+		newFunction(ast.EQ, newCol("t_5", 13, 2), newLonglong(2)))
+	c.Assert(EqualByPosition(expr1, expr4, ctx), check.IsFalse)
+
+	// The constants must have the same types as well as the same values.
+	decOne := &Constant{Value: types.NewDecimalDatum(types.NewDecFromInt(1)), RetType: types.NewFieldType(mysql.TypeNewDecimal)}
+	c.Assert(One.Equal(decOne, ctx), check.IsTrue)
+	c.Assert(EqualByPosition(newLonglong(1), decOne, ctx), check.IsFalse)
+	c.Assert(EqualByPosition(newLonglong(1), newLonglong(1), ctx), check.IsTrue)
+	c.Assert(EqualByPosition(newCol("t_1", 1, 0), newLonglong(0), ctx), check.IsFalse)
+}
@@ -90,8 +90,9 @@ func formatENUS(number string, precision string) (string, error) {
 	return buffer.String(), nil
 }
 
+// formatZHCN uses the same grouping separator and decimal point as en_US.
 func formatZHCN(number string, precision string) (string, error) {
-	return "", errors.New("not implemented")
+	return formatENUS(number, precision)
 }
 
 func formatNotSupport(number string, precision string) (string, error) {
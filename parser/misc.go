@@ -344,6 +344,7 @@ var tokenMap = map[string]int{
 	"MIN":                        min,
 	"MINUTE":                     minute,
 	"MIN_ROWS":                   minRows,
+	"MEMBER":                     member,
 	"MOD":                        mod,
 	"MODE":                       mode,
 	"MODIFY":                     modify,
@@ -358,6 +359,7 @@ var tokenMap = map[string]int{
 	"NULLIF":                     nullIf,
 	"OCT":                        oct,
 	"OCTET_LENGTH":               octetLength,
+	"OF":                         of,
 	"OFFSET":                     offset,
 	"ON":                         on,
 	"ONLY":                       only,
@@ -591,6 +593,12 @@ var tokenMap = map[string]int{
 	"RELEASE_ALL_LOCKS":          releaseAllLocks,
 	"UUID":                       uuid,
 	"UUID_SHORT":                 uuidShort,
+	"UUID_TO_BIN":                uuidToBin,
+	"BIN_TO_UUID":                binToUUID,
+	"JSON_INSERT":                jsonInsert,
+	"JSON_REPLACE":               jsonReplace,
+	"JSON_SET":                   jsonSet,
+	"JSON_TYPE":                  jsonType,
 	"KILL":                       kill,
 }
 
@@ -91,7 +91,7 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"compact", "redundant", "sql_no_cache sql_no_cache", "sql_cache sql_cache", "action", "round",
 		"enable", "disable", "reverse", "space", "privileges", "get_lock", "release_lock", "sleep", "no", "greatest", "least",
 		"binlog", "hex", "unhex", "function", "indexes", "from_unixtime", "processlist", "events", "less", "than", "timediff",
-		"ln", "log", "log2", "log10", "timestampdiff", "pi", "quote", "none", "super",
+		"ln", "log", "log2", "log10", "timestampdiff", "pi", "quote", "none", "super", "member", "of",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -714,6 +714,7 @@ func (s *testParserSuite) TestBuiltin(c *C) {
 		{"SELECT GET_FORMAT(DATE, 'USA');", true},
 		{"SELECT GET_FORMAT(DATETIME, 'USA');", true},
 		{"SELECT GET_FORMAT(TIME, 'USA');", true},
+		{"SELECT GET_FORMAT(TIMESTAMP, 'USA');", true},
 
 		// for LOCALTIME, LOCALTIMESTAMP
 		{"SELECT LOCALTIME(), LOCALTIME(1)", true},
@@ -860,6 +861,21 @@ func (s *testParserSuite) TestBuiltin(c *C) {
 		{`SELECT RELEASE_ALL_LOCKS(1);`, true},
 		{`SELECT UUID(1);`, true},
 		{`SELECT UUID_SHORT(1)`, true},
+		{`SELECT UUID_TO_BIN(UUID())`, true},
+		{`SELECT BIN_TO_UUID(UUID_TO_BIN(UUID(), 1), 1)`, true},
+
+		// for json functions
+		{`SELECT JSON_TYPE('[1, 2]')`, true},
+		{`SELECT JSON_TYPE(c) FROM t`, true},
+		{`SELECT JSON_SET('{"a": 1}', '$.a', 2, '$.b', 3)`, true},
+		{`SELECT JSON_INSERT(c, '$[1]', 'x') FROM t`, true},
+		{`SELECT JSON_REPLACE(c, '$.a', c) FROM t`, true},
+		{`SELECT 1 MEMBER OF ('[1, 2]')`, true},
+		{`SELECT c MEMBER OF (JSON_SET(c, '$[1]', 'x')) FROM t WHERE c NOT MEMBER OF ('[1]')`, false},
+		{`SELECT c FROM t WHERE 'a' MEMBER OF (c) AND c MEMBER OF ('[1]')`, true},
+		{`SELECT c MEMBER OF '[1]' FROM t`, false},
+		{`SELECT c AS member FROM t`, true},
+		{`SELECT of MEMBER OF (member) FROM t AS of WHERE of.member MEMBER OF (of.of)`, true},
 
 		// for date_add
 		{`select date_add("2011-11-11 10:10:10.123456", interval 10 microsecond)`, true},
@@ -1065,6 +1081,8 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"CREATE TABLE foo (a bigint unsigned, b bool);", true},
 		{"CREATE TABLE foo (a TINYINT, b SMALLINT) CREATE TABLE bar (x INT, y int64)", false},
 		{"CREATE TABLE foo (a int, b float); CREATE TABLE bar (x double, y float)", true},
+		// of is not reserved.
+		{"CREATE TABLE of (of int, member json)", true},
 		{"CREATE TABLE foo (a bytes)", false},
 		{"CREATE TABLE foo (a SMALLINT UNSIGNED, b INT UNSIGNED)", true},
 		{"CREATE TABLE foo (a SMALLINT UNSIGNED, b INT UNSIGNED) -- foo", true},
@@ -1462,6 +1480,27 @@ func (s *testParserSuite) TestLikeEscape(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestSetCollation(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{`select a collate utf8_bin from t`, true},
+		{`select a collate "utf8_general_ci" = b from t`, true},
+		{`select * from t order by a collate utf8mb4_bin`, true},
+		{`select a collate from t`, false},
+	}
+	s.RunTest(c, table)
+
+	parser := New()
+	stmt, err := parser.ParseOneStmt("select a collate utf8_bin = b from t", "", "")
+	c.Assert(err, IsNil)
+	expr := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.BinaryOperationExpr)
+	collate, ok := expr.L.(*ast.SetCollationExpr)
+	c.Assert(ok, IsTrue)
+	c.Assert(collate.Collate, Equals, "utf8_bin")
+	_, ok = collate.Expr.(*ast.ColumnNameExpr)
+	c.Assert(ok, IsTrue)
+}
+
 func (s *testParserSuite) TestMysqlDump(c *C) {
 	defer testleak.AfterTest(c)()
 	// Statements used by mysqldump.
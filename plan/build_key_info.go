@@ -62,7 +62,7 @@ func (p *Selection) checkMaxOneRowCond(unique expression.Expression, constOrCorC
 	if !ok {
 		return false
 	}
-	if !p.children[0].Schema().IsUniqueKey(col) {
+	if !p.children[0].Schema().IsUniqueKey([]*expression.Column{col}) {
 		return false
 	}
 	_, okCon := constOrCorCol.(*expression.Constant)
@@ -121,15 +121,12 @@ func (p *Projection) buildKeyInfo() {
 
 func (p *LogicalJoin) buildKeyInfo() {
 	p.baseLogicalPlan.buildKeyInfo()
-	p.schema.MaxOneRow = p.children[0].Schema().MaxOneRow && p.children[1].Schema().MaxOneRow
+	lSchema, rSchema := p.children[0].Schema(), p.children[1].Schema()
+	p.schema.MaxOneRow = lSchema.MaxOneRow && rSchema.MaxOneRow
 	switch p.JoinType {
 	case SemiJoin, LeftOuterSemiJoin:
-		p.schema.Keys = p.children[0].Schema().Clone().Keys
+		p.schema.MergeKeys(lSchema, rSchema, int(p.JoinType))
 	case InnerJoin, LeftOuterJoin, RightOuterJoin:
-		// If there is no equal conditions, then cartesian product can't be prevented and unique key information will destroy.
-		if len(p.EqualConditions) == 0 {
-			return
-		}
 		lOk := false
 		rOk := false
 		// Such as 'select * from t1 join t2 where t1.a = t2.a and t1.b = t2.b'.
@@ -139,13 +136,13 @@ func (p *LogicalJoin) buildKeyInfo() {
 		for _, expr := range p.EqualConditions {
 			ln := expr.GetArgs()[0].(*expression.Column)
 			rn := expr.GetArgs()[1].(*expression.Column)
-			for _, key := range p.children[0].Schema().Keys {
+			for _, key := range lSchema.Keys {
 				if len(key) == 1 && key[0].Equal(ln, p.ctx) {
 					lOk = true
 					break
 				}
 			}
-			for _, key := range p.children[1].Schema().Keys {
+			for _, key := range rSchema.Keys {
 				if len(key) == 1 && key[0].Equal(rn, p.ctx) {
 					rOk = true
 					break
@@ -156,10 +153,15 @@ func (p *LogicalJoin) buildKeyInfo() {
 		// another side's unique key information will all be reserved.
 		// If it's an outer join, NULL value will fill some position, which will destroy the unique key information.
 		if lOk && p.JoinType != LeftOuterJoin {
-			p.schema.Keys = append(p.schema.Keys, p.children[1].Schema().Keys...)
+			p.schema.Keys = append(p.schema.Keys, rSchema.Keys...)
 		}
 		if rOk && p.JoinType != RightOuterJoin {
-			p.schema.Keys = append(p.schema.Keys, p.children[0].Schema().Keys...)
+			p.schema.Keys = append(p.schema.Keys, lSchema.Keys...)
+		}
+		// Otherwise, the cartesian product can't be prevented, but a left key combined with a right key is still
+		// unique for inner join.
+		if len(p.schema.Keys) == 0 {
+			p.schema.MergeKeys(lSchema, rSchema, int(p.JoinType))
 		}
 	}
 }
@@ -73,7 +73,7 @@ func (a *LogicalAggregation) canPullUp() bool {
 			if err != nil {
 				return false
 			}
-			if con, ok := expr.(*expression.Constant); !ok || !con.Value.IsNull() {
+			if !expression.IsNullConstant(expr) {
 				return false
 			}
 		}
@@ -186,8 +186,11 @@ func (p *Selection) checkScanController() int {
 		if !expr.IsCorrelated() {
 			continue
 		}
-		cond := pushDownNot(expr, false, nil)
-		corCols := extractCorColumns(cond)
+		cond, err := expression.PushDownNot(nil, expr, false)
+		if err != nil {
+			cond = expr
+		}
+		corCols := expression.ExtractCorrelatedColumns(cond)
 		for _, col := range corCols {
 			*col.Data = expression.One.Value
 		}
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/sessionctx/varsutil"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -77,7 +78,7 @@ func (b *planBuilder) rewrite(expr ast.ExprNode, p LogicalPlan, aggMapper map[*a
 	if len(er.ctxStack) != 1 {
 		return nil, nil, errors.Errorf("context len %v is invalid", len(er.ctxStack))
 	}
-	if getRowLen(er.ctxStack[0]) != 1 {
+	if expression.GetRowLen(er.ctxStack[0]) != 1 {
 		return nil, nil, ErrOperandColumns.GenByArgs(1)
 	}
 	result := expression.FoldConstant(er.ctxStack[0])
@@ -96,48 +97,25 @@ type expressionRewriter struct {
 	asScalar bool
 }
 
-func getRowLen(e expression.Expression) int {
-	if f, ok := e.(*expression.ScalarFunction); ok && f.FuncName.L == ast.RowFunc {
-		return len(f.GetArgs())
-	}
-	if c, ok := e.(*expression.Constant); ok && c.Value.Kind() == types.KindRow {
-		return len(c.Value.GetRow())
-	}
-	return 1
-}
-
-func getRowArg(e expression.Expression, idx int) expression.Expression {
-	if f, ok := e.(*expression.ScalarFunction); ok {
-		return f.GetArgs()[idx]
-	}
-	c, _ := e.(*expression.Constant)
-	d := c.Value.GetRow()[idx]
-	return &expression.Constant{Value: d, RetType: c.GetType()}
-}
-
-// constructBinaryOpFunctions converts (a0,a1,a2) op (b0,b1,b2) to (a0 op b0) and (a1 op b1) and (a2 op b2).
+// constructBinaryOpFunction builds l op r, where op is one of EQ, NE and NullEQ. The row comparisons, like
+// (a0,a1) = (b0,b1), are expanded into element-wise comparisons by expression.NewFunction.
 func (er *expressionRewriter) constructBinaryOpFunction(l expression.Expression, r expression.Expression, op string) (expression.Expression, error) {
-	lLen, rLen := getRowLen(l), getRowLen(r)
-	if lLen == 1 && rLen == 1 {
-		return expression.NewFunction(er.ctx, op, types.NewFieldType(mysql.TypeTiny), l, r)
-	} else if rLen != lLen {
-		return nil, ErrOperandColumns.GenByArgs(lLen)
+	f, err := expression.NewFunction(er.ctx, op, types.NewFieldType(mysql.TypeTiny), l, r)
+	if terror.ErrorEqual(err, expression.ErrOperandColumns) {
+		// The mismatch may be found in nested rows, report it as the optimizer error like the other row checks.
+		return nil, ErrOperandColumns.Gen("%s", errors.Cause(err).(*terror.Error).ToSQLError().Message)
 	}
-	funcs := make([]expression.Expression, lLen)
-	for i := 0; i < lLen; i++ {
-		var err error
-		funcs[i], err = er.constructBinaryOpFunction(getRowArg(l, i), getRowArg(r, i), op)
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-	}
-	return expression.ComposeCNFCondition(er.ctx, funcs...), nil
+	return f, errors.Trace(err)
 }
 
 func (er *expressionRewriter) buildSubquery(subq *ast.SubqueryExpr) LogicalPlan {
 	outerSchema := er.schema.Clone()
 	er.b.outerSchemas = append(er.b.outerSchemas, outerSchema)
+	// DEFAULT(col) in the subquery refers to its own data sources.
+	insertCols := er.b.insertCols
+	er.b.insertCols = nil
 	np := er.b.buildResultSetNode(subq.Query)
+	er.b.insertCols = insertCols
 	er.b.outerSchemas = er.b.outerSchemas[0 : len(er.b.outerSchemas)-1]
 	if er.b.err != nil {
 		er.err = errors.Trace(er.b.err)
@@ -194,8 +172,19 @@ func (er *expressionRewriter) Enter(inNode ast.Node) (ast.Node, bool) {
 		return er.handleScalarSubquery(v)
 	case *ast.ParenthesesExpr:
 	case *ast.ValuesExpr:
-		er.ctxStack = append(er.ctxStack, expression.NewValuesFunc(v.Column.Refer.Column.Offset, &v.Type, er.ctx))
+		// ON DUPLICATE KEY UPDATE is evaluated on the row being updated followed by the insert row, so VALUES(col)
+		// reads the insert row after the columns of the table.
+		offset := v.Column.Refer.Column.Offset
+		if er.schema != nil {
+			offset += er.schema.Len()
+		}
+		er.ctxStack = append(er.ctxStack, expression.NewValuesFunc(offset, &v.Type, er.ctx))
 		return inNode, true
+	case *ast.DefaultExpr:
+		if er.b.insertCols != nil && v.Name != nil {
+			er.insertDefaultToConstant(v)
+			return inNode, true
+		}
 	default:
 		er.asScalar = true
 	}
@@ -219,11 +208,11 @@ func (er *expressionRewriter) handleCompareSubquery(v *ast.CompareSubqueryExpr)
 	}
 	// Only (a,b,c) = all (...) and (a,b,c) != any () can use row expression.
 	canMultiCol := (!v.All && v.Op == opcode.EQ) || (v.All && v.Op == opcode.NE)
-	if !canMultiCol && (getRowLen(lexpr) != 1 || np.Schema().Len() != 1) {
+	if !canMultiCol && (expression.GetRowLen(lexpr) != 1 || np.Schema().Len() != 1) {
 		er.err = ErrOperandColumns.GenByArgs(1)
 		return v, true
 	}
-	lLen := getRowLen(lexpr)
+	lLen := expression.GetRowLen(lexpr)
 	if lLen != np.Schema().Len() {
 		er.err = ErrOperandColumns.GenByArgs(lLen)
 		return v, true
@@ -237,7 +226,7 @@ func (er *expressionRewriter) handleCompareSubquery(v *ast.CompareSubqueryExpr)
 		for _, col := range np.Schema().Columns {
 			args = append(args, col.Clone())
 		}
-		rexpr, er.err = expression.NewFunction(er.ctx, ast.RowFunc, types.NewFieldType(types.KindRow), args...)
+		rexpr, er.err = expression.NewFunction(er.ctx, ast.RowFunc, nil, args...)
 		if er.err != nil {
 			er.err = errors.Trace(er.err)
 			return v, true
@@ -470,7 +459,7 @@ func (er *expressionRewriter) handleInSubquery(v *ast.PatternInExpr) (ast.Node,
 	if er.err != nil {
 		return v, true
 	}
-	lLen := getRowLen(lexpr)
+	lLen := expression.GetRowLen(lexpr)
 	if lLen != np.Schema().Len() {
 		er.err = ErrOperandColumns.GenByArgs(lLen)
 		return v, true
@@ -646,6 +635,12 @@ func (er *expressionRewriter) Leave(inNode ast.Node) (retNode ast.Node, ok bool)
 		er.isNullToExpression(v)
 	case *ast.IsTruthExpr:
 		er.isTrueToScalarFunc(v)
+	case *ast.SetCollationExpr:
+		er.setCollationToScalarFunc(v)
+	case *ast.DefaultExpr:
+		if er.b.insertCols == nil || v.Name == nil {
+			er.evalDefaultExpr(v)
+		}
 	default:
 		er.err = errors.Errorf("UnknownType: %T", v)
 		return retNode, false
@@ -723,7 +718,7 @@ func (er *expressionRewriter) unaryOpToExpression(v *ast.UnaryOperationExpr) {
 		er.err = errors.Errorf("Unknown Unary Op %T", v.Op)
 		return
 	}
-	if getRowLen(er.ctxStack[stkLen-1]) != 1 {
+	if expression.GetRowLen(er.ctxStack[stkLen-1]) != 1 {
 		er.err = ErrOperandColumns.GenByArgs(1)
 		return
 	}
@@ -738,8 +733,8 @@ func (er *expressionRewriter) binaryOpToExpression(v *ast.BinaryOperationExpr) {
 		function, er.err = er.constructBinaryOpFunction(er.ctxStack[stkLen-2], er.ctxStack[stkLen-1],
 			v.Op.String())
 	default:
-		lLen := getRowLen(er.ctxStack[stkLen-2])
-		rLen := getRowLen(er.ctxStack[stkLen-1])
+		lLen := expression.GetRowLen(er.ctxStack[stkLen-2])
+		rLen := expression.GetRowLen(er.ctxStack[stkLen-1])
 		switch v.Op {
 		case opcode.GT, opcode.GE, opcode.LT, opcode.LE:
 			if lLen != rLen {
@@ -784,7 +779,7 @@ func (er *expressionRewriter) notToExpression(hasNot bool, op string, tp *types.
 
 func (er *expressionRewriter) isNullToExpression(v *ast.IsNullExpr) {
 	stkLen := len(er.ctxStack)
-	if getRowLen(er.ctxStack[stkLen-1]) != 1 {
+	if expression.GetRowLen(er.ctxStack[stkLen-1]) != 1 {
 		er.err = ErrOperandColumns.GenByArgs(1)
 		return
 	}
@@ -807,7 +802,7 @@ func (er *expressionRewriter) isTrueToScalarFunc(v *ast.IsTruthExpr) {
 	if v.True == 0 {
 		op = ast.IsFalsity
 	}
-	if getRowLen(er.ctxStack[stkLen-1]) != 1 {
+	if expression.GetRowLen(er.ctxStack[stkLen-1]) != 1 {
 		er.err = ErrOperandColumns.GenByArgs(1)
 		return
 	}
@@ -816,13 +811,29 @@ func (er *expressionRewriter) isTrueToScalarFunc(v *ast.IsTruthExpr) {
 	er.ctxStack = append(er.ctxStack, function)
 }
 
+func (er *expressionRewriter) setCollationToScalarFunc(v *ast.SetCollationExpr) {
+	stkLen := len(er.ctxStack)
+	if expression.GetRowLen(er.ctxStack[stkLen-1]) != 1 {
+		er.err = ErrOperandColumns.GenByArgs(1)
+		return
+	}
+	collation := &expression.Constant{Value: types.NewStringDatum(v.Collate), RetType: types.NewFieldType(mysql.TypeVarString)}
+	function, err := expression.NewFunction(er.ctx, ast.Collate, &v.Type, er.ctxStack[stkLen-1], collation)
+	if err != nil {
+		er.err = errors.Trace(err)
+		return
+	}
+	er.ctxStack = er.ctxStack[:stkLen-1]
+	er.ctxStack = append(er.ctxStack, function)
+}
+
 // inToExpression convert in expression to a scalar function. The argument lLen means the length of in list.
 // The argument not means if the expression is not in. The tp stands for the expression type, which is always bool.
 func (er *expressionRewriter) inToExpression(lLen int, not bool, tp *types.FieldType) {
 	stkLen := len(er.ctxStack)
-	l := getRowLen(er.ctxStack[stkLen-lLen-1])
+	l := expression.GetRowLen(er.ctxStack[stkLen-lLen-1])
 	for i := 0; i < lLen; i++ {
-		if l != getRowLen(er.ctxStack[stkLen-lLen+i]) {
+		if l != expression.GetRowLen(er.ctxStack[stkLen-lLen+i]) {
 			er.err = ErrOperandColumns.GenByArgs(l)
 			return
 		}
@@ -928,6 +939,24 @@ func (er *expressionRewriter) betweenToExpression(v *ast.BetweenExpr) {
 	if er.err != nil {
 		return
 	}
+	// An expensive expression is evaluated only once by the between function. Columns and constants are
+	// expanded to comparisons, so that they can be used to build ranges and be pushed down.
+	switch er.ctxStack[stkLen-3].(type) {
+	case *expression.Column, *expression.CorrelatedColumn, *expression.Constant:
+	default:
+		funcName := ast.Between
+		if v.Not {
+			funcName = ast.NotBetween
+		}
+		function, err := expression.NewFunction(er.ctx, funcName, &v.Type, er.ctxStack[stkLen-3:]...)
+		if err != nil {
+			er.err = errors.Trace(err)
+			return
+		}
+		er.ctxStack = er.ctxStack[:stkLen-3]
+		er.ctxStack = append(er.ctxStack, function)
+		return
+	}
 	var op string
 	var l, r expression.Expression
 	l, er.err = expression.NewFunction(er.ctx, ast.GE, &v.Type, er.ctxStack[stkLen-3], er.ctxStack[stkLen-2])
@@ -957,7 +986,7 @@ func (er *expressionRewriter) betweenToExpression(v *ast.BetweenExpr) {
 
 func (er *expressionRewriter) checkArgsOneColumn(args ...expression.Expression) {
 	for _, arg := range args {
-		if getRowLen(arg) != 1 {
+		if expression.GetRowLen(arg) != 1 {
 			er.err = ErrOperandColumns.GenByArgs(1)
 			return
 		}
@@ -977,6 +1006,62 @@ func (er *expressionRewriter) funcCallToExpression(v *ast.FuncCallExpr) {
 	er.ctxStack = append(er.ctxStack, function)
 }
 
+// evalDefaultExpr rewrites DEFAULT(col) to a default function, the column has been pushed to the stack when
+// visiting v.Name.
+func (er *expressionRewriter) evalDefaultExpr(v *ast.DefaultExpr) {
+	if v.Name == nil {
+		er.err = errors.New("DEFAULT without a column name can only be used as a value")
+		return
+	}
+	stkLen := len(er.ctxStack)
+	col, ok := er.ctxStack[stkLen-1].(*expression.Column)
+	if !ok {
+		er.err = ErrUnknownColumn.GenByArgs(v.Name.Name.O, "field_list")
+		return
+	}
+	colInfo := findColumnInfo(er.p, col)
+	if colInfo == nil {
+		er.err = ErrUnknownColumn.GenByArgs(v.Name.Name.O, "field_list")
+		return
+	}
+	fn, err := expression.NewDefaultFunc(col, colInfo, er.ctx)
+	if err != nil {
+		er.err = errors.Trace(err)
+		return
+	}
+	er.ctxStack[stkLen-1] = fn
+}
+
+// insertDefaultToConstant rewrites DEFAULT(col) in an insert statement to the default value of col in the inserted
+// table, the table isn't a data source of the statement so col can't be resolved from the plan.
+func (er *expressionRewriter) insertDefaultToConstant(v *ast.DefaultExpr) {
+	value, err := er.b.findDefaultValue(er.b.insertCols, v.Name)
+	if err != nil {
+		er.err = errors.Trace(err)
+		return
+	}
+	er.ctxStack = append(er.ctxStack, value)
+}
+
+// findColumnInfo finds the meta of col from the DataSource of p that produces it.
+func findColumnInfo(p LogicalPlan, col *expression.Column) *model.ColumnInfo {
+	if p == nil {
+		return nil
+	}
+	if ds, ok := p.(*DataSource); ok {
+		if idx := ds.Schema().ColumnIndex(col); idx != -1 {
+			return ds.Columns[idx]
+		}
+		return nil
+	}
+	for _, child := range p.Children() {
+		if colInfo := findColumnInfo(child.(LogicalPlan), col); colInfo != nil {
+			return colInfo
+		}
+	}
+	return nil
+}
+
 func (er *expressionRewriter) toColumn(v *ast.ColumnName) {
 	column, err := er.schema.FindColumn(v)
 	if err != nil {
@@ -235,6 +235,18 @@ func (s *testExpressionSuite) TestIsTruth(c *C) {
 			exprStr:   "NULL IS TRUE",
 			resultStr: "0",
 		},
+		{
+			exprStr:   "0.1 IS TRUE",
+			resultStr: "1",
+		},
+		{
+			exprStr:   "0.0 IS FALSE",
+			resultStr: "1",
+		},
+		{
+			exprStr:   "'0.2' IS NOT TRUE",
+			resultStr: "0",
+		},
 		{
 			exprStr:   "1 IS FALSE",
 			resultStr: "0",
@@ -144,18 +144,6 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) LogicalPlan {
 	}
 }
 
-func extractCorColumns(expr expression.Expression) (cols []*expression.CorrelatedColumn) {
-	switch v := expr.(type) {
-	case *expression.CorrelatedColumn:
-		return []*expression.CorrelatedColumn{v}
-	case *expression.ScalarFunction:
-		for _, arg := range v.GetArgs() {
-			cols = append(cols, extractCorColumns(arg)...)
-		}
-	}
-	return
-}
-
 func extractOnCondition(conditions []expression.Expression, left LogicalPlan, right LogicalPlan) (
 	eqCond []*expression.ScalarFunction, leftCond []expression.Expression, rightCond []expression.Expression,
 	otherCond []expression.Expression) {
@@ -1205,7 +1193,7 @@ func (b *planBuilder) buildUpdateLists(list []*ast.Assignment, p LogicalPlan) ([
 			b.err = errors.Trace(errors.Errorf("could not find column %s.%s", col.TblName, col.ColName))
 			return nil, nil
 		}
-		newExpr, np, err := b.rewrite(assign.Expr, p, nil, false)
+		newExpr, np, err := b.rewrite(assignmentExpr(assign), p, nil, false)
 		if err != nil {
 			b.err = errors.Trace(err)
 			return nil, nil
@@ -1401,6 +1401,15 @@ func (s *testPlanSuite) TestUniqueKeyInfo(c *C) {
 				"Projection_4": {{"t1.f"}, {"t1.g"}, {"t1.f", "t1.g"}, {"t1.a"}},
 			},
 		},
+		{
+			sql: "select t1.a, t2.a from t t1 join t t2 on t1.b = t2.b",
+			ans: map[string][][]string{
+				"TableScan_1":  {{"t1.a"}},
+				"TableScan_2":  {{"t2.a"}},
+				"Join_3":       {{"t1.a", "t2.a"}},
+				"Projection_4": {{"t1.a", "t2.a"}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		comment := Commentf("for %s", tt.sql)
@@ -47,15 +47,15 @@ type JoinType int
 
 const (
 	// InnerJoin means inner join.
-	InnerJoin JoinType = iota
+	InnerJoin JoinType = expression.JoinTypeInner
 	// LeftOuterJoin means left join.
-	LeftOuterJoin
+	LeftOuterJoin JoinType = expression.JoinTypeLeftOuter
 	// RightOuterJoin means right join.
-	RightOuterJoin
+	RightOuterJoin JoinType = expression.JoinTypeRightOuter
 	// SemiJoin means if row a in table A matches some rows in B, just output a.
-	SemiJoin
+	SemiJoin JoinType = expression.JoinTypeSemi
 	// LeftOuterSemiJoin means if row a in table A matches some rows in B, output (a, true), otherwise, output (a, false).
-	LeftOuterSemiJoin
+	LeftOuterSemiJoin JoinType = expression.JoinTypeLeftOuterSemi
 )
 
 const (
@@ -111,16 +111,16 @@ func (p *LogicalJoin) attachOnConds(onConds []expression.Expression) {
 func (p *LogicalJoin) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, fun := range p.EqualConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.LeftConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.RightConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.OtherConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	return corCols
 }
@@ -137,7 +137,7 @@ type Projection struct {
 func (p *Projection) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, expr := range p.Exprs {
-		corCols = append(corCols, extractCorColumns(expr)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(expr)...)
 	}
 	return corCols
 }
@@ -157,11 +157,11 @@ type LogicalAggregation struct {
 func (p *LogicalAggregation) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, expr := range p.GroupByItems {
-		corCols = append(corCols, extractCorColumns(expr)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(expr)...)
 	}
 	for _, fun := range p.AggFuncs {
 		for _, arg := range fun.GetArgs() {
-			corCols = append(corCols, extractCorColumns(arg)...)
+			corCols = append(corCols, expression.ExtractCorrelatedColumns(arg)...)
 		}
 	}
 	return corCols
@@ -193,7 +193,7 @@ type Selection struct {
 func (p *Selection) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, cond := range p.Conditions {
-		corCols = append(corCols, extractCorColumns(cond)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(cond)...)
 	}
 	return corCols
 }
@@ -278,7 +278,7 @@ type Sort struct {
 func (p *Sort) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, item := range p.ByItems {
-		corCols = append(corCols, extractCorColumns(item.Expr)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(item.Expr)...)
 	}
 	return corCols
 }
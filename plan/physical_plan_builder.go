@@ -1228,8 +1228,11 @@ func (p *Selection) makeScanController() *physicalPlanInfo {
 		if !expr.IsCorrelated() {
 			continue
 		}
-		cond := pushDownNot(expr, false, nil)
-		corCols := extractCorColumns(cond)
+		cond, err := expression.PushDownNot(nil, expr, false)
+		if err != nil {
+			cond = expr
+		}
+		corCols := expression.ExtractCorrelatedColumns(cond)
 		for _, col := range corCols {
 			*col.Data = expression.One.Value
 		}
@@ -996,7 +996,9 @@ func (s *testPlanSuite) TestRangeBuilder(c *C) {
 		c.Assert(selection, NotNil, Commentf("expr:%v", tt.exprStr))
 		result := fullRange
 		for _, cond := range selection.Conditions {
-			result = rb.intersection(result, rb.build(pushDownNot(cond, false, nil)))
+			cond, err = expression.PushDownNot(nil, cond, false)
+			c.Assert(err, IsNil)
+			result = rb.intersection(result, rb.build(cond))
 		}
 		c.Assert(rb.err, IsNil)
 		got := fmt.Sprintf("%v", result)
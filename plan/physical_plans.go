@@ -570,16 +570,16 @@ func (p *PhysicalMergeJoin) tryConsumeOrder(prop *requiredProperty, eqCond *expr
 func (p *PhysicalHashJoin) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, fun := range p.EqualConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.LeftConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.RightConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.OtherConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	return corCols
 }
@@ -587,16 +587,16 @@ func (p *PhysicalHashJoin) extractCorrelatedCols() []*expression.CorrelatedColum
 func (p *PhysicalHashSemiJoin) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, fun := range p.EqualConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.LeftConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.RightConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	for _, fun := range p.OtherConditions {
-		corCols = append(corCols, extractCorColumns(fun)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(fun)...)
 	}
 	return corCols
 }
@@ -615,11 +615,11 @@ func (p *PhysicalApply) extractCorrelatedCols() []*expression.CorrelatedColumn {
 func (p *PhysicalAggregation) extractCorrelatedCols() []*expression.CorrelatedColumn {
 	corCols := p.basePlan.extractCorrelatedCols()
 	for _, expr := range p.GroupByItems {
-		corCols = append(corCols, extractCorColumns(expr)...)
+		corCols = append(corCols, expression.ExtractCorrelatedColumns(expr)...)
 	}
 	for _, fun := range p.AggFuncs {
 		for _, arg := range fun.GetArgs() {
-			corCols = append(corCols, extractCorColumns(arg)...)
+			corCols = append(corCols, expression.ExtractCorrelatedColumns(arg)...)
 		}
 	}
 	return corCols
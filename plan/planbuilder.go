@@ -118,6 +118,9 @@ type planBuilder struct {
 	is           infoschema.InfoSchema
 	outerSchemas []*expression.Schema
 	inUpdateStmt bool
+	// insertCols are the columns of the inserted table, DEFAULT(col) in the values and assignments of an insert
+	// statement is resolved from them.
+	insertCols []*table.Column
 	// colMapper stores the column that must be pre-resolved.
 	colMapper map[*ast.ColumnNameExpr]int
 	// Collect the visit information for privilege check.
@@ -622,6 +625,14 @@ func (b *planBuilder) findDefaultValue(cols []*table.Column, name *ast.ColumnNam
 	return nil, ErrUnknownColumn.GenByArgs(name.Name.O, "field_list")
 }
 
+// assignmentExpr returns the value expression of assign, a bare DEFAULT is completed with the assigned column.
+func assignmentExpr(assign *ast.Assignment) ast.ExprNode {
+	if dft, ok := assign.Expr.(*ast.DefaultExpr); ok && dft.Name == nil {
+		return &ast.DefaultExpr{Name: assign.Column}
+	}
+	return assign.Expr
+}
+
 func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	ts, ok := insert.Table.TableRefs.Left.(*ast.TableSource)
 	if !ok {
@@ -656,6 +667,8 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 	})
 
 	cols := table.Cols()
+	b.insertCols = cols
+	defer func() { b.insertCols = nil }()
 	for _, valuesItem := range insert.Lists {
 		exprList := make([]expression.Expression, 0, len(valuesItem))
 		for i, valueItem := range valuesItem {
@@ -694,7 +707,12 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		}
 		// Here we keep different behaviours with MySQL. MySQL allow set a = b, b = a and the result is NULL, NULL.
 		// It's unreasonable.
-		expr, _, err := b.rewrite(assign.Expr, nil, nil, true)
+		var expr expression.Expression
+		if dft, ok := assignmentExpr(assign).(*ast.DefaultExpr); ok {
+			expr, err = b.findDefaultValue(cols, dft.Name)
+		} else {
+			expr, _, err = b.rewrite(assign.Expr, nil, nil, true)
+		}
 		if err != nil {
 			b.err = errors.Trace(err)
 			return nil
@@ -716,7 +734,12 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 			b.err = errors.Errorf("Can't find column %s", assign.Column)
 			return nil
 		}
-		expr, _, err := b.rewrite(assign.Expr, mockTablePlan, nil, true)
+		var expr expression.Expression
+		if dft, ok := assignmentExpr(assign).(*ast.DefaultExpr); ok {
+			expr, err = b.findDefaultValue(cols, dft.Name)
+		} else {
+			expr, _, err = b.rewrite(assign.Expr, mockTablePlan, nil, true)
+		}
 		if err != nil {
 			b.err = errors.Trace(err)
 			return nil
@@ -727,6 +750,7 @@ func (b *planBuilder) buildInsert(insert *ast.InsertStmt) Plan {
 		})
 	}
 	if insert.Select != nil {
+		b.insertCols = nil
 		selectPlan := b.build(insert.Select)
 		if b.err != nil {
 			return nil
@@ -18,7 +18,6 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
-	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
@@ -196,9 +195,12 @@ func DetachIndexFilterConditions(conditions []expression.Expression, indexColumn
 func DetachIndexScanConditions(conditions []expression.Expression, index *model.IndexInfo) (accessConds []expression.Expression,
 	filterConds []expression.Expression, accessEqualCount int, accessInAndEqCount int) {
 	accessConds = make([]expression.Expression, len(index.Columns))
-	// pushDownNot here can convert query 'not (a != 1)' to 'a = 1'.
+	// PushDownNot here can convert query 'not (a != 1)' to 'a = 1'.
+	// A condition which fails to be rewritten is kept as it is.
 	for i, cond := range conditions {
-		conditions[i] = pushDownNot(cond, false, nil)
+		if newCond, err := expression.PushDownNot(nil, cond, false); err == nil {
+			conditions[i] = newCond
+		}
 	}
 	for _, cond := range conditions {
 		offset := getEQFunctionOffset(cond, index.Columns)
@@ -272,7 +274,9 @@ func DetachTableScanConditions(conditions []expression.Expression, table *model.
 		length: types.UnspecifiedLength,
 	}
 	for _, cond := range conditions {
-		cond = pushDownNot(cond, false, nil)
+		if newCond, err := expression.PushDownNot(nil, cond, false); err == nil {
+			cond = newCond
+		}
 		if !checker.check(cond) {
 			filterConditions = append(filterConditions, cond)
 			continue
@@ -463,60 +467,3 @@ func (c *conditionChecker) checkColumn(expr expression.Expression) bool {
 	}
 	return true
 }
-
-var oppositeOp = map[string]string{
-	ast.LT: ast.GE,
-	ast.GE: ast.LT,
-	ast.GT: ast.LE,
-	ast.LE: ast.GT,
-	ast.EQ: ast.NE,
-	ast.NE: ast.EQ,
-}
-
-func pushDownNot(expr expression.Expression, not bool, ctx context.Context) expression.Expression {
-	if f, ok := expr.(*expression.ScalarFunction); ok {
-		switch f.FuncName.L {
-		case ast.UnaryNot:
-			return pushDownNot(f.GetArgs()[0], !not, f.GetCtx())
-		case ast.LT, ast.GE, ast.GT, ast.LE, ast.EQ, ast.NE:
-			if not {
-				nf, _ := expression.NewFunction(f.GetCtx(), oppositeOp[f.FuncName.L], f.GetType(), f.GetArgs()...)
-				return nf
-			}
-			for i, arg := range f.GetArgs() {
-				f.GetArgs()[i] = pushDownNot(arg, false, f.GetCtx())
-			}
-			return f
-		case ast.AndAnd:
-			if not {
-				args := f.GetArgs()
-				for i, a := range args {
-					args[i] = pushDownNot(a, true, f.GetCtx())
-				}
-				nf, _ := expression.NewFunction(f.GetCtx(), ast.OrOr, f.GetType(), args...)
-				return nf
-			}
-			for i, arg := range f.GetArgs() {
-				f.GetArgs()[i] = pushDownNot(arg, false, f.GetCtx())
-			}
-			return f
-		case ast.OrOr:
-			if not {
-				args := f.GetArgs()
-				for i, a := range args {
-					args[i] = pushDownNot(a, true, f.GetCtx())
-				}
-				nf, _ := expression.NewFunction(f.GetCtx(), ast.AndAnd, f.GetType(), args...)
-				return nf
-			}
-			for i, arg := range f.GetArgs() {
-				f.GetArgs()[i] = pushDownNot(arg, false, f.GetCtx())
-			}
-			return f
-		}
-	}
-	if not {
-		expr, _ = expression.NewFunction(ctx, ast.UnaryNot, types.NewFieldType(mysql.TypeTiny), expr)
-	}
-	return expr
-}
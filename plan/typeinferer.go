@@ -97,6 +97,11 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 		v.handleRegexpExpr(x)
 	case *ast.SelectStmt:
 		v.selectStmt(x)
+	case *ast.SetCollationExpr:
+		// Copy a new field type, the collation is checked when the expression is rewritten.
+		tp := *x.Expr.GetType()
+		tp.Collate = strings.ToLower(x.Collate)
+		x.SetType(&tp)
 	case *ast.UnaryOperationExpr:
 		v.unaryOperation(x)
 	case *ast.ValueExpr:
@@ -301,7 +306,16 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		chs = charset.CharsetBin
 	)
 	switch x.FnName.L {
-	case ast.Abs, ast.Ifnull, ast.Nullif:
+	case ast.Ifnull:
+		if len(x.Args) != 2 {
+			tp = types.NewFieldType(mysql.TypeNull)
+			break
+		}
+		tp = types.MergeFieldTypes([]*types.FieldType{x.Args[0].GetType(), x.Args[1].GetType()})
+		if isNotNullExpr(x.Args[0]) || isNotNullExpr(x.Args[1]) {
+			tp.Flag |= mysql.NotNullFlag
+		}
+	case ast.Abs, ast.Nullif:
 		if len(x.Args) == 0 {
 			tp = types.NewFieldType(mysql.TypeNull)
 			break
@@ -320,6 +334,8 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		switch t {
 		case mysql.TypeBit, mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLonglong:
 			tp = types.NewFieldType(mysql.TypeLonglong)
+			// Rounding or truncating an integer keeps its sign.
+			tp.Flag |= x.Args[0].GetType().Flag & mysql.UnsignedFlag
 		case mysql.TypeNewDecimal:
 			tp = types.NewFieldType(mysql.TypeNewDecimal)
 		default:
@@ -334,6 +350,14 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 			for i := 1; i < len(x.Args); i++ {
 				tp = mergeCmpType(tp, x.Args[i].GetType())
 			}
+			// The temporal arguments mixed with strings are compared as times, so is the result.
+			fts := make([]*types.FieldType, 0, len(x.Args))
+			for _, arg := range x.Args {
+				fts = append(fts, arg.GetType())
+			}
+			if timeTp, ok := types.MergeTimeCmpTypes(fts); ok {
+				tp = types.NewFieldType(timeTp)
+			}
 		} else {
 			tp = types.NewFieldType(mysql.TypeNull)
 		}
@@ -368,15 +392,20 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		}
 	// number related
 	case ast.Ln, ast.Log, ast.Log2, ast.Log10, ast.Sqrt, ast.PI, ast.Exp, ast.Degrees, ast.Sin, ast.Cos, ast.Tan,
-		ast.Cot, ast.Acos, ast.Asin, ast.Atan, ast.Pow, ast.Power, ast.Rand, ast.Radians:
+		ast.Cot, ast.Acos, ast.Asin, ast.Atan, ast.Atan2, ast.Pow, ast.Power, ast.Rand, ast.Radians:
 		tp = types.NewFieldType(mysql.TypeDouble)
 	case ast.MicroSecond, ast.Second, ast.Minute, ast.Hour, ast.Day, ast.Week, ast.Month, ast.Year,
 		ast.DayOfWeek, ast.DayOfMonth, ast.DayOfYear, ast.Weekday, ast.WeekOfYear, ast.YearWeek, ast.DateDiff,
 		ast.FoundRows, ast.Length, ast.Extract, ast.Locate, ast.UnixTimestamp, ast.Quarter, ast.IsIPv4, ast.ToDays,
-		ast.ToSeconds, ast.Strcmp, ast.IsNull, ast.BitLength, ast.CharLength, ast.CRC32, ast.TimestampDiff,
+		ast.ToSeconds, ast.Strcmp, ast.BitLength, ast.CharLength, ast.CRC32, ast.TimestampDiff,
 		ast.Sign, ast.IsIPv6, ast.Ord, ast.Instr, ast.BitCount, ast.TimeToSec, ast.FindInSet, ast.Field,
-		ast.GetLock, ast.ReleaseLock, ast.Interval, ast.Position, ast.PeriodAdd:
+		ast.GetLock, ast.ReleaseLock, ast.Interval, ast.Position, ast.PeriodAdd, ast.PeriodDiff, ast.Benchmark,
+		ast.JSONMemberOf, ast.JSONLength, ast.JSONDepth, ast.JSONValid:
 		tp = types.NewFieldType(mysql.TypeLonglong)
+	case ast.IsNull:
+		// ISNULL(expr) is never NULL.
+		tp = types.NewFieldType(mysql.TypeTiny)
+		tp.Flag |= mysql.NotNullFlag
 	case ast.ConnectionID, ast.InetAton:
 		tp = types.NewFieldType(mysql.TypeLonglong)
 		tp.Flag |= mysql.UnsignedFlag
@@ -386,8 +415,21 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		tp.Decimal = v.getFsp(x)
 	case ast.Curdate, ast.CurrentDate, ast.Date, ast.FromDays, ast.MakeDate:
 		tp = types.NewFieldType(mysql.TypeDate)
-	case ast.DateAdd, ast.DateSub, ast.AddDate, ast.SubDate, ast.Timestamp, ast.TimestampAdd, ast.StrToDate:
+	case ast.DateAdd, ast.DateSub, ast.AddDate, ast.SubDate, ast.Timestamp, ast.TimestampAdd, ast.ConvertTz:
+		tp = types.NewFieldType(mysql.TypeDatetime)
+	case ast.StrToDate:
+		// The type depends on the specifiers of the format, a non-constant format results in a datetime.
 		tp = types.NewFieldType(mysql.TypeDatetime)
+		tp.Decimal = types.MaxFsp
+		if len(x.Args) == 2 {
+			if format, ok := x.Args[1].(*ast.ValueExpr); ok && !format.GetDatum().IsNull() {
+				str, err := format.GetDatum().ToString()
+				if err != nil {
+					v.err = err
+				}
+				tp.Tp, tp.Decimal = types.StrToDateFormatType(str)
+			}
+		}
 	case ast.Now, ast.Sysdate, ast.CurrentTimestamp, ast.UTCTimestamp:
 		tp = types.NewFieldType(mysql.TypeDatetime)
 		tp.Decimal = v.getFsp(x)
@@ -399,17 +441,30 @@ func (v *typeInferrer) handleFuncCallExpr(x *ast.FuncCallExpr) {
 		tp = types.NewFieldType(mysql.TypeVarString)
 		chs = v.defaultCharset
 		tp.Flen = 40
+	case ast.SHA2:
+		tp = types.NewFieldType(mysql.TypeVarString)
+		chs = v.defaultCharset
+		tp.Flen = 128
 	case ast.DayName, ast.Version, ast.Database, ast.User, ast.CurrentUser, ast.Schema,
 		ast.Concat, ast.ConcatWS, ast.Left, ast.Right, ast.Lcase, ast.Lower, ast.Repeat,
-		ast.Replace, ast.Ucase, ast.Upper, ast.Convert, ast.Substring, ast.Elt,
-		ast.SubstringIndex, ast.Trim, ast.LTrim, ast.RTrim, ast.Reverse, ast.Hex, ast.Unhex,
+		ast.Replace, ast.Ucase, ast.Upper, ast.Convert, ast.Substring, ast.Substr, ast.Mid, ast.Elt,
+		ast.SubstringIndex, ast.Trim, ast.LTrim, ast.RTrim, ast.Reverse, ast.Hex,
 		ast.DateFormat, ast.Rpad, ast.Lpad, ast.CharFunc, ast.Conv, ast.MakeSet, ast.Oct, ast.UUID,
 		ast.InsertFunc, ast.Bin, ast.Quote, ast.Format, ast.FromBase64, ast.ToBase64, ast.ExportSet,
-		ast.AesEncrypt, ast.AesDecrypt, ast.SHA2, ast.InetNtoa, ast.Inet6Aton:
+		ast.AesEncrypt, ast.AesDecrypt, ast.InetNtoa, ast.Inet6Aton, ast.JSONType, ast.Charset, ast.Collation,
+		ast.JSONSet, ast.JSONInsert, ast.JSONReplace, ast.JSONKeys, ast.JSONMergePatch, ast.JSONMergePreserve,
+		ast.JSONQuote, ast.JSONPretty:
 		tp = types.NewFieldType(mysql.TypeVarString)
 		chs = v.defaultCharset
-	case ast.RandomBytes:
+	case ast.RandomBytes, ast.Unhex:
+		tp = types.NewFieldType(mysql.TypeVarString)
+	case ast.UUIDToBin:
 		tp = types.NewFieldType(mysql.TypeVarString)
+		tp.Flen = 16
+	case ast.BinToUUID:
+		tp = types.NewFieldType(mysql.TypeVarString)
+		chs = v.defaultCharset
+		tp.Flen = 36
 	case ast.If:
 		// TODO: fix this
 		// See https://dev.mysql.com/doc/refman/5.5/en/control-flow-functions.html#function_if
@@ -535,6 +590,14 @@ func (v *typeInferrer) convertValueToColumnTypeIfNeeded(x *ast.PatternInExpr) {
 	}
 }
 
+// isNotNullExpr checks if the expression is a not null value or has the not null flag.
+func isNotNullExpr(expr ast.ExprNode) bool {
+	if v, ok := expr.(*ast.ValueExpr); ok {
+		return !v.Datum.IsNull()
+	}
+	return mysql.HasNotNullFlag(expr.GetType().Flag)
+}
+
 func aggFieldType(args []ast.ExprNode) *types.FieldType {
 	var currType types.FieldType
 	for _, arg := range args {
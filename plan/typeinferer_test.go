@@ -78,7 +78,7 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 
 		{"c_int is true", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"c_double is null", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
-		{"isnull(1/0)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
+		{"isnull(1/0)", mysql.TypeTiny, charset.CharsetBin, mysql.BinaryFlag | mysql.NotNullFlag},
 		{"cast(1 as decimal)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag},
 
 		{"1 and 1", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
@@ -127,7 +127,10 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"abs(1.1)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag},
 		{"abs(cast(\"20150817015609\" as DATETIME))", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"IF(1>2,2,3)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
-		{"IFNULL(1,0)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
+		{"IFNULL(1,0)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag | mysql.NotNullFlag},
+		{"IFNULL(c_int, c_double)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
+		{"IFNULL(c_decimal, 1.25)", mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag | mysql.NotNullFlag},
+		{"IFNULL(c_varchar, 'abc')", mysql.TypeVarchar, charset.CharsetUTF8, mysql.NotNullFlag},
 		{"POW(2,2)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"POWER(2,2)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"LN(3)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
@@ -146,6 +149,7 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"ASIN(1)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"ATAN(1)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"ATAN(0, 1)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
+		{"ATAN2(0, 1)", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"rand()", mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{"curdate()", mysql.TypeDate, charset.CharsetBin, mysql.BinaryFlag},
 		{"current_date()", mysql.TypeDate, charset.CharsetBin, mysql.BinaryFlag},
@@ -155,6 +159,10 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"curtime()", mysql.TypeDuration, charset.CharsetBin, mysql.BinaryFlag},
 		{"curtime(2)", mysql.TypeDuration, charset.CharsetBin, mysql.BinaryFlag},
 		{"makedate(2017,31)", mysql.TypeDate, charset.CharsetBin, mysql.BinaryFlag},
+		{"convert_tz('2004-01-01 12:00:00', '+00:00', '+10:00')", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
+		{"str_to_date('2017-01-02', '%Y-%m-%d')", mysql.TypeDate, charset.CharsetBin, mysql.BinaryFlag},
+		{"str_to_date('2017-01-02 10:11', '%Y-%m-%d %H:%i')", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
+		{"str_to_date(c_char, c_char)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
 		{"maketime(12, 15, 30)", mysql.TypeDuration, charset.CharsetBin, mysql.BinaryFlag},
 		{"sec_to_time(2378)", mysql.TypeDuration, charset.CharsetBin, mysql.BinaryFlag},
 		{"current_timestamp()", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
@@ -180,6 +188,7 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"length('tidb')", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"is_ipv4('192.168.1.1')", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"period_add(199206, 2)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
+		{"period_diff(199206, 199302)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"now()", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
 		{"from_unixtime(1447430881)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
 		{"from_unixtime(1447430881, '%Y %D %M %h:%i:%s %x')", mysql.TypeVarString, charset.CharsetUTF8, 0},
@@ -225,6 +234,10 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"greatest(c_enum, c_int)", mysql.TypeString, charset.CharsetBin, mysql.BinaryFlag},
 		{"greatest(c_set, c_int)", mysql.TypeString, charset.CharsetBin, mysql.BinaryFlag},
 		{"greatest(c_enum, c_set)", mysql.TypeString, charset.CharsetUTF8, 0},
+		{"greatest(c_datetime, c_datetime)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
+		{"greatest(c_datetime, c_timestamp)", mysql.TypeDatetime, charset.CharsetBin, mysql.BinaryFlag},
+		{"greatest(c_timestamp, '2017-01-01', null)", mysql.TypeTimestamp, charset.CharsetBin, mysql.BinaryFlag},
+		{"greatest(c_datetime, c_int)", mysql.TypeVarString, charset.CharsetBin, mysql.BinaryFlag},
 		{"interval(1, 2, 3)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"interval(1.0, 2.0, 3.0)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"interval('1', '2', '3')", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
@@ -243,8 +256,8 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"truncate(1000, 2)", mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{"hex('TiDB')", mysql.TypeVarString, charset.CharsetUTF8, 0},
 		{"hex(12)", mysql.TypeVarString, charset.CharsetUTF8, 0},
-		{"unhex('TiDB')", mysql.TypeVarString, charset.CharsetUTF8, 0},
-		{"unhex(12)", mysql.TypeVarString, charset.CharsetUTF8, 0},
+		{"unhex('TiDB')", mysql.TypeVarString, charset.CharsetBin, mysql.BinaryFlag},
+		{"unhex(12)", mysql.TypeVarString, charset.CharsetBin, mysql.BinaryFlag},
 		{"DATE_FORMAT('2009-10-04 22:23:00', '%W %M %Y')", mysql.TypeVarString, charset.CharsetUTF8, 0},
 		{"rpad('TiDB', 12, 'go')", mysql.TypeVarString, charset.CharsetUTF8, 0},
 		{"lpad('TiDB', 12, 'go')", mysql.TypeVarString, charset.CharsetUTF8, 0},
@@ -297,6 +310,10 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{`any_value("abc")`, mysql.TypeVarString, charset.CharsetUTF8, 0},
 		{`any_value(1)`, mysql.TypeLonglong, charset.CharsetBin, mysql.BinaryFlag},
 		{`any_value(1.234)`, mysql.TypeNewDecimal, charset.CharsetBin, mysql.BinaryFlag},
+		{`any_value(c_int)`, mysql.TypeLong, charset.CharsetBin, mysql.BinaryFlag},
+		{`any_value(c_char)`, mysql.TypeString, charset.CharsetUTF8, 0},
+		{`c_char collate utf8_general_ci`, mysql.TypeString, charset.CharsetUTF8, 0},
+		{`c_binary collate 'binary'`, mysql.TypeString, charset.CharsetBin, mysql.BinaryFlag},
 		{`degrees(1)`, mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{`radians(90)`, mysql.TypeDouble, charset.CharsetBin, mysql.BinaryFlag},
 		{`make_set(1 | 3, "hello", "nice", null, "world")`, mysql.TypeVarString, charset.CharsetUTF8, 0},
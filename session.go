@@ -665,6 +665,9 @@ func (s *session) ExecutePreparedStmt(stmtID uint32, args ...interface{}) (ast.R
 		return nil, errors.Trace(err)
 	}
 	s.prepareTxnCtx()
+	// Reset the statement context like the EXECUTE statement of the text protocol does, so that each
+	// execution has its own warnings and start time.
+	resetStmtCtx(s, &ast.ExecuteStmt{})
 	st := executor.CompileExecutePreparedStmt(s, stmtID, args...)
 
 	r, err := runStmt(s, st)
@@ -126,6 +126,22 @@ func (s *testSessionSuite) TestPrepare(c *C) {
 	rs.Close()
 	c.Assert(err, IsNil)
 
+	// Each execution of a prepared statement has its own start time.
+	id, _, _, err = se.PrepareStmt("select now(6)")
+	c.Assert(err, IsNil)
+	var last string
+	for i := 0; i < 3; i++ {
+		rs, err = se.ExecutePreparedStmt(id)
+		c.Assert(err, IsNil)
+		r, err = rs.Next()
+		c.Assert(err, IsNil)
+		now := r.Data[0].GetMysqlTime().String()
+		rs.Close()
+		c.Assert(now, Not(Equals), last)
+		last = now
+		time.Sleep(10 * time.Millisecond)
+	}
+
 	mustExecSQL(c, se, dropDBSQL)
 }
 
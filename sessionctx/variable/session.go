@@ -161,10 +161,6 @@ type SessionVars struct {
 	// AllowSubqueryUnFolding can be set to true to fold in subquery
 	AllowInSubqueryUnFolding bool
 
-	// CurrInsertValues is used to record current ValuesExpr's values.
-	// See http://dev.mysql.com/doc/refman/5.7/en/miscellaneous-functions.html#function_values
-	CurrInsertValues interface{}
-
 	// Per-connection time zones. Each client that connects has its own time zone setting, given by the session time_zone variable.
 	// See https://dev.mysql.com/doc/refman/5.7/en/time-zone-support.html
 	TimeZone *time.Location
@@ -203,6 +199,9 @@ type SessionVars struct {
 
 	// Max row count that the outer table of index nested loop join could be without force hint.
 	MaxRowCountForINLJ int
+
+	// RoundHalfEven makes the ROUND function round a tie to the nearest even digit.
+	RoundHalfEven bool
 }
 
 // NewSessionVars creates a session vars object.
@@ -224,6 +223,7 @@ func NewSessionVars() *SessionVars {
 		IndexSerialScanConcurrency: DefIndexSerialScanConcurrency,
 		DistSQLScanConcurrency:     DefDistSQLScanConcurrency,
 		MaxRowCountForINLJ:         DefMaxRowCountForINLJ,
+		RoundHalfEven:              DefRoundHalfEven,
 	}
 }
 
@@ -292,6 +292,8 @@ const (
 	CharacterSetResults = "character_set_results"
 	MaxAllowedPacket    = "max_allowed_packet"
 	TimeZone            = "time_zone"
+	DefaultWeekFormat   = "default_week_format"
+	LcTimeNames         = "lc_time_names"
 )
 
 // TableDelta stands for the changed count for one table.
@@ -315,7 +317,28 @@ type StatementContext struct {
 		affectedRows uint64
 		foundRows    uint64
 		warnings     []error
+		nowTime      time.Time
+	}
+}
+
+// GetNowTime gets the current time of the statement, NOW() and the likes read it so that they return the same
+// value within a statement. It's the time set by SetNowTime when the statement starts, or the time of the first
+// call if it isn't set.
+func (sc *StatementContext) GetNowTime() time.Time {
+	sc.mu.Lock()
+	if sc.mu.nowTime.IsZero() {
+		sc.mu.nowTime = time.Now()
 	}
+	now := sc.mu.nowTime
+	sc.mu.Unlock()
+	return now
+}
+
+// SetNowTime sets the current time of the statement.
+func (sc *StatementContext) SetNowTime(now time.Time) {
+	sc.mu.Lock()
+	sc.mu.nowTime = now
+	sc.mu.Unlock()
 }
 
 // AddAffectedRows adds affected rows.
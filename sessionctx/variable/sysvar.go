@@ -583,7 +583,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "innodb_thread_concurrency", "0"},
 	{ScopeGlobal, "slave_allow_batching", "OFF"},
 	{ScopeGlobal, "innodb_buffer_pool_dump_pct", ""},
-	{ScopeGlobal | ScopeSession, "lc_time_names", "en_US"},
+	{ScopeGlobal | ScopeSession, LcTimeNames, "en_US"},
 	{ScopeGlobal | ScopeSession, "max_statement_time", ""},
 	{ScopeGlobal | ScopeSession, "end_markers_in_json", "OFF"},
 	{ScopeGlobal, "avoid_temporal_upgrade", "OFF"},
@@ -607,6 +607,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal | ScopeSession, TiDBSkipDDLWait, boolToIntStr(DefSkipDDLWait)},
 	{ScopeGlobal | ScopeSession, TiDBSkipUTF8Check, boolToIntStr(DefSkipUTF8Check)},
 	{ScopeSession, TiDBBatchInsert, boolToIntStr(DefBatchInsert)},
+	{ScopeSession, TiDBRoundHalfEven, boolToIntStr(DefRoundHalfEven)},
 }
 
 // SetNamesVariables is the system variable names related to set names statements.
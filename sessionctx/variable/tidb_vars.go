@@ -48,6 +48,10 @@ const (
 	// those indices can be scanned concurrently, with the cost of higher system performance impact.
 	TiDBBuildStatsConcurrency = "tidb_build_stats_concurrency"
 
+	// tidb_round_half_even is used to make the ROUND function round a tie to the nearest even digit,
+	// which is also known as banker's rounding. By default, a tie is rounded away from zero as MySQL does.
+	TiDBRoundHalfEven = "tidb_round_half_even"
+
 	/* Session and global */
 
 	// tidb_distsql_scan_concurrency is used to set the concurrency of a distsql scan task.
@@ -107,4 +111,5 @@ const (
 	DefOptAggPushDown             = true
 	DefOptInSubqUnfolding         = false
 	DefBatchInsert                = false
+	DefRoundHalfEven              = false
 )
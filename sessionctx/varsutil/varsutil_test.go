@@ -166,6 +166,13 @@ func (s *testVarsutilSuite) TestVarsutil(c *C) {
 	c.Assert(v.MaxRowCountForINLJ, Equals, 128)
 	SetSessionSystemVar(v, variable.TiDBMaxRowCountForINLJ, types.NewStringDatum("127"))
 	c.Assert(v.MaxRowCountForINLJ, Equals, 127)
+
+	// Test case for tidb_round_half_even.
+	c.Assert(v.RoundHalfEven, IsFalse)
+	SetSessionSystemVar(v, variable.TiDBRoundHalfEven, types.NewStringDatum("1"))
+	c.Assert(v.RoundHalfEven, IsTrue)
+	SetSessionSystemVar(v, variable.TiDBRoundHalfEven, types.NewStringDatum("0"))
+	c.Assert(v.RoundHalfEven, IsFalse)
 }
 
 type mockGlobalAccessor struct {
@@ -137,6 +137,7 @@ func Parse(ctx context.Context, src string) ([]ast.StmtNode, error) {
 func resetStmtCtx(ctx context.Context, s ast.StmtNode) {
 	sessVars := ctx.GetSessionVars()
 	sc := new(variable.StatementContext)
+	sc.SetNowTime(time.Now())
 	switch s.(type) {
 	case *ast.UpdateStmt, *ast.InsertStmt, *ast.DeleteStmt:
 		sc.IgnoreTruncate = false
@@ -35,6 +35,8 @@ type Context struct {
 	Store       kv.Storage     // mock global variable
 	sessionVars *variable.SessionVars
 	mux         sync.Mutex // fix data race in ddl test.
+	goCtx       goctx.Context
+	cancel      goctx.CancelFunc
 }
 
 // SetValue implements context.Context SetValue interface.
@@ -146,17 +148,52 @@ func (c *Context) GetSessionManager() util.SessionManager {
 
 // Cancel implements the Session interface.
 func (c *Context) Cancel() {
+	c.cancel()
 }
 
 // GoCtx returns standard context.Context that bind with current transaction.
 func (c *Context) GoCtx() goctx.Context {
-	return goctx.Background()
+	return c.goCtx
 }
 
 // NewContext creates a new mocked context.Context.
 func NewContext() *Context {
-	return &Context{
+	sctx := &Context{
 		values:      make(map[fmt.Stringer]interface{}),
 		sessionVars: variable.NewSessionVars(),
 	}
+	sctx.goCtx, sctx.cancel = goctx.WithCancel(goctx.Background())
+	sctx.sessionVars.GlobalVarsAccessor = newMockGlobalAccessor()
+	return sctx
+}
+
+// mockGlobalAccessor implements variable.GlobalVarAccessor with the default system variable values.
+type mockGlobalAccessor struct {
+	mu   sync.Mutex
+	vars map[string]string
+}
+
+func newMockGlobalAccessor() *mockGlobalAccessor {
+	m := &mockGlobalAccessor{
+		vars: make(map[string]string, len(variable.SysVars)),
+	}
+	for name, val := range variable.SysVars {
+		m.vars[name] = val.Value
+	}
+	return m
+}
+
+// GetGlobalSysVar implements variable.GlobalVarAccessor GetGlobalSysVar interface.
+func (m *mockGlobalAccessor) GetGlobalSysVar(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.vars[name], nil
+}
+
+// SetGlobalSysVar implements variable.GlobalVarAccessor SetGlobalSysVar interface.
+func (m *mockGlobalAccessor) SetGlobalSysVar(name string, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vars[name] = value
+	return nil
 }
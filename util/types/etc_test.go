@@ -171,6 +171,37 @@ func (s *testTypeEtcSuite) TestRound(c *C) {
 	}
 }
 
+func (s *testTypeEtcSuite) TestRoundHalfEven(c *C) {
+	defer testleak.AfterTest(c)()
+	tbl := []struct {
+		Input  float64
+		Dec    int
+		Expect float64
+	}{
+		{2.5, 0, 2},
+		{3.5, 0, 4},
+		{-2.5, 0, -2},
+		{-3.5, 0, -4},
+		{2.51, 0, 3},
+		{1.25, 1, 1.2},
+		{250, -2, 200},
+		{350, -2, 400},
+		{23.298, -1, 20},
+		{0.5, 0, 0},
+		{1.5, 0, 2},
+		{-0.5, 0, 0},
+		{-1.5, 0, -2},
+		{-2.51, 0, -3},
+		{0.49999999999999994, 0, 0},
+		{4503599627370497, 0, 4503599627370497},
+	}
+
+	for _, t := range tbl {
+		f := RoundHalfEven(t.Input, t.Dec)
+		c.Assert(f, Equals, t.Expect, Commentf("%v %v", t.Input, t.Dec))
+	}
+}
+
 func (s *testTypeEtcSuite) TestTruncate(c *C) {
 	defer testleak.AfterTest(c)()
 	tbl := []struct {
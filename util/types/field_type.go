@@ -229,6 +229,161 @@ func MergeFieldType(a byte, b byte) byte {
 	return fieldTypeMergeRules[ia][ib]
 }
 
+// maxDecimalWidth is the maximum number of digits of the decimal type.
+const maxDecimalWidth = 65
+
+// MergeFieldTypes aggregates the field types of the corresponding columns of UNION to the type of the result column.
+// The type is merged by MergeFieldType, the length and the decimal are widened to hold the values of all the types,
+// and the string types with different charsets are merged to binary.
+// See https://dev.mysql.com/doc/refman/5.7/en/union.html
+func MergeFieldTypes(fts []*FieldType) *FieldType {
+	if len(fts) == 0 {
+		return nil
+	}
+	tp := fts[0].Tp
+	for _, ft := range fts[1:] {
+		tp = MergeFieldType(tp, ft.Tp)
+	}
+	res := NewFieldType(tp)
+	res.Flag = mysql.UnsignedFlag | mysql.NotNullFlag
+	for _, ft := range fts {
+		if ft.Tp == mysql.TypeNull {
+			res.Flag &^= mysql.NotNullFlag
+			continue
+		}
+		if !mysql.HasUnsignedFlag(ft.Flag) {
+			res.Flag &^= mysql.UnsignedFlag
+		}
+		if !mysql.HasNotNullFlag(ft.Flag) {
+			res.Flag &^= mysql.NotNullFlag
+		}
+	}
+	if res.ToClass() == ClassString {
+		res.Flag &^= mysql.UnsignedFlag
+	}
+
+	switch res.ToClass() {
+	case ClassDecimal:
+		// The integer part and the fractional part are widened separately, so decimal(5,3) and decimal(10,0)
+		// are merged to decimal(13,3).
+		intLen, frac := 0, 0
+		for _, ft := range fts {
+			if ft.Tp == mysql.TypeNull {
+				continue
+			}
+			if ft.Flen == UnspecifiedLength || (ft.ToClass() != ClassInt && ft.Decimal == UnspecifiedLength) {
+				intLen, frac = UnspecifiedLength, UnspecifiedLength
+				break
+			}
+			ftFrac := 0
+			if ft.ToClass() != ClassInt {
+				ftFrac = ft.Decimal
+			}
+			intLen, frac = myMax(intLen, ft.Flen-ftFrac), myMax(frac, ftFrac)
+		}
+		if frac != UnspecifiedLength {
+			res.Decimal = myMin(frac, MaxFraction)
+			res.Flen = myMin(intLen+res.Decimal, maxDecimalWidth)
+		}
+	default:
+		res.Flen, res.Decimal = 0, 0
+		for _, ft := range fts {
+			if ft.Tp == mysql.TypeNull {
+				continue
+			}
+			if res.Flen != UnspecifiedLength {
+				if ft.Flen == UnspecifiedLength {
+					res.Flen = UnspecifiedLength
+				} else {
+					res.Flen = myMax(res.Flen, ft.Flen)
+				}
+			}
+			if res.Decimal != UnspecifiedLength && (ft.ToClass() == ClassReal || IsTypeFractionable(ft.Tp)) {
+				if ft.Decimal == UnspecifiedLength {
+					res.Decimal = UnspecifiedLength
+				} else {
+					res.Decimal = myMax(res.Decimal, ft.Decimal)
+				}
+			}
+		}
+		if res.ToClass() != ClassReal && !IsTypeFractionable(res.Tp) {
+			res.Decimal = UnspecifiedLength
+		}
+	}
+
+	if res.ToClass() != ClassString || isTypeTemporal(res.Tp) {
+		SetBinChsClnFlag(res)
+		return res
+	}
+	res.Charset, res.Collate = mergeCharsets(fts)
+	if res.Charset == charset.CharsetBin {
+		res.Flag |= mysql.BinaryFlag
+	}
+	return res
+}
+
+// MergeTimeCmpTypes returns the type in which GREATEST and LEAST compare the arguments of types fts as times.
+// ok is true if there is a date, datetime or timestamp, and the others are such types, strings or NULL. The type
+// is the one of the temporal arguments if they are all of it, otherwise datetime.
+func MergeTimeCmpTypes(fts []*FieldType) (tp byte, ok bool) {
+	for _, ft := range fts {
+		switch ft.Tp {
+		case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+			if tp != 0 && tp != ft.Tp {
+				tp = mysql.TypeDatetime
+			} else {
+				tp = ft.Tp
+			}
+		case mysql.TypeNull:
+		default:
+			if !IsTypeChar(ft.Tp) && !IsTypeVarchar(ft.Tp) && !IsTypeBlob(ft.Tp) {
+				return 0, false
+			}
+		}
+	}
+	return tp, tp != 0
+}
+
+func isTypeTemporal(tp byte) bool {
+	switch tp {
+	case mysql.TypeDate, mysql.TypeNewDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		return true
+	}
+	return false
+}
+
+// mergeCharsets returns the charset and the collation for the string type merged from fts.
+// Only the string types count, and if their charsets differ, it's binary.
+func mergeCharsets(fts []*FieldType) (string, string) {
+	var chs, coll string
+	for _, ft := range fts {
+		if ft.ToClass() != ClassString || ft.Charset == "" || ft.Tp == mysql.TypeNull || isTypeTemporal(ft.Tp) {
+			continue
+		}
+		if chs == "" {
+			chs, coll = ft.Charset, ft.Collate
+			continue
+		}
+		if !strings.EqualFold(chs, ft.Charset) {
+			return charset.CharsetBin, charset.CollationBin
+		}
+		if !strings.EqualFold(coll, ft.Collate) {
+			// The collations of a charset are merged to its default one.
+			coll = ""
+		}
+	}
+	if chs == "" {
+		return mysql.DefaultCharset, mysql.DefaultCollationName
+	}
+	if coll == "" {
+		var err error
+		if coll, err = charset.GetDefaultCollation(chs); err != nil {
+			return charset.CharsetBin, charset.CollationBin
+		}
+	}
+	return chs, coll
+}
+
 func getFieldTypeIndex(tp byte) int {
 	itp := int(tp)
 	if itp < fieldTypeTearFrom {
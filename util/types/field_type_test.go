@@ -135,3 +135,87 @@ func (s *testFieldTypeSuite) TestDefaultTypeForValue(c *C) {
 		c.Assert(ft.Tp, Equals, tt.tp, Commentf("%v %v", ft, tt))
 	}
 }
+
+func (s *testFieldTypeSuite) TestMergeFieldTypes(c *C) {
+	defer testleak.AfterTest(c)()
+	newFt := func(tp byte, flen, decimal int, flag uint, chs, coll string) *FieldType {
+		return &FieldType{Tp: tp, Flen: flen, Decimal: decimal, Flag: flag, Charset: chs, Collate: coll}
+	}
+	binFlag := uint(mysql.BinaryFlag)
+	tests := []struct {
+		fts    []*FieldType
+		expect *FieldType
+	}{
+		// Decimal and int.
+		{
+			[]*FieldType{newFt(mysql.TypeNewDecimal, 5, 3, 0, "binary", "binary"), newFt(mysql.TypeNewDecimal, 10, 0, 0, "binary", "binary")},
+			newFt(mysql.TypeNewDecimal, 13, 3, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeLong, 11, 0, 0, "binary", "binary"), newFt(mysql.TypeNewDecimal, 10, 2, 0, "binary", "binary")},
+			newFt(mysql.TypeNewDecimal, 13, 2, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeNewDecimal, 60, 0, 0, "binary", "binary"), newFt(mysql.TypeNewDecimal, 40, 30, 0, "binary", "binary")},
+			newFt(mysql.TypeNewDecimal, 65, 30, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeLong, 10, 0, mysql.UnsignedFlag|mysql.NotNullFlag, "binary", "binary"), newFt(mysql.TypeLonglong, 20, 0, mysql.UnsignedFlag, "binary", "binary")},
+			newFt(mysql.TypeLonglong, 20, UnspecifiedLength, mysql.UnsignedFlag|binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeLong, 10, 0, mysql.UnsignedFlag, "binary", "binary"), newFt(mysql.TypeLonglong, 20, 0, 0, "binary", "binary")},
+			newFt(mysql.TypeLonglong, 20, UnspecifiedLength, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeDouble, 10, 2, 0, "binary", "binary"), newFt(mysql.TypeFloat, 5, 4, 0, "binary", "binary")},
+			newFt(mysql.TypeDouble, 10, 4, binFlag, "binary", "binary"),
+		},
+		// Char and varchar.
+		{
+			[]*FieldType{newFt(mysql.TypeString, 10, 0, 0, "utf8", "utf8_bin"), newFt(mysql.TypeVarchar, 20, 0, 0, "utf8", "utf8_bin")},
+			newFt(mysql.TypeVarchar, 20, UnspecifiedLength, 0, "utf8", "utf8_bin"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_bin"), newFt(mysql.TypeLong, 11, 0, 0, "binary", "binary")},
+			newFt(mysql.TypeVarchar, 11, UnspecifiedLength, 0, "utf8", "utf8_bin"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_general_ci"), newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_general_ci")},
+			newFt(mysql.TypeVarchar, 5, UnspecifiedLength, 0, "utf8", "utf8_general_ci"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_general_ci"), newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_unicode_ci")},
+			newFt(mysql.TypeVarchar, 5, UnspecifiedLength, 0, "utf8", mysql.DefaultCollationName),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_bin"), newFt(mysql.TypeVarchar, 8, 0, 0, "latin1", "latin1_bin")},
+			newFt(mysql.TypeVarchar, 8, UnspecifiedLength, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeVarchar, 5, 0, 0, "utf8", "utf8_bin"), newFt(mysql.TypeBlob, 100, 0, binFlag, "binary", "binary")},
+			newFt(mysql.TypeBlob, 100, UnspecifiedLength, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeNull, 0, 0, 0, "binary", "binary"), newFt(mysql.TypeVarchar, 3, 0, mysql.NotNullFlag, "utf8", "utf8_bin")},
+			newFt(mysql.TypeVarchar, 3, UnspecifiedLength, 0, "utf8", "utf8_bin"),
+		},
+		// Temporal types.
+		{
+			[]*FieldType{newFt(mysql.TypeDate, 10, 0, 0, "binary", "binary"), newFt(mysql.TypeDatetime, 23, 3, 0, "binary", "binary")},
+			newFt(mysql.TypeDatetime, 23, 3, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeDatetime, 22, 2, 0, "binary", "binary"), newFt(mysql.TypeTimestamp, 26, 6, 0, "binary", "binary")},
+			newFt(mysql.TypeDatetime, 26, 6, binFlag, "binary", "binary"),
+		},
+		{
+			[]*FieldType{newFt(mysql.TypeDate, 10, 0, 0, "binary", "binary"), newFt(mysql.TypeLong, 11, 0, 0, "binary", "binary")},
+			newFt(mysql.TypeVarchar, 11, UnspecifiedLength, 0, "utf8", "utf8_bin"),
+		},
+	}
+	for _, t := range tests {
+		c.Assert(MergeFieldTypes(t.fts), DeepEquals, t.expect, Commentf("%v", t.fts))
+	}
+	c.Assert(MergeFieldTypes(nil), IsNil)
+}
@@ -55,10 +55,10 @@ func (s *testTimeSuite) TestTimeFormatMethod(c *C) {
 			// For invalid date month or year = 0, MySQL behavior is confusing, %U (which format Week()) is 52, but Week() is 0.
 			// It's because in MySQL, Week() checks invalid date before processing, but DateFormat() don't.
 			// So there are some difference to MySQL here (%U %u %V %v), TiDB user should not rely on those corner case behavior.
-			// %W %w %a is not compatible in this case because Week() use GoTime() currently.
+			// %W %w %a are computed from the day number as MySQL does.
 			"0000-01-00 00:00:00.123456",
 			`%b %M %m %c %D %d %e %j %k %h %i %p %r %T %s %f %U %u %V %v %a %W %w %X %x %Y %y %%`,
-			`Jan January 01 1 0th 00 0 000 0 12 00 AM 12:00:00 AM 00:00:00 00 123456 00 00 00 52 Sun Sunday 0 4294967295 4294967295 0000 00 %`,
+			`Jan January 01 1 0th 00 0 000 0 12 00 AM 12:00:00 AM 00:00:00 00 123456 00 00 00 52 Sat Saturday 6 4294967295 4294967295 0000 00 %`,
 		},
 	}
 	for i, t := range tblDate {
@@ -97,6 +97,22 @@ func (s *testTimeSuite) TestStrToDate(c *C) {
 		{`10:13 PM`, `%l:%i %p`, FromDate(0, 0, 0, 22, 13, 0, 0)},
 		{`12:00:00 AM`, `%h:%i:%s %p`, FromDate(0, 0, 0, 0, 0, 0, 0)},
 		{`12:00:00 PM`, `%h:%i:%s %p`, FromDate(0, 0, 0, 12, 0, 0, 0)},
+		{`11/22/2016`, `%c/%e/%Y`, FromDate(2016, 11, 22, 0, 0, 0, 0)},
+		{`10:11:12.5`, `%T.%f`, FromDate(0, 0, 0, 10, 11, 12, 500000)},
+		// The rest of the format is ignored if the input is shorter.
+		{`2016-11`, `%Y-%m-%d %T`, FromDate(2016, 11, 0, 0, 0, 0, 0)},
+		{`Nov 22, 16`, `%b %d, %y`, FromDate(2016, 11, 22, 0, 0, 0, 0)},
+		{`22 Nov 99`, `%d %b %y`, FromDate(1999, 11, 22, 0, 0, 0, 0)},
+		{`Tue, 22nd Nov 2016`, `%a, %D %b %Y`, FromDate(2016, 11, 22, 0, 0, 0, 0)},
+		{`1st`, `%D`, FromDate(0, 0, 1, 0, 0, 0, 0)},
+		{`2016 327`, `%Y %j`, FromDate(2016, 11, 22, 0, 0, 0, 0)},
+		{`200442 Monday`, `%X%V %W`, FromDate(2004, 10, 18, 0, 0, 0, 0)},
+		{`2013 32 Tuesday`, `%Y %U %W`, FromDate(2013, 8, 13, 0, 0, 0, 0)},
+		{`2013 1 1`, `%Y %u %w`, FromDate(2012, 12, 31, 0, 0, 0, 0)},
+		{`2017 01 sun`, `%x %v %a`, FromDate(2017, 1, 8, 0, 0, 0, 0)},
+		{`11:30:00 PM`, `%I:%i:%s %p`, FromDate(0, 0, 0, 23, 30, 0, 0)},
+		{`09:30 AM`, `%h:%i %p`, FromDate(0, 0, 0, 9, 30, 0, 0)},
+		{`12:30`, `%h:%i`, FromDate(0, 0, 0, 0, 30, 0, 0)},
 	}
 	for i, tt := range tests {
 		var t Time
@@ -115,9 +131,41 @@ func (s *testTimeSuite) TestStrToDate(c *C) {
 		{`23:60:12`, `%T`}, // invalid minute
 		{`18`, `%l`},
 		{`00:21:22 AM`, `%h:%i:%s %p`},
+		{`13:00:00 PM`, `%h:%i:%s %p`},
+		{`10:00 PM`, `%H:%i %p`}, // AM or PM follows a 24-hour hour
+		{`Tux`, `%a`},
+		{`Mon`, `%W`},
+		{`7`, `%w`},
+		{`22xx`, `%D`},
+		{`2016 367`, `%Y %j`},
+		{`200442 Monday`, `%Y%V %W`},   // %V without %X
+		{`200442 Monday`, `%X%v %W`},   // %v with %X
+		{`2004 42 Monday`, `%X %U %W`}, // %U with %X
+		{`2004 00 Mon`, `%x %v %a`},    // %v starts from 1
 	}
 	for _, tt := range errTests {
 		var t Time
 		c.Assert(t.StrToDate(tt.input, tt.format), IsFalse)
 	}
 }
+
+func (s *testTimeSuite) TestStrToDateFormatType(c *C) {
+	tests := []struct {
+		format string
+		tp     byte
+		fsp    int
+	}{
+		{`%Y-%m-%d`, mysql.TypeDate, 0},
+		{`%b %D, %y`, mysql.TypeDate, 0},
+		{`%Y-%m-%d %H:%i:%s`, mysql.TypeDatetime, 0},
+		{`%T`, mysql.TypeDatetime, 0},
+		{`%Y%m%d %T.%f`, mysql.TypeDatetime, 6},
+		{`abc`, mysql.TypeDatetime, 0},
+		{`%%Y %`, mysql.TypeDatetime, 0},
+	}
+	for _, tt := range tests {
+		tp, fsp := StrToDateFormatType(tt.format)
+		c.Assert(tp, Equals, tt.tp, Commentf("%s", tt.format))
+		c.Assert(fsp, Equals, tt.fsp, Commentf("%s", tt.format))
+	}
+}
@@ -46,6 +46,31 @@ func Round(f float64, dec int) float64 {
 	return RoundFloat(tmp) / shift
 }
 
+// RoundHalfEven rounds the argument f to dec decimal places like Round, but a tie is rounded to the
+// nearest even digit, e.g. 2.5 -> 2, 3.5 -> 4.
+func RoundHalfEven(f float64, dec int) float64 {
+	shift := math.Pow10(dec)
+	tmp := f * shift
+	if math.IsInf(tmp, 0) {
+		return f
+	}
+	return roundFloatHalfEven(tmp) / shift
+}
+
+// roundFloatHalfEven rounds f to the nearest integer value, a tie is rounded to the nearest even integer.
+func roundFloatHalfEven(f float64) float64 {
+	abs := math.Abs(f)
+	// A float64 not less than 2^52 has no fractional part.
+	if abs >= 1<<52 {
+		return f
+	}
+	r := math.Floor(abs + 0.5)
+	if r-abs == 0.5 && math.Mod(r, 2) != 0 {
+		r--
+	}
+	return math.Copysign(r, f)
+}
+
 // Truncate truncates the argument f to dec decimal places.
 // dec defaults to 0 if not specified. dec can be negative
 // to cause dec digits left of the decimal point of the
@@ -47,6 +47,8 @@ const (
 
 	// ModeHalfEven rounds normally.
 	ModeHalfEven RoundMode = "ModeHalfEven"
+	// ModeBankers rounds a tie to the nearest even digit, it's also known as banker's rounding.
+	ModeBankers RoundMode = "ModeBankers"
 	// Truncate just truncates the decimal.
 	ModeTruncate RoundMode = "Truncate"
 	// Ceiling is not supported now.
@@ -176,6 +178,12 @@ func (d *MyDecimal) IsNegative() bool {
 	return d.negative
 }
 
+// Abs sets to the absolute value of d, to can be d itself.
+func (d *MyDecimal) Abs(to *MyDecimal) {
+	*to = *d
+	to.negative = false
+}
+
 // String returns the decimal string representation rounded to resultFrac.
 func (d *MyDecimal) String() string {
 	tmp := *d
@@ -678,6 +686,7 @@ func (d *MyDecimal) doMiniRightShift(shift, beg, end int) {
 //    frac			- to what position after fraction point to round. can be negative!
 //    roundMode		- round to nearest even or truncate
 // 			ModeHalfEven rounds normally.
+// 			ModeBankers rounds a tie to the nearest even digit.
 // 			Truncate just truncates the decimal.
 //
 // NOTES
@@ -703,7 +712,7 @@ func (d *MyDecimal) Round(to *MyDecimal, frac int, roundMode RoundMode) (err err
 	switch roundMode {
 	case modeCeiling:
 		roundDigit = 0
-	case ModeHalfEven:
+	case ModeHalfEven, ModeBankers:
 		roundDigit = 5
 	case ModeTruncate:
 		roundDigit = 10
@@ -761,6 +770,13 @@ func (d *MyDecimal) Round(to *MyDecimal, frac int, roundMode RoundMode) (err err
 			digAfterScale := d.wordBuf[toIdx+1] / digMask // the first digit after scale.
 			// If first digit after scale is 5 and round even, do increment if digit at scale is odd.
 			doInc = (digAfterScale > 5) || (digAfterScale == 5)
+			if digAfterScale == 5 && roundMode == ModeBankers {
+				var digAtScale int32
+				if toIdx >= 0 {
+					digAtScale = to.wordBuf[toIdx] % 10
+				}
+				doInc = digAtScale%2 == 1 || d.wordBuf[toIdx+1]%digMask != 0 || d.hasNonZeroWord(toIdx+2, wordsInt+wordsFrac)
+			}
 		case 10:
 			// Never round, just truncate.
 			doInc = false
@@ -781,7 +797,19 @@ func (d *MyDecimal) Round(to *MyDecimal, frac int, roundMode RoundMode) (err err
 		pos := wordsFracTo*digitsPerWord - frac - 1
 		shiftedNumber := to.wordBuf[toIdx] / powers10[pos]
 		digAfterScale := shiftedNumber % 10
-		if digAfterScale > roundDigit || (roundDigit == 5 && digAfterScale == 5) {
+		doInc := digAfterScale > roundDigit || (roundDigit == 5 && digAfterScale == 5)
+		if digAfterScale == 5 && roundMode == ModeBankers {
+			digAtScale := (shiftedNumber / 10) % 10
+			if pos == digitsPerWord-1 {
+				// The digit at scale is the last digit of the previous word.
+				digAtScale = 0
+				if toIdx > 0 {
+					digAtScale = to.wordBuf[toIdx-1] % 10
+				}
+			}
+			doInc = digAtScale%2 == 1 || to.wordBuf[toIdx]%powers10[pos] != 0 || d.hasNonZeroWord(toIdx+1, wordsInt+wordsFrac)
+		}
+		if doInc {
 			shiftedNumber += 10
 		}
 		to.wordBuf[toIdx] = powers10[pos] * (shiftedNumber - digAfterScale)
@@ -871,6 +899,16 @@ func (d *MyDecimal) Round(to *MyDecimal, frac int, roundMode RoundMode) (err err
 	return
 }
 
+// hasNonZeroWord checks if any word in d.wordBuf[start:end] is not zero.
+func (d *MyDecimal) hasNonZeroWord(start, end int) bool {
+	for i := myMax(start, 0); i < end && i < wordBufLen; i++ {
+		if d.wordBuf[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // FromInt sets the decimal value from int64.
 func (d *MyDecimal) FromInt(val int64) *MyDecimal {
 	var uVal uint64
@@ -42,6 +42,28 @@ func (s *testMyDecimalSuite) TestFromInt(c *C) {
 	}
 }
 
+func (s *testMyDecimalSuite) TestAbs(c *C) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"-12345.678", "12345.678"},
+		{"12345.678", "12345.678"},
+		{"0", "0"},
+		{"-0.00000000000000000000000000001", "0.00000000000000000000000000001"},
+	}
+	for _, tt := range tests {
+		var dec, result MyDecimal
+		c.Assert(dec.FromString([]byte(tt.input)), IsNil)
+		dec.Abs(&result)
+		c.Check(string(result.ToString()), Equals, tt.output)
+		c.Check(string(dec.ToString()), Equals, tt.input)
+		// The result can be the decimal itself.
+		dec.Abs(&dec)
+		c.Check(string(dec.ToString()), Equals, tt.output)
+	}
+}
+
 func (s *testMyDecimalSuite) TestFromUint(c *C) {
 	tests := []struct {
 		input  uint64
@@ -266,15 +288,15 @@ func (s *testMyDecimalSuite) TestRound(c *C) {
 		output string
 		err    error
 	}
-	var doTest = func(c *C, tests []tcase) {
+	var doTest = func(c *C, tests []tcase, mode RoundMode) {
 		for _, ca := range tests {
 			var dec MyDecimal
 			dec.FromString([]byte(ca.input))
 			var rounded MyDecimal
-			err := dec.Round(&rounded, ca.scale, ModeHalfEven)
+			err := dec.Round(&rounded, ca.scale, mode)
 			c.Check(err, Equals, ca.err)
 			result := rounded.ToString()
-			c.Check(string(result), Equals, ca.output)
+			c.Check(string(result), Equals, ca.output, Commentf("%s %v %s", ca.input, ca.scale, mode))
 		}
 	}
 	tests := []tcase{
@@ -294,7 +316,30 @@ func (s *testMyDecimalSuite) TestRound(c *C) {
 		{".999", 0, "1", nil},
 		{"999999999", -9, "1000000000", nil},
 	}
-	doTest(c, tests)
+	doTest(c, tests, ModeHalfEven)
+
+	tests = []tcase{
+		{"2.5", 0, "2", nil},
+		{"3.5", 0, "4", nil},
+		{"-2.5", 0, "-2", nil},
+		{"-3.5", 0, "-4", nil},
+		{"2.51", 0, "3", nil},
+		{"2.500000000001", 0, "3", nil},
+		{"0.5", 0, "0", nil},
+		{"1.25", 1, "1.2", nil},
+		{"1.35", 1, "1.4", nil},
+		{"1.250000000000001", 1, "1.3", nil},
+		{"0.0000000025", 9, "0.000000002", nil},
+		{"0.0000000035", 9, "0.000000004", nil},
+		{"250", -2, "200", nil},
+		{"350", -2, "400", nil},
+		{"251", -2, "300", nil},
+		{"250.0000001", -2, "300", nil},
+		{"1500000000", -9, "2000000000", nil},
+		{"2500000000", -9, "2000000000", nil},
+		{"15.17", 1, "15.2", nil},
+	}
+	doTest(c, tests, ModeBankers)
 }
 
 func (s *testMyDecimalSuite) TestFromString(c *C) {
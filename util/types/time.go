@@ -576,6 +576,12 @@ func parseDatetime(str string, fsp int) (Time, error) {
 	case 3:
 		// YYYY-MM-DD
 		err = scanTimeArgs(seps, &year, &month, &day)
+	case 4:
+		// YYYY-MM-DD HH
+		err = scanTimeArgs(seps, &year, &month, &day, &hour)
+	case 5:
+		// YYYY-MM-DD HH-MM
+		err = scanTimeArgs(seps, &year, &month, &day, &hour, &minute)
 	case 6:
 		// We don't have fractional seconds part.
 		// YYYY-MM-DD HH-MM-SS
@@ -1325,6 +1331,44 @@ func ExtractTimeNum(unit string, t Time) (int64, error) {
 	}
 }
 
+// ExtractDurationNum extracts duration value number from time unit and format.
+// The hours of a duration aren't split into days, so the day part of a composite unit is always 0,
+// e.g. DAY_HOUR of '50:10:10' is 50, and the units larger than a day are extracted to 0.
+// A negative duration is extracted to a negative number.
+func ExtractDurationNum(unit string, d Duration) (int64, error) {
+	sign, h, m, s, frac := splitDuration(d.Duration)
+	var n int64
+	switch strings.ToUpper(unit) {
+	case "MICROSECOND":
+		n = int64(frac)
+	case "SECOND":
+		n = int64(s)
+	case "MINUTE":
+		n = int64(m)
+	case "HOUR":
+		n = int64(h)
+	case "DAY", "WEEK", "MONTH", "QUARTER", "YEAR", "YEAR_MONTH":
+		n = 0
+	case "SECOND_MICROSECOND":
+		n = int64(s)*1000000 + int64(frac)
+	case "MINUTE_MICROSECOND":
+		n = int64(m)*100000000 + int64(s)*1000000 + int64(frac)
+	case "MINUTE_SECOND":
+		n = int64(m*100 + s)
+	case "HOUR_MICROSECOND", "DAY_MICROSECOND":
+		n = int64(h)*10000000000 + int64(m)*100000000 + int64(s)*1000000 + int64(frac)
+	case "HOUR_SECOND", "DAY_SECOND":
+		n = int64(h)*10000 + int64(m)*100 + int64(s)
+	case "HOUR_MINUTE", "DAY_MINUTE":
+		n = int64(h)*100 + int64(m)
+	case "DAY_HOUR":
+		n = int64(h)
+	default:
+		return 0, errors.Errorf("invalid unit %s", unit)
+	}
+	return int64(sign) * n, nil
+}
+
 func extractSingleTimeValue(unit string, format string) (int64, int64, int64, gotime.Duration, error) {
 	iv, err := strconv.ParseInt(format, 10, 64)
 	if err != nil {
@@ -1656,11 +1700,18 @@ func ParseTimeFromInt64(num int64) (Time, error) {
 // according to layout.
 // See http://dev.mysql.com/doc/refman/5.7/en/date-and-time-functions.html#function_date-format
 func (t Time) DateFormat(layout string) (string, error) {
+	return t.DateFormatWithLocale(layout, DefaultDateLocale)
+}
+
+// DateFormatWithLocale is like DateFormat, but the names of the months and the weekdays are in the locale,
+// which is a value of lc_time_names like "de_DE". The unsupported locales fall back to DefaultDateLocale.
+func (t Time) DateFormatWithLocale(layout string, locale string) (string, error) {
+	names := getDateLocale(locale)
 	var buf bytes.Buffer
 	inPatternMatch := false
 	for _, b := range layout {
 		if inPatternMatch {
-			if err := t.convertDateFormat(b, &buf); err != nil {
+			if err := t.convertDateFormat(b, names, &buf); err != nil {
 				return "", errors.Trace(err)
 			}
 			inPatternMatch = false
@@ -1674,28 +1725,95 @@ func (t Time) DateFormat(layout string) (string, error) {
 			buf.WriteRune(b)
 		}
 	}
+	// A trailing '%' is kept as it is.
+	if inPatternMatch {
+		buf.WriteByte('%')
+	}
 	return buf.String(), nil
 }
 
-var abbrevWeekdayName = []string{
-	"Sun", "Mon", "Tue",
-	"Wed", "Thu", "Fri", "Sat",
+// DefaultDateLocale is the default value of lc_time_names.
+const DefaultDateLocale = "en_US"
+
+// dateLocale holds the names of the months and the weekdays in a locale, the weekdays start from Monday.
+type dateLocale struct {
+	monthNames         []string
+	abbrevMonthNames   []string
+	weekdayNames       []string
+	abbrevWeekdayNames []string
+}
+
+// dateLocales maps the values of lc_time_names to the names used by DATE_FORMAT.
+// See https://dev.mysql.com/doc/refman/5.7/en/locale-support.html
+var dateLocales = map[string]*dateLocale{
+	DefaultDateLocale: {
+		monthNames:         MonthNames,
+		abbrevMonthNames:   []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdayNames:       WeekdayNames,
+		abbrevWeekdayNames: []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"},
+	},
+	"de_DE": {
+		monthNames:         []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		abbrevMonthNames:   []string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		weekdayNames:       []string{"Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag", "Sonntag"},
+		abbrevWeekdayNames: []string{"Mo", "Di", "Mi", "Do", "Fr", "Sa", "So"},
+	},
+	"es_ES": {
+		monthNames:         []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		abbrevMonthNames:   []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		weekdayNames:       []string{"lunes", "martes", "miércoles", "jueves", "viernes", "sábado", "domingo"},
+		abbrevWeekdayNames: []string{"lun", "mar", "mié", "jue", "vie", "sáb", "dom"},
+	},
+	"fr_FR": {
+		monthNames:         []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		abbrevMonthNames:   []string{"janv", "févr", "mars", "avr", "mai", "juin", "juil", "août", "sept", "oct", "nov", "déc"},
+		weekdayNames:       []string{"lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi", "dimanche"},
+		abbrevWeekdayNames: []string{"lun", "mar", "mer", "jeu", "ven", "sam", "dim"},
+	},
 }
 
-func (t Time) convertDateFormat(b rune, buf *bytes.Buffer) error {
+// getDateLocale returns the names of locale, the unsupported locales fall back to DefaultDateLocale.
+func getDateLocale(locale string) *dateLocale {
+	if names, ok := dateLocales[locale]; ok {
+		return names
+	}
+	return dateLocales[DefaultDateLocale]
+}
+
+// MonthNameWithLocale returns the name of month, which is in [1, 12], in the locale of lc_time_names.
+func MonthNameWithLocale(month int, locale string) string {
+	return getDateLocale(locale).monthNames[month-1]
+}
+
+// WeekdayNameWithLocale returns the name of weekday, which is in [0, 6] starting from Monday, in the locale
+// of lc_time_names.
+func WeekdayNameWithLocale(weekday int, locale string) string {
+	return getDateLocale(locale).weekdayNames[weekday]
+}
+
+// dateFormatWeekday returns the weekday of t starting from Monday as 0, it's computed from the day number
+// as MySQL does, so the dates with zero parts have weekdays too. The zero date has no weekday.
+func (t Time) dateFormatWeekday() (int, error) {
+	if t.Time.Year() == 0 && t.Time.Month() == 0 {
+		return 0, errors.Trace(ErrInvalidTimeFormat)
+	}
+	return calcWeekday(calcDaynr(t.Time.Year(), t.Time.Month(), t.Time.Day()), false), nil
+}
+
+func (t Time) convertDateFormat(b rune, names *dateLocale, buf *bytes.Buffer) error {
 	switch b {
 	case 'b':
 		m := t.Time.Month()
 		if m == 0 || m > 12 {
 			return errors.Trace(ErrInvalidTimeFormat)
 		}
-		buf.WriteString(MonthNames[m-1][:3])
+		buf.WriteString(names.abbrevMonthNames[m-1])
 	case 'M':
 		m := t.Time.Month()
 		if m == 0 || m > 12 {
 			return errors.Trace(ErrInvalidTimeFormat)
 		}
-		buf.WriteString(MonthNames[m-1])
+		buf.WriteString(names.monthNames[m-1])
 	case 'm':
 		fmt.Fprintf(buf, "%02d", t.Time.Month())
 	case 'c':
@@ -1764,13 +1882,20 @@ func (t Time) convertDateFormat(b rune, buf *bytes.Buffer) error {
 	case 'v':
 		_, w := t.Time.YearWeek(3)
 		fmt.Fprintf(buf, "%02d", w)
-	case 'a':
-		weekday := t.Time.Weekday()
-		buf.WriteString(abbrevWeekdayName[weekday])
-	case 'W':
-		buf.WriteString(t.Time.Weekday().String())
-	case 'w':
-		fmt.Fprintf(buf, "%d", t.Time.Weekday())
+	case 'a', 'W', 'w':
+		weekday, err := t.dateFormatWeekday()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		switch b {
+		case 'a':
+			buf.WriteString(names.abbrevWeekdayNames[weekday])
+		case 'W':
+			buf.WriteString(names.weekdayNames[weekday])
+		default:
+			// %w counts from Sunday as 0.
+			fmt.Fprintf(buf, "%d", (weekday+1)%7)
+		}
 	case 'X':
 		year, _ := t.Time.YearWeek(2)
 		if year < 0 {
@@ -1835,31 +1960,117 @@ func (t *Time) StrToDate(date, format string) bool {
 	return true
 }
 
+// StrToDateFormatType returns the type of the result of STR_TO_DATE with format, it's a date if format
+// only contains the date specifiers, otherwise a datetime, and the fsp is MaxFsp if format contains %f.
+func StrToDateFormatType(format string) (tp byte, fsp int) {
+	var hasDate, hasTime bool
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'a', 'b', 'c', 'D', 'd', 'e', 'j', 'M', 'm', 'U', 'u', 'V', 'v', 'W', 'w', 'X', 'x', 'Y', 'y':
+			hasDate = true
+		case 'f':
+			hasTime = true
+			fsp = MaxFsp
+		case 'H', 'h', 'I', 'i', 'k', 'l', 'p', 'r', 'S', 's', 'T':
+			hasTime = true
+		}
+	}
+	if hasDate && !hasTime {
+		return mysql.TypeDate, DefaultFsp
+	}
+	return mysql.TypeDatetime, fsp
+}
+
+// maxDaynr is the day number of 9999-12-31.
+const maxDaynr = 3652424
+
 // mysqlTimeFix fixes the mysqlTime use the values in the context.
 func mysqlTimeFix(t *mysqlTime, ctx map[string]int) error {
 	// Key of the ctx is the format char, such as `%j` `%p` and so on.
 	if yearOfDay, ok := ctx["%j"]; ok {
-		// TODO: Implement the function that converts day of year to yy:mm:dd.
-		_ = yearOfDay
+		if err := setDateFromDaynr(t, calcDaynr(int(t.year), 1, 1)+yearOfDay-1); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := fixDateOfWeek(t, ctx); err != nil {
+		return errors.Trace(err)
 	}
-	if valueAMorPm, ok := ctx["%p"]; ok {
-		if t.hour == 0 {
+	if _, ok := ctx["%h"]; ok {
+		// The hour is in 12-hour format, 12 AM is 00 and 12 PM is 12.
+		if t.hour < 1 || t.hour > 12 {
 			return ErrInvalidTimeFormat
 		}
-		if t.hour == 12 {
-			// 12 is a special hour.
-			switch valueAMorPm {
-			case constForAM:
-				t.hour = 0
-			case constForPM:
-				t.hour = 12
-			}
-			return nil
-		}
-		if valueAMorPm == constForPM {
+		t.hour %= 12
+		if ctx["%p"] == constForPM {
 			t.hour += 12
 		}
+	} else if _, ok := ctx["%p"]; ok {
+		// AM or PM must follow a 12-hour hour.
+		return ErrInvalidTimeFormat
+	}
+	return nil
+}
+
+// fixDateOfWeek sets the date of t to the weekday of %a, %W or %w in the week of %U, %u, %V or %v.
+// The week of %V or %v is in the year of %X or %x respectively, and the week of %U or %u is in the year of %Y.
+func fixDateOfWeek(t *mysqlTime, ctx map[string]int) error {
+	weekday, ok := ctx["%w"]
+	if !ok {
+		return nil
+	}
+	var weekToken string
+	for _, token := range []string{"%U", "%u", "%V", "%v"} {
+		if _, ok := ctx[token]; ok {
+			weekToken = token
+		}
+	}
+	if weekToken == "" {
+		return nil
+	}
+	week := ctx[weekToken]
+	sundayFirst := weekToken == "%U" || weekToken == "%V"
+	strict := weekToken == "%V" || weekToken == "%v"
+	year, hasYear := ctx["%X"]
+	yearSundayFirst := hasYear
+	if !hasYear {
+		year, hasYear = ctx["%x"]
+	}
+	if strict != hasYear || (strict && yearSundayFirst != sundayFirst) {
+		return ErrInvalidTimeFormat
+	}
+	if !strict {
+		year = int(t.year)
+	}
+
+	days := calcDaynr(year, 1, 1)
+	firstWeekday := calcWeekday(days, sundayFirst)
+	// The days are the sum of the days till the first day of the first week, the days of the whole weeks
+	// before the week and the position of the day in the week.
+	if sundayFirst {
+		if firstWeekday != 0 {
+			days += 7
+		}
+		days += (week-1)*7 - firstWeekday + weekday%7
+	} else {
+		if firstWeekday > 3 {
+			days += 7
+		}
+		days += (week-1)*7 - firstWeekday + weekday - 1
 	}
+	return errors.Trace(setDateFromDaynr(t, days))
+}
+
+// setDateFromDaynr sets the date of t to the day of daynr.
+func setDateFromDaynr(t *mysqlTime, daynr int) error {
+	if daynr <= 0 || daynr > maxDaynr {
+		return ErrInvalidTimeFormat
+	}
+	year, month, day := getDateFromDaynr(uint(daynr))
+	t.year, t.month, t.day = uint16(year), uint8(month), uint8(day)
 	return nil
 }
 
@@ -1878,6 +2089,10 @@ func strToDate(t *mysqlTime, date string, format string, ctx map[string]int) boo
 		// Extra characters at the end of date are ignored.
 		return true
 	}
+	if date == "" {
+		// The rest of the format is ignored if the date is shorter than the format, as MySQL does.
+		return true
+	}
 
 	dateRemain, succ := matchDateWithToken(t, date, token, ctx)
 	if !succ {
@@ -1919,16 +2134,6 @@ func skipWhiteSpace(input string) string {
 	return ""
 }
 
-var weekdayAbbrev = map[string]gotime.Weekday{
-	"Sun": gotime.Sunday,
-	"Mon": gotime.Monday,
-	"Tue": gotime.Tuesday,
-	"Wed": gotime.Wednesday,
-	"Thu": gotime.Tuesday,
-	"Fri": gotime.Friday,
-	"Sat": gotime.Saturday,
-}
-
 var monthAbbrev = map[string]gotime.Month{
 	"Jan": gotime.January,
 	"Feb": gotime.February,
@@ -1947,14 +2152,16 @@ var monthAbbrev = map[string]gotime.Month{
 type dateFormatParser func(t *mysqlTime, date string, ctx map[string]int) (remain string, succ bool)
 
 var dateFormatParserTable = map[string]dateFormatParser{
+	"%a": abbreviatedWeekday,         // Abbreviated weekday name (Sun..Sat)
 	"%b": abbreviatedMonth,           // Abbreviated month name (Jan..Dec)
 	"%c": monthNumeric,               // Month, numeric (0..12)
+	"%D": dayOfMonthWithSuffix,       // Day of the month with English suffix (0th, 1st, 2nd, 3rd)
 	"%d": dayOfMonthNumericTwoDigits, // Day of the month, numeric (00..31)
 	"%e": dayOfMonthNumeric,          // Day of the month, numeric (0..31)
 	"%f": microSeconds,               // Microseconds (000000..999999)
-	"%h": hour24TwoDigits,            // Hour (01..12)
-	"%H": hour24TwoDigits,            // Hour (01..12)
-	"%I": hour24TwoDigits,            // Hour (01..12)
+	"%h": hour12TwoDigits,            // Hour (01..12)
+	"%H": hour24TwoDigits,            // Hour (00..23)
+	"%I": hour12TwoDigits,            // Hour (01..12)
 	"%i": minutesNumeric,             // Minutes, numeric (00..59)
 	"%j": dayOfYearThreeDigits,       // Day of year (001..366)
 	"%k": hour24Numeric,              // Hour (0..23)
@@ -1966,20 +2173,16 @@ var dateFormatParserTable = map[string]dateFormatParser{
 	"%s": secondsNumeric,             // Seconds (00..59)
 	"%S": secondsNumeric,             // Seconds (00..59)
 	"%T": time24Hour,                 // Time, 24-hour (hh:mm:ss)
+	"%U": weekMode0,                  // Week (00..53), where Sunday is the first day of the week; WEEK() mode 0
+	"%u": weekMode1,                  // Week (00..53), where Monday is the first day of the week; WEEK() mode 1
+	"%V": weekMode2,                  // Week (01..53), where Sunday is the first day of the week; WEEK() mode 2; used with %X
+	"%v": weekMode3,                  // Week (01..53), where Monday is the first day of the week; WEEK() mode 3; used with %x
+	"%W": weekdayName,                // Weekday name (Sunday..Saturday)
+	"%w": dayOfWeek,                  // Day of the week (0=Sunday..6=Saturday)
+	"%X": yearOfWeekSundayFirst,      // Year for the week where Sunday is the first day of the week, numeric, four digits; used with %V
+	"%x": yearOfWeekMondayFirst,      // Year for the week, where Monday is the first day of the week, numeric, four digits; used with %v
 	"%Y": yearNumericFourDigits,      // Year, numeric, four digits
-	// TODO: Add the following...
-	// "%a": abbreviatedWeekday,         // Abbreviated weekday name (Sun..Sat)
-	// "%D": dayOfMonthWithSuffix,       // Day of the month with English suffix (0th, 1st, 2nd, 3rd)
-	// "%U": weekMode0,                  // Week (00..53), where Sunday is the first day of the week; WEEK() mode 0
-	// "%u": weekMode1,                  // Week (00..53), where Monday is the first day of the week; WEEK() mode 1
-	// "%V": weekMode2,                  // Week (01..53), where Sunday is the first day of the week; WEEK() mode 2; used with %X
-	// "%v": weekMode3,                  // Week (01..53), where Monday is the first day of the week; WEEK() mode 3; used with %x
-	// "%W": weekdayName,                // Weekday name (Sunday..Saturday)
-	// "%w": dayOfWeek,                  // Day of the week (0=Sunday..6=Saturday)
-	// "%X": yearOfWeek,                 // Year for the week where Sunday is the first day of the week, numeric, four digits; used with %V
-	// "%x": yearOfWeek,                 // Year for the week, where Monday is the first day of the week, numeric, four digits; used with %v
-	// Deprecated since MySQL 5.7.5
-	// "%y": yearTwoDigits,         // Year, numeric (two digits)
+	"%y": yearTwoDigits,              // Year, numeric (two digits)
 }
 
 func matchDateWithToken(t *mysqlTime, date string, token string, ctx map[string]int) (remain string, succ bool) {
@@ -2014,6 +2217,17 @@ func hour24TwoDigits(t *mysqlTime, input string, ctx map[string]int) (string, bo
 	return input[2:], true
 }
 
+// hour12TwoDigits parses the hour in 12-hour format, which is fixed with %p in mysqlTimeFix.
+func hour12TwoDigits(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	v, succ := parseDigits(input, 2)
+	if !succ || v == 0 || v > 12 {
+		return input, false
+	}
+	t.hour = uint8(v)
+	ctx["%h"] = v
+	return input[2:], true
+}
+
 func secondsNumeric(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
 	v, succ := parseDigits(input, 2)
 	if !succ || v >= 60 {
@@ -2149,7 +2363,7 @@ func dayOfMonthNumeric(t *mysqlTime, input string, ctx map[string]int) (string,
 	if len(remain) == len(input) || v > 31 {
 		return input, false
 	}
-	t.month = uint8(v)
+	t.day = uint8(v)
 	return remain, true
 }
 
@@ -2170,19 +2384,25 @@ func hour12Numeric(t *mysqlTime, input string, ctx map[string]int) (string, bool
 		return input, false
 	}
 	t.hour = uint8(v)
+	ctx["%h"] = v
 	return remain, true
 }
 
+// microSeconds parses up to 6 digits as the fraction of a second, so "123" is 123000 microseconds.
 func microSeconds(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
-	if len(input) < 6 {
+	n := 0
+	for n < len(input) && n < 6 && input[n] >= '0' && input[n] <= '9' {
+		n++
+	}
+	if n == 0 {
 		return input, false
 	}
-	v, err := strconv.ParseUint(input[:6], 10, 64)
+	v, err := strconv.ParseUint(input[:n]+strings.Repeat("0", 6-n), 10, 64)
 	if err != nil {
 		return input, false
 	}
 	t.microsecond = uint32(v)
-	return input[6:], true
+	return input[n:], true
 }
 
 func yearNumericFourDigits(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
@@ -2194,6 +2414,78 @@ func yearNumericFourDigits(t *mysqlTime, input string, ctx map[string]int) (stri
 	return input[4:], true
 }
 
+// parseLeadingDigits parses at most n leading digits of input, it returns the parsed int and the remain data.
+// The remain data is input if there is no leading digit.
+func parseLeadingDigits(input string, n int) (int, string) {
+	i := 0
+	for i < len(input) && i < n && input[i] >= '0' && input[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, input
+	}
+	v, err := strconv.Atoi(input[:i])
+	if err != nil {
+		return 0, input
+	}
+	return v, input[i:]
+}
+
+// yearTwoDigits parses a two digits year, 00..69 is 2000..2069 and 70..99 is 1970..1999.
+func yearTwoDigits(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	v, remain := parseLeadingDigits(input, 2)
+	if len(remain) == len(input) {
+		return input, false
+	}
+	v = adjustYear(v)
+	t.year = uint16(v)
+	return remain, true
+}
+
+// parseWeekNumber parses the week number of token, which is one of %U, %u, %V and %v.
+func parseWeekNumber(input string, ctx map[string]int, token string) (string, bool) {
+	v, remain := parseLeadingDigits(input, 2)
+	if len(remain) == len(input) || v > 53 || (v == 0 && (token == "%V" || token == "%v")) {
+		return input, false
+	}
+	ctx[token] = v
+	return remain, true
+}
+
+func weekMode0(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	return parseWeekNumber(input, ctx, "%U")
+}
+
+func weekMode1(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	return parseWeekNumber(input, ctx, "%u")
+}
+
+func weekMode2(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	return parseWeekNumber(input, ctx, "%V")
+}
+
+func weekMode3(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	return parseWeekNumber(input, ctx, "%v")
+}
+
+// parseYearOfWeek parses the year of the week of token, which is %X or %x.
+func parseYearOfWeek(input string, ctx map[string]int, token string) (string, bool) {
+	v, remain := parseLeadingDigits(input, 4)
+	if len(remain) == len(input) {
+		return input, false
+	}
+	ctx[token] = v
+	return remain, true
+}
+
+func yearOfWeekSundayFirst(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	return parseYearOfWeek(input, ctx, "%X")
+}
+
+func yearOfWeekMondayFirst(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	return parseYearOfWeek(input, ctx, "%x")
+}
+
 func dayOfYearThreeDigits(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
 	v, succ := parseDigits(input, 3)
 	if !succ || v == 0 || v > 366 {
@@ -2213,17 +2505,53 @@ func monthNumericTwoDigits(t *mysqlTime, input string, ctx map[string]int) (stri
 	return input[2:], true
 }
 
+// The weekday of %a, %W and %w is kept in the context as 1 for Monday .. 7 for Sunday.
+
+// leadingWord returns the leading letters of input.
+func leadingWord(input string) string {
+	for i, c := range input {
+		if !unicode.IsLetter(c) {
+			return input[:i]
+		}
+	}
+	return input
+}
+
 func abbreviatedWeekday(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
-	if len(input) >= 3 {
-		dayName := input[:3]
-		if _, ok := weekdayAbbrev[dayName]; ok {
-			// TODO: We need refact mysql time to support this.
-			return input, false
+	word := leadingWord(input)
+	for i, name := range WeekdayNames {
+		if strings.EqualFold(word, name[:3]) {
+			ctx["%w"] = i + 1
+			return input[len(word):], true
 		}
 	}
 	return input, false
 }
 
+func weekdayName(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	word := leadingWord(input)
+	for i, name := range WeekdayNames {
+		if strings.EqualFold(word, name) {
+			ctx["%w"] = i + 1
+			return input[len(word):], true
+		}
+	}
+	return input, false
+}
+
+func dayOfWeek(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
+	v, remain := parseLeadingDigits(input, 1)
+	if len(remain) == len(input) || v > 6 {
+		return input, false
+	}
+	if v == 0 {
+		// Sunday.
+		v = 7
+	}
+	ctx["%w"] = v
+	return remain, true
+}
+
 func abbreviatedMonth(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
 	if len(input) >= 3 {
 		monthName := input[:3]
@@ -2248,47 +2576,22 @@ func fullNameMonth(t *mysqlTime, input string, ctx map[string]int) (string, bool
 func monthNumeric(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
 	v, rem := parseTwoNumeric(input)
 	if len(rem) == len(input) || v > 12 {
-		return rem, false
+		return input, false
 	}
 	t.month = uint8(v)
-	return rem, false
+	return rem, true
 }
 
-//  dayOfMonthWithSuffix returns different suffix according t being which day. i.e. 0 return th. 1 return st.
+// dayOfMonthWithSuffix parses the day of the month followed by an English suffix, i.e. 1st, 2nd, 3rd and 4th.
 func dayOfMonthWithSuffix(t *mysqlTime, input string, ctx map[string]int) (string, bool) {
-	month, remain := parseOrdinalNumbers(input)
-	if month >= 0 {
-		t.month = uint8(month)
-		return remain, true
-	}
-	return input, false
-}
-
-func parseOrdinalNumbers(input string) (value int, remain string) {
-	for i, c := range input {
-		if !unicode.IsDigit(c) {
-			v, err := strconv.ParseUint(input[:i], 10, 64)
-			if err != nil {
-				return -1, input
-			}
-			value = int(v)
-			break
-		}
+	v, remain := parseLeadingDigits(input, 2)
+	if len(remain) == len(input) || v > 31 || len(remain) < 2 {
+		return input, false
 	}
-	switch {
-	case strings.HasPrefix(remain, "st"):
-		if value == 1 {
-			remain = remain[2:]
-			return
-		}
-	case strings.HasPrefix(remain, "nd"):
-		if value == 2 {
-			remain = remain[2:]
-			return
-		}
-	case strings.HasPrefix(remain, "th"):
-		remain = remain[2:]
-		return
+	suffix := strings.ToLower(remain[:2])
+	if suffix != "st" && suffix != "nd" && suffix != "rd" && suffix != "th" {
+		return input, false
 	}
-	return -1, input
+	t.day = uint8(v)
+	return remain[2:], true
 }
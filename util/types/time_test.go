@@ -47,6 +47,8 @@ func (s *testTimeSuite) TestDateTime(c *C) {
 		{"20121231113045", "2012-12-31 11:30:45"},
 		{"121231113045", "2012-12-31 11:30:45"},
 		{"2012-02-29", "2012-02-29 00:00:00"},
+		{"2012-12-31 11:30", "2012-12-31 11:30:00"},
+		{"2012-12-31 11", "2012-12-31 11:00:00"},
 	}
 
 	for _, test := range table {
@@ -87,6 +89,7 @@ func (s *testTimeSuite) TestDateTime(c *C) {
 		"1000-09-31 00:00:00",
 		"1001-02-29 00:00:00",
 		"2017-00-05 08:40:59.575601",
+		"1000-13-01 11:30",
 	}
 
 	for _, test := range errTable {